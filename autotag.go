@@ -1,16 +1,16 @@
 package autotag
 
 import (
-	"bytes"
 	"errors"
 	"fmt"
-	"log"
 	"os"
+	"path"
 	"path/filepath"
 	"regexp"
 	"sort"
 	"strconv"
 	"strings"
+	"text/template"
 	"time"
 
 	"github.com/gogs/git-module"
@@ -20,17 +20,69 @@ import (
 const (
 	// datetimeTsLayout is the YYYYMMDDHHMMSS time format
 	datetimeTsLayout = "20060102150405"
+
+	// DefaultVersionRefNamespace is the ref namespace of the version tags
+	DefaultVersionRefNamespace = "refs/tags"
+
+	// preReleaseTypeToken in the pre-release name is replaced by the type of the deciding commit
+	preReleaseTypeToken = "{type}"
+)
+
+// schemeAliases maps the alternative names of the schemes to the name they are handled by
+var schemeAliases = map[string]string{
+	"scope":               "scope-conventional",
+	"module-conventional": "scope-conventional",
+}
+
+// Tag date sources select which date of a tag is used for date based selection.
+const (
+	// TagDateCommit uses the committer date of the tagged commit (default)
+	TagDateCommit = "commit"
+	// TagDateTag uses the tagger date of annotated tags, falling back to the commit date for
+	// lightweight tags
+	TagDateTag = "tag"
+)
+
+// Commit filters select which commits of the scanned range are considered for the version bump.
+const (
+	// CommitFilterAll considers every commit (default)
+	CommitFilterAll = "all"
+	// CommitFilterMergesOnly only considers merge commits, eg: when PR titles carry the message
+	CommitFilterMergesOnly = "merges-only"
+	// CommitFilterNoMerges ignores merge commits, eg: when feature commits carry the message
+	CommitFilterNoMerges = "no-merges"
 )
 
+// commitTypePattern matches the type of a conventional commit: letters, digits and underscores,
+// with single hyphens in between, eg: `bug-fix`, but not `-fix` or `fix-`
+const commitTypePattern = `[\p{L}\p{M}\p{N}_]+(?:-[\p{L}\p{M}\p{N}_]+)*`
+
 var (
 	// autotag commit message scheme:
 	majorRex = regexp.MustCompile(`(?i)\[major\]|\#major`)
 	minorRex = regexp.MustCompile(`(?i)\[minor\]|\#minor`)
 	patchRex = regexp.MustCompile(`(?i)\[patch\]|\#patch`)
 
-	// conventional commit message scheme:
+	// conventional commit message scheme, the type and scope may have unicode letters, the type
+	// also inner hyphens, eg: `bug-fix`:
 	// https://regex101.com/r/XciTmT/2
-	conventionalCommitRex = regexp.MustCompile(`^\s*(?P<type>\w+)(?P<scope>(?:\([^()\r\n]*\)|\()?(?P<breaking>!)?)(?P<subject>:.*)?`)
+	conventionalCommitRex = regexp.MustCompile(`^\s*(?P<type>` + commitTypePattern + `)(?P<scope>(?:\([^()\r\n]*\)|\()?(?P<breaking>!)?)(?P<subject>:.*)?`)
+
+	// lenientCommitRex is conventionalCommitRex tolerating blanks around the scope, the `!` and
+	// before the colon, eg: `feat (api) : add login`
+	lenientCommitRex = regexp.MustCompile(`^\s*(?P<type>` + commitTypePattern + `)[ \t]*(?P<scope>(?:\([^()\r\n]*\)|\()?[ \t]*(?P<breaking>!)?)[ \t]*(?P<subject>:.*)?`)
+
+	// commitTypeRex matches a conventional commit type, eg: the types of the bump rules
+	commitTypeRex = regexp.MustCompile(`^` + commitTypePattern + `$`)
+
+	// graduateFooterRex matches the footer of a commit graduating a 0.x version to 1.0.0
+	graduateFooterRex = regexp.MustCompile(`(?im)^Graduate:[ \t]*true[ \t]*$`)
+	// skipReleaseFooterRex matches the footer of a commit that doesn't bump the version yet, eg: a
+	// feature behind a flag
+	skipReleaseFooterRex = regexp.MustCompile(`(?im)^Skip-Release:[ \t]*true[ \t]*$`)
+
+	// commitRegexGroups are the named capture groups a custom commit regex must provide
+	commitRegexGroups = []string{"type", "scope", "breaking", "subject"}
 
 	// versionRex matches semVer style versions, eg: `v1.0.0`
 	// https://regex101.com/r/hx8zW8/1
@@ -40,6 +92,9 @@ var (
 	// https://semver.org/#spec-item-9
 	semVerPreReleaseName = regexp.MustCompile(`^[0-9A-Za-z-]+$`)
 
+	// invalidPreReleaseRex matches the characters not allowed in a pre-release identifier
+	invalidPreReleaseRex = regexp.MustCompile(`[^0-9A-Za-z-]`)
+
 	// semVerBuildMetaRex validates SemVer build metadata strings according to
 	// https://semver.org/#spec-item-10
 	semVerBuildMetaRex = regexp.MustCompile(`^[0-9A-Za-z-]+$`)
@@ -56,6 +111,24 @@ type GitRepoConfig struct {
 	// must be provided.
 	Branch string
 
+	// ReadGitConfig reads the options without an explicit value from the `autotag.*` keys of the git
+	// config of the repo, eg: `git config autotag.prerelease rc`. The keys are branch, scheme,
+	// prerelease, timestamp, buildmetadata, tagformat, initialversion, prefix and the repeatable
+	// stablebranch, ignoredtype and bumprule (`<type>:<level>`). Explicit options take precedence,
+	// the list options as a whole. An unknown key is an error.
+	ReadGitConfig bool
+
+	// Rev optionally selects the commit to calculate the release for instead of the head of the
+	// Branch, eg: a commit SHA or the ephemeral `pipeline-123` tag a CI pipeline puts on the commit
+	// it was triggered for. Tags are dereferenced to their commit. The Branch still decides the
+	// branch based options, eg: StableBranches.
+	Rev string
+
+	// TagMergeBase optionally releases the merge-base of two refs instead of the head of the
+	// Branch, eg: `{"main", "integration"}` to release only what is merged to both. The merge-base
+	// is both the commit whose history is analyzed and the target of the tag, like a Rev.
+	TagMergeBase [2]string
+
 	// PreReleaseName is the optional string to be appended to a tag being
 	// generated (e.g., v.1.2.3-pre) to indicate the pre-release type.
 	//
@@ -66,8 +139,44 @@ type GitRepoConfig struct {
 	// 		* alpha
 	// 		* beta
 	// 		* rc
+	//
+	// The {type} token is replaced by the lowercased conventional commit type of the commit that
+	// decided the bump, eg: `{type}.rc` produces v1.3.0-feat.rc. The token is dropped when no
+	// commit decided the bump.
 	PreReleaseName string
 
+	// PreReleaseNameFunc optionally resolves the pre-release name of a scope on the branch instead
+	// of the static PreReleaseName, eg: `beta` for scope `api` but `alpha` for scope
+	// `experimental` on branch `develop`. The scope is empty for the "autotag" and "conventional"
+	// schemes. An empty name produces a stable version unless PreReleaseTimestampLayout is set,
+	// which fails on a branch that isn't one of the StableBranches.
+	PreReleaseNameFunc func(scope, branch string) string
+
+	// TypeChannels optionally maps the type of the commit that decided the bump to the pre-release
+	// channel of the release, eg: `feat` to `beta` and `fix` to StableChannel, so a range whose top
+	// change is a feature is released as `1.3.0-beta` while a range of fixes on the same branch is
+	// released as the stable `1.2.4`. The other types, and bumps no commit decided, use the
+	// PreReleaseName or PreReleaseNameFunc. The channel of a scope in the ScopeRegistry takes
+	// precedence.
+	TypeChannels map[string]string
+
+	// PreReleaseIncrement appends a counter to the pre-release name, one more than the highest
+	// counter of the existing pre-release tags of the core version and name, eg: v1.3.0-rc.3 after
+	// v1.3.0-rc.2. Stable versions, ie: with an empty pre-release name, don't get a counter.
+	PreReleaseIncrement bool
+
+	// PreReleaseCounter optionally returns the counter of PreReleaseIncrement instead of the tags,
+	// eg: the build number of the CI, which grows across branches. It gets the core version, eg:
+	// 1.3.0, and the pre-release name as channel, eg: `rc`. Setting it implies PreReleaseIncrement.
+	PreReleaseCounter func(core *version.Version, channel string) (int, error)
+
+	// VersionTransform optionally transforms the calculated version as the last step before the tag
+	// name is formed, after the pre-release and build metadata are appended, eg: to strip the
+	// pre-release on protected branches. It gets the result of the version before the transform,
+	// with the Scope, Current, Next, Tag, PreRelease and BuildMetadata set. An error aborts the
+	// calculation, the transformed version must still be valid SemVer.
+	VersionTransform func(v *version.Version, res Result) (*version.Version, error)
+
 	// PreReleaseTimestampLayout is the optional value that's used to append a
 	// timestamp to the git tag. The timezone will always be UTC. This value can
 	// either be the string `epoch` to be the UNIX epoch, or a Golang time
@@ -86,6 +195,13 @@ type GitRepoConfig struct {
 	// 		v1.2.3-pre.1499308568
 	PreReleaseTimestampLayout string
 
+	// AllowMetadataOnlyBump lets the "scope-conventional" scheme tag the base version again with
+	// other BuildMetadata when no commit bumps it, eg: `api-v1.2.3+ci.43` for a rebuild of
+	// `api-v1.2.3+ci.42`, instead of returning ErrNoBump. The tags of the same version with other
+	// build metadata then don't count as a collision, see ErrVersionExists and ErrAlreadyTagged.
+	// The default keeps requiring a higher version.
+	AllowMetadataOnlyBump bool
+
 	// BuildMetadata is an optional string appended by a plus sign and a series of dot separated
 	// identifiers immediately following the patch or pre-release version. Identifiers MUST comprise
 	// only ASCII alphanumerics and hyphen [0-9A-Za-z-]. Identifiers MUST NOT be empty. Build metadata
@@ -95,6 +211,12 @@ type GitRepoConfig struct {
 	// https://semver.org/#spec-item-10
 	BuildMetadata string
 
+	// BuildMetadataFromEnv names an environment variable, eg: `BUILD_NUMBER`, whose value is
+	// appended to the build metadata when the repo is created, for traceability to the CI run. It
+	// is added as another identifier after BuildMetadata, eg: `g12345678.42`. The variable must be
+	// set and the result must be valid SemVer build metadata.
+	BuildMetadataFromEnv string
+
 	// Scheme is the versioning scheme to use when determining the version of the next
 	// tag. If not specified the default "autotag" is used.
 	//
@@ -108,15 +230,498 @@ type GitRepoConfig struct {
 	//
 	//   * "conventional" implements the Conventional Commits v1.0.0 scheme.
 	//     * https://www.conventionalcommits.org/en/v1.0.0/#summary w
+	//
+	//   * "scope-conventional" versions each scope of a monorepo on its own: the scope of the latest
+	//     conventional commit selects the `<scope>-v1.2.3` tags to bump. "scope" and
+	//     "module-conventional" are aliases.
 	Scheme string
 
+	// Unscoped versions a single-module repo with the "scope-conventional" scheme: the tags are the
+	// plain `v1.2.3` tags and the conventional type or breaking change decides the bump, a scope in
+	// the message isn't required. It is the "conventional" scheme, so a repo can switch without
+	// changing the scheme, and the options that need scopes are rejected.
+	Unscoped bool
+
 	// Prefix prepends literal 'v' to the tag, eg: v1.0.0. Enabled by default
 	Prefix bool
+
+	// MajorBump, MinorBump and PatchBump optionally override the version arithmetic applied for
+	// each bump level. The commit message scheme still decides which level applies, the functions
+	// only compute the resulting version. If not specified SemVer increments are used.
+	MajorBump BumpFunc
+	MinorBump BumpFunc
+	PatchBump BumpFunc
+
+	// BumpFromBody optionally derives the bump level from the body of a commit message, eg: a "Type
+	// of change" checklist of a squash merged PR. When it returns true its level overrides the level
+	// of the commit message header. The body is the message without its first line.
+	BumpFromBody func(body string) (BumpLevel, bool)
+
+	// OnScopeError decides how NextScopeVersions and AutoTagScopes continue after an error of a
+	// scope, eg: a tag that can't be created. By default (nil) they abort, returning the error.
+	OnScopeError func(scope string, err error) ScopeErrorAction
+
+	// ScopeNormalizer optionally maps the scopes of the "scope-conventional" scheme to the scope
+	// their versions are compared and selected by, eg: `api-2023` and `api-2024` to `api` for scopes
+	// partitioned by year, so the base version is selected across the partitions. New tags keep the
+	// scope of the commit, eg: `api-2024-v1.3.0` after `api-2023-v1.2.0`. The batch operations over
+	// all scopes group the scopes by their normalized scope and tag with the scope of its most
+	// recent commit. The keys of CurrentVersions are normalized scopes.
+	ScopeNormalizer func(scope string) string
+
+	// ChangelogSubjectNormalizer optionally maps the subjects of the commits of a changelog to the
+	// key they are deduplicated by, eg: to collapse a commit and its squash merge. By default (nil)
+	// the subjects are normalized by NormalizeChangelogSubject.
+	ChangelogSubjectNormalizer func(subject string) string
+
+	// Verbosity is the level of the messages logged to the standard logger. By default
+	// (VerbosityInfo) only the meaningful events are logged: the base version, the calculated
+	// version, the tags written and the errors. VerbosityDebug also logs routine steps such as the
+	// skipped tags and commits, VerbosityQuiet logs nothing.
+	Verbosity Verbosity
+
+	// OnWarning optionally receives the warnings, eg: of CommitsSinceBaseWarning, instead of the
+	// logger, so they can be reported regardless of the Verbosity. By default (nil) they are logged
+	// as meaningful events.
+	OnWarning func(msg string)
+
+	// AllScopes prepares the repo only for the operations over all scopes of the
+	// "scope-conventional" scheme, eg: Preview. The version of the latest commit isn't calculated,
+	// so it doesn't need a scope, and AutoTag tags all scopes like AutoTagScopes.
+	AllScopes bool
+
+	// Submodules calculates the versions of the git submodules of the branch instead of the repo,
+	// for monorepos with a repo per service. Each submodule is versioned by its own tags and the
+	// commits of its main or master branch, with this configuration, see SubmoduleResults. Unlike
+	// the "scope-conventional" scheme, where the scopes share the tags and history of one repo,
+	// the scope of a Result is the submodule path and isn't part of the tag. Preview returns the
+	// results of the submodules and AutoTag tags each submodule repo.
+	Submodules bool
+
+	// Workers is the number of goroutines parsing the commit messages concurrently in the operations
+	// over all scopes, eg: NextScopeVersions, for large monorepos. 0 or 1 (default) parse them
+	// sequentially. The results don't depend on it; a BumpFromBody must be safe for concurrent use.
+	Workers int
+
+	// GitRetry retries the git operations reading the tags and the branch and creating the tag
+	// when they fail transiently, eg: on a locked ref in a busy CI. No retries by default.
+	GitRetry GitRetry
+
+	// PathScopes maps directories to scopes for the "scope-conventional" scheme, eg: `services/api`
+	// to `api`. When the commit message has no scope, the scope of the files changed by the latest
+	// commit is used, provided they all belong to the same scope. A directory matches itself and
+	// everything below it, the deepest match wins. Backslashes are accepted as path separators.
+	PathScopes map[string]string
+
+	// WorkspaceManifest is a file listing the packages of the workspace by name and path, relative
+	// to the repo, eg: generated from the pnpm, turbo or go workspace. The packages are the scopes
+	// of the "scope-conventional" scheme: their paths derive the scope like PathScopes, which take
+	// precedence for the same directory, and with AllScopes a package without a base version is
+	// reported as failed instead of being left out. See readWorkspaceManifest for the format.
+	WorkspaceManifest string
+
+	// ScopeFromOwnersFile is a CODEOWNERS style file, eg: `.github/CODEOWNERS`, deriving the scope of
+	// the changed files from their owner when the commit message has no scope, like PathScopes
+	// does: `@acme/billing` owns the files of the `billing` scope. Overlapping patterns follow
+	// CODEOWNERS, the last match wins. PathScopes take precedence for the files they match.
+	ScopeFromOwnersFile string
+
+	// ScopeRegistry is a YAML file, relative to the repo, with the settings of the scopes of the
+	// "scope-conventional" scheme, eg: DefaultScopeRegistry: their tag format, initial version,
+	// pre-release channel and allowed commit types. The settings of a scope take precedence over
+	// the global options, which apply to the settings it leaves unset and to the other scopes. A
+	// scope with its own tag format only has the tags of that format. Formats a tag could follow
+	// for two scopes fail with ErrAmbiguousTagFormat. See LoadScopeRegistry for the format.
+	ScopeRegistry string
+
+	// StableBranches optionally restricts stable (non pre-release) versions to these branches, eg:
+	// `main`. Glob patterns like `release/*` are supported. On other branches a pre-release name or
+	// timestamp must be configured, so feature branches can't accidentally produce a stable tag.
+	StableBranches []string
+
+	// BranchBumpCap optionally caps the bump level on branches matching a glob pattern, eg:
+	// `release/*` to BumpPatch for hotfix branches. A bump above the cap, whatever the commits or
+	// a forced Bump ask for, is reduced to the cap, so a `feat` on `release/1.x` is a patch. If
+	// several patterns match the branch the lowest cap applies.
+	BranchBumpCap map[string]BumpLevel
+
+	// BranchScopePattern is an optional regular expression with a named `scope` capture group,
+	// matched against the branch name to derive the scope when the commit message of the
+	// "scope-conventional" scheme has none, eg: `^release/(?P<scope>[^/]+)$`.
+	BranchScopePattern string
+
+	// BranchVersionPattern is an optional regular expression with named `major` and `minor`
+	// capture groups, matched against the branch name to derive the version line of a release
+	// branch, eg: `^release/(?:[^/]+/)?(?P<major>\d+)\.(?P<minor>\d+)$` for `release/api/1.3`. The
+	// next version must stay on the major.minor line of the branch, so only the patch and
+	// pre-release advance: a bump leaving the line, eg: a breaking change, fails with
+	// ErrOutsideVersionLine. Branches that don't match aren't constrained.
+	BranchVersionPattern string
+
+	// BaseOnPreRelease uses the highest pre-release tag as the base version when no stable
+	// (non pre-release) version tag exists, eg: for a scope that is still in initial development.
+	// The core version reserved by the pre-release is released first, so a patch on
+	// 1.0.0-rc.2 results in 1.0.0.
+	BaseOnPreRelease bool
+
+	// PreReleaseOrder ranks the pre-release channels, lowest first, when BaseOnPreRelease picks the
+	// highest pre-release, eg: `alpha`, `beta`, `rc`. The channel is the first identifier of the
+	// pre-release, compared case insensitive, so `RC.1` is higher than `beta.5` although it sorts
+	// lower in SemVer. Unlisted channels rank below the listed ones. Versions with a different core
+	// version and those of the same channel keep the SemVer order. By default (nil) the SemVer
+	// order is used. It has no effect with BaseByRecency.
+	PreReleaseOrder []string
+
+	// BaseByRecency selects the base version by the most recent tag (date of the tagged commit)
+	// rather than the highest SemVer version. After a revert or downgrade the latest tag can be
+	// lower than an older one; by default the older-but-higher tag is the base, which makes the
+	// next version jump back up. With BaseByRecency the next version follows the latest tag
+	// instead, at the risk of producing a version that was already released before.
+	BaseByRecency bool
+
+	// BasePerMajor selects the base version within the major line of the most recent stable version
+	// tagged in the history of the branch, eg: for a hotfix on a `release/1.x` branch while 2.x is
+	// released from main. The highest version of that major line is the base, also when it is
+	// tagged on another branch, so parallel hotfixes don't collide. Without a stable version tag in
+	// the history of the branch all versions are considered.
+	BasePerMajor bool
+
+	// TagDateSource selects the date of a tag used wherever tags are selected by date, eg:
+	// BaseByRecency. With "commit" (default) the committer date of the tagged commit is used, which
+	// every tag has. With "tag" the tagger date of annotated tags is used instead; an annotated tag
+	// created long after its commit then counts as recent even though its commit is old.
+	// Lightweight tags have no tagger date and always use the commit date.
+	TagDateSource string
+
+	// ReportTaggers fills in who cut the releases, see InventoryTag.Tagger and Stats.Releasers: the
+	// tagger of an annotated tag, the author of the tagged commit for a lightweight tag. It's off by
+	// default since every tag object has to be read.
+	ReportTaggers bool
+
+	// InitialVersion is the optional base version used when no usable version tag exists, eg:
+	// `0.0.0`. When not specified a missing stable version tag is an error.
+	InitialVersion string
+
+	// CommitsSinceBaseWarning optionally counts the commits of the branch since the base version
+	// into Result.CommitsSinceBase and logs a warning if there are more, eg: 500. Many commits since
+	// the base tag can mean a missed release or a misconfigured scope. Counting is disabled by
+	// default (0).
+	CommitsSinceBaseWarning int
+
+	// WarnOnUnusualBump warns, see OnWarning, if the bump is higher than any of the recent
+	// releases of the scope, eg: a major after a history of patches, as large jumps often are a
+	// misparsed commit message. The levels of the releases are the bumps between the stable
+	// version tags, a scope needs at least 3 releases, or UnusualBumpReleases if fewer. Forced
+	// bumps and graduating to 1.0.0 aren't checked.
+	WarnOnUnusualBump bool
+
+	// UnusualBumpReleases is the number of recent releases WarnOnUnusualBump compares the bump to,
+	// 5 if not set
+	UnusualBumpReleases int
+
+	// StrictUnusualBump fails with ErrUnusualBump instead of the warning of WarnOnUnusualBump.
+	// Requires WarnOnUnusualBump.
+	StrictUnusualBump bool
+
+	// MaxMajor optionally caps the major version as a safety rail, eg: against a misparsed commit
+	// message creating `api-v9999.0.0`. A bump above it fails with ErrMajorCap instead of creating
+	// the tag, also on a forced Bump. No cap by default (0).
+	MaxMajor int
+
+	// SinceRef optionally bounds the history scanned for a version without a base tag, eg: the
+	// first release of a repo adopting autotag mid-life: SinceRef and the commits before it are
+	// ignored for the bump. Versions with a base tag always count the commits since the tag. It
+	// may be any revision, eg: a commit SHA or a tag.
+	SinceRef string
+
+	// Graduate releases 1.0.0 if the major version of the base is 0, regardless of the bump level
+	// of the commits, eg: for the deliberate "we're going stable" release. A commit with a
+	// `Graduate: true` footer graduates the version (or its scope) without the option. Versions
+	// with a major version above 0 are bumped as usual.
+	Graduate bool
+
+	// CommitFilter selects which commits between the current tag and the branch are considered for
+	// the version bump: "all" (default), "merges-only" or "no-merges".
+	CommitFilter string
+
+	// IgnoreCommits are commit SHAs, or prefixes of at least 4 characters, excluded from the
+	// version bump, eg: a commit with a misleading message. They are merged with the commits
+	// listed in the IgnoreFile of the repository.
+	IgnoreCommits []string
+
+	// IgnoreAuthors excludes the commits of these authors from the version bump, eg:
+	// `dependabot[bot]`. A pattern matches the author name or email case insensitive, `*` matches
+	// any characters. They are merged with the authors listed in the IgnoreFile of the repository.
+	IgnoreAuthors []string
+
+	// AuthorEmailFilter is an allow-list of emails whose commits drive the version bump, eg: the
+	// domains of the org members. A commit counts if its author or its committer email matches,
+	// so an external contribution squash merged or rebased by a maintainer still counts, the other
+	// commits are excluded like IgnoreAuthors. An entry is an email pattern, case insensitive with
+	// `*` matching any characters, or a domain, eg: `example.com` for `*@example.com`. All commits
+	// count without a filter.
+	AuthorEmailFilter []string
+
+	// RequireSignedCommits only counts commits whose GPG/SSH signature verifies with the git
+	// configuration of the repo, eg: the keys of `gpg.ssh.allowedSignersFile`, towards the bump.
+	// What happens to the others is decided by UnsignedCommitPolicy.
+	RequireSignedCommits bool
+
+	// UnsignedCommitPolicy is UnsignedSkip (default) to exclude the commits without a verified
+	// signature from the bump like IgnoreAuthors, or UnsignedError to fail with ErrUnsignedCommit.
+	// Requires RequireSignedCommits.
+	UnsignedCommitPolicy string
+
+	// CancelReverts makes a revert of a commit of the release cancel it: neither the reverted
+	// commit nor its revert count towards the bump, eg: a feat and its revert net to no minor bump.
+	// A revert is recognized by its header, `Revert "<header>"` of git revert or the conventional
+	// `revert: <header>`, and matched by the reverted SHA in its body or else by the header. A
+	// revert of a commit of an earlier release still counts.
+	CancelReverts bool
+
+	// SkipEmptyRelease makes NewRepo return ErrNoBump instead of the fallback patch bump if no
+	// commit since the current tag qualifies for a release after filtering, ie: all of them are
+	// excluded by CommitFilter, IgnoreCommits, IgnoreAuthors or AuthorEmailFilter, or have one of
+	// the IgnoredTypes.
+	// A forced Bump always releases.
+	SkipEmptyRelease bool
+
+	// ScanBodyHeaders also evaluates the conventional commit headers in the body of a commit
+	// message, eg: the subjects of the commits of a squash merge, and takes the highest bump. With
+	// the "scope-conventional" scheme each header counts for its own scope.
+	ScanBodyHeaders bool
+
+	// CurrentVersions are base versions tracked outside of git, by scope ("" for the schemes
+	// without scopes), eg: for shallow clones without tags. The tags of those scopes are not read,
+	// only the commits since the tag of the version are parsed for the bump, all commits if there
+	// is no such tag. The other scopes fall back to the tags.
+	CurrentVersions map[string]*version.Version
+
+	// Releases are the releases tracked outside of git the base versions are selected from instead
+	// of the version tags, eg: the GitHub releases of the repo. The base version is selected as
+	// from the tags, a release marked PreRelease counts as a pre-release, see BaseOnPreRelease.
+	// Only the commits since the tag of the base release are parsed, all commits if the tag
+	// doesn't exist in the repo. CurrentVersions take precedence.
+	Releases []Release
+
+	// StrictTypeCase only accepts lowercase conventional commit types. By default types are matched
+	// case insensitive, so `Feat(api):` is a minor bump just like `feat(api):`.
+	StrictTypeCase bool
+
+	// StrictCommitFormat only accepts conventional commit headers without blanks around the scope
+	// and the colon. By default common typos like `feat (api): add login` or `feat(api) : add login`
+	// are tolerated. It has no effect with a CommitRegex.
+	StrictCommitFormat bool
+
+	// RequireConventional ignores the commits of the "conventional" and "scope-conventional"
+	// schemes whose header isn't a conventional commit header, ie: a type and a colon, eg: `fixed
+	// the bug`. By default the first word of such a message is taken as its type, so it bumps the
+	// patch version. Ignored commits don't count as qualifying commits for SkipEmptyRelease.
+	RequireConventional bool
+
+	// CommitRegex is an optional regular expression replacing the built-in conventional commit
+	// header regex of the "conventional" and "scope-conventional" schemes. It must contain the
+	// named capture groups `type`, `scope`, `breaking` and `subject`. Parentheses around the scope
+	// and a leading colon of the subject are removed, just like with the built-in regex.
+	CommitRegex *regexp.Regexp
+
+	// BumpRules maps conventional commit types to the level they bump, eg: `perf` to BumpMinor.
+	// The rules are merged with the default rules (`feat` is a minor bump), types without a rule
+	// bump the patch version. A breaking change (`!` or a `BREAKING CHANGE:` footer) is always a
+	// major bump. Types are matched case insensitive unless StrictTypeCase is set.
+	BumpRules BumpRules
+
+	// BumpFooters are conventional commit footer keys whose value sets the bump level of a commit,
+	// eg: `Release-As: major` makes a `fix` commit a major bump. They are merged with the default
+	// `Release-As` footer and matched case insensitive. A footer overrides the level of the type,
+	// a breaking change and BumpFromBody; values that aren't a bump level are ignored.
+	BumpFooters []string
+
+	// BumpEscalation raises the bump level of the commits matching a rule to at least the level of
+	// the rule, eg: a `fix(security)` commit or one with a `Security: true` footer to a minor bump.
+	// The rules match on the type, scope and footers of a commit and apply after the BumpRules;
+	// a level set by a bump footer, eg: `Release-As: patch`, isn't escalated.
+	BumpEscalation []EscalationRule
+
+	// Scope releases this scope with the "scope-conventional" scheme instead of the scope derived
+	// from the latest commit, its files or the branch. Its tags are still used for the base version.
+	// The bump level is decided by the commits of the scope since its base tag, as by
+	// NextScopeVersion, so the latest commit may be of another scope or have none. Only if the
+	// scope has no commits the latest commit decides.
+	Scope string
+
+	// Bump forces this bump level instead of the level of the commits, eg: for a manual release.
+	// With Scope set the commit messages aren't parsed at all. BumpNone (default) keeps the
+	// commit driven bump.
+	Bump BumpLevel
+
+	// CheckRemote is the name of a remote, eg: `origin`, checked with `git ls-remote` before the tag
+	// is created. AutoTag returns ErrRemoteTagExists if the remote already has the tag. The check
+	// is disabled by default.
+	CheckRemote string
+
+	// VersionFromTagMessage also reads the version of annotated tags whose name isn't a version tag
+	// from the tag message, eg: of legacy tags named by codename. The first word of the message that
+	// is a version tag name of the scheme is used, eg: `api-v1.2.3` of `Release api-v1.2.3`, so
+	// the scope is parsed from the message as well. It isn't supported with RemoteTags.
+	VersionFromTagMessage bool
+
+	// CollectSkippedTags records the tags passed over when the base version is selected, with the
+	// reason, eg: to tell why a tag didn't become the base version. See SkippedTags. It is off by
+	// default, as it keeps the names of all non version tags.
+	CollectSkippedTags bool
+
+	// RemoteTags reads the version tags from TagRemote with `git ls-remote` instead of the local
+	// tags, so no tags have to be fetched, eg: in a `git clone --no-tags` checkout. The commits of
+	// the tags still have to be in the local history. AutoTag pushes the new tag to TagRemote. It
+	// is only supported by the default Tagger.
+	RemoteTags bool
+
+	// TagRemote is the remote of RemoteTags. By default it is the remote the branch tracks, eg:
+	// `upstream` in a fork, so the same remote is used as by `git pull`; NewRepo fails if the
+	// branch has no upstream.
+	TagRemote string
+
+	// RequireUpToDate makes AutoTag return ErrBranchBehind instead of tagging when the upstream
+	// of the branch has commits the branch doesn't have. The branch must have an upstream.
+	RequireUpToDate bool
+
+	// RequireCleanTree makes AutoTag return ErrDirtyTree instead of tagging when the working tree
+	// has uncommitted changes to tracked files. It is skipped for bare repos.
+	RequireCleanTree bool
+
+	// AllowedEvents optionally restricts creating tags to the CI events listed, eg: `push`, so
+	// tags are never created on a `pull_request`. On any other Event, also on none, AutoTag and the
+	// other operations creating tags fail with ErrEventNotAllowed. Calculating the versions isn't
+	// restricted.
+	AllowedEvents []string
+
+	// Event is the CI event of the run checked against AllowedEvents, eg: from GITHUB_EVENT_NAME
+	Event string
+
+	// AvoidReusedVersions returns ErrReusedVersion instead of a next version that was used by a
+	// tag which has been deleted since. Only deleted annotated tags are detected, their tag objects
+	// are kept until git garbage collects them.
+	AvoidReusedVersions bool
+
+	// PostTagHooks are called in order with the Result of every tag AutoTag creates, once it is
+	// created and pushed, eg: to notify a chat or call a webhook. AutoTagScopes calls them after all
+	// tags of the batch are created. A failing hook stops the others and AutoTag returns
+	// ErrPostTagHook, although the tag exists.
+	PostTagHooks []PostTagHook
+
+	// PostTagHookWarn only logs the errors of the PostTagHooks, so AutoTag succeeds and all hooks
+	// run
+	PostTagHookWarn bool
+
+	// ReservedVersions are versions the calculated versions must not land on, eg: a pre-announced
+	// `2.0.0` of a coordinated release. Only the major.minor.patch core is compared, so pre-releases
+	// of a reserved version are avoided too. What happens instead is decided by
+	// ReservedVersionPolicy. To release a reserved version, remove it from the list.
+	ReservedVersions []string
+
+	// SkipExisting bumps a next version that is already tagged again by the same level until the
+	// tag is free, eg: the patch bump of 1.2.3 results in 1.2.6 if 1.2.4 and 1.2.5 were tagged out
+	// of band, instead of failing with ErrVersionExists. A stable version of a pre-release tag is
+	// still released. The risk is that the skipped tags aren't on the branch, so the new version
+	// doesn't contain their changes although it is higher, and a mistakenly created tag goes
+	// unnoticed.
+	SkipExisting bool
+
+	// ReservedVersionPolicy is ReservedSkip (default) to bump a reserved version again by the same
+	// level, eg: the minor bump of 1.3.0 results in 1.5.0 if 1.4.0 is reserved, or ReservedError to
+	// fail with ErrReservedVersion.
+	ReservedVersionPolicy string
+
+	// ASCIIScopes rejects scopes with non-ASCII characters with ErrNonASCIIScope, eg: where other
+	// tools can't handle them. Scopes and types may have any unicode letters by default.
+	ASCIIScopes bool
+
+	// FollowMergeParent makes the "scope-conventional" scheme fall back to the commits of the merged
+	// branch when the latest commit is a merge without a conventional commit message, eg: a
+	// `Merge branch 'feature'` boilerplate message. The newest conventional commit of the merged
+	// branch (second parent) is used instead.
+	FollowMergeParent bool
+
+	// IgnoredTypes are the conventional commit types of the "scope-conventional" scheme that don't
+	// warrant a tag, eg: `chore` or `docs`. NewRepo returns ErrIgnoredType for those commits. The
+	// other schemes only skip them with SkipEmptyRelease.
+	IgnoredTypes []string
+
+	// OverrideMessage is the optional message of a pending commit on top of the branch, eg: from a
+	// pre-commit hook, to preview the version it would produce. The tag history is still read from
+	// git. The "scope-conventional" scheme uses it instead of the message of the latest commit,
+	// the other schemes parse it after the commits since the current tag (skipped with the
+	// "merges-only" CommitFilter, a pending commit is never a merge).
+	OverrideMessage string
+
+	// PRTitle is the optional title of the pull request being merged, eg: from the CI environment,
+	// for squash merge workflows where the commit messages on the branch are noise but the PR title
+	// is a clean conventional commit header. It is parsed like a commit message for the bump and,
+	// with the "scope-conventional" scheme, the scope. PRTitleMode decides whether it replaces the
+	// commit messages (the default) or is evaluated in addition to them.
+	PRTitle string
+	// PRTitleMode is PRTitleReplace (default) or PRTitleMerge. With PRTitleMerge the
+	// "scope-conventional" scheme takes the scope of the title, or of the latest commit if the
+	// title has none, and the higher level of both for it.
+	PRTitleMode string
+
+	// WriteVersionFile is the optional path of a file GitRepo.WriteVersionFile writes the new
+	// version to, eg: `VERSION`, for tooling that reads the version from a file rather than git
+	// tags. The version is written without prefix or scope, eg: `1.2.3`. A `{scope}` in the path is
+	// replaced with the scope of the "scope-conventional" scheme, eg: `services/{scope}/VERSION`.
+	// Relative paths are relative to the repository root.
+	WriteVersionFile string
+
+	// TagFormat is the optional format of the tag names, with a `{version}` and for the
+	// "scope-conventional" scheme a `{scope}` placeholder, eg: `{scope}@{version}` or
+	// `release-{version}`. It replaces the default formats `{scope}-v{version}` and `v{version}`
+	// (without the `v` if Prefix isn't set), and is used both to find and to create tags.
+	TagFormat string
+
+	// ScopeVersionSeparator separates the scope and the version in the tag names of the
+	// "scope-conventional" scheme, eg: `@` for `api@1.2.3` or `/` for `api/v1.2.3`. Defaults to `-`.
+	// It's a shorthand for the common TagFormat `{scope}<separator>v{version}` and can't be combined
+	// with a TagFormat.
+	ScopeVersionSeparator string
+
+	// VersionRefNamespace is the ref namespace versions are read from and written to, eg:
+	// `refs/autotag` to keep release refs out of `git tag`. Defaults to `refs/tags`. Refs outside of
+	// `refs/tags` are plain (lightweight) refs, so tag signing requires the default namespace.
+	VersionRefNamespace string
+
+	// Tagger is an optional Tagger used to create the new tag. If not specified tags are created
+	// with the git CLI via git-module.
+	Tagger Tagger
+
+	// SignTag creates a GPG/SSH signed annotated tag (`git tag -s`). Signing is done by git using
+	// its configured signing program, so it is only supported by the default Tagger. Failing to
+	// sign is an error, an unsigned tag is never created instead.
+	SignTag bool
+
+	// SigningKey is the optional key used to sign the tag (`git tag -u`). If not specified git's
+	// default signing key (`user.signingKey`) is used. Requires SignTag.
+	SigningKey string
+
+	// TagMessageTemplate is an optional text/template rendering the message of the created tags
+	// from their Result, eg: `Release {{.Tag}}\n\n{{.BumpReason}}`, see ParseResultTemplate. The
+	// tags are annotated then. Preview renders the message of every result too, so a template
+	// failing on a result is reported before any tag is created.
+	TagMessageTemplate string
+
+	// TagRangeTrailers appends the range of the release to the message of the created tags as
+	// trailers: the base tag and the tagged commit, eg: `From: api-v1.2.0` and `To: <sha>`. The
+	// tags are annotated then, the message is the tag name without a TagMessageTemplate. There is
+	// no From trailer without a base tag, eg: for the InitialVersion.
+	TagRangeTrailers bool
 }
 
 // GitRepo represents a repository we want to run actions against
 type GitRepo struct {
-	repo *git.Repository
+	repo         *git.Repository
+	workTree     string
+	refNamespace string
+	tagger       Tagger
 
 	currentVersion *version.Version
 	currentTag     *git.Commit
@@ -124,18 +729,184 @@ type GitRepo struct {
 	scope          string
 	branch         string
 	branchID       string // commit id of the branch latest commit (where we will apply the tag)
+	targetCommit   *git.Commit
 
 	preReleaseName            string
+	preReleaseNameFunc        func(scope, branch string) string
+	typeChannels              map[string]string
+	preReleaseIncrement       bool
+	preReleaseCounterFunc     func(core *version.Version, channel string) (int, error)
+	versionTransform          func(v *version.Version, res Result) (*version.Version, error)
 	preReleaseTimestampLayout string
 	buildMetadata             string
+	allowMetadataOnly         bool
+	// preReleaseOnly is set on a branch that isn't one of the StableBranches
+	preReleaseOnly bool
 
 	scheme string
 
 	prefix bool
+
+	tagFormat string
+	tagRex    *regexp.Regexp // matches tags of a custom tag format, nil for the default format
+
+	majorBump BumpFunc
+	minorBump BumpFunc
+	patchBump BumpFunc
+
+	bumpFromBody func(body string) (BumpLevel, bool)
+	onScopeError func(scope string, err error) ScopeErrorAction
+	normalizer   func(scope string) string
+	verbosity    Verbosity
+	onWarning    func(msg string)
+	allScopes    bool
+	// submoduleCfg is the configuration of the submodule repos, nil without Submodules
+	submoduleCfg *GitRepoConfig
+	workers      int
+	gitRetry     GitRetry
+
+	// subjectNormalizer is the dedup key of the commits of a changelog, see ChangelogSubjectNormalizer
+	subjectNormalizer func(subject string) string
+
+	ignoreCommits []string
+	ignoreAuthors []*regexp.Regexp
+	allowedEmails []*regexp.Regexp
+
+	// signatures caches whether the signature of a commit verifies, nil without
+	// RequireSignedCommits, see signedCommit
+	signatures     *signatureCache
+	unsignedPolicy string
+
+	bumpReason     string
+	decidingCommit string
+	bumpType       string
+	// changedPaths are the files the scope was derived from, see Result.ChangedPaths
+	changedPaths []string
+
+	scanBodyHeaders bool
+
+	// currentVersions are the base versions set with CurrentVersions, see injectedBase
+	currentVersions map[string]*version.Version
+	// releases are the releases set with Releases, see loadReleases
+	releases []Release
+
+	// tags is the cached tag index, see loadTags
+	tags tagIndex
+
+	branchScopeRex *regexp.Regexp
+	pathScopes     map[string]string
+	ownerRules     []ownerRule
+	// workspaceScopes are the scopes of the WorkspaceManifest
+	workspaceScopes []string
+	// registry are the settings of the scopes of the ScopeRegistry, formattedScopes the ones with
+	// a tag format in order
+	registry        map[string]*scopeConfig
+	formattedScopes []string
+	// versionLine is the major.minor line of the branch, see BranchVersionPattern, nil if the
+	// branch has none
+	versionLine *version.Version
+
+	baseOnPreRelease bool
+	preReleaseOrder  []string
+	baseByRecency    bool
+	basePerMajor     bool
+	tagDateSource    string
+	reportTaggers    bool
+	initialVersion   *version.Version
+	graduate         bool
+	// sinceID is the commit of SinceRef
+	sinceID string
+
+	// commitsSinceBase counts the commits since the base version, see countCommitsSinceBase
+	commitsSinceBaseWarning int
+	commitsSinceBase        int
+
+	// warnUnusualBump compares the bumps to the recent releases, see WarnOnUnusualBump
+	warnUnusualBump     bool
+	unusualBumpReleases int
+	strictUnusualBump   bool
+	// maxMajor is the highest major version, 0 without a cap, see MaxMajor
+	maxMajor int
+
+	signTag bool
+	// tagMessageTmpl renders the message of the created tags, see TagMessageTemplate
+	tagMessageTmpl *template.Template
+	rangeTrailers  bool
+
+	commitFilter   string
+	strictTypeCase bool
+	commitRex      *regexp.Regexp
+	// requireConventional ignores free-form commits, see RequireConventional
+	requireConventional bool
+
+	overrideMessage  string
+	prTitle          string
+	prTitleMode      string
+	ignoredTypes     []string
+	skipEmptyRelease bool
+	versionFile      string
+
+	followMergeParent bool
+	cancelReverts     bool
+	bumpRules         BumpRules
+	bumpFooters       []*regexp.Regexp
+	escalations       []escalation
+
+	releaseScope string
+	rev          string
+	releaseBump  BumpLevel
+	// bumpCap is the cap of BranchBumpCap for the branch, BumpNone if no pattern matches
+	bumpCap BumpLevel
+
+	requireUpToDate  bool
+	requireCleanTree bool
+	checkRemote      string
+	// allowedEvents are the CI events tags are created on, see AllowedEvents
+	allowedEvents []string
+	event         string
+
+	// remoteTags reads the version tags from tagRemote, remoteTagCommits caches them, see
+	// remoteVersionTags
+	remoteTags       bool
+	tagRemote        string
+	remoteTagCommits map[string]string
+
+	// versionFromMessage reads the versions of tags from their message, see versionFromTagMessage
+	versionFromMessage bool
+
+	// collectSkipped records the skipped tags, see SkippedTags. collectingSkips is set while the
+	// base version is selected.
+	collectSkipped  bool
+	collectingSkips bool
+	nonVersionTags  []string
+	skipped         []SkippedTag
+
+	avoidReusedVersions bool
+	reservedVersions    []*version.Version
+	postTagHooks        []PostTagHook
+	postTagHookWarn     bool
+	reservedPolicy      string
+	skipExisting        bool
+	asciiScopes         bool
+
+	// deleted are the deleted version tags, see deletedTags
+	deleted map[string][]deletedTag
 }
 
 // NewRepo is a constructor for a repo object, parsing the tags that exist
 func NewRepo(cfg GitRepoConfig) (*GitRepo, error) {
+	if cfg.ReadGitConfig {
+		var err error
+		if cfg, err = applyGitConfig(cfg); err != nil {
+			return nil, err
+		}
+	}
+	if scheme, ok := schemeAliases[cfg.Scheme]; ok {
+		cfg.Scheme = scheme
+	}
+	if cfg.Unscoped && cfg.Scheme == "scope-conventional" {
+		cfg.Scheme = "conventional"
+	}
 	if err := validateConfig(cfg); err != nil {
 		return nil, err
 	}
@@ -144,6 +915,24 @@ func NewRepo(cfg GitRepoConfig) (*GitRepo, error) {
 		cfg.PreReleaseTimestampLayout = datetimeTsLayout
 	}
 
+	if cfg.BuildMetadataFromEnv != "" {
+		build := os.Getenv(cfg.BuildMetadataFromEnv)
+		if build == "" {
+			return nil, fmt.Errorf("build metadata environment variable %s is not set", cfg.BuildMetadataFromEnv)
+		}
+		if cfg.BuildMetadata != "" {
+			build = cfg.BuildMetadata + "." + build
+		}
+		if !validateSemVerBuildMetadata(build) {
+			return nil, fmt.Errorf("'%s' from environment variable %s is not valid SemVer build metadata", build, cfg.BuildMetadataFromEnv)
+		}
+		cfg.BuildMetadata = build
+	}
+
+	workTree, err := filepath.Abs(cfg.RepoPath)
+	if err != nil {
+		return nil, err
+	}
 	gitDirPath, err := generateGitDirPath(cfg.RepoPath)
 	if err != nil {
 		return nil, err
@@ -153,11 +942,14 @@ func NewRepo(cfg GitRepoConfig) (*GitRepo, error) {
 		return nil, err
 	}
 
-	log.Println("Opening repo at", gitDirPath)
+	logf(cfg.Verbosity, VerbosityDebug, "Opening repo at %s", gitDirPath)
 	repo, err := git.Open(gitDirPath)
 	if err != nil {
 		return nil, err
 	}
+	if err := checkEmptyRepo(repo); err != nil {
+		return nil, err
+	}
 
 	if cfg.Branch == "" {
 		branches, err := repo.Branches()
@@ -182,41 +974,302 @@ func NewRepo(cfg GitRepoConfig) (*GitRepo, error) {
 		}
 	}
 
+	preReleaseOnly := len(cfg.StableBranches) > 0 && !matchBranch(cfg.StableBranches, cfg.Branch)
+	if preReleaseOnly && cfg.PreReleaseNameFunc == nil && len(cfg.TypeChannels) == 0 &&
+		cfg.PreReleaseName == "" && cfg.PreReleaseTimestampLayout == "" {
+		return nil, fmt.Errorf("branch '%s' isn't a stable branch and can only produce pre-releases, a pre-release name or timestamp is required", cfg.Branch)
+	}
+
+	refNamespace := strings.TrimSuffix(cfg.VersionRefNamespace, "/")
+	if refNamespace == "" {
+		refNamespace = DefaultVersionRefNamespace
+	}
+
+	tagRemote := cfg.TagRemote
+	if tagRemote == "" && cfg.RemoteTags {
+		if tagRemote, err = trackingRemote(repo.Path(), cfg.Branch); err != nil {
+			return nil, err
+		}
+	}
+
+	tagger := cfg.Tagger
+	if tagger == nil {
+		tagger = &gitTagger{repo: repo, namespace: refNamespace, sign: cfg.SignTag, signingKey: cfg.SigningKey}
+	}
+
 	r := &GitRepo{
+		graduate:                  cfg.Graduate,
+		commitsSinceBaseWarning:   cfg.CommitsSinceBaseWarning,
+		warnUnusualBump:           cfg.WarnOnUnusualBump,
+		unusualBumpReleases:       defaultUnusualBumpReleases,
+		strictUnusualBump:         cfg.StrictUnusualBump,
+		maxMajor:                  cfg.MaxMajor,
 		repo:                      repo,
+		workTree:                  workTree,
+		refNamespace:              refNamespace,
+		tagger:                    tagger,
+		versionFile:               cfg.WriteVersionFile,
+		followMergeParent:         cfg.FollowMergeParent,
+		releaseScope:              cfg.Scope,
+		rev:                       cfg.Rev,
+		releaseBump:               cfg.Bump,
+		checkRemote:               cfg.CheckRemote,
 		branch:                    cfg.Branch,
+		bumpCap:                   branchBumpCap(cfg.BranchBumpCap, cfg.Branch),
 		preReleaseName:            cfg.PreReleaseName,
+		preReleaseNameFunc:        cfg.PreReleaseNameFunc,
+		typeChannels:              cfg.TypeChannels,
+		preReleaseIncrement:       cfg.PreReleaseIncrement || cfg.PreReleaseCounter != nil,
+		allowMetadataOnly:         cfg.AllowMetadataOnlyBump,
+		preReleaseCounterFunc:     cfg.PreReleaseCounter,
+		versionTransform:          cfg.VersionTransform,
+		preReleaseOnly:            preReleaseOnly,
 		preReleaseTimestampLayout: cfg.PreReleaseTimestampLayout,
 		buildMetadata:             cfg.BuildMetadata,
 		scheme:                    cfg.Scheme,
 		prefix:                    cfg.Prefix,
+		baseOnPreRelease:          cfg.BaseOnPreRelease,
+		preReleaseOrder:           cfg.PreReleaseOrder,
+		baseByRecency:             cfg.BaseByRecency,
+		basePerMajor:              cfg.BasePerMajor,
+		tagDateSource:             cfg.TagDateSource,
+		reportTaggers:             cfg.ReportTaggers,
+		signTag:                   cfg.SignTag,
+		rangeTrailers:             cfg.TagRangeTrailers,
+		commitFilter:              cfg.CommitFilter,
+		strictTypeCase:            cfg.StrictTypeCase,
+		requireConventional:       cfg.RequireConventional,
+		commitRex:                 cfg.CommitRegex,
+		overrideMessage:           cfg.OverrideMessage,
+		prTitle:                   cfg.PRTitle,
+		prTitleMode:               cfg.PRTitleMode,
+		ignoredTypes:              cfg.IgnoredTypes,
+		skipEmptyRelease:          cfg.SkipEmptyRelease,
+		cancelReverts:             cfg.CancelReverts,
+		majorBump:                 cfg.MajorBump,
+		bumpFromBody:              cfg.BumpFromBody,
+		onScopeError:              cfg.OnScopeError,
+		normalizer:                cfg.ScopeNormalizer,
+		subjectNormalizer:         cfg.ChangelogSubjectNormalizer,
+		verbosity:                 cfg.Verbosity,
+		onWarning:                 cfg.OnWarning,
+		allScopes:                 cfg.AllScopes,
+		workers:                   cfg.Workers,
+		gitRetry:                  cfg.GitRetry,
+		scanBodyHeaders:           cfg.ScanBodyHeaders,
+		requireUpToDate:           cfg.RequireUpToDate,
+		requireCleanTree:          cfg.RequireCleanTree,
+		allowedEvents:             cfg.AllowedEvents,
+		event:                     cfg.Event,
+		remoteTags:                cfg.RemoteTags,
+		versionFromMessage:        cfg.VersionFromTagMessage,
+		collectSkipped:            cfg.CollectSkippedTags,
+		tagRemote:                 tagRemote,
+		avoidReusedVersions:       cfg.AvoidReusedVersions,
+		reservedPolicy:            cfg.ReservedVersionPolicy,
+		skipExisting:              cfg.SkipExisting,
+		postTagHooks:              cfg.PostTagHooks,
+		postTagHookWarn:           cfg.PostTagHookWarn,
+		asciiScopes:               cfg.ASCIIScopes,
+		currentVersions:           copyVersions(cfg.CurrentVersions),
+		releases:                  copyReleases(cfg.Releases),
+		minorBump:                 cfg.MinorBump,
+		patchBump:                 cfg.PatchBump,
 	}
-
-	if r.scheme == "scope-conventional" {
-		if err = r.scopeSchemeCalcVersion(); err != nil {
+	r.configureBumps(cfg)
+	if cfg.TagMergeBase != [2]string{} {
+		if r.rev, err = r.mergeBase(cfg.TagMergeBase); err != nil {
 			return nil, err
 		}
-		return r, nil
 	}
-
-	err = r.parseTags()
+	separator := cfg.ScopeVersionSeparator
+	if separator == "" {
+		separator = defaultScopeVersionSeparator
+	}
+	r.tagFormat = defaultTagFormat(cfg.Scheme, cfg.Prefix, separator)
+	if separator != defaultScopeVersionSeparator {
+		r.tagRex = scopeSeparatorRegex(separator)
+	}
+	if cfg.TagFormat != "" {
+		r.tagFormat = cfg.TagFormat
+		r.tagRex = tagFormatRegex(cfg.TagFormat)
+	}
+	ignoreCommits, ignoreAuthors, err := readIgnoreFile(r.workTree)
 	if err != nil {
 		return nil, err
 	}
-
-	if err := r.calcVersion(); err != nil {
+	for _, shas := range [][]string{cfg.IgnoreCommits, ignoreCommits} {
+		for _, sha := range shas {
+			r.ignoreCommits = append(r.ignoreCommits, strings.ToLower(sha))
+		}
+	}
+	for _, patterns := range [][]string{cfg.IgnoreAuthors, ignoreAuthors} {
+		for _, pattern := range patterns {
+			r.ignoreAuthors = append(r.ignoreAuthors, authorPatternRex(pattern))
+		}
+	}
+	for _, pattern := range cfg.AuthorEmailFilter {
+		r.allowedEmails = append(r.allowedEmails, emailPatternRex(pattern))
+	}
+	if cfg.RequireSignedCommits {
+		r.signatures, r.unsignedPolicy = &signatureCache{signed: map[string]bool{}}, cfg.UnsignedCommitPolicy
+	}
+	if cfg.BranchScopePattern != "" {
+		r.branchScopeRex = regexp.MustCompile(cfg.BranchScopePattern)
+	}
+	if cfg.BranchVersionPattern != "" {
+		r.versionLine = branchVersionLine(regexp.MustCompile(cfg.BranchVersionPattern), cfg.Branch)
+	}
+	if cfg.ScopeFromOwnersFile != "" {
+		if r.ownerRules, err = readOwnersFile(r.workTree, cfg.ScopeFromOwnersFile); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.WorkspaceManifest != "" {
+		packages, err := readWorkspaceManifest(r.workTree, cfg.WorkspaceManifest)
+		if err != nil {
+			return nil, err
+		}
+		r.pathScopes = make(map[string]string, len(packages)+len(cfg.PathScopes))
+		for _, pkg := range packages {
+			r.pathScopes[normalizeScopePath(pkg.Path)] = pkg.Name
+			r.workspaceScopes = append(r.workspaceScopes, pkg.Name)
+		}
+	}
+	if cfg.ScopeRegistry != "" {
+		if r.registry, err = readScopeRegistry(r.workTree, cfg.ScopeRegistry); err != nil {
+			return nil, err
+		}
+		r.formattedScopes = formattedScopes(r.registry)
+		if err = r.checkAmbiguousFormats(); err != nil {
+			return nil, err
+		}
+	}
+	if len(cfg.PathScopes) > 0 {
+		if r.pathScopes == nil {
+			r.pathScopes = make(map[string]string, len(cfg.PathScopes))
+		}
+		for dir, scope := range cfg.PathScopes {
+			r.pathScopes[normalizeScopePath(dir)] = scope
+		}
+	}
+	if cfg.InitialVersion != "" {
+		if r.initialVersion, err = parseVersion(cfg.InitialVersion); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.UnusualBumpReleases > 0 {
+		r.unusualBumpReleases = cfg.UnusualBumpReleases
+	}
+	if cfg.TagMessageTemplate != "" {
+		if r.tagMessageTmpl, err = ParseResultTemplate(cfg.TagMessageTemplate); err != nil {
+			return nil, fmt.Errorf("tag message: %s", err)
+		}
+	}
+	if r.reservedVersions, err = parseReservedVersions(cfg.ReservedVersions); err != nil {
 		return nil, err
 	}
+	if cfg.Submodules {
+		submoduleCfg := cfg
+		r.submoduleCfg = &submoduleCfg
+	}
+	if cfg.SinceRef != "" {
+		since, err := repo.CommitByRevision(cfg.SinceRef)
+		if err != nil {
+			return nil, fmt.Errorf("since ref '%s' not found: %s", cfg.SinceRef, err)
+		}
+		r.sinceID = since.ID.String()
+	}
 
+	if err = r.calculate(); err != nil {
+		return nil, err
+	}
 	return r, nil
 }
 
+// configureBumps sets up how commit messages are parsed and versions bumped: the default bump
+// functions, the commit regex, the bump rules, footers and escalations of cfg
+func (r *GitRepo) configureBumps(cfg GitRepoConfig) {
+	if r.majorBump == nil {
+		r.majorBump = majorBumper.bump
+	}
+	if r.minorBump == nil {
+		r.minorBump = minorBumper.bump
+	}
+	if r.patchBump == nil {
+		r.patchBump = patchBumper.bump
+	}
+	if r.commitRex == nil {
+		r.commitRex = lenientCommitRex
+		if cfg.StrictCommitFormat {
+			r.commitRex = conventionalCommitRex
+		}
+	}
+	r.bumpRules = make(BumpRules, len(defaultBumpRules)+len(cfg.BumpRules))
+	for typ, level := range defaultBumpRules {
+		r.bumpRules[typ] = level
+	}
+	for typ, level := range cfg.BumpRules {
+		r.bumpRules[normalizeType(typ, r.strictTypeCase)] = level
+	}
+	for _, keys := range [][]string{defaultBumpFooters, cfg.BumpFooters} {
+		for _, key := range keys {
+			r.bumpFooters = append(r.bumpFooters, bumpFooterRegex(key))
+		}
+	}
+	r.escalations = newEscalations(cfg.BumpEscalation, r.strictTypeCase)
+}
+
+// calculate calculates the next version according to the scheme. With AllScopes or Submodules
+// only the branch is resolved, the scopes or submodules are calculated by the batch operations.
+func (r *GitRepo) calculate() error {
+	if r.allScopes || r.submoduleCfg != nil {
+		return r.retrieveBranchInfo()
+	}
+
+	scope := ""
+	if r.scheme == "scope-conventional" {
+		if err := r.scopeSchemeCalcVersion(); err != nil {
+			return err
+		}
+		scope = r.scope
+	} else {
+		if err := r.parseTags(); err != nil {
+			return err
+		}
+		if err := r.calcVersion(); err != nil {
+			return err
+		}
+	}
+	r.infof("calculated version %s from %s: %s\n", r.newVersion, r.currentVersion, r.bumpReason)
+	var err error
+	if r.commitsSinceBase, err = r.countCommitsSinceBase(scope, r.currentVersion, r.currentTag); err != nil {
+		return err
+	}
+	return r.checkReusedVersion(scope, r.newVersion)
+}
+
 func validateConfig(cfg GitRepoConfig) error {
+	switch cfg.Scheme {
+	case "", "autotag", "conventional", "scope-conventional":
+	default:
+		return fmt.Errorf("scheme '%s' is not valid; must be (autotag|conventional|scope-conventional)", cfg.Scheme)
+	}
+
 	if cfg.BuildMetadata != "" && !validateSemVerBuildMetadata(cfg.BuildMetadata) {
 		return fmt.Errorf("'%s' is not valid SemVer build metadata", cfg.BuildMetadata)
 	}
 
-	if cfg.PreReleaseName != "" && !validateSemVerPreReleaseName(cfg.PreReleaseName) {
+	for typ, channel := range cfg.TypeChannels {
+		if !commitTypeRex.MatchString(typ) {
+			return fmt.Errorf("type channel type '%s' is not a valid commit type", typ)
+		}
+		if channel != StableChannel && !validateSemVerPreReleaseName(preReleaseTypeName(channel, "type")) {
+			return fmt.Errorf("channel '%s' of type '%s' is not valid SemVer pre-release name", channel, typ)
+		}
+	}
+
+	if cfg.PreReleaseName != "" && !validateSemVerPreReleaseName(preReleaseTypeName(cfg.PreReleaseName, "type")) {
 		return fmt.Errorf("'%s' is not valid SemVer pre-release name", cfg.PreReleaseName)
 	}
 
@@ -227,272 +1280,1219 @@ func validateConfig(cfg GitRepoConfig) error {
 		return fmt.Errorf("pre-release-timestamp '%s' is not valid; must be (datetime|epoch)", cfg.PreReleaseTimestampLayout)
 	}
 
-	return nil
-}
-
-func generateGitDirPath(repoPath string) (string, error) {
-	absolutePath, err := filepath.Abs(repoPath)
-	if err != nil {
-		return "", err
+	if cfg.BranchScopePattern != "" {
+		rex, err := regexp.Compile(cfg.BranchScopePattern)
+		if err != nil {
+			return fmt.Errorf("branch scope pattern '%s' is not valid: %s", cfg.BranchScopePattern, err)
+		}
+		if rex.SubexpIndex("scope") < 0 {
+			return fmt.Errorf("branch scope pattern '%s' must contain a named 'scope' capture group", cfg.BranchScopePattern)
+		}
 	}
 
-	return filepath.Join(absolutePath, ".git"), nil
-}
+	if cfg.BranchVersionPattern != "" {
+		rex, err := regexp.Compile(cfg.BranchVersionPattern)
+		if err != nil {
+			return fmt.Errorf("branch version pattern '%s' is not valid: %s", cfg.BranchVersionPattern, err)
+		}
+		if rex.SubexpIndex("major") < 0 || rex.SubexpIndex("minor") < 0 {
+			return fmt.Errorf("branch version pattern '%s' must contain named 'major' and 'minor' capture groups", cfg.BranchVersionPattern)
+		}
+	}
 
-// Parse tags on repo, sort them, and store the most recent revision in the repo object
-func (r *GitRepo) parseTags() error {
-	log.Println("Parsing repository tags")
+	for dir, scope := range cfg.PathScopes {
+		if normalizeScopePath(dir) == "" || scope == "" {
+			return fmt.Errorf("path scope '%s': '%s' must have a directory and a scope", dir, scope)
+		}
+	}
 
-	versions := make(map[*version.Version]*git.Commit)
+	if cfg.WorkspaceManifest != "" && cfg.Scheme != "scope-conventional" {
+		return fmt.Errorf("workspace manifest '%s' requires the scope-conventional scheme", cfg.WorkspaceManifest)
+	}
+	if cfg.RequireConventional && cfg.Scheme != "conventional" && cfg.Scheme != "scope-conventional" {
+		return fmt.Errorf("requiring conventional commits requires the conventional or scope-conventional scheme")
+	}
+	if cfg.ScopeRegistry != "" && cfg.Scheme != "scope-conventional" {
+		return fmt.Errorf("scope registry '%s' requires the scope-conventional scheme", cfg.ScopeRegistry)
+	}
 
-	tags, err := r.repo.Tags()
-	if err != nil {
-		return fmt.Errorf("failed to fetch tags: %s", err.Error())
+	if strings.Contains(cfg.WriteVersionFile, "{scope}") && cfg.Scheme != "scope-conventional" {
+		return fmt.Errorf("version file '%s': {scope} requires the scope-conventional scheme", cfg.WriteVersionFile)
 	}
 
-	for tag, commit := range tags {
-		v, err := maybeVersionFromTag(commit)
-		if err != nil {
-			log.Println("skipping non version tag: ", tag)
-			continue
+	if cfg.ScopeVersionSeparator != "" {
+		if cfg.Scheme != "scope-conventional" {
+			return fmt.Errorf("scope version separator '%s' requires the scope-conventional scheme", cfg.ScopeVersionSeparator)
 		}
-
-		if v == nil {
-			log.Println("skipping non version tag: ", tag)
-			continue
+		if cfg.TagFormat != "" {
+			return fmt.Errorf("scope version separator '%s' can't be combined with a tag format", cfg.ScopeVersionSeparator)
 		}
-
-		c, err := r.repo.CommitByRevision(commit)
-		if err != nil {
-			return fmt.Errorf("error reading commit '%s':  %s", commit, err)
+		if strings.ContainsAny(cfg.ScopeVersionSeparator, invalidRefChars) {
+			return fmt.Errorf("scope version separator '%s' contains characters not allowed in tag names", cfg.ScopeVersionSeparator)
 		}
-		versions[v] = c
 	}
 
-	keys := make([]*version.Version, 0, len(versions))
-	for key := range versions {
-		keys = append(keys, key)
+	if cfg.TagFormat != "" {
+		if err := validateTagFormat(cfg.TagFormat, cfg.Scheme); err != nil {
+			return err
+		}
 	}
-	sort.Sort(sort.Reverse(version.Collection(keys)))
 
-	// loop over the tags and find the last reachable non pre-release tag,
-	// because we want to calculate the tag from v1.2.3 not v1.2.4-pre1.`
-	for _, version := range keys {
-		if len(version.Prerelease()) == 0 {
-			r.currentVersion = version
-			r.currentTag = versions[version]
-			return nil
+	if cfg.CommitRegex != nil {
+		for _, group := range commitRegexGroups {
+			if cfg.CommitRegex.SubexpIndex(group) < 0 {
+				return fmt.Errorf("commit regex '%s' must contain a named '%s' capture group", cfg.CommitRegex, group)
+			}
 		}
-		log.Printf("skipping pre-release tag version: %s", version.String())
 	}
 
-	return fmt.Errorf("no stable (non pre-release) version tags found")
-}
-
-func maybeVersionFromTag(tag string) (*version.Version, error) {
-	if tag == "" {
-		return nil, fmt.Errorf("empty tag not supported")
+	for _, pattern := range cfg.StableBranches {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("stable branch pattern '%s' is not valid: %s", pattern, err)
+		}
 	}
 
-	ver, vErr := parseVersion(tag)
-	if vErr != nil {
-		return nil, fmt.Errorf("couldn't parse version %s: %s", tag, vErr)
+	for pattern, level := range cfg.BranchBumpCap {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("branch bump cap pattern '%s' is not valid: %s", pattern, err)
+		}
+		if level < BumpPatch || level > BumpMajor {
+			return fmt.Errorf("bump cap %d of branch pattern '%s' is not valid", level, pattern)
+		}
 	}
-	return ver, nil
-}
 
-// parseVersion returns a version object from a parsed string. This normalizes semver strings, and adds the ability to parse strings with 'v' leader. so that `v1.0.1`->     `1.0.1`  which we need for berkshelf to work
-func parseVersion(v string) (*version.Version, error) {
-	if versionRex.MatchString(v) {
-		m := versionRex.FindStringSubmatch(v)
-		if len(m) >= 2 {
-			v = m[1]
+	for typ, level := range cfg.BumpRules {
+		if !commitTypeRex.MatchString(typ) {
+			return fmt.Errorf("bump rule type '%s' is not a valid commit type", typ)
+		}
+		if level < BumpNone || level > BumpMajor {
+			return fmt.Errorf("bump level %d of type '%s' is not valid", level, typ)
 		}
 	}
 
-	nVersion, err := version.NewVersion(v)
-	if err != nil && nVersion != nil && len(nVersion.Segments()) >= 1 {
-		return nVersion, err
+	for _, rule := range cfg.BumpEscalation {
+		if err := validateEscalationRule(rule); err != nil {
+			return err
+		}
 	}
-	return nVersion, nil
-}
 
-// LatestVersion Reports the Latest version of the given repo
-// TODO:(jnelson) this could be more intelligent, looking for a nil new and reporting the latest version found if we refactor autobump at some point Mon Sep 14 13:05:49 2015
-func (r *GitRepo) LatestVersion() string {
-	if r.scheme == "scope-conventional" {
-		if r.scope == "" {
-			return "no scope"
+	for _, key := range cfg.BumpFooters {
+		if !footerKeyRex.MatchString(key) {
+			return fmt.Errorf("bump footer '%s' is not a valid footer key", key)
 		}
-		return r.scope + "-v" + r.newVersion.String()
 	}
-	return "v" + r.newVersion.String()
-}
 
-func (r *GitRepo) retrieveBranchInfo() error {
-	id, err := r.repo.BranchCommitID(r.branch)
-	if err != nil {
-		return fmt.Errorf("error getting head commit: %s ", err.Error())
+	if err := validateCurrentVersions(cfg.Scheme, cfg.CurrentVersions); err != nil {
+		return err
 	}
 
-	r.branchID = id
-	return nil
-}
-
-func preReleaseVersion(v *version.Version, name, tsLayout string) (*version.Version, error) {
-	if len(name) == 0 && len(tsLayout) == 0 {
-		return v, nil
+	if err := validateReleases(cfg.Releases); err != nil {
+		return err
+	}
+	if len(cfg.Releases) > 0 && cfg.RemoteTags {
+		return fmt.Errorf("releases and remote tags can't be combined")
+	}
+	if cfg.VersionFromTagMessage && cfg.RemoteTags {
+		return fmt.Errorf("versions from tag messages aren't supported with remote tags")
 	}
 
-	if len(v.Prerelease()) > 0 {
-		return nil, errors.New("*version.Version already has a PreRelease value set")
+	for _, sha := range cfg.IgnoreCommits {
+		if !commitSHARex.MatchString(sha) {
+			return fmt.Errorf("ignored commit '%s' is not a commit SHA", sha)
+		}
 	}
 
-	buf := &bytes.Buffer{}
+	if cfg.Workers < 0 {
+		return fmt.Errorf("number of workers must not be negative, got %d", cfg.Workers)
+	}
 
-	if _, err := buf.WriteString(name); err != nil {
-		return nil, err
+	if err := cfg.GitRetry.validate(); err != nil {
+		return err
 	}
 
-	if len(tsLayout) > 0 {
-		// XXX(theckman): if the buffer already has content written to it, add
-		// the `.` character as a delimiter. The `+` character was not used as
-		// the delimiter because some systems that support version tags do not
-		// allow it within the string (looking at you, Docker).
-		if buf.Len() > 0 {
-			if _, err := buf.WriteString("."); err != nil {
-				return nil, err
-			}
-		}
+	if cfg.AllScopes && cfg.Scheme != "scope-conventional" {
+		return fmt.Errorf("all scopes requires the scope-conventional scheme")
+	}
 
-		var (
-			timestamp   string
-			currentTime = timeNow().UTC()
-		)
+	if cfg.AllScopes && cfg.WriteVersionFile != "" {
+		return fmt.Errorf("a version file can't be written for all scopes")
+	}
 
-		if tsLayout == "epoch" {
-			timestamp = strconv.FormatInt(currentTime.Unix(), 10)
-		} else {
-			timestamp = currentTime.Format(tsLayout)
-		}
+	if cfg.Submodules && (cfg.Scheme == "scope-conventional" || cfg.Scope != "") {
+		return fmt.Errorf("submodules can't be combined with the scope-conventional scheme")
+	}
 
-		if _, err := buf.WriteString(timestamp); err != nil {
-			return nil, err
+	if cfg.Scope != "" && cfg.Scheme != "scope-conventional" {
+		return fmt.Errorf("scope '%s' requires the scope-conventional scheme", cfg.Scope)
+	}
+	if cfg.ASCIIScopes {
+		if err := validateASCIIScope(cfg.Scope); err != nil {
+			return err
 		}
 	}
 
-	verStr := fmt.Sprintf("%s-%s", v.String(), buf.String())
-	return version.NewVersion(verStr)
-}
+	if cfg.Bump < BumpNone || cfg.Bump > BumpMajor {
+		return fmt.Errorf("bump level %d is not valid", cfg.Bump)
+	}
 
-// calcVersion looks over commits since the last tag, and will apply the version bump needed. It will patch if no other instruction is found
-// it populates the repo.newVersion with the new calculated version
-func (r *GitRepo) calcVersion() error {
-	r.newVersion = r.currentVersion
-	if err := r.retrieveBranchInfo(); err != nil {
-		return err
+	switch cfg.CommitFilter {
+	case "", CommitFilterAll, CommitFilterMergesOnly, CommitFilterNoMerges:
+		// nothing -- valid values
+	default:
+		return fmt.Errorf("commit filter '%s' is not valid; must be (%s|%s|%s)", cfg.CommitFilter, CommitFilterAll, CommitFilterMergesOnly, CommitFilterNoMerges)
 	}
 
-	startCommit, err := r.repo.BranchCommit(r.branch)
-	if err != nil {
-		return err
+	switch cfg.PRTitleMode {
+	case "", PRTitleReplace, PRTitleMerge:
+		// nothing -- valid values
+	default:
+		return fmt.Errorf("PR title mode '%s' is not valid; must be (%s|%s)", cfg.PRTitleMode, PRTitleReplace, PRTitleMerge)
 	}
 
-	revList := []string{fmt.Sprintf("%s..%s", r.currentTag.ID, startCommit.ID)}
+	if cfg.PRTitle != "" && cfg.OverrideMessage != "" {
+		return fmt.Errorf("a PR title can't be combined with an override message")
+	}
 
-	l, err := r.repo.RevList(revList)
-	if err != nil {
-		log.Printf("Error loading history for tag '%s': %s ", r.currentVersion, err.Error())
+	switch cfg.TagDateSource {
+	case "", TagDateCommit, TagDateTag:
+		// nothing -- valid values
+	default:
+		return fmt.Errorf("tag date source '%s' is not valid; must be (%s|%s)", cfg.TagDateSource, TagDateCommit, TagDateTag)
 	}
 
-	// r.branchID is newest commit; r.currentTag.ID is oldest
-	log.Printf("Checking commits from %s to %s ", r.branchID, r.currentTag.ID)
+	switch cfg.ReservedVersionPolicy {
+	case "", ReservedSkip, ReservedError:
+		// nothing -- valid values
+	default:
+		return fmt.Errorf("reserved version policy '%s' is not valid; must be (%s|%s)", cfg.ReservedVersionPolicy, ReservedSkip, ReservedError)
+	}
+
+	switch cfg.UnsignedCommitPolicy {
+	case "", UnsignedSkip, UnsignedError:
+		// nothing -- valid values
+	default:
+		return fmt.Errorf("unsigned commit policy '%s' is not valid; must be (%s|%s)", cfg.UnsignedCommitPolicy, UnsignedSkip, UnsignedError)
+	}
+	if cfg.UnsignedCommitPolicy != "" && !cfg.RequireSignedCommits {
+		return fmt.Errorf("an unsigned commit policy requires signed commits to be required")
+	}
+
+	if _, err := parseReservedVersions(cfg.ReservedVersions); err != nil {
+		return err
+	}
+
+	if cfg.UnusualBumpReleases < 0 {
+		return fmt.Errorf("unusual bump releases must not be negative")
+	}
+	if cfg.StrictUnusualBump && !cfg.WarnOnUnusualBump {
+		return fmt.Errorf("strict unusual bumps require the unusual bump warning to be enabled")
+	}
+	if cfg.MaxMajor < 0 {
+		return fmt.Errorf("max major must not be negative")
+	}
+
+	if mb := cfg.TagMergeBase; mb != [2]string{} {
+		if mb[0] == "" || mb[1] == "" {
+			return fmt.Errorf("the merge-base to tag requires two refs")
+		}
+		if cfg.Rev != "" {
+			return fmt.Errorf("a revision and a merge-base to tag are mutually exclusive")
+		}
+	}
+
+	if cfg.SigningKey != "" && !cfg.SignTag {
+		return fmt.Errorf("a signing key requires tag signing to be enabled")
+	}
+
+	if ns := strings.TrimSuffix(cfg.VersionRefNamespace, "/"); ns != "" {
+		if !strings.HasPrefix(ns, "refs/") {
+			return fmt.Errorf("version ref namespace '%s' must start with refs/", cfg.VersionRefNamespace)
+		}
+		if cfg.SignTag && ns != DefaultVersionRefNamespace {
+			return fmt.Errorf("tag signing requires the %s version ref namespace", DefaultVersionRefNamespace)
+		}
+		if cfg.TagMessageTemplate != "" && ns != DefaultVersionRefNamespace {
+			return fmt.Errorf("a tag message template requires the %s version ref namespace", DefaultVersionRefNamespace)
+		}
+		if cfg.TagRangeTrailers && ns != DefaultVersionRefNamespace {
+			return fmt.Errorf("tag range trailers require the %s version ref namespace", DefaultVersionRefNamespace)
+		}
+	}
+
+	if cfg.SignTag && cfg.Tagger != nil {
+		return fmt.Errorf("tag signing is only supported by the default git tagger")
+	}
+
+	if cfg.RemoteTags && cfg.Tagger != nil {
+		return fmt.Errorf("remote tags are only supported by the default git tagger")
+	}
+
+	if cfg.InitialVersion != "" {
+		if _, err := version.NewVersion(strings.TrimPrefix(cfg.InitialVersion, "v")); err != nil {
+			return fmt.Errorf("initial version '%s' is not valid: %s", cfg.InitialVersion, err)
+		}
+	}
+
+	return nil
+}
+
+// matchBranch reports whether branch matches one of the branch name glob patterns
+func matchBranch(patterns []string, branch string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, branch); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// branchBumpCap returns the lowest cap of the patterns matching branch, BumpNone if none matches
+func branchBumpCap(caps map[string]BumpLevel, branch string) BumpLevel {
+	capLevel := BumpNone
+	for pattern, level := range caps {
+		if ok, _ := path.Match(pattern, branch); ok && (capLevel == BumpNone || level < capLevel) {
+			capLevel = level
+		}
+	}
+	return capLevel
+}
+
+// capped reports whether a bump of level is reduced by the cap of the branch, see BranchBumpCap
+func (r *GitRepo) capped(level BumpLevel) bool {
+	return r.bumpCap != BumpNone && level > r.bumpCap
+}
+
+// cappedReason appends the cap of the branch to the reason of a bump of level, if it is capped
+func (r *GitRepo) cappedReason(level BumpLevel, reason string) string {
+	if !r.capped(level) {
+		return reason
+	}
+	return fmt.Sprintf("%s, capped to %s on branch '%s'", reason, r.bumpCap, r.branch)
+}
+
+// generateGitDirPath returns the git dir of the repo at repoPath, the `.git` dir or the dir a `.git`
+// file of a submodule points to
+func generateGitDirPath(repoPath string) (string, error) {
+	absolutePath, err := filepath.Abs(repoPath)
+	if err != nil {
+		return "", err
+	}
+
+	return resolveGitLink(filepath.Join(absolutePath, ".git"))
+}
+
+// Parse tags on repo, sort them, and store the most recent revision in the repo object
+func (r *GitRepo) parseTags() error {
+	r.debugf("Parsing repository tags")
+
+	ok, err := r.selectCurrentVersion("")
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("no stable (non pre-release) version tags found")
+	}
+	r.infof("currentVersion: %s\n", r.currentVersion)
+	return nil
+}
+
+// versionTags returns the names of the version tags, ie: the refs in the version ref namespace
+// without the namespace prefix
+func (r *GitRepo) versionTags() ([]string, error) {
+	if r.remoteTags {
+		commits, err := r.remoteVersionTags()
+		if err != nil {
+			return nil, err
+		}
+		names := make([]string, 0, len(commits))
+		for name := range commits {
+			names = append(names, name)
+		}
+		return names, nil
+	}
+
+	if r.refNamespace == DefaultVersionRefNamespace {
+		var tags []string
+		err := r.retryGit(func() (err error) {
+			tags, err = r.repo.Tags()
+			return err
+		})
+		return tags, err
+	}
+
+	prefix := r.refNamespace + "/"
+	var stdout []byte
+	err := r.retryGit(func() (err error) {
+		stdout, err = git.NewCommand("for-each-ref", "--format=%(refname)", prefix).RunInDir(r.repo.Path())
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, ref := range strings.Split(string(stdout), "\n") {
+		if name := strings.TrimPrefix(strings.TrimSpace(ref), prefix); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// tagCommit returns the commit the version tag name points to. The full ref is peeled to a
+// commit, so lightweight tags, annotated tags and tags of annotated tags all resolve to the tagged
+// commit, and a branch with the same name doesn't get in the way.
+func (r *GitRepo) tagCommit(name string) (*git.Commit, error) {
+	if r.remoteTags {
+		return r.remoteTagCommit(name)
+	}
+	return r.repo.CommitByRevision(r.refNamespace + "/" + name + "^{commit}")
+}
+
+// tagRef is a version tag, its scope before normalization and the commit it points to, see
+// tagRefCommit, nil for a release without a tag in the repo. preRelease marks a release flagged as
+// pre-release, see Release.
+type tagRef struct {
+	name       string
+	scope      string
+	target     *tagTarget
+	preRelease bool
+}
+
+// tagDate returns the date of the tag used for date based selection. With the "tag" date source
+// this is the tagger date of an annotated tag, lightweight tags (and the default "commit" date
+// source) use the committer date of the tagged commit.
+func (r *GitRepo) tagDate(ref tagRef) time.Time {
+	if r.tagDateSource == TagDateTag {
+		tag, err := r.repo.Tag(ref.name)
+		if err == nil && tag.Type() == git.ObjectTag && tag.Tagger() != nil {
+			return tag.Tagger().When
+		}
+	}
+	c, err := r.tagRefCommit(ref)
+	if err != nil {
+		r.debugf("no date of tag %s: %s", ref.name, err)
+	}
+	if c == nil {
+		return time.Time{}
+	}
+	return c.Committer.When
+}
+
+// tagCreator returns who created the tag of ref: the tagger of an annotated tag, otherwise the author
+// of the tagged commit. It's nil for a release without a tag in the repo.
+func (r *GitRepo) tagCreator(ref tagRef) (*git.Signature, error) {
+	tag, err := r.repo.Tag(ref.name)
+	if err == nil && tag.Type() == git.ObjectTag && tag.Tagger() != nil {
+		return tag.Tagger(), nil
+	}
+	c, err := r.tagRefCommit(ref)
+	if err != nil || c == nil {
+		return nil, err
+	}
+	return c.Author, nil
+}
+
+// selectCurrentVersion sets the current version and tag to the base version of scope, see
+// baseVersionOf. It returns false if no base version could be selected.
+func (r *GitRepo) selectCurrentVersion(scope string) (bool, error) {
+	r.skipped, r.collectingSkips = nil, r.collectSkipped
+	v, tag, ok, err := r.baseVersionOf(scope)
+	r.collectingSkips = false
+	if ok {
+		r.currentVersion = v
+		r.currentTag = tag
+	}
+	return ok, err
+}
+
+// baseVersionOf selects the base version of scope ("" for the schemes without scopes) and its
+// tagged commit: the version set with CurrentVersions, otherwise the one selectBaseVersion selects
+// from the tags.
+func (r *GitRepo) baseVersionOf(scope string) (*version.Version, *git.Commit, bool, error) {
+	if v, tag, ok := r.injectedBase(scope); ok {
+		return v, tag, true, nil
+	}
+	versions, err := r.scopeVersions(scope)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	return r.selectBaseVersion(scope, versions)
+}
+
+// selectBaseVersion selects the base version of scope and its tagged commit from the parsed tag
+// versions.
+// The highest (or with BaseByRecency the most recent) stable version is preferred, with
+// BasePerMajor only of the major line of the branch. If there is none the highest pre-release is
+// used when BaseOnPreRelease is set, otherwise the configured initial version (without a tag). It
+// returns false if no base version could be selected. Only the commit of the selected tag is
+// resolved, unless BasePerMajor has to look up the tags of the branch.
+func (r *GitRepo) selectBaseVersion(scope string, versions map[*version.Version]tagRef) (*version.Version, *git.Commit, bool, error) {
+	keys := make([]*version.Version, 0, len(versions))
+	for key := range versions {
+		keys = append(keys, key)
+	}
+	r.sortVersionsDesc(keys, versions)
+	if r.basePerMajor {
+		keys = r.majorLine(keys, versions)
+	}
+	if r.baseByRecency {
+		// a stable sort keeps the SemVer order for tags of the same date
+		dates := make(map[*version.Version]time.Time, len(keys))
+		for _, key := range keys {
+			dates[key] = r.tagDate(versions[key])
+		}
+		sort.SliceStable(keys, func(i, j int) bool {
+			return dates[keys[i]].After(dates[keys[j]])
+		})
+	}
+
+	// loop over the tags and find the last reachable non pre-release tag,
+	// because we want to calculate the tag from v1.2.3 not v1.2.4-pre1.`
+	for _, version := range keys {
+		if len(version.Prerelease()) == 0 && !versions[version].preRelease {
+			c, err := r.tagRefCommit(versions[version])
+			return version, c, err == nil, err
+		}
+		r.debugf("skipping pre-release tag version: %s", version.String())
+		r.skipTag(versions[version].name, SkipReasonPreRelease)
+	}
+
+	if r.baseOnPreRelease && len(keys) > 0 {
+		base := keys[0]
+		if len(r.preReleaseOrder) > 0 && !r.baseByRecency {
+			base = r.highestPreRelease(keys)
+		}
+		r.infof("no stable version found, using pre-release version %s as base", base)
+		r.unskipTag(versions[base].name)
+		c, err := r.tagRefCommit(versions[base])
+		return base, c, err == nil, err
+	}
+
+	if initial := r.scopeInitialVersion(scope); initial != nil {
+		r.infof("no stable version found, using initial version %s as base", initial)
+		return initial, nil, true, nil
+	}
+
+	return nil, nil, false, nil
+}
+
+// highestPreRelease returns the highest of the pre-release versions, ranking the channels of the
+// same core version by PreReleaseOrder
+func (r *GitRepo) highestPreRelease(versions []*version.Version) *version.Version {
+	highest := versions[0]
+	for _, v := range versions[1:] {
+		if r.comparePreRelease(v, highest) > 0 {
+			highest = v
+		}
+	}
+	return highest
+}
+
+// comparePreRelease compares the pre-release versions a and b like version.Compare, except that
+// versions of the same core version and different channels are ordered by PreReleaseOrder
+func (r *GitRepo) comparePreRelease(a, b *version.Version) int {
+	if coreSegments(a) == coreSegments(b) {
+		ca, cb := preReleaseChannel(a), preReleaseChannel(b)
+		if ca != cb {
+			ra, rb := r.channelRank(ca), r.channelRank(cb)
+			if ra != rb {
+				if ra > rb {
+					return 1
+				}
+				return -1
+			}
+		}
+	}
+	return a.Compare(b)
+}
+
+// channelRank returns the index of channel in PreReleaseOrder, -1 if it isn't listed
+func (r *GitRepo) channelRank(channel string) int {
+	for i, c := range r.preReleaseOrder {
+		if strings.EqualFold(c, channel) {
+			return i
+		}
+	}
+	return -1
+}
+
+// preReleaseChannel returns the first identifier of the pre-release of v, eg: `rc` of
+// `1.0.0-rc.1`
+func preReleaseChannel(v *version.Version) string {
+	channel, _, _ := strings.Cut(v.Prerelease(), ".")
+	return channel
+}
+
+// promotePreRelease adjusts the version bumped from a pre-release base so the core version
+// reserved by the pre-release is released first, eg: 1.0.0-rc.2 with a patch or minor bump
+// results in 1.0.0, while 1.2.3-rc.1 with a minor bump results in 1.3.0 as usual.
+func promotePreRelease(base, next *version.Version) *version.Version {
+	if len(base.Prerelease()) == 0 {
+		return next
+	}
+
+	core := base.Core()
+	b, n := core.Segments(), next.Segments()
+	switch {
+	case n[0] > b[0]:
+		if b[1] == 0 && b[2] == 0 {
+			return core
+		}
+	case n[1] > b[1]:
+		if b[2] == 0 {
+			return core
+		}
+	default:
+		return core
+	}
+	return next
+}
+
+// maybeVersionFromTag parses the version of a tag name. Build metadata, eg: `+ci.42` of
+// `v1.2.3+ci.42`, is kept in the version, but like in SemVer it doesn't take part in the precedence
+// of the base version selection, and a bump starts from the core version, so the next version
+// doesn't inherit it.
+func maybeVersionFromTag(tag string) (*version.Version, error) {
+	if tag == "" {
+		return nil, fmt.Errorf("empty tag not supported")
+	}
+
+	ver, vErr := parseVersion(tag)
+	if vErr != nil {
+		return nil, fmt.Errorf("couldn't parse version %s: %s", tag, vErr)
+	}
+	return ver, nil
+}
+
+// parseVersion returns a version object from a parsed string. This normalizes semver strings, and adds the ability to parse strings with 'v' leader. so that `v1.0.1`->     `1.0.1`  which we need for berkshelf to work
+func parseVersion(v string) (*version.Version, error) {
+	if versionRex.MatchString(v) {
+		m := versionRex.FindStringSubmatch(v)
+		if len(m) >= 2 {
+			v = m[1]
+		}
+	}
+
+	nVersion, err := version.NewVersion(v)
+	if err != nil && nVersion != nil && len(nVersion.Segments()) >= 1 {
+		return nVersion, err
+	}
+	return nVersion, nil
+}
+
+// LatestVersion Reports the Latest version of the given repo as the name of the tag it creates, see
+// FormatTag, eg: `1.2.3` without Prefix, `api@1.2.3` with the TagFormat `{scope}@{version}`.
+// "no scope" if the "scope-conventional" scheme has no scope.
+// TODO:(jnelson) this could be more intelligent, looking for a nil new and reporting the latest version found if we refactor autobump at some point Mon Sep 14 13:05:49 2015
+func (r *GitRepo) LatestVersion() string {
+	if r.scheme == "scope-conventional" && r.scope == "" {
+		return "no scope"
+	}
+	return r.FormatTag(r.scope, r.newVersion)
+}
+
+// Result is the outcome of the version calculation of a repo
+type Result struct {
+	// Repo is the path of the repository of the result in a MultiRepoCalc, empty elsewhere
+	Repo string
+	// Scope of the version with the "scope-conventional" scheme, empty otherwise
+	Scope string
+	// Current is the base version the next version is bumped from
+	Current *version.Version
+	// Next is the calculated version
+	Next *version.Version
+	// Tag is the name of the tag of the next version, relative to the version ref namespace
+	Tag string
+	// PreRelease is set when the next version has a pre-release part, eg: `1.2.0-beta`
+	PreRelease bool
+	// BuildMetadata is the build metadata of the next version without the `+`, if any
+	BuildMetadata string
+	// BumpReason tells why the bump was chosen, eg: `major because commit 1a2b3c4 has a BREAKING
+	// CHANGE footer`
+	BumpReason string
+	// DecidingCommit is the SHA of the commit that decided the bump, empty if no commit did, eg: for
+	// a forced bump or a pending commit. Of the commits with the highest bump the most recent by
+	// committer date decides, on the same date the later one in the history.
+	DecidingCommit string
+	// ChangedPaths are the files changed by the latest commit that derived its scope with
+	// PathScopes or ScopeFromOwnersFile, eg: to decide what to rebuild. It is empty if the scope
+	// wasn't derived from the files, and in the results of Preview.
+	ChangedPaths []string
+	// CommitsSinceBase is the number of commits of the branch since the base version, counted only
+	// with CommitsSinceBaseWarning. Without a base tag it counts the whole history.
+	CommitsSinceBase int
+	// Status is the outcome of the scope in AutoTagScopeResults, StatusNone elsewhere
+	Status Status
+	// Err is the error of the scope in a Preview, the other fields may be unset then. With
+	// StatusSkipped it tells why the scope has no release.
+	Err error
+	// TargetCommit is the commit the tag is created on: the head of the branch, Rev or the
+	// merge-base of TagMergeBase. It is set also when nothing is tagged, eg: by Preview, but not
+	// without a Next version or by LoadResults.
+	TargetCommit *git.Commit
+}
+
+// Result reports the calculated version of the repo, so downstream steps don't have to parse the
+// version string, eg: to route pre-releases to a beta channel
+func (r *GitRepo) Result() Result {
+	res := r.result(r.scope, r.currentVersion, r.newVersion)
+	res.BumpReason = r.bumpReason
+	res.DecidingCommit = r.decidingCommit
+	res.ChangedPaths = r.changedPaths
+	res.CommitsSinceBase = r.commitsSinceBase
+	return res
+}
+
+// result returns the result of the next version of scope bumped from current
+func (r *GitRepo) result(scope string, current, next *version.Version) Result {
+	return Result{
+		Scope:         scope,
+		Current:       current,
+		Next:          next,
+		Tag:           r.FormatTag(scope, next),
+		PreRelease:    next.Prerelease() != "",
+		BuildMetadata: next.Metadata(),
+		TargetCommit:  r.targetCommit,
+	}
+}
+
+func (r *GitRepo) retrieveBranchInfo() error {
+	commit, err := r.branchCommit()
+	if err != nil {
+		if r.rev != "" {
+			return err
+		}
+		return fmt.Errorf("error getting head commit: %s ", err.Error())
+	}
+
+	r.branchID, r.targetCommit = commit.ID.String(), commit
+	return nil
+}
+
+// preReleaseTypeName replaces the {type} token of the pre-release name with typ. Characters of typ
+// that aren't valid in a pre-release identifier are replaced with `-`, identifiers left empty by an
+// empty typ are dropped: `{type}.rc` is `rc` then.
+func preReleaseTypeName(name, typ string) string {
+	if !strings.Contains(name, preReleaseTypeToken) {
+		return name
+	}
+	typ = invalidPreReleaseRex.ReplaceAllString(typ, "-")
+
+	var identifiers []string
+	for _, id := range strings.Split(strings.ReplaceAll(name, preReleaseTypeToken, typ), ".") {
+		if id != "" {
+			identifiers = append(identifiers, id)
+		}
+	}
+	return strings.Join(identifiers, ".")
+}
+
+// buildMetadataVersion returns v with the build metadata, replacing any metadata of v. The core
+// version and pre-release of v are kept. The error names the attempted version and the part that
+// likely caused it.
+func buildMetadataVersion(v *version.Version, metadata string) (*version.Version, error) {
+	core := coreSegments(v)
+	s := fmt.Sprintf("%d.%d.%d", core[0], core[1], core[2])
+	if pre := v.Prerelease(); pre != "" {
+		s += "-" + pre
+	}
+	s += "+" + metadata
+
+	withMetadata, err := version.NewVersion(s)
+	if err != nil {
+		part := fmt.Sprintf("pre-release '%s'", v.Prerelease())
+		if !validateSemVerBuildMetadata(metadata) {
+			part = fmt.Sprintf("build metadata '%s'", metadata)
+		}
+		return nil, fmt.Errorf("invalid version '%s', the %s is likely invalid: %s", s, part, err)
+	}
+	return withMetadata, nil
+}
+
+func preReleaseVersion(v *version.Version, name, tsLayout string) (*version.Version, error) {
+	if len(name) == 0 && len(tsLayout) == 0 {
+		return v, nil
+	}
+
+	if len(v.Prerelease()) > 0 {
+		return nil, errors.New("*version.Version already has a PreRelease value set")
+	}
+
+	// the pre-release is built from dot separated identifiers, so numeric parts like the
+	// timestamp are compared numerically (beta.2 < beta.10) rather than as part of a single
+	// alphanumeric identifier
+	var identifiers []string
+	if len(name) > 0 {
+		identifiers = append(identifiers, strings.Split(name, ".")...)
+	}
+
+	if len(tsLayout) > 0 {
+		// XXX(theckman): the `+` character was not used as the delimiter because some systems
+		// that support version tags do not allow it within the string (looking at you, Docker).
+		var (
+			timestamp   string
+			currentTime = timeNow().UTC()
+		)
+
+		if tsLayout == "epoch" {
+			timestamp = strconv.FormatInt(currentTime.Unix(), 10)
+		} else {
+			timestamp = currentTime.Format(tsLayout)
+		}
+		identifiers = append(identifiers, timestamp)
+	}
+
+	for _, id := range identifiers {
+		if !validateSemVerPreReleaseIdentifier(id) {
+			return nil, fmt.Errorf("'%s' is not a valid SemVer pre-release identifier", id)
+		}
+	}
+
+	verStr := fmt.Sprintf("%s-%s", v.String(), strings.Join(identifiers, "."))
+	return version.NewVersion(verStr)
+}
+
+// calcVersion looks over commits since the last tag, and will apply the version bump needed. It will patch if no other instruction is found
+// it populates the repo.newVersion with the new calculated version
+func (r *GitRepo) calcVersion() error {
+	r.newVersion = r.currentVersion
+	if err := r.retrieveBranchInfo(); err != nil {
+		return err
+	}
+
+	// a forced bump ignores the commits
+	if r.releaseBump != BumpNone {
+		r.infof("Forcing a %s bump\n", r.releaseBump)
+		r.bumpReason = fmt.Sprintf("%s because the bump is forced", r.releaseBump)
+		next, err := r.bumpVersion(r.releaseBump.bumper(), r.currentVersion)
+		if err != nil {
+			return err
+		}
+		level := r.releaseBump
+		if r.graduates(r.currentVersion, false) {
+			r.bumpReason, level = "major because the version graduates to 1.0.0", BumpMajor
+		}
+		r.bumpReason = r.cappedReason(level, r.bumpReason)
+		r.newVersion, err = r.finishVersion(r.scope, r.currentVersion, next, "", false)
+		return err
+	}
+
+	startCommit, err := r.branchCommit()
+	if err != nil {
+		return err
+	}
+
+	// without a base tag (initial version) the whole history is checked, up to SinceRef
+	revList := r.historyRange(r.currentTag, startCommit.ID.String())
+
+	l, err := r.repo.RevList(revList)
+	if err != nil {
+		r.infof("Error loading history for tag '%s': %s ", r.currentVersion, err.Error())
+	}
 
-	// Revlist returns in reverse Crhonological We want chonological. Then check each commit for bump messages
+	// r.branchID is newest commit; r.currentTag.ID is oldest
+	r.debugf("Checking commits from %s to %s ", r.branchID, revList[0])
+	if err := r.checkSignedCommits(l); err != nil {
+		return err
+	}
+	cancelled := r.cancelledCommits(l)
+
+	// Revlist returns in reverse Crhonological We want chonological. Then check each commit for bump messages.
+	// Of the commits with the highest bump the most recent by committer date decides it, the later
+	// one in the history on the same date, so the attribution doesn't depend on the traversal.
+	var (
+		decided    bool
+		decidedAt  time.Time
+		qualifying int
+		held       int
+		graduate   bool
+	)
+	if r.prTitleReplaces() {
+		l = nil
+	}
 	for i := len(l) - 1; i >= 0; i-- {
 		commit := l[i] // getting the reverse order element
 		if commit == nil {
 			return fmt.Errorf("commit pointed to nil object. This should not happen.")
 		}
 
-		v, nerr := r.parseCommit(commit)
+		if !r.includeCommit(commit) || cancelled[commit.ID.String()] {
+			continue
+		}
+		if r.qualifies(commit.Message) {
+			qualifying++
+		} else if skipReleaseFooterRex.MatchString(commit.Message) {
+			held++
+		}
+
+		v, d, nerr := r.parseCommit(commit)
 		if nerr != nil {
-			log.Fatal(nerr)
+			return fmt.Errorf("error parsing commit %s: %w", commit.ID, nerr)
 		}
 
-		if v != nil && v.String() > r.newVersion.String() {
+		graduate = graduate || d.graduate
+		if v == nil {
+			continue
+		}
+		tie := decided && v.Equal(r.newVersion) && !commit.Committer.When.Before(decidedAt)
+		if v.GreaterThan(r.newVersion) || tie {
+			r.newVersion = v
+			r.decideBump(d, commit.ID.String())
+			decided, decidedAt = true, commit.Committer.When
+		}
+	}
+
+	// the pending commit comes last
+	if r.overrideMessage != "" && r.commitFilter != CommitFilterMergesOnly {
+		r.debugf("Parsing pending commit: %s\n", r.overrideMessage)
+		v, d, err := r.parseMessage(r.overrideMessage)
+		if err != nil {
+			return err
+		}
+		if r.qualifies(r.overrideMessage) {
+			qualifying++
+		} else if skipReleaseFooterRex.MatchString(r.overrideMessage) {
+			held++
+		}
+		graduate = graduate || d.graduate
+		// the pending commit is the most recent, it wins a tie
+		if v != nil && (v.GreaterThan(r.newVersion) || (decided && v.Equal(r.newVersion))) {
+			r.newVersion = v
+			r.decideBump(d, "")
+		}
+	}
+
+	// the PR title counts like the most recent commit
+	if r.prTitle != "" {
+		v, d, err := r.parsePRTitle()
+		if err != nil {
+			return err
+		}
+		if r.qualifies(r.prTitle) {
+			qualifying++
+		}
+		graduate = graduate || d.graduate
+		if v != nil && (v.GreaterThan(r.newVersion) || (decided && v.Equal(r.newVersion))) {
 			r.newVersion = v
+			r.decidePRTitle(d)
 		}
 	}
 
+	// nothing to release once the commits are filtered, also if the commits hold their release back
+	// with a `Skip-Release: true` footer
+	if (r.skipEmptyRelease || held > 0) && qualifying == 0 {
+		return fmt.Errorf("%w: no qualifying commits since %s", ErrNoBump, r.currentVersion)
+	}
+
 	// if there is no movement on the version from commits, bump patch
 	if r.newVersion == r.currentVersion {
-		if r.newVersion, err = patchBumper.bump(r.currentVersion); err != nil {
+		if r.newVersion, err = r.PatchBump(); err != nil {
 			return err
 		}
+		r.bumpReason, r.bumpType = "patch because no commit asks for a bump", ""
+	}
+	level := NewVersionDelta(r.currentVersion, r.newVersion).Level
+	if r.graduates(r.currentVersion, graduate) {
+		r.bumpReason, level = "major because the version graduates to 1.0.0", BumpMajor
 	}
+	r.bumpReason = r.cappedReason(level, r.bumpReason)
+	r.newVersion, err = r.finishVersion(r.scope, r.currentVersion, r.newVersion, r.bumpType, graduate)
+	return err
+}
 
+// finishVersion completes the version of scope bumped from base: a graduating 0.x base becomes
+// 1.0.0, a pre-release base is promoted, then the configured pre-release name/timestamp and build
+// metadata are appended, see decorateVersion. typ replaces the {type} token of the pre-release
+// name, graduate is set by a `Graduate: true` footer. With SkipExisting a version that is already
+// tagged is bumped again, see skipExisting.
+func (r *GitRepo) finishVersion(scope string, base, next *version.Version, typ string, graduate bool) (*version.Version, error) {
+	var err error
+	graduating := r.graduates(base, graduate)
+	if graduating {
+		next = version.Must(version.NewVersion("1.0.0"))
+	}
+	if r.capped(NewVersionDelta(base, next).Level) {
+		if next, err = r.bumpVersion(r.bumpCap.bumper(), base); err != nil {
+			return nil, err
+		}
+	}
+	if !graduating {
+		if err := r.checkUnusualBump(scope, base, next); err != nil {
+			return nil, err
+		}
+	}
+	next = promotePreRelease(base, next)
+	if next, err = r.avoidReserved(base, next); err != nil {
+		return nil, err
+	}
+	if err := r.checkVersionLine(scope, base, next); err != nil {
+		return nil, err
+	}
+	if err := r.checkMaxMajor(scope, base, next); err != nil {
+		return nil, err
+	}
+	if r.skipExisting {
+		return r.skipExistingVersion(scope, base, next, typ)
+	}
+	return r.decorateVersion(scope, base, next, typ)
+}
+
+// decorateVersion appends the configured pre-release name/timestamp of scope and build metadata to
+// next, bumped from base, and applies the VersionTransform. typ replaces the {type} token of the
+// pre-release name.
+func (r *GitRepo) decorateVersion(scope string, base, next *version.Version, typ string) (*version.Version, error) {
 	// append pre-release-name and/or pre-release-timestamp to the version
-	if len(r.preReleaseName) > 0 || len(r.preReleaseTimestampLayout) > 0 {
-		if r.newVersion, err = preReleaseVersion(r.newVersion, r.preReleaseName, r.preReleaseTimestampLayout); err != nil {
-			return err
+	preReleaseName, err := r.scopePreReleaseName(scope, typ)
+	if err != nil {
+		return nil, err
+	}
+	if len(preReleaseName) > 0 || len(r.preReleaseTimestampLayout) > 0 {
+		name := preReleaseTypeName(preReleaseName, typ)
+		if name != "" && r.preReleaseIncrement {
+			n, err := r.preReleaseCounter(scope, next, name)
+			if err != nil {
+				return nil, err
+			}
+			name = fmt.Sprintf("%s.%d", name, n)
+		}
+		if next, err = preReleaseVersion(next, name, r.preReleaseTimestampLayout); err != nil {
+			return nil, err
 		}
 	}
 
 	// append optional build metadata
 	if r.buildMetadata != "" {
-		if r.newVersion, err = version.NewVersion(fmt.Sprintf("%s+%s", r.newVersion.String(), r.buildMetadata)); err != nil {
-			return err
+		if next, err = buildMetadataVersion(next, r.buildMetadata); err != nil {
+			return nil, err
 		}
 	}
 
-	return nil
+	return r.transformVersion(scope, base, next)
 }
 
-// AutoTag applies the new version tag thats calculated
+// scopePreReleaseName returns the pre-release name of scope for a bump decided by a commit of typ:
+// the channel of the ScopeRegistry or of TypeChannels, otherwise resolved by PreReleaseNameFunc if
+// set
+func (r *GitRepo) scopePreReleaseName(scope, typ string) (string, error) {
+	if c := r.scopeSettings(scope); c != nil && c.preRelease != "" {
+		return c.preRelease, nil
+	}
+	if name, ok := r.typeChannel(typ); ok {
+		if name == "" && r.preReleaseTimestampLayout == "" && r.preReleaseOnly {
+			return "", fmt.Errorf("branch '%s' isn't a stable branch and can only produce pre-releases, type '%s' is released as stable", r.branch, typ)
+		}
+		return name, nil
+	}
+	if r.preReleaseNameFunc == nil {
+		return r.preReleaseName, nil
+	}
+
+	name := r.preReleaseNameFunc(scope, r.branch)
+	if name != "" && !validateSemVerPreReleaseName(preReleaseTypeName(name, "type")) {
+		return "", fmt.Errorf("'%s' of scope '%s' is not valid SemVer pre-release name", name, scope)
+	}
+	if name == "" && r.preReleaseTimestampLayout == "" && r.preReleaseOnly {
+		return "", fmt.Errorf("branch '%s' isn't a stable branch and can only produce pre-releases, no pre-release name for scope '%s'", r.branch, scope)
+	}
+	return name, nil
+}
+
+// graduates reports whether the base is a 0.x version graduating to 1.0.0, with the Graduate
+// option or a `Graduate: true` footer
+func (r *GitRepo) graduates(base *version.Version, footer bool) bool {
+	return (r.graduate || footer) && coreSegments(base)[0] == 0
+}
+
+// AutoTag applies the new version tag thats calculated. With AllScopes it creates the tags of all
+// scopes, see AutoTagScopes, with Submodules the tag of every submodule in its own repo.
 func (r *GitRepo) AutoTag() error {
+	if r.allScopes {
+		_, err := r.AutoTagScopes()
+		return err
+	}
+	if r.submoduleCfg != nil {
+		return r.autoTagSubmodules()
+	}
 	return r.tagNewVersion()
 }
 
 func (r *GitRepo) tagNewVersion() error {
-	// TODO:(jnelson) These should be configurable? Mon Sep 14 12:02:52 2015
-	var tagName string
-	if r.scheme == "scope-conventional" {
-		if r.scope == "" {
-			return nil
-		}
-		tagName = fmt.Sprintf("%s-v%s", r.scope, r.newVersion.String())
-	} else {
-		tagName = fmt.Sprintf("v%s", r.newVersion.String())
+	if r.scheme == "scope-conventional" && r.scope == "" {
+		return nil
+	}
+	if err := r.checkEvent(); err != nil {
+		return err
+	}
+	if err := r.checkUpToDate(); err != nil {
+		return err
+	}
+	if err := r.checkCleanTree(); err != nil {
+		return err
+	}
+	if err := r.checkVersionCollision(r.scope, r.newVersion); err != nil {
+		return err
+	}
+	if err := r.checkAlreadyTagged(r.scope, r.newVersion); err != nil {
+		return err
 	}
-	if !r.prefix {
-		tagName = r.newVersion.String()
+	tagName := r.FormatTag(r.scope, r.newVersion)
+	if err := r.checkRemoteTag(tagName); err != nil {
+		return err
+	}
+	res := r.Result()
+	if err := r.createTag(res); err != nil {
+		return err
 	}
+	return r.runPostTagHooks(res)
+}
 
-	log.Println("Writing Tag", tagName)
-	err := r.repo.CreateTag(tagName, r.branchID)
+// createTag creates the tag of res on the branch commit with the configured tagger
+func (r *GitRepo) createTag(res Result) error {
+	tagName := res.Tag
+	message, err := r.TagMessage(res)
 	if err != nil {
+		return err
+	}
+	// signed tags are annotated and need a message
+	if r.signTag && message == "" {
+		message = tagName
+	}
+	annotated := r.signTag || r.tagMessageTmpl != nil || r.rangeTrailers
+
+	r.infof("Writing Tag %s", tagName)
+	err = r.retryGit(func() error {
+		return r.tagger.Create(tagName, r.branchID, message, annotated)
+	})
+	r.RefreshTags()
+	if err != nil {
+		if r.signTag {
+			return fmt.Errorf("error creating signed tag (check the signing key and agent): %s", err.Error())
+		}
 		return fmt.Errorf("error creating tag: %s", err.Error())
 	}
+	return r.pushRemoteTag(tagName)
+}
+
+// WriteVersionFile writes the new version to the configured version file, creating missing
+// directories. It is independent of AutoTag, so the file can also be written when only calculating
+// the version. Nothing is written if no version file is configured.
+func (r *GitRepo) WriteVersionFile() error {
+	if r.versionFile == "" {
+		return nil
+	}
+
+	file := filepath.FromSlash(strings.ReplaceAll(r.versionFile, "{scope}", r.scope))
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(r.workTree, file)
+	}
+	r.infof("Writing version file %s", file)
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return fmt.Errorf("error creating the directory of version file '%s': %s", file, err)
+	}
+	if err := os.WriteFile(file, []byte(r.newVersion.String()+"\n"), 0o644); err != nil {
+		return fmt.Errorf("error writing version file '%s': %s", file, err)
+	}
 	return nil
 }
 
+// includeCommit reports whether the commit should be considered for the version bump
+func (r *GitRepo) includeCommit(commit *git.Commit) bool {
+	return !r.ignoredCommit(commit) && !r.filteredCommit(commit)
+}
+
+// filteredCommit reports whether the CommitFilter excludes the commit
+func (r *GitRepo) filteredCommit(commit *git.Commit) bool {
+	merge := commit.ParentsCount() > 1
+	switch {
+	case r.commitFilter == CommitFilterMergesOnly && !merge:
+		r.debugf("skipping non-merge commit %s\n", commit.ID)
+		return true
+	case r.commitFilter == CommitFilterNoMerges && merge:
+		r.debugf("skipping merge commit %s\n", commit.ID)
+		return true
+	}
+	return false
+}
+
+// bumpDecision is the bump level of a commit message and the signal of the message that decided
+// it, eg: `has type 'feat'`. typ is the lowercased conventional commit type of the message, if any,
+// graduate is set by a `Graduate: true` footer.
+type bumpDecision struct {
+	level    BumpLevel
+	signal   string
+	typ      string
+	graduate bool
+}
+
 // parseCommit looks at HEAD commit see if we want to increment major/minor/patch
-func (r *GitRepo) parseCommit(commit *git.Commit) (*version.Version, error) {
-	var b bumper
-	msg := commit.Message
-	log.Printf("Parsing %s: %s\n", commit.ID, msg)
+func (r *GitRepo) parseCommit(commit *git.Commit) (*version.Version, bumpDecision, error) {
+	r.debugf("Parsing %s: %s\n", commit.ID, commit.Message)
+	return r.parseMessage(commit.Message)
+}
 
+// parseMessage bumps the current version according to the commit message msg and the scheme. The
+// decision is returned along with the version. A commit with a `Skip-Release: true` footer doesn't
+// bump the version.
+func (r *GitRepo) parseMessage(msg string) (*version.Version, bumpDecision, error) {
+	var d bumpDecision
+	if skipReleaseFooterRex.MatchString(msg) {
+		r.debugf("skipping the release of a commit with a Skip-Release footer\n")
+		return nil, d, nil
+	}
 	switch r.scheme {
 	case "conventional":
-		b = parseConventionalCommit(msg)
+		d = r.commitDecision(msg)
 	case "", "autotag":
-		b = parseAutotagCommit(msg)
+		var keyword string
+		if d.level, keyword = parseAutotagCommit(msg); d.level != BumpNone {
+			r.debugf("%s bump\n", d.level)
+			d.signal = fmt.Sprintf("has the %s keyword", keyword)
+		}
+		if level, ok := r.bodyLevel(msg); ok {
+			d = bumpDecision{level: level, signal: "has a body matched by BumpFromBody"}
+		}
 	}
 
-	// fallback to patch bump if no matches from the scheme parsers
-	if b != nil {
-		return b.bump(r.currentVersion)
+	v, err := r.bumpVersion(d.level.bumper(), r.currentVersion)
+	return v, d, err
+}
+
+// decideBump records why the new version was chosen: the decision of the commit with the id, the
+// pending commit if id is empty
+func (r *GitRepo) decideBump(d bumpDecision, id string) {
+	r.decidingCommit = id
+	r.bumpReason = d.reason(id)
+	r.bumpType = d.typ
+}
+
+// reason describes the decision of the commit with the id, the pending commit if id is empty, eg:
+// `minor because commit 1a2b3c4 has type 'feat'`
+func (d bumpDecision) reason(id string) string {
+	who := "the pending commit"
+	if id != "" {
+		who = "commit " + shortID(id)
+	}
+	return fmt.Sprintf("%s because %s %s", d.level, who, d.signal)
+}
+
+// shortID abbreviates a commit SHA for messages
+func shortID(id string) string {
+	if len(id) > 7 {
+		return id[:7]
+	}
+	return id
+}
+
+// bumpVersion bumps base by the level of b using the configured bump functions. It returns nil if
+// b is nil, the caller must decide what action to take.
+func (r *GitRepo) bumpVersion(b bumper, base *version.Version) (*version.Version, error) {
+	switch b {
+	case majorBumper:
+		return r.majorBump(base)
+	case minorBumper:
+		return r.minorBump(base)
+	case patchBumper:
+		return r.patchBump(base)
 	}
 
 	return nil, nil
@@ -504,64 +2504,162 @@ func (r *GitRepo) parseCommit(commit *git.Commit) (*version.Version, error) {
 //   - [minor] or #minor: minor version bump
 //   - [patch] or #patch: patch version bump
 //
-// If no action is present nil is returned and the caller must decide what action to take.
-func parseAutotagCommit(msg string) bumper {
-	if majorRex.MatchString(msg) {
-		log.Println("major bump")
-		return majorBumper
+// If no action is present BumpNone is returned and the caller must decide what action to take. The
+// matched keyword is returned along with the level.
+func parseAutotagCommit(msg string) (BumpLevel, string) {
+	if keyword := majorRex.FindString(msg); keyword != "" {
+		return BumpMajor, keyword
 	}
 
-	if minorRex.MatchString(msg) {
-		log.Println("minor bump")
-		return minorBumper
+	if keyword := minorRex.FindString(msg); keyword != "" {
+		return BumpMinor, keyword
 	}
 
-	if patchRex.MatchString(msg) {
-		log.Println("patch bump")
-		return patchBumper
+	if keyword := patchRex.FindString(msg); keyword != "" {
+		return BumpPatch, keyword
 	}
 
-	return nil
+	return BumpNone, ""
 }
 
-// parseConventionalCommit implements the Conventional Commit scheme. Given a commit message
-// it will return the correct version bumper. In the case of non-confirming conventional commit
-// it will return nil and the caller will decide what action to take.
+// commitLevel implements the Conventional Commit scheme. Given a commit message it returns the
+// bump level of its type according to the bump rules, a breaking change is always a major bump.
+// The type is matched case insensitive unless StrictTypeCase is set. A level BumpFromBody derives
+// from the body takes precedence.
 // https://www.conventionalcommits.org/en/v1.0.0/#summary
-func parseConventionalCommit(msg string) bumper {
-	matches := findNamedMatches(conventionalCommitRex, msg)
+func (r *GitRepo) commitLevel(msg string) BumpLevel {
+	return r.commitDecision(msg).level
+}
+
+// commitDecision returns the bump level of msg like commitLevel does, along with the signal that
+// decided it, eg: `has a BREAKING CHANGE footer`. With ScanBodyHeaders the highest level of all
+// headers of the message is returned.
+func (r *GitRepo) commitDecision(msg string) bumpDecision {
+	var best bumpDecision
+	for i, header := range r.commitHeaders(msg) {
+		if d := r.headerDecision(header); i == 0 || d.level > best.level {
+			d.graduate = d.graduate || best.graduate
+			best = d
+		}
+	}
+	return best
+}
+
+// commitHeaders returns the conventional commit headers of msg to evaluate: msg itself and, with
+// ScanBodyHeaders, every later line that is a conventional commit header, eg: the subjects a squash
+// merge concatenates in the body. A commit with a `Skip-Release: true` footer has none.
+func (r *GitRepo) commitHeaders(msg string) []string {
+	if skipReleaseFooterRex.MatchString(msg) {
+		return nil
+	}
+	headers := []string{msg}
+	if !r.scanBodyHeaders {
+		return headers
+	}
+	lines := strings.Split(msg, "\n")
+	for _, line := range lines[1:] {
+		line = strings.TrimSpace(strings.TrimLeft(line, "*- \t"))
+		if parseCommitMessage(r.commitRex, line).conventional() {
+			headers = append(headers, line)
+		}
+	}
+	return headers
+}
+
+// headerDecision returns the decision of a single conventional commit header, see commitDecision.
+// With RequireConventional a header that isn't conventional has no decision, neither has a commit
+// with a `Skip-Release: true` footer.
+func (r *GitRepo) headerDecision(msg string) bumpDecision {
+	if !r.conventionalHeader(msg) || skipReleaseFooterRex.MatchString(msg) {
+		return bumpDecision{}
+	}
+	d := r.headerLevel(msg)
+	d.graduate = graduateFooterRex.MatchString(msg)
+	return d
+}
+
+// conventionalHeader reports whether msg counts as a conventional commit: with RequireConventional
+// only if its header has a type and a colon, otherwise always
+func (r *GitRepo) conventionalHeader(msg string) bool {
+	return !r.requireConventional || parseCommitMessage(r.commitRex, msg).conventional()
+}
+
+// qualifies reports whether the commit message msg counts as a qualifying commit for
+// SkipEmptyRelease: it isn't of an ignored type, doesn't have a `Skip-Release: true` footer nor,
+// with RequireConventional, is free-form
+func (r *GitRepo) qualifies(msg string) bool {
+	return r.conventionalHeader(msg) && !skipReleaseFooterRex.MatchString(msg) &&
+		!r.ignoredType(parseCommitMessage(r.commitRex, msg).ype)
+}
+
+// headerLevel returns the bump level of a single conventional commit header and its signal
+func (r *GitRepo) headerLevel(msg string) bumpDecision {
+	m := parseCommitMessage(r.commitRex, msg)
+	lower := strings.ToLower(m.ype)
+
+	if d, ok := r.footerDecision(msg); ok {
+		d.typ = lower
+		return d
+	}
+	return r.escalate(r.ruleLevel(m, msg), m, msg)
+}
+
+// ruleLevel returns the bump level of the conventional commit header m of msg by BumpFromBody, a
+// breaking change or the bump rule of its type, and its signal
+func (r *GitRepo) ruleLevel(m CommitMessage, msg string) bumpDecision {
+	lower := strings.ToLower(m.ype)
+	if level, ok := r.bodyLevel(msg); ok {
+		return bumpDecision{level: level, signal: "has a body matched by BumpFromBody", typ: lower}
+	}
 
 	// If the commit contains a footer with 'BREAKING CHANGE:' it is always a major bump
 	if strings.Contains(msg, "\nBREAKING CHANGE:") {
-		return majorBumper
+		return bumpDecision{level: BumpMajor, signal: "has a BREAKING CHANGE footer", typ: lower}
 	}
 
 	// if the type/scope in the header includes a trailing '!' this is a breaking change
-	if breaking, ok := matches["breaking"]; ok && breaking == "!" {
-		return majorBumper
+	if m.Breaking() {
+		return bumpDecision{level: BumpMajor, signal: "has a breaking change marker (!)", typ: lower}
 	}
 
-	// if the type in the header is 'feat' it is a minor change
-	if typ, ok := matches["type"]; ok && typ == "feat" {
-		return minorBumper
+	typ := normalizeType(m.ype, r.strictTypeCase)
+	if _, ok := r.bumpRules[typ]; !ok {
+		return bumpDecision{level: BumpPatch, signal: fmt.Sprintf("has type '%s' without a bump rule", m.ype), typ: lower}
 	}
+	return bumpDecision{level: r.bumpRules.level(typ), signal: fmt.Sprintf("has type '%s'", m.ype), typ: lower}
+}
 
-	return nil
+// bodyLevel returns the bump level BumpFromBody derives from the body of msg, if one is configured
+func (r *GitRepo) bodyLevel(msg string) (BumpLevel, bool) {
+	if r.bumpFromBody == nil {
+		return BumpNone, false
+	}
+	_, body, _ := strings.Cut(msg, "\n")
+	return r.bumpFromBody(body)
 }
 
 // MajorBump will bump the version one major rev 1.0.0 -> 2.0.0
 func (r *GitRepo) MajorBump() (*version.Version, error) {
-	return majorBumper.bump(r.currentVersion)
+	return r.majorBump(r.currentVersion)
 }
 
 // MinorBump will bump the version one minor rev 1.1.0 -> 1.2.0
 func (r *GitRepo) MinorBump() (*version.Version, error) {
-	return minorBumper.bump(r.currentVersion)
+	return r.minorBump(r.currentVersion)
 }
 
 // PatchBump will bump the version one patch rev 1.1.1 -> 1.1.2
 func (r *GitRepo) PatchBump() (*version.Version, error) {
-	return patchBumper.bump(r.currentVersion)
+	return r.patchBump(r.currentVersion)
+}
+
+// normalizeType lowercases a conventional commit type, eg: `Feat` or `FEAT` are treated as
+// `feat`. With strictCase the type is returned as written, so only lowercase types match.
+func normalizeType(typ string, strictCase bool) string {
+	if strictCase {
+		return typ
+	}
+	return strings.ToLower(typ)
 }
 
 // findNamedMatches is a helper function for use with regexes containing named capture groups.
@@ -597,9 +2695,22 @@ func validateSemVerPreReleaseName(meta string) bool {
 	identifiers := strings.Split(meta, ".")
 
 	for _, s := range identifiers {
-		if s == "" || strings.HasPrefix(s, "0") || !semVerPreReleaseName.MatchString(s) {
+		if !validateSemVerPreReleaseIdentifier(s) {
 			return false
 		}
 	}
 	return true
 }
+
+// validateSemVerPreReleaseIdentifier validates a single dot separated pre-release identifier
+// according to https://semver.org/#spec-item-9: alphanumerics and hyphens, and numeric
+// identifiers must not include leading zeroes.
+func validateSemVerPreReleaseIdentifier(id string) bool {
+	if id == "" || !semVerPreReleaseName.MatchString(id) {
+		return false
+	}
+	if _, err := strconv.ParseUint(id, 10, 64); err == nil && len(id) > 1 && id[0] == '0' {
+		return false
+	}
+	return true
+}
@@ -1,10 +1,15 @@
 package main
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"os"
+	"regexp"
+	"text/template"
+	"time"
 
 	"github.com/jessevdk/go-flags"
 	"github.com/pantheon-systems/autotag"
@@ -12,15 +17,96 @@ import (
 
 // Options holds the CLI args
 type Options struct {
-	JustVersion         bool   `short:"n" description:"Just output the next version, don't autotag"`
-	Verbose             bool   `short:"v" description:"Enable verbose logging"`
-	Branch              string `short:"b" long:"branch" description:"Git branch to scan (defaults to main, then master)" default:""`
-	RepoPath            string `short:"r" long:"repo" description:"Path to the repo" default:"./" `
-	PreReleaseName      string `short:"p" long:"pre-release-name" description:"create a pre-release tag"`
-	PreReleaseTimestamp string `short:"T" long:"pre-release-timestamp" description:"create a pre-release tag and append a timestamp (can be: datetime|epoch)"`
-	BuildMetadata       string `short:"m" long:"build-metadata" description:"optional SemVer build metadata to append to the version with '+' character"`
-	Scheme              string `short:"s" long:"scheme" description:"The commit message scheme to use (can be: autotag|conventional)" default:"autotag"`
-	NoVersionPrefix     bool   `short:"e" long:"empty-version-prefix" description:"Do not prepend v to version tag"`
+	JustVersion         bool              `short:"n" description:"Just output the name of the next version tag, don't autotag"`
+	Verbose             []bool            `short:"v" description:"Enable logging, -v logs the base and calculated versions and the tags written, -vv also the skipped tags and commits"`
+	Branch              string            `short:"b" long:"branch" description:"Git branch to scan (defaults to main, then master)" default:""`
+	RepoPath            string            `short:"r" long:"repo" description:"Path to the repo" default:"./" `
+	GitConfig           bool              `long:"git-config" description:"Read the options not given on the command line from the autotag.* keys of the git config"`
+	Rev                 string            `long:"rev" description:"Commit SHA or tag to release instead of the head of the branch, eg: the tag of a CI pipeline"`
+	TagMergeBase        []string          `long:"tag-merge-base" description:"Release the merge-base of two refs instead of the head of the branch, given twice, eg: --tag-merge-base=main --tag-merge-base=integration"`
+	PreReleaseName      string            `short:"p" long:"pre-release-name" description:"create a pre-release tag"`
+	TypeChannels        map[string]string `long:"type-channel" description:"Pre-release channel of the releases decided by a commit type, eg: feat:beta or fix:stable (can be repeated)"`
+	PreReleaseIncrement bool              `long:"pre-release-increment" description:"Append a counter to the pre-release name, one more than the highest existing pre-release tag of the version"`
+	PreReleaseTimestamp string            `short:"T" long:"pre-release-timestamp" description:"create a pre-release tag and append a timestamp (can be: datetime|epoch)"`
+	BuildMetadata       string            `short:"m" long:"build-metadata" description:"optional SemVer build metadata to append to the version with '+' character"`
+	AllowMetadataOnly   bool              `long:"allow-metadata-only-bump" description:"With scope-conventional, tag the base version again with new build metadata when no commit bumps it"`
+	BuildMetadataEnv    string            `long:"build-metadata-env" description:"Environment variable with a CI build number to append to the build metadata, eg: BUILD_NUMBER"`
+	Scheme              string            `short:"s" long:"scheme" description:"The commit message scheme to use (can be: autotag|conventional|scope-conventional, default: autotag)"`
+	Unscoped            bool              `long:"unscoped" description:"With the scope-conventional scheme, version a single-module repo by plain v1.2.3 tags without requiring scopes"`
+	NoVersionPrefix     bool              `short:"e" long:"empty-version-prefix" description:"Do not prepend v to version tag, nor to the printed version"`
+	StableBranches      []string          `long:"stable-branch" description:"Branch (glob pattern) allowed to produce stable versions, other branches require a pre-release (can be repeated)"`
+	BranchBumpCap       map[string]string `long:"branch-bump-cap" description:"Highest bump level on branches matching a glob pattern, eg: release/*:patch (can be repeated, levels: patch|minor|major)"`
+	PathScopes          map[string]string `long:"path-scope" description:"Scope of the files changed in a directory when the commit has none, eg: services/api:api (can be repeated)"`
+	ScopeRegistry       string            `long:"scope-registry" description:"YAML file with the tag format, initial version, pre-release channel and allowed types of the scopes, eg: .autotag/scopes.yaml"`
+	WorkspaceManifest   string            `long:"workspace-manifest" description:"JSON or YAML file listing the packages of a monorepo by name and path, every package is a scope, eg: workspace.yaml"`
+	OwnersFile          string            `long:"owners-file" description:"CODEOWNERS style file deriving the scope of the changed files from their owner when the commit has none, eg: .github/CODEOWNERS"`
+	BranchVersionRegex  string            `long:"branch-version-pattern" description:"Regex with named 'major' and 'minor' groups used to derive the major.minor line the version must stay on from the branch name"`
+	BranchScopePattern  string            `long:"branch-scope-pattern" description:"Regex with a named 'scope' group used to derive the scope from the branch name when the commit has none"`
+	BaseOnPreRelease    bool              `long:"base-on-pre-release" description:"Use the highest pre-release tag as the base when no stable tag exists"`
+	PreReleaseOrder     []string          `long:"pre-release-order" description:"Pre-release channel, lowest first, ranking the pre-release tags with --base-on-pre-release, eg: alpha (can be repeated)"`
+	InitialVersion      string            `long:"initial-version" description:"Base version to use when no usable version tag exists, eg: 0.0.0"`
+	CommitsSinceWarning int               `long:"commits-since-base-warning" description:"Warn if the branch has more commits since the base version, eg: 500 (default: no warning)"`
+	WarnUnusualBump     bool              `long:"warn-unusual-bump" description:"Warn if the bump is higher than any of the recent releases of the scope, eg: a major after patches"`
+	UnusualReleases     int               `long:"unusual-bump-releases" description:"Number of recent releases --warn-unusual-bump compares the bump to (default: 5)"`
+	StrictUnusualBump   bool              `long:"strict-unusual-bump" description:"Fail instead of warning with --warn-unusual-bump"`
+	MaxMajor            int               `long:"max-major" description:"Fail instead of tagging a version with a higher major, eg: 9 (default: no cap)"`
+	SinceRef            string            `long:"since-ref" description:"Ignore this commit and its history for a version without a base tag, eg: the commit autotag was adopted at"`
+	Graduate            bool              `long:"graduate" description:"Release 1.0.0 if the major version of the base is 0, regardless of the commits"`
+	BaseByRecency       bool              `long:"base-by-recency" description:"Use the most recent tag as the base instead of the highest version"`
+	BasePerMajor        bool              `long:"base-per-major" description:"Use the highest version in the major line of the latest tag on the branch as the base"`
+	TagDateSource       string            `long:"tag-date-source" description:"Date of a tag used for date based selection (can be: commit|tag)" default:"commit"`
+	SignTag             bool              `long:"sign" description:"Create a GPG/SSH signed annotated tag"`
+	SigningKey          string            `long:"signing-key" description:"Key used to sign the tag (defaults to git's user.signingKey)"`
+	RangeTrailers       bool              `long:"range-trailers" description:"Append From: <base tag> and To: <sha> trailers to the message of an annotated tag"`
+	TagMessage          string            `long:"tag-message" description:"Go text/template rendering the message of an annotated tag, also rendered with -n and --list to check it, eg: 'Release {{.Tag}}'"`
+	StrictCommitFormat  bool              `long:"strict-commit-format" description:"Reject conventional commit headers with blanks around the scope or colon"`
+	RequireConventional bool              `long:"require-conventional" description:"Ignore commits without a conventional commit header (type and colon) instead of bumping the patch version"`
+	StrictTypeCase      bool              `long:"strict-type-case" description:"Only accept lowercase conventional commit types"`
+	CommitFilter        string            `long:"commit-filter" description:"Commits considered for the version bump (can be: all|merges-only|no-merges)" default:"all"`
+	OverrideMessage     string            `long:"override-message" description:"Message of a pending commit to preview the version it would produce, eg: from a pre-commit hook"`
+	PRTitle             string            `long:"pr-title" description:"Title of the pull request being merged, parsed like a commit message for the bump, eg: from the CI environment"`
+	PRTitleMode         string            `long:"pr-title-mode" description:"Whether the PR title replaces the commit messages or is evaluated in addition to them (can be: replace|merge)" default:"replace"`
+	BumpRules           map[string]string `long:"bump-rule" description:"Bump level of a conventional commit type, eg: perf:minor (can be repeated, levels: none|patch|minor|major)"`
+	BumpFooters         []string          `long:"bump-footer" description:"Footer key whose value sets the bump level of a commit, in addition to Release-As (can be repeated)"`
+	CancelReverts       bool              `long:"cancel-reverts" description:"A revert of a commit since the current tag cancels it, neither counts towards the version bump"`
+	FollowMergeParent   bool              `long:"follow-merge-parent" description:"Use the newest conventional commit of the merged branch when the latest commit is a merge without one"`
+	IgnoredTypes        []string          `long:"ignored-type" description:"Conventional commit type that doesn't warrant a tag with the scope-conventional scheme, eg: chore (can be repeated)"`
+	TagFormat           string            `long:"tag-format" description:"Format of the tag names with {version} and {scope} placeholders, eg: {scope}@{version}"`
+	ScopeSeparator      string            `long:"scope-version-separator" description:"Separator between the scope and the version in tag names, eg: @ or / (default: -)"`
+	VersionRefNamespace string            `long:"version-ref-namespace" description:"Ref namespace versions are read from and written to, eg: refs/autotag" default:"refs/tags"`
+	VersionFile         string            `long:"version-file" description:"Write the version to this file, eg: VERSION or services/{scope}/VERSION (also with -n)"`
+	CommitRegex         string            `long:"commit-regex" description:"Custom conventional commit header regex with named 'type', 'scope', 'breaking' and 'subject' groups"`
+	Scope               string            `long:"scope" description:"Release this scope with the scope-conventional scheme regardless of the scope of the latest commit"`
+	Bump                string            `long:"bump" description:"Force a bump level regardless of the commits (can be: patch|minor|major)"`
+	Workers             int               `long:"workers" description:"Number of goroutines parsing the commit messages with --list (default: 1)"`
+	OutputTemplate      string            `long:"output-template" description:"Go text/template rendering the result instead of the tag name, eg: '{{.Scope}}: {{.Current}} -> {{.Next}} ({{.Level}})'"`
+	List                bool              `long:"list" description:"Only list the current and next version of every scope of the scope-conventional scheme, don't autotag"`
+	Submodules          bool              `long:"submodules" description:"With --list, list the current and next version of every git submodule instead of the scopes"`
+	ChangelogJSON       bool              `long:"changelog-json" description:"Print the commits of the release in the JSON shape of the conventional-changelog tools instead of the version, one object per scope with --list"`
+	ShellExports        bool              `long:"shell-exports" description:"Print the versions as eval-able shell exports, eg: export API_VERSION=1.3.0, one per scope with --list"`
+	JSONLines           bool              `long:"json-lines" description:"With --list, print every scope as a JSON object per line as soon as it is calculated"`
+	IgnoreCommits       []string          `long:"ignore-commit" description:"Commit SHA excluded from the version bump (can be repeated)"`
+	IgnoreAuthors       []string          `long:"ignore-author" description:"Author name or email (* matches any characters) whose commits are excluded from the version bump, eg: dependabot[bot] (can be repeated)"`
+	AuthorEmails        []string          `long:"author-email" description:"Email pattern or domain of the authors or committers whose commits drive the version bump, eg: example.com (can be repeated)"`
+	RequireSigned       bool              `long:"require-signed-commits" description:"Only count commits whose GPG/SSH signature verifies towards the version bump"`
+	UnsignedPolicy      string            `long:"unsigned-commit-policy" description:"What to do with a commit without a verified signature with --require-signed-commits (can be: skip|error, default: skip)"`
+	SkipEmptyRelease    bool              `long:"skip-empty-release" description:"Don't tag if no commit qualifies for a release after ignoring and filtering the commits"`
+	ScanBodyHeaders     bool              `long:"scan-body-headers" description:"Also evaluate the conventional commit headers in the message body, eg: of squash merges"`
+	CheckRemote         string            `long:"check-remote" description:"Fail before tagging if the tag already exists on this remote, eg: origin"`
+	GitRetryAttempts    int               `long:"git-retry-attempts" description:"Number of tries of git operations failing transiently, eg: on a locked ref (default: 1)"`
+	GitRetryBackoff     time.Duration     `long:"git-retry-backoff" description:"Delay before the first retry of a git operation, doubled for every further retry" default:"1s"`
+	RemoteTags          bool              `long:"remote-tags" description:"Read the version tags from the tag remote with git ls-remote instead of the local tags, and push the new tag to it"`
+	TagRemote           string            `long:"tag-remote" description:"Remote of --remote-tags (defaults to the remote the branch tracks)"`
+	VersionFromMessage  bool              `long:"version-from-tag-message" description:"Read the version of annotated tags without a version tag name from the tag message, eg: Release v1.2.3"`
+	RequireUpToDate     bool              `long:"require-up-to-date" description:"Fail before tagging if the upstream of the branch has commits the branch doesn't have"`
+	AllowedEvents       []string          `long:"allowed-event" description:"CI event tags are created on, eg: push, other events fail (can be repeated)"`
+	Event               string            `long:"event" description:"CI event of the run checked against --allowed-event, eg: $GITHUB_EVENT_NAME"`
+	RequireCleanTree    bool              `long:"require-clean-tree" description:"Fail before tagging if the working tree has uncommitted changes to tracked files"`
+	AvoidReusedVersions bool              `long:"avoid-reused-versions" description:"Fail if the next version was used by a deleted annotated tag"`
+	ReservedVersions    []string          `long:"reserved-version" description:"Version the next version must not land on, eg: a pre-announced 2.0.0 (can be repeated)"`
+	ReservedPolicy      string            `long:"reserved-version-policy" description:"What to do when the next version is reserved (can be: skip|error)" default:"skip"`
+	SkipExisting        bool              `long:"skip-existing" description:"Bump a next version that is already tagged again by the same level until the tag is free"`
+	ASCIIScopes         bool              `long:"ascii-scopes" description:"Reject scopes with non-ASCII characters with the scope-conventional scheme"`
 }
 
 var opts Options
@@ -35,27 +121,241 @@ func init() {
 
 func main() {
 	log.SetOutput(io.Discard)
-	if opts.Verbose {
+	verbosity := autotag.VerbosityQuiet
+	if len(opts.Verbose) > 0 {
 		log.SetOutput(os.Stderr)
+		verbosity = autotag.Verbosity(len(opts.Verbose) - 1)
+	}
+
+	if opts.Submodules && !opts.List {
+		log.SetOutput(os.Stderr)
+		log.Println("Error initializing: --submodules requires --list")
+		os.Exit(1)
+	}
+
+	var mergeBase [2]string
+	if len(opts.TagMergeBase) > 0 {
+		if len(opts.TagMergeBase) != 2 {
+			log.SetOutput(os.Stderr)
+			log.Println("Error initializing: --tag-merge-base must be given twice")
+			os.Exit(1)
+		}
+		copy(mergeBase[:], opts.TagMergeBase)
+	}
+
+	var commitRex *regexp.Regexp
+	if opts.CommitRegex != "" {
+		var err error
+		if commitRex, err = regexp.Compile(opts.CommitRegex); err != nil {
+			log.SetOutput(os.Stderr)
+			log.Println("Error initializing: invalid commit regex: " + err.Error())
+			os.Exit(1)
+		}
+	}
+
+	bumpRules := make(autotag.BumpRules, len(opts.BumpRules))
+	for typ, name := range opts.BumpRules {
+		level, err := autotag.ParseBumpLevel(name)
+		if err != nil {
+			log.SetOutput(os.Stderr)
+			log.Println("Error initializing: " + err.Error())
+			os.Exit(1)
+		}
+		bumpRules[typ] = level
+	}
+
+	bumpCaps := make(map[string]autotag.BumpLevel, len(opts.BranchBumpCap))
+	for pattern, name := range opts.BranchBumpCap {
+		level, err := autotag.ParseBumpLevel(name)
+		if err != nil {
+			log.SetOutput(os.Stderr)
+			log.Println("Error initializing: " + err.Error())
+			os.Exit(1)
+		}
+		bumpCaps[pattern] = level
+	}
+
+	var outputTmpl *template.Template
+	if opts.OutputTemplate != "" {
+		var err error
+		if outputTmpl, err = autotag.ParseResultTemplate(opts.OutputTemplate); err != nil {
+			log.SetOutput(os.Stderr)
+			log.Println("Error initializing: " + err.Error())
+			os.Exit(1)
+		}
+	}
+
+	bump := autotag.BumpNone
+	if opts.Bump != "" {
+		var err error
+		if bump, err = autotag.ParseBumpLevel(opts.Bump); err != nil {
+			log.SetOutput(os.Stderr)
+			log.Println("Error initializing: " + err.Error())
+			os.Exit(1)
+		}
 	}
 
 	r, err := autotag.NewRepo(autotag.GitRepoConfig{
 		RepoPath:                  opts.RepoPath,
 		Branch:                    opts.Branch,
 		PreReleaseName:            opts.PreReleaseName,
+		TypeChannels:              opts.TypeChannels,
 		PreReleaseTimestampLayout: opts.PreReleaseTimestamp,
+		PreReleaseIncrement:       opts.PreReleaseIncrement,
 		BuildMetadata:             opts.BuildMetadata,
+		AllowMetadataOnlyBump:     opts.AllowMetadataOnly,
+		BuildMetadataFromEnv:      opts.BuildMetadataEnv,
 		Scheme:                    opts.Scheme,
+		ReadGitConfig:             opts.GitConfig,
+		Unscoped:                  opts.Unscoped,
 		Prefix:                    !opts.NoVersionPrefix,
+		PathScopes:                opts.PathScopes,
+		WorkspaceManifest:         opts.WorkspaceManifest,
+		ScopeRegistry:             opts.ScopeRegistry,
+		StableBranches:            opts.StableBranches,
+		BranchBumpCap:             bumpCaps,
+		BranchScopePattern:        opts.BranchScopePattern,
+		BranchVersionPattern:      opts.BranchVersionRegex,
+		ScopeFromOwnersFile:       opts.OwnersFile,
+		BaseOnPreRelease:          opts.BaseOnPreRelease,
+		PreReleaseOrder:           opts.PreReleaseOrder,
+		InitialVersion:            opts.InitialVersion,
+		SinceRef:                  opts.SinceRef,
+		CommitsSinceBaseWarning:   opts.CommitsSinceWarning,
+		WarnOnUnusualBump:         opts.WarnUnusualBump,
+		UnusualBumpReleases:       opts.UnusualReleases,
+		StrictUnusualBump:         opts.StrictUnusualBump,
+		MaxMajor:                  opts.MaxMajor,
+		Graduate:                  opts.Graduate,
+		BaseByRecency:             opts.BaseByRecency,
+		BasePerMajor:              opts.BasePerMajor,
+		TagDateSource:             opts.TagDateSource,
+		SignTag:                   opts.SignTag,
+		SigningKey:                opts.SigningKey,
+		TagMessageTemplate:        opts.TagMessage,
+		TagRangeTrailers:          opts.RangeTrailers,
+		CommitFilter:              opts.CommitFilter,
+		StrictTypeCase:            opts.StrictTypeCase,
+		StrictCommitFormat:        opts.StrictCommitFormat,
+		RequireConventional:       opts.RequireConventional,
+		CommitRegex:               commitRex,
+		OverrideMessage:           opts.OverrideMessage,
+		PRTitle:                   opts.PRTitle,
+		PRTitleMode:               opts.PRTitleMode,
+		IgnoredTypes:              opts.IgnoredTypes,
+		FollowMergeParent:         opts.FollowMergeParent,
+		CancelReverts:             opts.CancelReverts,
+		BumpRules:                 bumpRules,
+		BumpFooters:               opts.BumpFooters,
+		WriteVersionFile:          opts.VersionFile,
+		VersionRefNamespace:       opts.VersionRefNamespace,
+		TagFormat:                 opts.TagFormat,
+		ScopeVersionSeparator:     opts.ScopeSeparator,
+		Scope:                     opts.Scope,
+		Rev:                       opts.Rev,
+		TagMergeBase:              mergeBase,
+		Bump:                      bump,
+		CheckRemote:               opts.CheckRemote,
+		RequireUpToDate:           opts.RequireUpToDate,
+		RequireCleanTree:          opts.RequireCleanTree,
+		AllowedEvents:             opts.AllowedEvents,
+		Event:                     opts.Event,
+		RemoteTags:                opts.RemoteTags,
+		VersionFromTagMessage:     opts.VersionFromMessage,
+		TagRemote:                 opts.TagRemote,
+		AvoidReusedVersions:       opts.AvoidReusedVersions,
+		ReservedVersions:          opts.ReservedVersions,
+		ReservedVersionPolicy:     opts.ReservedPolicy,
+		SkipExisting:              opts.SkipExisting,
+		ASCIIScopes:               opts.ASCIIScopes,
+		ScanBodyHeaders:           opts.ScanBodyHeaders,
+		IgnoreCommits:             opts.IgnoreCommits,
+		IgnoreAuthors:             opts.IgnoreAuthors,
+		AuthorEmailFilter:         opts.AuthorEmails,
+		RequireSignedCommits:      opts.RequireSigned,
+		UnsignedCommitPolicy:      opts.UnsignedPolicy,
+		SkipEmptyRelease:          opts.SkipEmptyRelease,
+		AllScopes:                 opts.List && !opts.Submodules,
+		Submodules:                opts.Submodules,
+		Workers:                   opts.Workers,
+		GitRetry:                  autotag.GitRetry{Attempts: opts.GitRetryAttempts, Backoff: opts.GitRetryBackoff},
+		Verbosity:                 verbosity,
+		// the warnings are printed without -v too
+		OnWarning: func(msg string) { fmt.Fprintln(os.Stderr, "warning: "+msg) },
 	})
+	if errors.Is(err, autotag.ErrNoScope) || errors.Is(err, autotag.ErrIgnoredType) || errors.Is(err, autotag.ErrNoBump) || errors.Is(err, autotag.ErrIgnoredCommit) {
+		// nothing to tag, report why
+		fmt.Println(err)
+		os.Exit(0)
+	}
 	if err != nil {
 		log.SetOutput(os.Stderr)
 		log.Println("Error initializing: " + err.Error())
 		os.Exit(1)
 	}
 
-	// Tag unless asked otherwise
-	if !opts.JustVersion {
+	if opts.ChangelogJSON {
+		changelogs, err := r.ConventionalChangelogs()
+		if err == nil {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			err = enc.Encode(changelogs)
+		}
+		if err != nil {
+			log.SetOutput(os.Stderr)
+			log.Println("Error writing the changelog: " + err.Error())
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	if opts.ShellExports {
+		if err := r.ExportShellVars(os.Stdout); err != nil {
+			log.SetOutput(os.Stderr)
+			log.Println("Error exporting versions: " + err.Error())
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	if opts.List && opts.JSONLines {
+		if err := r.StreamResults(os.Stdout); err != nil {
+			log.SetOutput(os.Stderr)
+			log.Println("Error listing versions: " + err.Error())
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	if opts.List {
+		results, err := r.Preview()
+		if err != nil {
+			log.SetOutput(os.Stderr)
+			log.Println("Error listing versions: " + err.Error())
+			os.Exit(1)
+		}
+		for _, res := range results {
+			if outputTmpl != nil {
+				printResult(outputTmpl, res)
+				continue
+			}
+			switch {
+			case res.Err != nil:
+				fmt.Printf("%s: %s\n", res.Scope, res.Err)
+			case res.Next == nil:
+				fmt.Printf("%s: %s (no changes)\n", res.Scope, res.Current)
+			default:
+				fmt.Printf("%s: %s -> %s\n", res.Scope, res.Current, res.Tag)
+			}
+		}
+		os.Exit(0)
+	}
+
+	// Tag unless asked otherwise, a dry run still checks the tag message renders
+	if opts.JustVersion {
+		if _, err := r.TagMessage(r.Result()); err != nil {
+			log.SetOutput(os.Stderr)
+			log.Println("Error rendering the tag message: " + err.Error())
+			os.Exit(1)
+		}
+	} else {
 		err = r.AutoTag()
 		if err != nil {
 			log.SetOutput(os.Stderr)
@@ -64,8 +364,27 @@ func main() {
 		}
 	}
 
-	fmt.Println(r.LatestVersion())
+	if err = r.WriteVersionFile(); err != nil {
+		log.SetOutput(os.Stderr)
+		log.Println("Error writing version file: " + err.Error())
+		os.Exit(1)
+	}
 
-	// TODO:(jnelson) Add -major -minor -patch flags for force bumps Fri Sep 11 10:04:20 2015
+	if outputTmpl != nil {
+		printResult(outputTmpl, r.Result())
+		os.Exit(0)
+	}
+	fmt.Println(r.LatestVersion())
 	os.Exit(0)
 }
+
+// printResult prints the result rendered with the output template
+func printResult(t *template.Template, res autotag.Result) {
+	out, err := res.Render(t)
+	if err != nil {
+		log.SetOutput(os.Stderr)
+		log.Println("Error rendering the output: " + err.Error())
+		os.Exit(1)
+	}
+	fmt.Println(out)
+}
@@ -1,13 +1,19 @@
 package autotag
 
 import (
+	"errors"
 	"fmt"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/alecthomas/assert"
 	"github.com/gogs/git-module"
+	"github.com/hashicorp/go-version"
 )
 
 func init() {
@@ -49,6 +55,42 @@ type testRepoSetup struct {
 
 	// (optional) Supply a list of commits to apply so you can test the logic between to possible tags wheere they may be more complex multiple bumps
 	commitList []string
+
+	// (optional) tagger used to create tags. If not set, tags are written to the test repo
+	tagger Tagger
+
+	// (optional) override the version arithmetic of a bump level
+	majorBump BumpFunc
+	minorBump BumpFunc
+	patchBump BumpFunc
+
+	// (optional) regex deriving the scope from the branch name
+	branchScopePattern string
+
+	// (optional) base the version on the highest pre-release when there is no stable tag
+	baseOnPreRelease bool
+
+	// (optional) base version used when no usable version tag exists
+	initialVersion string
+
+	// (optional) only accept lowercase conventional commit types
+	strictTypeCase bool
+
+	// (optional) custom conventional commit header regex
+	commitRegex *regexp.Regexp
+
+	// (optional) message of a pending commit to parse
+	overrideMessage string
+
+	// (optional) bump levels of conventional commit types
+	bumpRules BumpRules
+
+	// (optional) path of the version file
+	versionFile string
+
+	// (optional) create signed tags, with an optional signing key
+	signTag    bool
+	signingKey string
 }
 
 // newTestRepo creates a new git repo in a temporary directory and returns an autotag.GitRepo struct for
@@ -98,6 +140,20 @@ func newTestRepo(t *testing.T, setup testRepoSetup) GitRepo {
 		BuildMetadata:             setup.buildMetadata,
 		Scheme:                    setup.scheme,
 		Prefix:                    !setup.disablePrefix,
+		Tagger:                    setup.tagger,
+		MajorBump:                 setup.majorBump,
+		MinorBump:                 setup.minorBump,
+		PatchBump:                 setup.patchBump,
+		BranchScopePattern:        setup.branchScopePattern,
+		BaseOnPreRelease:          setup.baseOnPreRelease,
+		InitialVersion:            setup.initialVersion,
+		StrictTypeCase:            setup.strictTypeCase,
+		CommitRegex:               setup.commitRegex,
+		OverrideMessage:           setup.overrideMessage,
+		BumpRules:                 setup.bumpRules,
+		WriteVersionFile:          setup.versionFile,
+		SignTag:                   setup.signTag,
+		SigningKey:                setup.signingKey,
 	})
 
 	if err != nil {
@@ -145,6 +201,71 @@ func TestValidateConfig(t *testing.T) {
 			},
 			shouldErr: true,
 		},
+		{
+			name: "negative git retry attempts",
+			cfg: GitRepoConfig{
+				Branch:   "master",
+				GitRetry: GitRetry{Attempts: -1},
+			},
+			shouldErr: true,
+		},
+		{
+			name: "release without a tag",
+			cfg: GitRepoConfig{
+				Branch:   "master",
+				Releases: []Release{{PreRelease: true}},
+			},
+			shouldErr: true,
+		},
+		{
+			name: "releases with remote tags",
+			cfg: GitRepoConfig{
+				Branch:     "master",
+				Releases:   []Release{{Tag: "v1.0.0"}},
+				RemoteTags: true,
+			},
+			shouldErr: true,
+		},
+		{
+			name: "version from tag message with remote tags",
+			cfg: GitRepoConfig{
+				Branch:                "master",
+				VersionFromTagMessage: true,
+				RemoteTags:            true,
+			},
+			shouldErr: true,
+		},
+		{
+			name: "invalid branch bump cap",
+			cfg: GitRepoConfig{
+				Branch:        "master",
+				BranchBumpCap: map[string]BumpLevel{"release/*": BumpNone},
+			},
+			shouldErr: true,
+		},
+		{
+			name: "invalid scheme",
+			cfg: GitRepoConfig{
+				Branch: "master",
+				Scheme: "semantic",
+			},
+			shouldErr: true,
+		},
+		{
+			name: "pre-release-name with type token",
+			cfg: GitRepoConfig{
+				Branch:         "master",
+				PreReleaseName: "{type}.rc",
+			},
+		},
+		{
+			name: "invalid pre-release-name with type token",
+			cfg: GitRepoConfig{
+				Branch:         "master",
+				PreReleaseName: "{type}_rc",
+			},
+			shouldErr: true,
+		},
 		{
 			name: "invalid pre-release-timestamp",
 			cfg: GitRepoConfig{
@@ -153,6 +274,195 @@ func TestValidateConfig(t *testing.T) {
 			},
 			shouldErr: true,
 		},
+		{
+			name: "invalid branch scope pattern",
+			cfg: GitRepoConfig{
+				Branch:             "master",
+				BranchScopePattern: "release/(",
+			},
+			shouldErr: true,
+		},
+		{
+			name: "branch scope pattern without scope group",
+			cfg: GitRepoConfig{
+				Branch:             "master",
+				BranchScopePattern: "^release/(.+)$",
+			},
+			shouldErr: true,
+		},
+		{
+			name: "commit regex without breaking group",
+			cfg: GitRepoConfig{
+				Branch:      "master",
+				CommitRegex: regexp.MustCompile(`^(?P<type>\w+)(?P<scope>\(\w+\))?(?P<subject>:.*)`),
+			},
+			shouldErr: true,
+		},
+		{
+			name: "invalid bump rule type",
+			cfg: GitRepoConfig{
+				Branch:    "master",
+				BumpRules: BumpRules{"fix-": BumpPatch},
+			},
+			shouldErr: true,
+		},
+		{
+			name: "invalid bump level",
+			cfg: GitRepoConfig{
+				Branch:    "master",
+				BumpRules: BumpRules{"perf": BumpLevel(7)},
+			},
+			shouldErr: true,
+		},
+		{
+			name: "path scope without directory",
+			cfg: GitRepoConfig{
+				Branch:     "master",
+				PathScopes: map[string]string{"./": "root"},
+			},
+			shouldErr: true,
+		},
+		{
+			name: "version file with scope without scope scheme",
+			cfg: GitRepoConfig{
+				Branch:           "master",
+				WriteVersionFile: "services/{scope}/VERSION",
+			},
+			shouldErr: true,
+		},
+		{
+			name: "version ref namespace outside of refs",
+			cfg: GitRepoConfig{
+				Branch:              "master",
+				VersionRefNamespace: "autotag",
+			},
+			shouldErr: true,
+		},
+		{
+			name: "signing in a custom version ref namespace",
+			cfg: GitRepoConfig{
+				Branch:              "master",
+				VersionRefNamespace: "refs/autotag",
+				SignTag:             true,
+			},
+			shouldErr: true,
+		},
+		{
+			name: "tag format without version",
+			cfg: GitRepoConfig{
+				Branch:    "master",
+				TagFormat: "release",
+			},
+			shouldErr: true,
+		},
+		{
+			name: "invalid stable branch pattern",
+			cfg: GitRepoConfig{
+				Branch:         "master",
+				StableBranches: []string{"release/["},
+			},
+			shouldErr: true,
+		},
+		{
+			name: "invalid initial version",
+			cfg: GitRepoConfig{
+				Branch:         "master",
+				InitialVersion: "one",
+			},
+			shouldErr: true,
+		},
+		{
+			name: "invalid commit filter",
+			cfg: GitRepoConfig{
+				Branch:       "master",
+				CommitFilter: "merges",
+			},
+			shouldErr: true,
+		},
+		{
+			name: "invalid tag date source",
+			cfg: GitRepoConfig{
+				Branch:        "master",
+				TagDateSource: "tagger",
+			},
+			shouldErr: true,
+		},
+		{
+			name: "signing key without signing",
+			cfg: GitRepoConfig{
+				Branch:     "master",
+				SigningKey: "ABCDEF",
+			},
+			shouldErr: true,
+		},
+		{
+			name: "signing with a custom tagger",
+			cfg: GitRepoConfig{
+				Branch:  "master",
+				SignTag: true,
+				Tagger:  &fakeTagger{},
+			},
+			shouldErr: true,
+		},
+		{
+			name: "scope version separator without the scope-conventional scheme",
+			cfg: GitRepoConfig{
+				Branch:                "master",
+				ScopeVersionSeparator: "@",
+			},
+			shouldErr: true,
+		},
+		{
+			name: "scope version separator with a tag format",
+			cfg: GitRepoConfig{
+				Branch:                "master",
+				Scheme:                "scope-conventional",
+				TagFormat:             "{scope}@{version}",
+				ScopeVersionSeparator: "@",
+			},
+			shouldErr: true,
+		},
+		{
+			name: "scope version separator not allowed in tag names",
+			cfg: GitRepoConfig{
+				Branch:                "master",
+				Scheme:                "scope-conventional",
+				ScopeVersionSeparator: ":",
+			},
+			shouldErr: true,
+		},
+		{
+			name: "ignored commit is not a SHA",
+			cfg: GitRepoConfig{
+				Branch:        "master",
+				IgnoreCommits: []string{"HEAD~1"},
+			},
+			shouldErr: true,
+		},
+		{
+			name: "all scopes without the scope-conventional scheme",
+			cfg: GitRepoConfig{
+				Branch:    "master",
+				AllScopes: true,
+			},
+			shouldErr: true,
+		},
+		{
+			name: "scope without the scope-conventional scheme",
+			cfg: GitRepoConfig{
+				Branch: "master",
+				Scope:  "api",
+			},
+			shouldErr: true,
+		},
+		{
+			name: "invalid bump level",
+			cfg: GitRepoConfig{
+				Branch: "master",
+				Bump:   BumpMajor + 1,
+			},
+			shouldErr: true,
+		},
 		{
 			name: "valid config with all options used",
 			cfg: GitRepoConfig{
@@ -161,6 +471,12 @@ func TestValidateConfig(t *testing.T) {
 				PreReleaseTimestampLayout: "epoch",
 				BuildMetadata:             "g12345678",
 				Prefix:                    true,
+				BranchScopePattern:        "^release/(?P<scope>[^/]+)$",
+				InitialVersion:            "v0.0.0",
+				SignTag:                   true,
+				SigningKey:                "ABCDEF",
+				CommitFilter:              CommitFilterNoMerges,
+				CommitRegex:               regexp.MustCompile(`^(?P<type>\w+)\[(?P<scope>\w+)\](?P<breaking>!)?(?P<subject>:.*)`),
 			},
 			shouldErr: false,
 		},
@@ -338,83 +654,263 @@ func TestPatch(t *testing.T) {
 	}
 }
 
-func TestMissingInitialTag(t *testing.T) {
-	tr := createTestRepo(t, "")
-	repo, err := git.Open(tr)
-	checkFatal(t, err)
-	defer cleanupTestRepo(t, repo)
-
-	updateReadme(t, repo, "a commit before any usable tag has been created")
-
-	_, err = NewRepo(GitRepoConfig{
-		RepoPath: repo.Path(),
-		Branch:   "master",
-	})
-	assert.Error(t, err)
-}
+func TestCustomBumpFuncs(t *testing.T) {
+	// calendar versioning: a minor bump rolls the month, a patch bump increments the last segment
+	calverMinor := func(cv *version.Version) (*version.Version, error) {
+		return version.NewVersion(timeNow().UTC().Format("2006.1") + ".0")
+	}
+	calverPatch := func(cv *version.Version) (*version.Version, error) {
+		s := cv.Segments()
+		return version.NewVersion(fmt.Sprintf("%d.%d.%d", s[0], s[1], s[2]+1))
+	}
 
-func TestAutoTag(t *testing.T) {
 	tests := []struct {
 		name        string
 		setup       testRepoSetup
-		shouldErr   bool
 		expectedTag string
 	}{
-		// tests for autotag (default) scheme
 		{
-			name: "autotag scheme, [major] bump",
+			name: "autotag scheme, custom minor",
 			setup: testRepoSetup{
 				scheme:     "autotag",
-				nextCommit: "[major] this is a big release\n\nfoo bar baz\n",
-				initialTag: "v1.0.0",
+				nextCommit: "[minor] roll the month",
+				initialTag: "v2018.12.4",
+				minorBump:  calverMinor,
+				patchBump:  calverPatch,
 			},
-			expectedTag: "v2.0.0",
+			expectedTag: "v2019.1.0",
 		},
 		{
-			name: "autotag scheme, [minor] bump",
+			name: "conventional scheme, custom minor",
 			setup: testRepoSetup{
-				scheme:     "autotag",
-				nextCommit: "[minor] this is a smaller release\n\nfoo bar baz\n",
-				initialTag: "v1.0.0",
+				scheme:     "conventional",
+				nextCommit: "feat: roll the month",
+				initialTag: "v2018.12.4",
+				minorBump:  calverMinor,
+				patchBump:  calverPatch,
 			},
-			expectedTag: "v1.1.0",
+			expectedTag: "v2019.1.0",
 		},
 		{
-			name: "autotag scheme, patch bump",
+			name: "default patch fallback uses custom patch",
 			setup: testRepoSetup{
 				scheme:     "autotag",
-				nextCommit: "this is just a basic change\n\nfoo bar baz\n",
-				initialTag: "v1.0.0",
+				nextCommit: "no keywords here",
+				initialTag: "v2018.12.4",
+				minorBump:  calverMinor,
+				patchBump:  calverPatch,
 			},
-			expectedTag: "v1.0.1",
+			expectedTag: "v2018.12.5",
 		},
 		{
-			name: "autotag scheme, #major bump",
+			name: "unset bump funcs keep SemVer arithmetic",
 			setup: testRepoSetup{
 				scheme:     "autotag",
-				nextCommit: "#major this is a big release\n\nfoo bar baz\n",
-				initialTag: "v1.0.0",
+				nextCommit: "[major] breaking",
+				initialTag: "v2018.12.4",
+				minorBump:  calverMinor,
 			},
-			expectedTag: "v2.0.0",
+			expectedTag: "v2019.0.0",
 		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := newTestRepo(t, tc.setup)
+			defer cleanupTestRepo(t, r.repo)
+
+			assert.Equal(t, tc.expectedTag, r.LatestVersion())
+		})
+	}
+}
+
+func TestCustomBumpFuncError(t *testing.T) {
+	tr := createTestRepo(t, "")
+	repo, err := git.Open(tr)
+	checkFatal(t, err)
+	defer cleanupTestRepo(t, repo)
+
+	seedTestRepo(t, "v1.0.0", repo)
+	updateReadme(t, repo, "feat: add login")
+
+	errCalver := errors.New("no calendar version")
+	_, err = NewRepo(GitRepoConfig{
+		RepoPath:  repo.Path(),
+		Branch:    "master",
+		Scheme:    "conventional",
+		MinorBump: func(*version.Version) (*version.Version, error) { return nil, errCalver },
+	})
+	assert.True(t, errors.Is(err, errCalver), "expected %v, got %v", errCalver, err)
+}
+
+func TestPromotePreRelease(t *testing.T) {
+	for _, tc := range []struct {
+		base, next, expected string
+	}{
+		{"1.0.0-rc.2", "1.0.1", "1.0.0"},
+		{"1.0.0-rc.2", "1.1.0", "1.0.0"},
+		{"1.0.0-rc.2", "2.0.0", "1.0.0"},
+		{"1.2.0-rc.1", "1.3.0", "1.2.0"},
+		{"1.2.0-rc.1", "2.0.0", "2.0.0"},
+		{"1.2.3-rc.1", "1.3.0", "1.3.0"},
+		{"1.2.3", "1.2.4", "1.2.4"},
+	} {
+		base, err := version.NewVersion(tc.base)
+		checkFatal(t, err)
+		next, err := version.NewVersion(tc.next)
+		checkFatal(t, err)
+
+		assert.Equal(t, tc.expected, promotePreRelease(base, next).String(), "%s -> %s", tc.base, tc.next)
+	}
+}
+
+func TestCommitFilter(t *testing.T) {
+	tests := []struct {
+		name        string
+		filter      string
+		branchMsgs  []string
+		mergeMsg    string
+		expectedTag string
+	}{
 		{
-			name: "autotag scheme, #minor bump",
-			setup: testRepoSetup{
-				scheme:     "autotag",
-				nextCommit: "#minor this is a smaller release\n\nfoo bar baz\n",
-				initialTag: "v1.0.0",
-			},
+			name:        "all, feature commit drives the bump",
+			filter:      CommitFilterAll,
+			branchMsgs:  []string{"feat: add login"},
+			mergeMsg:    "Merge branch 'feature'",
 			expectedTag: "v1.1.0",
 		},
 		{
-			name: "pre-release-name with patch bump",
-			setup: testRepoSetup{
-				scheme:         "autotag",
-				nextCommit:     "#patch bump",
-				initialTag:     "v1.0.0",
-				preReleaseName: "dev",
-			},
-			expectedTag: "v1.0.1-dev",
+			name:        "merges-only ignores the feature commits",
+			filter:      CommitFilterMergesOnly,
+			branchMsgs:  []string{"feat: add login"},
+			mergeMsg:    "Merge branch 'feature'",
+			expectedTag: "v1.0.1",
+		},
+		{
+			name:        "merges-only uses the merge message",
+			filter:      CommitFilterMergesOnly,
+			branchMsgs:  []string{"fix: typo"},
+			mergeMsg:    "feat: add login (#12)",
+			expectedTag: "v1.1.0",
+		},
+		{
+			name:        "no-merges ignores the merge message",
+			filter:      CommitFilterNoMerges,
+			branchMsgs:  []string{"fix: typo"},
+			mergeMsg:    "feat: add login (#12)",
+			expectedTag: "v1.0.1",
+		},
+		{
+			name:        "default considers merges and feature commits",
+			filter:      "",
+			branchMsgs:  []string{"fix: typo"},
+			mergeMsg:    "feat: add login (#12)",
+			expectedTag: "v1.1.0",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tr := createTestRepo(t, "")
+			repo, err := git.Open(tr)
+			checkFatal(t, err)
+			defer cleanupTestRepo(t, repo)
+
+			seedTestRepo(t, "v1.0.0", repo)
+			updateReadme(t, repo, "chore: unrelated")
+			makeMerge(t, repo, "feature", tc.branchMsgs, tc.mergeMsg)
+
+			r, err := NewRepo(GitRepoConfig{
+				RepoPath:     repo.Path(),
+				Branch:       "master",
+				Scheme:       "conventional",
+				Prefix:       true,
+				CommitFilter: tc.filter,
+			})
+			checkFatal(t, err)
+
+			assert.Equal(t, tc.expectedTag, r.LatestVersion())
+		})
+	}
+}
+
+func TestMissingInitialTag(t *testing.T) {
+	tr := createTestRepo(t, "")
+	repo, err := git.Open(tr)
+	checkFatal(t, err)
+	defer cleanupTestRepo(t, repo)
+
+	updateReadme(t, repo, "a commit before any usable tag has been created")
+
+	_, err = NewRepo(GitRepoConfig{
+		RepoPath: repo.Path(),
+		Branch:   "master",
+	})
+	assert.Error(t, err)
+}
+
+func TestAutoTag(t *testing.T) {
+	tests := []struct {
+		name        string
+		setup       testRepoSetup
+		shouldErr   bool
+		expectedTag string
+	}{
+		// tests for autotag (default) scheme
+		{
+			name: "autotag scheme, [major] bump",
+			setup: testRepoSetup{
+				scheme:     "autotag",
+				nextCommit: "[major] this is a big release\n\nfoo bar baz\n",
+				initialTag: "v1.0.0",
+			},
+			expectedTag: "v2.0.0",
+		},
+		{
+			name: "autotag scheme, [minor] bump",
+			setup: testRepoSetup{
+				scheme:     "autotag",
+				nextCommit: "[minor] this is a smaller release\n\nfoo bar baz\n",
+				initialTag: "v1.0.0",
+			},
+			expectedTag: "v1.1.0",
+		},
+		{
+			name: "autotag scheme, patch bump",
+			setup: testRepoSetup{
+				scheme:     "autotag",
+				nextCommit: "this is just a basic change\n\nfoo bar baz\n",
+				initialTag: "v1.0.0",
+			},
+			expectedTag: "v1.0.1",
+		},
+		{
+			name: "autotag scheme, #major bump",
+			setup: testRepoSetup{
+				scheme:     "autotag",
+				nextCommit: "#major this is a big release\n\nfoo bar baz\n",
+				initialTag: "v1.0.0",
+			},
+			expectedTag: "v2.0.0",
+		},
+		{
+			name: "autotag scheme, #minor bump",
+			setup: testRepoSetup{
+				scheme:     "autotag",
+				nextCommit: "#minor this is a smaller release\n\nfoo bar baz\n",
+				initialTag: "v1.0.0",
+			},
+			expectedTag: "v1.1.0",
+		},
+		{
+			name: "pre-release-name with patch bump",
+			setup: testRepoSetup{
+				scheme:         "autotag",
+				nextCommit:     "#patch bump",
+				initialTag:     "v1.0.0",
+				preReleaseName: "dev",
+			},
+			expectedTag: "v1.0.1-dev",
 		},
 		{
 			name: "epoch pre-release-timestamp",
@@ -467,6 +963,26 @@ func TestAutoTag(t *testing.T) {
 			},
 			expectedTag: "v1.0.1",
 		},
+		{
+			name: "only pre-release tags with base on pre-release",
+			setup: testRepoSetup{
+				scheme:           "autotag",
+				nextCommit:       "#patch bump",
+				initialTag:       "v1.0.0-rc.1",
+				baseOnPreRelease: true,
+			},
+			expectedTag: "v1.0.0",
+		},
+		{
+			name: "only pre-release tags with initial version",
+			setup: testRepoSetup{
+				scheme:         "autotag",
+				nextCommit:     "#minor bump",
+				initialTag:     "v1.0.0-rc.1",
+				initialVersion: "0.0.0",
+			},
+			expectedTag: "v0.1.0",
+		},
 		{
 			name: "build metadata",
 			setup: testRepoSetup{
@@ -530,6 +1046,85 @@ func TestAutoTag(t *testing.T) {
 			},
 			expectedTag: "v1.1.0",
 		},
+		{
+			name: "conventional commits, custom commit regex",
+			setup: testRepoSetup{
+				scheme:      "conventional",
+				nextCommit:  "[feat] allow provided config object to extend other configs",
+				initialTag:  "v1.0.0",
+				commitRegex: regexp.MustCompile(`^\[(?P<type>\w+)(?P<breaking>!)?\](?P<scope>)(?P<subject>.*)`),
+			},
+			expectedTag: "v1.1.0",
+		},
+		{
+			name: "conventional commits, pending commit message",
+			setup: testRepoSetup{
+				scheme:          "conventional",
+				nextCommit:      "fix: correct typo",
+				initialTag:      "v1.0.0",
+				overrideMessage: "feat: add polish language",
+			},
+			expectedTag: "v1.1.0",
+		},
+		{
+			name: "autotag scheme, pending commit message",
+			setup: testRepoSetup{
+				scheme:          "autotag",
+				nextCommit:      "[minor] add a feature",
+				initialTag:      "v1.0.0",
+				overrideMessage: "[major] drop the old api",
+			},
+			expectedTag: "v2.0.0",
+		},
+		{
+			name: "conventional commits, perf outranks fix with bump rules",
+			setup: testRepoSetup{
+				scheme:     "conventional",
+				initialTag: "v1.0.0",
+				commitList: []string{"fix: correct typo", "perf: cache lookups", "fix: handle nil"},
+				bumpRules:  BumpRules{"perf": BumpMinor},
+			},
+			expectedTag: "v1.1.0",
+		},
+		{
+			name: "conventional commits, feat and perf with bump rules",
+			setup: testRepoSetup{
+				scheme:     "conventional",
+				initialTag: "v1.0.0",
+				commitList: []string{"perf: cache lookups", "feat: add polish language", "fix: correct typo"},
+				bumpRules:  BumpRules{"perf": BumpMinor, "fix": BumpPatch},
+			},
+			expectedTag: "v1.1.0",
+		},
+		{
+			name: "conventional commits, breaking perf with bump rules",
+			setup: testRepoSetup{
+				scheme:     "conventional",
+				initialTag: "v1.0.0",
+				commitList: []string{"fix: correct typo", "perf!: drop the cache", "feat: add polish language"},
+				bumpRules:  BumpRules{"perf": BumpMinor},
+			},
+			expectedTag: "v2.0.0",
+		},
+		{
+			name: "conventional commits, bump rule overrides feat",
+			setup: testRepoSetup{
+				scheme:     "conventional",
+				initialTag: "v1.0.0",
+				commitList: []string{"Feat: add polish language"},
+				bumpRules:  BumpRules{"FEAT": BumpPatch},
+			},
+			expectedTag: "v1.0.1",
+		},
+		{
+			name: "conventional commits, highest version is compared as semver",
+			setup: testRepoSetup{
+				scheme:     "conventional",
+				initialTag: "v1.9.0",
+				commitList: []string{"feat: add polish language", "fix: correct typo"},
+			},
+			expectedTag: "v1.10.0",
+		},
 		{
 			name: "conventional commits, minor bump with scope",
 			setup: testRepoSetup{
@@ -539,6 +1134,34 @@ func TestAutoTag(t *testing.T) {
 			},
 			expectedTag: "v1.1.0",
 		},
+		{
+			name: "conventional commits, capitalized type",
+			setup: testRepoSetup{
+				scheme:     "conventional",
+				nextCommit: "Feat: add polish language",
+				initialTag: "v1.0.0",
+			},
+			expectedTag: "v1.1.0",
+		},
+		{
+			name: "conventional commits, uppercase type",
+			setup: testRepoSetup{
+				scheme:     "conventional",
+				nextCommit: "FEAT(lang): add polish language",
+				initialTag: "v1.0.0",
+			},
+			expectedTag: "v1.1.0",
+		},
+		{
+			name: "conventional commits, strict type case",
+			setup: testRepoSetup{
+				scheme:         "conventional",
+				nextCommit:     "Feat: add polish language",
+				initialTag:     "v1.0.0",
+				strictTypeCase: true,
+			},
+			expectedTag: "v1.0.1",
+		},
 		{
 			name: "conventional commits, breaking change via ! appended to type",
 			setup: testRepoSetup{
@@ -632,38 +1255,1320 @@ func TestAutoTag(t *testing.T) {
 	}
 }
 
-func TestValidateSemVerBuildMetadata(t *testing.T) {
+// fakeTagger records the tags it is asked to create instead of writing them to a repo
+type fakeTagger struct {
+	created []fakeTag
+	deleted []string
+	err     error
+	// failOn makes creating only this tag fail with err
+	failOn string
+}
+
+type fakeTag struct {
+	name      string
+	target    string
+	message   string
+	annotated bool
+}
+
+func (f *fakeTagger) Create(name, target, message string, annotated bool) error {
+	if f.err != nil && (f.failOn == "" || f.failOn == name) {
+		return f.err
+	}
+	f.created = append(f.created, fakeTag{name: name, target: target, message: message, annotated: annotated})
+	return nil
+}
+
+func (f *fakeTagger) Delete(name string) error {
+	if f.err != nil && f.failOn == "" {
+		return f.err
+	}
+	f.deleted = append(f.deleted, name)
+	return nil
+}
+
+func TestAutoTagWithTagger(t *testing.T) {
+	tagger := &fakeTagger{}
+	r := newTestRepo(t, testRepoSetup{
+		nextCommit: "[minor] this is a smaller release",
+		initialTag: "v1.0.0",
+		tagger:     tagger,
+	})
+	defer cleanupTestRepo(t, r.repo)
+
+	err := r.AutoTag()
+	assert.NoError(t, err)
+
+	assert.Equal(t, 1, len(tagger.created))
+	assert.Equal(t, "v1.1.0", tagger.created[0].name)
+	assert.Equal(t, r.branchID, tagger.created[0].target)
+	assert.False(t, tagger.created[0].annotated)
+
+	// nothing is written to the repo when a custom tagger is used
+	tags, err := r.repo.Tags()
+	checkFatal(t, err)
+	assert.NotContains(t, tags, "v1.1.0")
+}
+
+func TestAutoTagTaggerError(t *testing.T) {
+	r := newTestRepo(t, testRepoSetup{
+		initialTag: "v1.0.0",
+		tagger:     &fakeTagger{err: fmt.Errorf("backend unavailable")},
+	})
+	defer cleanupTestRepo(t, r.repo)
+
+	err := r.AutoTag()
+	assert.Error(t, err)
+}
+
+func TestAutoTagSigned(t *testing.T) {
+	if _, err := exec.LookPath("ssh-keygen"); err != nil {
+		t.Skip("ssh-keygen not available")
+	}
+
+	key := filepath.Join(t.TempDir(), "signing_key")
+	out, err := exec.Command("ssh-keygen", "-q", "-t", "ed25519", "-N", "", "-f", key).CombinedOutput()
+	if err != nil {
+		t.Fatalf("ssh-keygen failed: %s: %s", err, out)
+	}
+
+	r := newTestRepo(t, testRepoSetup{
+		nextCommit: "[minor] signed release",
+		initialTag: "v1.0.0",
+		signTag:    true,
+		signingKey: key,
+	})
+	defer cleanupTestRepo(t, r.repo)
+
+	cmd := exec.Command("git", "config", "gpg.format", "ssh")
+	cmd.Dir = repoRoot(r.repo)
+	checkFatal(t, cmd.Run())
+
+	err = r.AutoTag()
+	assert.NoError(t, err)
+
+	cmd = exec.Command("git", "cat-file", "tag", "v1.1.0")
+	cmd.Dir = repoRoot(r.repo)
+	out, err = cmd.CombinedOutput()
+	checkFatal(t, err)
+	assert.Contains(t, string(out), "-----BEGIN SSH SIGNATURE-----")
+}
+
+func TestAutoTagSignedFailure(t *testing.T) {
+	r := newTestRepo(t, testRepoSetup{
+		nextCommit: "[minor] signed release",
+		initialTag: "v1.0.0",
+		signTag:    true,
+		signingKey: filepath.Join(t.TempDir(), "missing_key"),
+	})
+	defer cleanupTestRepo(t, r.repo)
+
+	cmd := exec.Command("git", "config", "gpg.format", "ssh")
+	cmd.Dir = repoRoot(r.repo)
+	checkFatal(t, cmd.Run())
+
+	err := r.AutoTag()
+	assert.Error(t, err)
+
+	// no unsigned tag is created as a fallback
+	tags, err := r.repo.Tags()
+	checkFatal(t, err)
+	assert.NotContains(t, tags, "v1.1.0")
+}
+
+func TestPreReleaseVersion(t *testing.T) {
+	v, err := version.NewVersion("1.2.3")
+	checkFatal(t, err)
+
 	tests := []struct {
-		name  string
-		meta  string
-		valid bool
+		name      string
+		preName   string
+		tsLayout  string
+		expected  string
+		shouldErr bool
 	}{
-		{
-			name:  "valid single-part metadata",
-			meta:  "g123456",
-			valid: true,
-		},
-		{
-			name:  "valid multi-part metadata",
-			meta:  "g123456.20200512",
-			valid: true,
-		},
-		{
-			name:  "invalid characters",
-			meta:  "g123456,foo_bar",
-			valid: false,
-		},
-		{
-			name:  "empty string",
-			meta:  "",
-			valid: false,
-		},
+		{name: "name only", preName: "beta", expected: "1.2.3-beta"},
+		{name: "dotted name", preName: "beta.3", expected: "1.2.3-beta.3"},
+		{name: "name and datetime", preName: "beta", tsLayout: datetimeTsLayout, expected: "1.2.3-beta.20190101000000"},
+		{name: "name and epoch", preName: "rc", tsLayout: "epoch", expected: fmt.Sprintf("1.2.3-rc.%d", timeNow().UTC().Unix())},
+		{name: "invalid identifier", preName: "beta_1", shouldErr: true},
+		{name: "leading zero numeric identifier", preName: "beta.03", shouldErr: true},
 	}
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			valid := validateSemVerBuildMetadata(tc.meta)
-			assert.Equal(t, tc.valid, valid)
-		})
+			pv, err := preReleaseVersion(v, tc.preName, tc.tsLayout)
+			if tc.shouldErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, pv.String())
+		})
+	}
+}
+
+func TestBuildMetadataVersion(t *testing.T) {
+	tests := []struct {
+		name      string
+		version   string
+		metadata  string
+		expected  string
+		errPart   string
+		shouldErr bool
+	}{
+		{name: "stable", version: "1.2.3", metadata: "g12345678", expected: "1.2.3+g12345678"},
+		{name: "pre-release", version: "1.2.3-rc.1", metadata: "g12345678.42", expected: "1.2.3-rc.1+g12345678.42"},
+		{name: "pre-release and timestamp", version: "1.2.3-beta.20190101000000", metadata: "g1", expected: "1.2.3-beta.20190101000000+g1"},
+		{name: "replaced metadata", version: "1.2.3-rc+a", metadata: "b", expected: "1.2.3-rc+b"},
+		{name: "invalid metadata", version: "1.2.3-rc.1", metadata: "build 42", errPart: "build metadata 'build 42'", shouldErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			v, err := buildMetadataVersion(version.Must(version.NewVersion(tc.version)), tc.metadata)
+			if tc.shouldErr {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tc.errPart)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, v.String())
+		})
+	}
+}
+
+func TestPreReleaseWithBuildMetadata(t *testing.T) {
+	r := newRepoFixture(t, GitRepoConfig{Prefix: true, PreReleaseName: "rc.1", PreReleaseTimestampLayout: "epoch", BuildMetadata: "g12345678"},
+		seedCommit("v1.0.0"),
+		testCommit{msg: "[minor] add login"},
+	)
+	assert.Equal(t, fmt.Sprintf("v1.1.0-rc.1.%d+g12345678", timeNow().UTC().Unix()), r.LatestVersion())
+	res := r.Result()
+	assert.True(t, res.PreRelease)
+	assert.Equal(t, "g12345678", res.BuildMetadata)
+}
+
+func TestPreReleaseIdentifierOrder(t *testing.T) {
+	v, err := version.NewVersion("1.2.3")
+	checkFatal(t, err)
+
+	beta2, err := preReleaseVersion(v, "beta.2", "")
+	checkFatal(t, err)
+	beta10, err := preReleaseVersion(v, "beta.10", "")
+	checkFatal(t, err)
+
+	// numeric identifiers are compared numerically, a run-on "beta10" would sort before "beta2"
+	assert.True(t, beta2.LessThan(beta10))
+	assert.True(t, beta10.LessThan(v))
+}
+
+func TestPreReleaseTypeName(t *testing.T) {
+	tests := []struct {
+		name     string
+		preName  string
+		typ      string
+		expected string
+	}{
+		{name: "without token", preName: "rc.1", typ: "feat", expected: "rc.1"},
+		{name: "type prefix", preName: "{type}.rc.1", typ: "feat", expected: "feat.rc.1"},
+		{name: "within an identifier", preName: "rc-{type}", typ: "fix", expected: "rc-fix"},
+		{name: "invalid characters", preName: "{type}.rc", typ: "ci_cd", expected: "ci-cd.rc"},
+		{name: "no type", preName: "{type}.rc", expected: "rc"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, preReleaseTypeName(tc.preName, tc.typ))
+		})
+	}
+}
+
+func TestPreReleaseType(t *testing.T) {
+	tests := []struct {
+		name        string
+		scheme      string
+		commit      string
+		expectedTag string
+	}{
+		{name: "feat", scheme: "conventional", commit: "feat: add login", expectedTag: "v1.1.0-feat.rc.1"},
+		{name: "fix", scheme: "conventional", commit: "fix: typo", expectedTag: "v1.0.1-fix.rc.1"},
+		{name: "uppercase type", scheme: "conventional", commit: "Fix: typo", expectedTag: "v1.0.1-fix.rc.1"},
+		{name: "breaking", scheme: "conventional", commit: "refactor!: drop v1 api", expectedTag: "v2.0.0-refactor.rc.1"},
+		{name: "breaking footer", scheme: "conventional", commit: "feat: new api\n\nBREAKING CHANGE: drop v1 api", expectedTag: "v2.0.0-feat.rc.1"},
+		{name: "no type with autotag scheme", scheme: "autotag", commit: "add login [minor]", expectedTag: "v1.1.0-rc.1"},
+		{name: "scope scheme", scheme: "scope-conventional", commit: "feat(api): add login", expectedTag: "api-v1.1.0-feat.rc.1"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := newRepoFixture(t, GitRepoConfig{Scheme: tc.scheme, Prefix: true, PreReleaseName: "{type}.rc.1"},
+				seedCommit("v1.0.0", "api-v1.0.0"),
+				testCommit{msg: tc.commit},
+			)
+			assert.Equal(t, tc.expectedTag, r.LatestVersion())
+		})
+	}
+}
+
+func TestValidateSemVerPreReleaseIdentifier(t *testing.T) {
+	for id, valid := range map[string]bool{
+		"beta":     true,
+		"0":        true,
+		"10":       true,
+		"0rc":      true,
+		"x-y":      true,
+		"01":       false,
+		"":         false,
+		"beta_1":   false,
+		"be.ta":    false,
+		"20240101": true,
+	} {
+		assert.Equal(t, valid, validateSemVerPreReleaseIdentifier(id), id)
+	}
+}
+
+func TestValidateSemVerBuildMetadata(t *testing.T) {
+	tests := []struct {
+		name  string
+		meta  string
+		valid bool
+	}{
+		{
+			name:  "valid single-part metadata",
+			meta:  "g123456",
+			valid: true,
+		},
+		{
+			name:  "valid multi-part metadata",
+			meta:  "g123456.20200512",
+			valid: true,
+		},
+		{
+			name:  "invalid characters",
+			meta:  "g123456,foo_bar",
+			valid: false,
+		},
+		{
+			name:  "empty string",
+			meta:  "",
+			valid: false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			valid := validateSemVerBuildMetadata(tc.meta)
+			assert.Equal(t, tc.valid, valid)
+		})
+	}
+}
+
+func TestParseBumpLevel(t *testing.T) {
+	for _, level := range []BumpLevel{BumpNone, BumpPatch, BumpMinor, BumpMajor} {
+		parsed, err := ParseBumpLevel(level.String())
+		assert.NoError(t, err)
+		assert.Equal(t, level, parsed)
+	}
+
+	_, err := ParseBumpLevel("huge")
+	assert.Error(t, err)
+}
+
+func TestWriteVersionFile(t *testing.T) {
+	tests := []struct {
+		name            string
+		setup           testRepoSetup
+		expectedPath    string
+		expectedContent string
+	}{
+		{
+			name: "version file",
+			setup: testRepoSetup{
+				scheme:      "conventional",
+				initialTag:  "v1.0.0",
+				nextCommit:  "feat: add polish language",
+				versionFile: "VERSION",
+			},
+			expectedPath:    "VERSION",
+			expectedContent: "1.1.0\n",
+		},
+		{
+			name: "version file with pre-release",
+			setup: testRepoSetup{
+				initialTag:     "v1.0.0",
+				nextCommit:     "[minor] add polish language",
+				preReleaseName: "rc",
+				versionFile:    "build/VERSION",
+			},
+			expectedPath:    "build/VERSION",
+			expectedContent: "1.1.0-rc\n",
+		},
+		{
+			name: "per scope version file",
+			setup: testRepoSetup{
+				scheme:      "scope-conventional",
+				initialTag:  "api-v1.0.0",
+				nextCommit:  "fix(api): correct typo",
+				versionFile: "services/{scope}/VERSION",
+			},
+			expectedPath:    "services/api/VERSION",
+			expectedContent: "1.0.1\n",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := newTestRepo(t, tc.setup)
+			defer cleanupTestRepo(t, r.repo)
+
+			// without tagging, eg: when only calculating the version
+			assert.NoError(t, r.WriteVersionFile())
+
+			content, err := os.ReadFile(filepath.Join(repoRoot(r.repo), tc.expectedPath))
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedContent, string(content))
+		})
+	}
+}
+
+func TestVersionRefNamespace(t *testing.T) {
+	tests := []struct {
+		name        string
+		scheme      string
+		initialRef  string
+		nextCommit  string
+		expectedRef string
+	}{
+		{
+			name:        "autotag scheme",
+			initialRef:  "v1.0.0",
+			nextCommit:  "[minor] add a feature",
+			expectedRef: "v1.1.0",
+		},
+		{
+			name:        "scope scheme",
+			scheme:      "scope-conventional",
+			initialRef:  "api-v1.0.0",
+			nextCommit:  "fix(api): correct typo",
+			expectedRef: "api-v1.0.1",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tr := createTestRepo(t, "")
+			repo, err := git.Open(tr)
+			checkFatal(t, err)
+			defer cleanupTestRepo(t, repo)
+
+			run := func(args ...string) string {
+				cmd := exec.Command("git", args...)
+				cmd.Dir = repoRoot(repo)
+				out, err := cmd.CombinedOutput()
+				if err != nil {
+					t.Fatalf("git %v failed: %s: %s", args, err, out)
+				}
+				return string(out)
+			}
+
+			makeCommit(repo, "this is a commit")
+			run("update-ref", "refs/autotag/"+tc.initialRef, "HEAD")
+			// a higher tag outside of the namespace is ignored
+			makeTag(repo, "v9.0.0")
+			makeTag(repo, "api-v9.0.0")
+			updateReadme(t, repo, tc.nextCommit)
+
+			r, err := NewRepo(GitRepoConfig{
+				RepoPath:            repo.Path(),
+				Branch:              "master",
+				Scheme:              tc.scheme,
+				Prefix:              true,
+				VersionRefNamespace: "refs/autotag/",
+			})
+			checkFatal(t, err)
+			assert.Equal(t, tc.expectedRef, r.LatestVersion())
+
+			assert.NoError(t, r.AutoTag())
+			assert.Equal(t, run("rev-parse", "HEAD"), run("rev-parse", "refs/autotag/"+tc.expectedRef))
+			assert.Equal(t, "api-v9.0.0\nv9.0.0\n", run("tag", "--list"))
+
+			// an existing version isn't overwritten
+			assert.Error(t, r.AutoTag())
+		})
+	}
+}
+
+func TestStableBranches(t *testing.T) {
+	tests := []struct {
+		name           string
+		branch         string
+		preReleaseName string
+		shouldErr      bool
+		expectedTag    string
+	}{
+		{
+			name:        "stable branch",
+			branch:      "main",
+			expectedTag: "v1.1.0",
+		},
+		{
+			name:        "stable branch pattern",
+			branch:      "release/1.x",
+			expectedTag: "v1.1.0",
+		},
+		{
+			name:           "feature branch is forced into a pre-release",
+			branch:         "feature/login",
+			preReleaseName: "login",
+			expectedTag:    "v1.1.0-login",
+		},
+		{
+			name:      "feature branch without pre-release config",
+			branch:    "feature/login",
+			shouldErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tr := createTestRepo(t, tc.branch)
+			repo, err := git.Open(tr)
+			checkFatal(t, err)
+			defer cleanupTestRepo(t, repo)
+
+			seedTestRepo(t, "v1.0.0", repo)
+			updateReadme(t, repo, "[minor] add login")
+
+			r, err := NewRepo(GitRepoConfig{
+				RepoPath:       repo.Path(),
+				Branch:         tc.branch,
+				Prefix:         true,
+				PreReleaseName: tc.preReleaseName,
+				StableBranches: []string{"main", "release/*"},
+			})
+			if tc.shouldErr {
+				assert.Error(t, err)
+				return
+			}
+			checkFatal(t, err)
+			assert.Equal(t, tc.expectedTag, r.LatestVersion())
+		})
+	}
+}
+
+func TestBranchBumpCap(t *testing.T) {
+	caps := map[string]BumpLevel{"release/*": BumpPatch, "release/next": BumpMinor, "next": BumpMinor}
+
+	tests := []struct {
+		name        string
+		branch      string
+		scheme      string
+		commit      string
+		bump        BumpLevel
+		expectedTag string
+	}{
+		{
+			name:        "feat on a capped release branch",
+			branch:      "release/1.x",
+			commit:      "feat: add login",
+			expectedTag: "v1.0.1",
+		},
+		{
+			name:        "breaking change capped to minor",
+			branch:      "next",
+			commit:      "feat!: drop the v1 api",
+			expectedTag: "v1.1.0",
+		},
+		{
+			name:        "lowest cap of the matching patterns",
+			branch:      "release/next",
+			commit:      "feat: add login",
+			expectedTag: "v1.0.1",
+		},
+		{
+			name:        "bump below the cap",
+			branch:      "next",
+			commit:      "fix: correct typo",
+			expectedTag: "v1.0.1",
+		},
+		{
+			name:        "forced bump",
+			branch:      "release/1.x",
+			commit:      "fix: correct typo",
+			bump:        BumpMajor,
+			expectedTag: "v1.0.1",
+		},
+		{
+			name:        "uncapped branch",
+			branch:      "main",
+			commit:      "feat: add login",
+			expectedTag: "v1.1.0",
+		},
+		{
+			name:        "scope",
+			branch:      "release/1.x",
+			scheme:      "scope-conventional",
+			commit:      "feat(api): add login",
+			expectedTag: "api-v1.0.1",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tr := createTestRepo(t, tc.branch)
+			repo, err := git.Open(tr)
+			checkFatal(t, err)
+			defer cleanupTestRepo(t, repo)
+
+			seedTestRepo(t, "v1.0.0", repo)
+			makeTag(repo, "api-v1.0.0")
+			updateReadme(t, repo, tc.commit)
+
+			scheme := tc.scheme
+			if scheme == "" {
+				scheme = "conventional"
+			}
+			r, err := NewRepo(GitRepoConfig{
+				RepoPath:      repo.Path(),
+				Branch:        tc.branch,
+				Scheme:        scheme,
+				Prefix:        true,
+				Bump:          tc.bump,
+				BranchBumpCap: caps,
+			})
+			checkFatal(t, err)
+			assert.Equal(t, tc.expectedTag, r.Result().Tag)
+		})
+	}
+}
+
+func TestBranchBumpCapReason(t *testing.T) {
+	r := newRepoFixture(t, GitRepoConfig{Scheme: "conventional", BranchBumpCap: map[string]BumpLevel{"master": BumpPatch}},
+		seedCommit("v1.0.0"),
+		testCommit{msg: "feat: add login"},
+	)
+	assert.Equal(t, "1.0.1", r.Result().Next.String())
+	assert.True(t, strings.HasSuffix(r.Result().BumpReason, ", capped to patch on branch 'master'"), r.Result().BumpReason)
+}
+
+func TestForcedBump(t *testing.T) {
+	tests := []struct {
+		name        string
+		commit      string
+		bump        BumpLevel
+		expectedTag string
+	}{
+		{
+			name:        "major despite a patch commit",
+			commit:      "[patch] fix typo",
+			bump:        BumpMajor,
+			expectedTag: "v2.0.0",
+		},
+		{
+			name:        "patch despite a major commit",
+			commit:      "[major] drop v1 api",
+			bump:        BumpPatch,
+			expectedTag: "v1.0.1",
+		},
+		{
+			name:        "commits decide without a forced bump",
+			commit:      "[minor] add login",
+			expectedTag: "v1.1.0",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tr := createTestRepo(t, "")
+			repo, err := git.Open(tr)
+			checkFatal(t, err)
+			defer cleanupTestRepo(t, repo)
+
+			seedTestRepo(t, "v1.0.0", repo)
+			updateReadme(t, repo, tc.commit)
+
+			r, err := NewRepo(GitRepoConfig{
+				RepoPath: repo.Path(),
+				Branch:   "master",
+				Prefix:   true,
+				Bump:     tc.bump,
+			})
+			checkFatal(t, err)
+			assert.Equal(t, tc.expectedTag, r.LatestVersion())
+		})
 	}
 }
+
+func TestResult(t *testing.T) {
+	tests := []struct {
+		name           string
+		preReleaseName string
+		buildMetadata  string
+		expected       Result
+	}{
+		{
+			name:     "stable",
+			expected: Result{Tag: "v1.1.0"},
+		},
+		{
+			name:           "pre-release",
+			preReleaseName: "beta",
+			expected:       Result{Tag: "v1.1.0-beta", PreRelease: true},
+		},
+		{
+			name:          "build metadata",
+			buildMetadata: "g12345678",
+			expected:      Result{Tag: "v1.1.0+g12345678", BuildMetadata: "g12345678"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tr := createTestRepo(t, "")
+			repo, err := git.Open(tr)
+			checkFatal(t, err)
+			defer cleanupTestRepo(t, repo)
+
+			seedTestRepo(t, "v1.0.0", repo)
+			updateReadme(t, repo, "[minor] add login")
+
+			r, err := NewRepo(GitRepoConfig{
+				RepoPath:       repo.Path(),
+				Branch:         "master",
+				Prefix:         true,
+				PreReleaseName: tc.preReleaseName,
+				BuildMetadata:  tc.buildMetadata,
+			})
+			checkFatal(t, err)
+
+			res := r.Result()
+			assert.Equal(t, "", res.Scope)
+			assert.Equal(t, "1.0.0", res.Current.String())
+			assert.Equal(t, tc.expected.Tag, "v"+res.Next.String())
+			assert.Equal(t, tc.expected.Tag, res.Tag)
+			assert.Equal(t, tc.expected.PreRelease, res.PreRelease)
+			assert.Equal(t, tc.expected.BuildMetadata, res.BuildMetadata)
+		})
+	}
+}
+
+func TestResultTargetCommit(t *testing.T) {
+	dir := newRepoDir(t,
+		seedCommit("v1.0.0"),
+		testCommit{msg: "fix(api): correct typo", tags: []string{"api-v1.0.0", "pipeline-123"}},
+		testCommit{msg: "feat(api): add login"},
+	)
+
+	cfg := GitRepoConfig{RepoPath: dir, Branch: "master", Scheme: "conventional"}
+	r, err := NewRepo(cfg)
+	checkFatal(t, err)
+	res := r.Result()
+	assert.Equal(t, runGit(t, dir, "rev-parse", "HEAD"), res.TargetCommit.ID.String())
+	assert.Equal(t, "feat(api): add login", strings.TrimSpace(res.TargetCommit.Message))
+
+	cfg.Rev = "pipeline-123"
+	r, err = NewRepo(cfg)
+	checkFatal(t, err)
+	assert.Equal(t, runGit(t, dir, "rev-parse", "pipeline-123"), r.Result().TargetCommit.ID.String())
+
+	// the results of a preview
+	r, err = NewRepo(GitRepoConfig{RepoPath: dir, Branch: "master", Scheme: "scope-conventional", AllScopes: true})
+	checkFatal(t, err)
+	results, err := r.Preview()
+	checkFatal(t, err)
+	var released int
+	for _, res := range results {
+		if res.Next == nil {
+			assert.Nil(t, res.TargetCommit, res.Scope)
+			continue
+		}
+		released++
+		assert.Equal(t, runGit(t, dir, "rev-parse", "HEAD"), res.TargetCommit.ID.String(), res.Scope)
+	}
+	assert.Equal(t, 1, released)
+}
+
+func TestBumpFromBody(t *testing.T) {
+	// bumpFromChecklist reads the checked "Type of change" box of a PR body
+	bumpFromChecklist := func(body string) (BumpLevel, bool) {
+		switch {
+		case strings.Contains(body, "- [x] Breaking change"):
+			return BumpMajor, true
+		case strings.Contains(body, "- [x] New feature"):
+			return BumpMinor, true
+		case strings.Contains(body, "- [x] Bug fix"):
+			return BumpPatch, true
+		}
+		return BumpNone, false
+	}
+
+	tests := []struct {
+		name        string
+		scheme      string
+		initialTag  string
+		commit      string
+		expectedTag string
+	}{
+		{
+			name:        "conventional checklist overrides the header",
+			scheme:      "conventional",
+			initialTag:  "v1.0.0",
+			commit:      "fix: add login (#12)\n\n- [ ] Bug fix\n- [x] New feature\n- [ ] Breaking change",
+			expectedTag: "v1.1.0",
+		},
+		{
+			name:        "conventional without checked box uses the header",
+			scheme:      "conventional",
+			initialTag:  "v1.0.0",
+			commit:      "feat: add login (#12)\n\n- [ ] Bug fix\n- [ ] New feature",
+			expectedTag: "v1.1.0",
+		},
+		{
+			name:        "autotag checklist overrides the header",
+			scheme:      "autotag",
+			initialTag:  "v1.0.0",
+			commit:      "[minor] drop v1 api (#13)\n\n- [x] Breaking change",
+			expectedTag: "v2.0.0",
+		},
+		{
+			name:        "header only message",
+			scheme:      "autotag",
+			initialTag:  "v1.0.0",
+			commit:      "[minor] add login - [x] Breaking change",
+			expectedTag: "v1.1.0",
+		},
+		{
+			name:        "scope checklist overrides the header",
+			scheme:      "scope-conventional",
+			initialTag:  "api-v1.0.0",
+			commit:      "fix(api): drop v1 api (#14)\n\n- [x] Breaking change",
+			expectedTag: "api-v2.0.0",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tr := createTestRepo(t, "")
+			repo, err := git.Open(tr)
+			checkFatal(t, err)
+			defer cleanupTestRepo(t, repo)
+
+			seedTestRepo(t, tc.initialTag, repo)
+			updateReadme(t, repo, tc.commit)
+
+			r, err := NewRepo(GitRepoConfig{
+				RepoPath:     repo.Path(),
+				Branch:       "master",
+				Scheme:       tc.scheme,
+				Prefix:       true,
+				BumpFromBody: bumpFromChecklist,
+			})
+			checkFatal(t, err)
+			assert.Equal(t, tc.expectedTag, r.LatestVersion())
+		})
+	}
+}
+
+func TestBuildMetadataFromEnv(t *testing.T) {
+	tests := []struct {
+		name          string
+		buildMetadata string
+		build         string
+		shouldErr     bool
+		expectedTag   string
+	}{
+		{
+			name:        "build number",
+			build:       "42",
+			expectedTag: "v1.1.0+42",
+		},
+		{
+			name:          "combined with sha metadata",
+			buildMetadata: "g12345678",
+			build:         "42",
+			expectedTag:   "v1.1.0+g12345678.42",
+		},
+		{
+			name:      "variable not set",
+			shouldErr: true,
+		},
+		{
+			name:      "invalid build metadata",
+			build:     "build 42",
+			shouldErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tr := createTestRepo(t, "")
+			repo, err := git.Open(tr)
+			checkFatal(t, err)
+			defer cleanupTestRepo(t, repo)
+
+			seedTestRepo(t, "v1.0.0", repo)
+			updateReadme(t, repo, "[minor] add login")
+
+			t.Setenv("AUTOTAG_TEST_BUILD_NUMBER", tc.build)
+			r, err := NewRepo(GitRepoConfig{
+				RepoPath:             repo.Path(),
+				Branch:               "master",
+				Prefix:               true,
+				BuildMetadata:        tc.buildMetadata,
+				BuildMetadataFromEnv: "AUTOTAG_TEST_BUILD_NUMBER",
+			})
+			if tc.shouldErr {
+				assert.Error(t, err)
+				return
+			}
+			checkFatal(t, err)
+			assert.Equal(t, tc.expectedTag, r.LatestVersion())
+		})
+	}
+}
+
+func TestBumpReason(t *testing.T) {
+	tests := []struct {
+		name            string
+		scheme          string
+		initialTag      string
+		commits         []string
+		overrideMessage string
+		bump            BumpLevel
+		deciding        int
+		expectedReason  string
+	}{
+		{
+			name:           "autotag keyword",
+			initialTag:     "v1.0.0",
+			commits:        []string{"[patch] fix typo", "[minor] add login", "[minor] add logout"},
+			deciding:       2,
+			expectedReason: "minor because commit %s has the [minor] keyword",
+		},
+		{
+			name:           "breaking change footer",
+			scheme:         "conventional",
+			initialTag:     "v1.0.0",
+			commits:        []string{"feat: add login", "fix: new config\n\nBREAKING CHANGE: config format"},
+			deciding:       1,
+			expectedReason: "major because commit %s has a BREAKING CHANGE footer",
+		},
+		{
+			name:           "type",
+			scheme:         "conventional",
+			initialTag:     "v1.0.0",
+			commits:        []string{"docs: readme", "feat: add login"},
+			deciding:       1,
+			expectedReason: "minor because commit %s has type 'feat'",
+		},
+		{
+			name:            "pending commit",
+			scheme:          "conventional",
+			initialTag:      "v1.0.0",
+			commits:         []string{"fix: typo"},
+			overrideMessage: "feat!: drop v1 api",
+			deciding:        -1,
+			expectedReason:  "major because the pending commit has a breaking change marker (!)",
+		},
+		{
+			name:           "no bump",
+			initialTag:     "v1.0.0",
+			commits:        []string{"fix typo"},
+			deciding:       -1,
+			expectedReason: "patch because no commit asks for a bump",
+		},
+		{
+			name:           "forced",
+			initialTag:     "v1.0.0",
+			commits:        []string{"[patch] fix typo"},
+			bump:           BumpMajor,
+			deciding:       -1,
+			expectedReason: "major because the bump is forced",
+		},
+		{
+			name:           "scope",
+			scheme:         "scope-conventional",
+			initialTag:     "api-v1.0.0",
+			commits:        []string{"feat(api)!: drop v1 api"},
+			deciding:       0,
+			expectedReason: "major because commit %s has a breaking change marker (!)",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tr := createTestRepo(t, "")
+			repo, err := git.Open(tr)
+			checkFatal(t, err)
+			defer cleanupTestRepo(t, repo)
+
+			seedTestRepo(t, tc.initialTag, repo)
+			var ids []string
+			for _, c := range tc.commits {
+				updateReadme(t, repo, c)
+				id, err := repo.BranchCommitID("master")
+				checkFatal(t, err)
+				ids = append(ids, id)
+			}
+
+			r, err := NewRepo(GitRepoConfig{
+				RepoPath:        repo.Path(),
+				Branch:          "master",
+				Scheme:          tc.scheme,
+				Prefix:          true,
+				OverrideMessage: tc.overrideMessage,
+				Bump:            tc.bump,
+			})
+			checkFatal(t, err)
+
+			res := r.Result()
+			if tc.deciding < 0 {
+				assert.Equal(t, "", res.DecidingCommit)
+				assert.Equal(t, tc.expectedReason, res.BumpReason)
+				return
+			}
+			assert.Equal(t, ids[tc.deciding], res.DecidingCommit)
+			assert.Equal(t, fmt.Sprintf(tc.expectedReason, ids[tc.deciding][:7]), res.BumpReason)
+		})
+	}
+}
+
+func TestScanBodyHeaders(t *testing.T) {
+	squash := "fix: login fixes (#42)\n\n* fix: correct typo\n* feat: remember me\n\nCo-authored-by: Jane <jane@example.com>"
+	tests := []struct {
+		name            string
+		scanBodyHeaders bool
+		expectedTag     string
+	}{
+		{
+			name:        "only the first header by default",
+			expectedTag: "v1.0.1",
+		},
+		{
+			name:            "highest bump of all headers",
+			scanBodyHeaders: true,
+			expectedTag:     "v1.1.0",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := newRepoFixture(t, GitRepoConfig{Scheme: "conventional", Prefix: true, ScanBodyHeaders: tc.scanBodyHeaders},
+				seedCommit("v1.0.0"),
+				testCommit{msg: squash},
+			)
+			assert.Equal(t, tc.expectedTag, r.LatestVersion())
+		})
+	}
+}
+
+func TestBumpReasonTieBreak(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	tests := []struct {
+		name     string
+		scheme   string
+		tag      string
+		commits  []string
+		dates    []time.Time
+		deciding int
+	}{
+		{
+			name:     "most recent commit",
+			tag:      "v1.0.0",
+			commits:  []string{"[minor] add login", "[minor] add logout"},
+			dates:    []time.Time{now.Add(-2 * time.Hour), now.Add(-time.Hour)},
+			deciding: 1,
+		},
+		{
+			name:     "most recent by committer date, not history",
+			tag:      "v1.0.0",
+			commits:  []string{"[minor] add login", "[minor] add logout"},
+			dates:    []time.Time{now.Add(-time.Hour), now.Add(-2 * time.Hour)},
+			deciding: 0,
+		},
+		{
+			name:     "later in history on the same date",
+			tag:      "v1.0.0",
+			commits:  []string{"[minor] add login", "[minor] add logout"},
+			dates:    []time.Time{now, now},
+			deciding: 1,
+		},
+		{
+			name:     "higher bump wins over the date",
+			tag:      "v1.0.0",
+			commits:  []string{"[major] drop v1", "[minor] add logout"},
+			dates:    []time.Time{now.Add(-2 * time.Hour), now.Add(-time.Hour)},
+			deciding: 0,
+		},
+		{
+			name:     "scope scheme",
+			scheme:   "scope-conventional",
+			tag:      "api-v1.0.0",
+			commits:  []string{"feat(api): add login", "feat(api): add logout"},
+			dates:    []time.Time{now.Add(-time.Hour), now.Add(-2 * time.Hour)},
+			deciding: 0,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tr := createTestRepo(t, "")
+			repo, err := git.Open(tr)
+			checkFatal(t, err)
+			defer cleanupTestRepo(t, repo)
+
+			seedTestRepo(t, tc.tag, repo)
+			var ids []string
+			for i, c := range tc.commits {
+				makeCommitAt(t, repo, c, tc.dates[i])
+				id, err := repo.BranchCommitID("master")
+				checkFatal(t, err)
+				ids = append(ids, id)
+			}
+
+			r, err := NewRepo(GitRepoConfig{
+				RepoPath:  repo.Path(),
+				Branch:    "master",
+				Scheme:    tc.scheme,
+				Prefix:    true,
+				AllScopes: tc.scheme == "scope-conventional",
+			})
+			checkFatal(t, err)
+
+			var res Result
+			if tc.scheme == "scope-conventional" {
+				results, err := r.Preview()
+				checkFatal(t, err)
+				res = results[0]
+			} else {
+				res = r.Result()
+			}
+			assert.Equal(t, ids[tc.deciding], res.DecidingCommit)
+		})
+	}
+}
+
+func TestSchemeAliases(t *testing.T) {
+	for _, scheme := range []string{"scope", "module-conventional"} {
+		t.Run(scheme, func(t *testing.T) {
+			r := newRepoFixture(t, GitRepoConfig{Scheme: scheme, Prefix: true},
+				seedCommit("v1.0.0", "api-v1.0.0"),
+				testCommit{msg: "feat(api): add login"},
+			)
+			assert.Equal(t, "scope-conventional", r.scheme)
+			assert.Equal(t, "api-v1.1.0", r.LatestVersion())
+		})
+	}
+}
+
+func TestPreReleaseOrder(t *testing.T) {
+	order := []string{"alpha", "beta", "rc"}
+	tests := []struct {
+		name            string
+		tags            []string
+		order           []string
+		expectedCurrent string
+	}{
+		{
+			name:            "rc after beta",
+			tags:            []string{"v1.0.0-beta.5", "v1.0.0-rc.1"},
+			order:           order,
+			expectedCurrent: "1.0.0-rc.1",
+		},
+		{
+			name:            "channels are case insensitive",
+			tags:            []string{"v1.0.0-beta.5", "v1.0.0-RC.1"},
+			order:           order,
+			expectedCurrent: "1.0.0-RC.1",
+		},
+		{
+			name:            "semver order by default",
+			tags:            []string{"v1.0.0-beta.5", "v1.0.0-RC.1"},
+			expectedCurrent: "1.0.0-beta.5",
+		},
+		{
+			name:            "custom channel order",
+			tags:            []string{"v1.0.0-pre.3", "v1.0.0-alpha.1"},
+			order:           []string{"pre", "alpha"},
+			expectedCurrent: "1.0.0-alpha.1",
+		},
+		{
+			name:            "unlisted channels rank lowest",
+			tags:            []string{"v1.0.0-dev.9", "v1.0.0-alpha.1"},
+			order:           order,
+			expectedCurrent: "1.0.0-alpha.1",
+		},
+		{
+			name:            "same channel",
+			tags:            []string{"v1.0.0-beta.10", "v1.0.0-beta.5", "v1.0.0-alpha.3"},
+			order:           order,
+			expectedCurrent: "1.0.0-beta.10",
+		},
+		{
+			name:            "higher core version",
+			tags:            []string{"v1.1.0-alpha.1", "v1.0.0-rc.9"},
+			order:           order,
+			expectedCurrent: "1.1.0-alpha.1",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := GitRepoConfig{Scheme: "conventional", Prefix: true, BaseOnPreRelease: true, PreReleaseOrder: tc.order}
+			r := newRepoFixture(t, cfg,
+				seedCommit(tc.tags...),
+				testCommit{msg: "fix: correct typo"},
+			)
+			assert.Equal(t, tc.expectedCurrent, r.Result().Current.Original())
+		})
+	}
+}
+
+func TestGraduate(t *testing.T) {
+	tests := []struct {
+		name           string
+		cfg            GitRepoConfig
+		tag            string
+		msg            string
+		expectedTag    string
+		expectedReason string
+	}{
+		{
+			name:        "not graduating",
+			cfg:         GitRepoConfig{Scheme: "conventional"},
+			tag:         "v0.7.3",
+			msg:         "fix: correct typo",
+			expectedTag: "v0.7.4",
+		},
+		{
+			name:           "graduate option",
+			cfg:            GitRepoConfig{Scheme: "conventional", Graduate: true},
+			tag:            "v0.7.3",
+			msg:            "fix: correct typo",
+			expectedTag:    "v1.0.0",
+			expectedReason: "major because the version graduates to 1.0.0",
+		},
+		{
+			name:        "graduate footer",
+			cfg:         GitRepoConfig{Scheme: "conventional"},
+			tag:         "v0.7.3",
+			msg:         "feat: 1.0 release\n\nGraduate: true",
+			expectedTag: "v1.0.0",
+		},
+		{
+			name:        "graduate footer of the scope",
+			cfg:         GitRepoConfig{Scheme: "scope-conventional"},
+			tag:         "api-v0.7.3",
+			msg:         "feat(api)!: 1.0 release\n\nGraduate: true",
+			expectedTag: "api-1.0.0",
+		},
+		{
+			name:        "graduate option with the autotag scheme",
+			cfg:         GitRepoConfig{Graduate: true},
+			tag:         "v0.7.3",
+			msg:         "[minor] add login",
+			expectedTag: "v1.0.0",
+		},
+		{
+			name:        "graduate to a pre-release",
+			cfg:         GitRepoConfig{Scheme: "conventional", Graduate: true, PreReleaseName: "rc"},
+			tag:         "v0.7.3",
+			msg:         "fix: correct typo",
+			expectedTag: "v1.0.0-rc",
+		},
+		{
+			name:        "forced bump",
+			cfg:         GitRepoConfig{Scheme: "conventional", Graduate: true, Bump: BumpPatch},
+			tag:         "v0.7.3",
+			msg:         "fix: correct typo",
+			expectedTag: "v1.0.0",
+		},
+		{
+			name:        "stable versions bump as usual",
+			cfg:         GitRepoConfig{Scheme: "conventional", Graduate: true},
+			tag:         "v1.2.3",
+			msg:         "fix: correct typo\n\nGraduate: true",
+			expectedTag: "v1.2.4",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.cfg.Prefix = tc.cfg.Scheme != "scope-conventional"
+			r := newRepoFixture(t, tc.cfg,
+				seedCommit(tc.tag),
+				testCommit{msg: tc.msg},
+			)
+			res := r.Result()
+			assert.Equal(t, tc.expectedTag, res.Tag)
+			if tc.expectedReason != "" {
+				assert.Equal(t, tc.expectedReason, res.BumpReason)
+			}
+		})
+	}
+}
+
+func TestGraduateScopes(t *testing.T) {
+	r := newRepoFixture(t, GitRepoConfig{Scheme: "scope-conventional", AllScopes: true},
+		seedCommit("api-v0.7.3", "web-v0.2.0"),
+		testCommit{msg: "feat(api): stable API\n\nGraduate: true", files: []string{"api/main.go"}},
+		testCommit{msg: "fix(api): correct typo", files: []string{"api/util.go"}},
+		testCommit{msg: "fix(web): correct typo", files: []string{"web/main.go"}},
+	)
+
+	versions, err := r.NextScopeVersions()
+	checkFatal(t, err)
+	assert.Equal(t, "1.0.0", versions["api"].String())
+	assert.Equal(t, "0.2.1", versions["web"].String())
+
+	next, err := r.NextScopeVersion("api")
+	checkFatal(t, err)
+	assert.Equal(t, "1.0.0", next.String())
+}
+
+func TestRevFromPipelineTag(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         GitRepoConfig
+		expectedTag string
+	}{
+		{
+			name:        "head of the branch",
+			cfg:         GitRepoConfig{Scheme: "conventional"},
+			expectedTag: "v1.1.0",
+		},
+		{
+			name:        "lightweight tag",
+			cfg:         GitRepoConfig{Scheme: "conventional", Rev: "pipeline-123"},
+			expectedTag: "v1.0.1",
+		},
+		{
+			name:        "scope",
+			cfg:         GitRepoConfig{Scheme: "scope-conventional", Rev: "pipeline-123"},
+			expectedTag: "api-v1.0.1",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tags := []string{"v1.0.0"}
+			if tc.cfg.Scheme == "scope-conventional" {
+				tags = []string{"api-v1.0.0"}
+			}
+			tc.cfg.Prefix = true
+			r := newRepoFixture(t, tc.cfg,
+				seedCommit(tags...),
+				testCommit{msg: "fix(api): correct typo", tags: []string{"pipeline-123"}},
+				testCommit{msg: "feat(api): add login"},
+			)
+			assert.Equal(t, tc.expectedTag, r.LatestVersion())
+		})
+	}
+}
+
+func TestRevNotFound(t *testing.T) {
+	dir := newRepoDir(t, seedCommit("v1.0.0"))
+	_, err := NewRepo(GitRepoConfig{RepoPath: dir, Branch: "master", Scheme: "conventional", Rev: "pipeline-404"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,30 @@
+package autotag
+
+import (
+	"fmt"
+
+	"github.com/gogs/git-module"
+	"github.com/hashicorp/go-version"
+)
+
+// countCommitsSinceBase returns the number of commits of the branch since the base version of
+// scope, tagged on baseTag, and warns if they are more than CommitsSinceBaseWarning. It returns 0
+// without counting if the warning isn't set.
+func (r *GitRepo) countCommitsSinceBase(scope string, base *version.Version, baseTag *git.Commit) (int, error) {
+	if r.commitsSinceBaseWarning <= 0 {
+		return 0, nil
+	}
+
+	n, err := r.repo.RevListCount(r.historyRange(baseTag, r.branchID))
+	if err != nil {
+		return 0, fmt.Errorf("error counting the commits since %s: %s", base, err)
+	}
+	if int(n) > r.commitsSinceBaseWarning {
+		name := base.String()
+		if scope != "" {
+			name = fmt.Sprintf("%s of scope %s", base, scope)
+		}
+		r.warnf("%d commits since the base version %s, more than %d: is a release missing or the scope misconfigured?", n, name, r.commitsSinceBaseWarning)
+	}
+	return int(n), nil
+}
@@ -0,0 +1,79 @@
+package autotag
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/assert"
+)
+
+func TestCommitsSinceBase(t *testing.T) {
+	tests := []struct {
+		name     string
+		warning  int
+		expected int
+		warned   bool
+	}{
+		{name: "disabled"},
+		{name: "below the threshold", warning: 3, expected: 3},
+		{name: "above the threshold", warning: 2, expected: 3, warned: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			log.SetOutput(&buf)
+			defer log.SetOutput(os.Stderr)
+
+			r := newRepoFixture(t, GitRepoConfig{Scheme: "conventional", Prefix: true, CommitsSinceBaseWarning: tc.warning},
+				seedCommit("v1.0.0"),
+				testCommit{msg: "docs: fix typo"},
+				testCommit{msg: "chore: update dependencies"},
+				testCommit{msg: "feat: add login"},
+			)
+			assert.Equal(t, tc.expected, r.Result().CommitsSinceBase)
+			assert.Equal(t, tc.warned, strings.Contains(buf.String(), "warning: 3 commits since the base version 1.0.0"), buf.String())
+		})
+	}
+}
+
+func TestCommitsSinceBaseOnWarning(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	// the CLI passes the warnings to stderr, the logger is discarded without -v
+	var warnings []string
+	newRepoFixture(t, GitRepoConfig{
+		Scheme:                  "conventional",
+		CommitsSinceBaseWarning: 1,
+		Verbosity:               VerbosityQuiet,
+		OnWarning:               func(msg string) { warnings = append(warnings, msg) },
+	},
+		seedCommit("v1.0.0"),
+		testCommit{msg: "docs: fix typo"},
+		testCommit{msg: "feat: add login"},
+	)
+	assert.Equal(t, []string{"2 commits since the base version 1.0.0, more than 1: is a release missing or the scope misconfigured?"}, warnings)
+	assert.Equal(t, "", buf.String())
+}
+
+func TestCommitsSinceBaseScopes(t *testing.T) {
+	r := newRepoFixture(t, GitRepoConfig{Scheme: "scope-conventional", Prefix: true, AllScopes: true, CommitsSinceBaseWarning: 10},
+		seedCommit("api-v1.0.0"),
+		testCommit{msg: "feat(web): add page", tags: []string{"web-v1.0.0"}},
+		testCommit{msg: "feat(api): add login"},
+		testCommit{msg: "fix(web): handle nil"},
+	)
+
+	preview, err := r.Preview()
+	checkFatal(t, err)
+	commits := make(map[string]int)
+	for _, res := range preview {
+		commits[res.Scope] = res.CommitsSinceBase
+	}
+	assert.Equal(t, map[string]int{"api": 3, "web": 2}, commits)
+}
@@ -10,6 +10,10 @@ type bumper interface {
 	bump(*version.Version) (*version.Version, error)
 }
 
+// BumpFunc computes the next version from the current version. It allows the default SemVer
+// arithmetic of a bump level to be replaced, eg: for calendar versioning schemes.
+type BumpFunc func(*version.Version) (*version.Version, error)
+
 type major struct{}
 type minor struct{}
 type patch struct{}
@@ -64,3 +68,70 @@ func (m patch) bump(cv *version.Version) (*version.Version, error) {
 	}
 	return version.NewVersion(vString)
 }
+
+// BumpLevel is the part of the version bumped by a commit. Levels are ordered, a higher level is a
+// bigger bump.
+type BumpLevel int
+
+const (
+	// BumpNone doesn't bump the version
+	BumpNone BumpLevel = iota
+	// BumpPatch bumps the patch version 1.1.1 -> 1.1.2
+	BumpPatch
+	// BumpMinor bumps the minor version 1.1.1 -> 1.2.0
+	BumpMinor
+	// BumpMajor bumps the major version 1.1.1 -> 2.0.0
+	BumpMajor
+)
+
+var bumpLevelNames = map[BumpLevel]string{
+	BumpNone:  "none",
+	BumpPatch: "patch",
+	BumpMinor: "minor",
+	BumpMajor: "major",
+}
+
+func (l BumpLevel) String() string {
+	if name, ok := bumpLevelNames[l]; ok {
+		return name
+	}
+	return fmt.Sprintf("BumpLevel(%d)", int(l))
+}
+
+// ParseBumpLevel parses the name of a bump level: none, patch, minor or major
+func ParseBumpLevel(name string) (BumpLevel, error) {
+	for level, n := range bumpLevelNames {
+		if n == name {
+			return level, nil
+		}
+	}
+	return BumpNone, fmt.Errorf("bump level '%s' is not valid; must be (none|patch|minor|major)", name)
+}
+
+// BumpRules maps conventional commit types to the level they bump, eg: `perf` to BumpMinor. Types
+// without a rule bump the patch version, breaking changes always bump the major version.
+type BumpRules map[string]BumpLevel
+
+// defaultBumpRules are the conventional commit rules: `feat` is a minor bump
+var defaultBumpRules = BumpRules{"feat": BumpMinor}
+
+// level returns the bump level of the commit type typ
+func (rules BumpRules) level(typ string) BumpLevel {
+	if level, ok := rules[typ]; ok {
+		return level
+	}
+	return BumpPatch
+}
+
+// bumper returns the bumper of the level, nil for BumpNone
+func (l BumpLevel) bumper() bumper {
+	switch l {
+	case BumpMajor:
+		return majorBumper
+	case BumpMinor:
+		return minorBumper
+	case BumpPatch:
+		return patchBumper
+	}
+	return nil
+}
@@ -0,0 +1,344 @@
+package autotag
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/gogs/git-module"
+)
+
+// ConventionalChangelog is the release of a scope in the JSON shape of the conventional-changelog
+// tools: the commits of the release grouped by type and the notes, eg: the breaking changes,
+// grouped by title. The JSON schema is:
+//
+//	{
+//	  "version": "1.1.0",
+//	  "scope": "api",
+//	  "commitGroups": [{"title": "Features", "commits": [<commit>, ...]}, ...],
+//	  "noteGroups": [{"title": "BREAKING CHANGE", "notes": [<note>, ...]}, ...]
+//	}
+//
+// where a commit is the object of conventional-commits-parser:
+//
+//	{
+//	  "type": "feat", "scope": "api", "subject": "add login", "header": "feat(api): add login",
+//	  "body": null, "footer": "Closes #12",
+//	  "notes": [{"title": "BREAKING CHANGE", "text": "..."}],
+//	  "references": [{"action": "Closes", "owner": null, "repository": null, "issue": "12",
+//	                  "raw": "#12", "prefix": "#"}],
+//	  "mentions": ["jane"], "revert": null, "hash": "<sha>"
+//	}
+//
+// The scope of the changelog is empty except with the "scope-conventional" scheme.
+type ConventionalChangelog struct {
+	Version      string                 `json:"version"`
+	Scope        string                 `json:"scope,omitempty"`
+	CommitGroups []ChangelogCommitGroup `json:"commitGroups"`
+	NoteGroups   []ChangelogNoteGroup   `json:"noteGroups"`
+}
+
+// ChangelogCommitGroup are the commits of a type, titled like the conventional-changelog-angular
+// preset, eg: `Bug Fixes` for `fix`. Other types are titled by the type.
+type ChangelogCommitGroup struct {
+	Title   string            `json:"title"`
+	Commits []ChangelogCommit `json:"commits"`
+}
+
+// ChangelogNoteGroup are the notes of the commits with the same title, eg: `BREAKING CHANGE`
+type ChangelogNoteGroup struct {
+	Title string          `json:"title"`
+	Notes []ChangelogNote `json:"notes"`
+}
+
+// ChangelogCommit is a commit of the release parsed like conventional-commits-parser does
+type ChangelogCommit struct {
+	Type       string               `json:"type"`
+	Scope      *string              `json:"scope"`
+	Subject    string               `json:"subject"`
+	Header     string               `json:"header"`
+	Body       *string              `json:"body"`
+	Footer     *string              `json:"footer"`
+	Notes      []ChangelogNote      `json:"notes"`
+	References []ChangelogReference `json:"references"`
+	Mentions   []string             `json:"mentions"`
+	Revert     *ChangelogRevert     `json:"revert"`
+	Hash       string               `json:"hash"`
+}
+
+// ChangelogNote is a note of a commit, eg: the text of a `BREAKING CHANGE:` footer
+type ChangelogNote struct {
+	Title string `json:"title"`
+	Text  string `json:"text"`
+}
+
+// ChangelogReference is an issue referenced by a commit, eg: `Closes owner/repo#12`. The action is
+// null for a reference in the header.
+type ChangelogReference struct {
+	Action     *string `json:"action"`
+	Owner      *string `json:"owner"`
+	Repository *string `json:"repository"`
+	Issue      string  `json:"issue"`
+	Raw        string  `json:"raw"`
+	Prefix     string  `json:"prefix"`
+}
+
+// ChangelogRevert is the commit reverted by a revert, see CancelReverts
+type ChangelogRevert struct {
+	Header string `json:"header"`
+	Hash   string `json:"hash,omitempty"`
+}
+
+// changelogTypeTitles are the titles of the commit groups, in order, of the
+// conventional-changelog-angular preset
+var changelogTypeTitles = []struct{ typ, title string }{
+	{"feat", "Features"},
+	{"fix", "Bug Fixes"},
+	{"perf", "Performance Improvements"},
+	{"revert", "Reverts"},
+	{"docs", "Documentation"},
+	{"style", "Styles"},
+	{"refactor", "Code Refactoring"},
+	{"test", "Tests"},
+	{"build", "Build System"},
+	{"ci", "Continuous Integration"},
+}
+
+var (
+	// footerLineRex matches the first line of a footer: a `Key: value` or `Key #value` trailer or a
+	// breaking change
+	footerLineRex = regexp.MustCompile(`^(?:BREAKING[ -]CHANGE|[A-Za-z][A-Za-z0-9-]*)(?:: | #)`)
+	// breakingNoteRex matches a breaking change footer and captures its text
+	breakingNoteRex = regexp.MustCompile(`^BREAKING[ -]CHANGE: ?(.*)$`)
+	// actionRex matches a footer closing issues, eg: `Closes #12, owner/repo#13`
+	actionRex = regexp.MustCompile(`(?i)^(close[sd]?|fix(?:e[sd])?|resolve[sd]?)\b:?\s+(.+)$`)
+	// issueRex matches an issue reference and captures the owner, repository and issue
+	issueRex = regexp.MustCompile(`(?:([\w.-]+)/([\w.-]+))?#(\d+)`)
+	// mentionRex matches a mention of a user, eg: `@jane`
+	mentionRex = regexp.MustCompile(`(?:^|[^\w@])@([\w][\w-]*)`)
+	// emptyParensRex matches the parentheses left of an issue reference, eg: `()` of `(#12)`
+	emptyParensRex = regexp.MustCompile(`[(\[]\s*[)\]]`)
+)
+
+// NormalizeChangelogSubject is the default ChangelogSubjectNormalizer: the subject trimmed,
+// lowercased and without issue references, so `Add login (#12)` and `add login` are the same entry
+func NormalizeChangelogSubject(subject string) string {
+	subject = emptyParensRex.ReplaceAllString(issueRex.ReplaceAllString(subject, ""), "")
+	return strings.ToLower(strings.Join(strings.Fields(subject), " "))
+}
+
+// parseChangelogCommit parses the message of the commit hash like conventional-commits-parser
+func parseChangelogCommit(rex *regexp.Regexp, hash, msg string) ChangelogCommit {
+	msg = strings.TrimSpace(strings.ReplaceAll(msg, "\r\n", "\n"))
+	header, rest, _ := strings.Cut(msg, "\n")
+	m := parseCommitMessage(rex, header)
+	c := ChangelogCommit{
+		Type:       m.Type(),
+		Subject:    m.Subject(),
+		Header:     header,
+		Notes:      []ChangelogNote{},
+		References: []ChangelogReference{},
+		Mentions:   []string{},
+		Hash:       hash,
+	}
+	if !m.conventional() {
+		c.Type, c.Subject = "", header
+	}
+	if m.Scope() != "" {
+		scope := m.Scope()
+		c.Scope = &scope
+	}
+
+	var paragraphs []string
+	for _, p := range strings.Split(strings.TrimSpace(rest), "\n\n") {
+		if p = strings.TrimSpace(p); p != "" {
+			paragraphs = append(paragraphs, p)
+		}
+	}
+	if n := len(paragraphs); n > 0 && footerLineRex.MatchString(paragraphs[n-1]) {
+		footer := paragraphs[n-1]
+		c.Footer, paragraphs = &footer, paragraphs[:n-1]
+	}
+	if len(paragraphs) > 0 {
+		body := strings.Join(paragraphs, "\n\n")
+		c.Body = &body
+	}
+
+	if c.Footer != nil {
+		for _, line := range strings.Split(*c.Footer, "\n") {
+			if n := breakingNoteRex.FindStringSubmatch(line); n != nil {
+				c.Notes = append(c.Notes, ChangelogNote{Title: "BREAKING CHANGE", Text: n[1]})
+				continue
+			}
+			if a := actionRex.FindStringSubmatch(line); a != nil {
+				c.References = append(c.References, issueReferences(&a[1], a[2])...)
+			}
+		}
+	}
+	if m.Breaking() && len(c.Notes) == 0 {
+		c.Notes = append(c.Notes, ChangelogNote{Title: "BREAKING CHANGE", Text: m.Subject()})
+	}
+	c.References = append(issueReferences(nil, c.Subject), c.References...)
+	for _, mention := range mentionRex.FindAllStringSubmatch(msg, -1) {
+		c.Mentions = append(c.Mentions, mention[1])
+	}
+	if revertHeader, sha, ok := parseRevert(msg); ok {
+		c.Revert = &ChangelogRevert{Header: revertHeader, Hash: sha}
+	}
+	return c
+}
+
+// issueReferences returns the issues referenced in text with the action, if any
+func issueReferences(action *string, text string) []ChangelogReference {
+	refs := []ChangelogReference{}
+	for _, m := range issueRex.FindAllStringSubmatch(text, -1) {
+		ref := ChangelogReference{Action: action, Issue: m[3], Raw: m[0], Prefix: "#"}
+		if m[1] != "" {
+			owner, repository := m[1], m[2]
+			ref.Owner, ref.Repository = &owner, &repository
+		}
+		refs = append(refs, ref)
+	}
+	return refs
+}
+
+// newConventionalChangelog groups the commits of the release of scope by type and their notes by
+// title. Commits without a conventional type aren't listed.
+func newConventionalChangelog(scope, version string, commits []ChangelogCommit) ConventionalChangelog {
+	cl := ConventionalChangelog{Version: version, Scope: scope, CommitGroups: []ChangelogCommitGroup{}, NoteGroups: []ChangelogNoteGroup{}}
+	groups := make(map[string][]ChangelogCommit)
+	var others []string
+	notes := make(map[string][]ChangelogNote)
+	var noteTitles []string
+	for _, c := range commits {
+		for _, note := range c.Notes {
+			if notes[note.Title] == nil {
+				noteTitles = append(noteTitles, note.Title)
+			}
+			notes[note.Title] = append(notes[note.Title], note)
+		}
+		if c.Type == "" {
+			continue
+		}
+		typ := strings.ToLower(c.Type)
+		if groups[typ] == nil && changelogTypeTitle(typ) == typ {
+			others = append(others, typ)
+		}
+		groups[typ] = append(groups[typ], c)
+	}
+
+	for _, t := range changelogTypeTitles {
+		if len(groups[t.typ]) > 0 {
+			cl.CommitGroups = append(cl.CommitGroups, ChangelogCommitGroup{Title: t.title, Commits: groups[t.typ]})
+		}
+	}
+	for _, typ := range others {
+		cl.CommitGroups = append(cl.CommitGroups, ChangelogCommitGroup{Title: typ, Commits: groups[typ]})
+	}
+	for _, title := range noteTitles {
+		cl.NoteGroups = append(cl.NoteGroups, ChangelogNoteGroup{Title: title, Notes: notes[title]})
+	}
+	return cl
+}
+
+// changelogTypeTitle returns the title of the commit group of typ, the type itself if it has none
+func changelogTypeTitle(typ string) string {
+	for _, t := range changelogTypeTitles {
+		if t.typ == typ {
+			return t.title
+		}
+	}
+	return typ
+}
+
+// ConventionalChangelogs returns the releases in the JSON shape of the conventional-changelog
+// tools, so their changelog generators can render the output of autotag, see
+// ConventionalChangelog. The commits are the ones counted towards the bump: since the base tag,
+// without the commits excluded by IgnoreCommits, IgnoreAuthors, CommitFilter and the like. With
+// AllScopes there is a changelog for every scope with a release, in order, otherwise one for the
+// version of the repo.
+func (r *GitRepo) ConventionalChangelogs() ([]ConventionalChangelog, error) {
+	if !r.allScopes {
+		if r.newVersion == nil {
+			return nil, fmt.Errorf("no version calculated for the changelog")
+		}
+		commits, err := r.changelogCommits(r.scope, r.currentTag)
+		if err != nil {
+			return nil, err
+		}
+		return []ConventionalChangelog{newConventionalChangelog(r.scope, r.newVersion.String(), commits)}, nil
+	}
+
+	bases, err := r.scopeBases()
+	if err != nil {
+		return nil, err
+	}
+	changelogs := []ConventionalChangelog{}
+	for _, scope := range sortedScopes(bases) {
+		base := bases[scope]
+		if base.err != nil || base.level == BumpNone {
+			continue
+		}
+		next, err := r.nextScopeVersion(scope, base)
+		if err != nil {
+			return nil, fmt.Errorf("error calculating the version of scope %s: %w", scope, err)
+		}
+		commits, err := r.changelogCommits(scope, base.tag)
+		if err != nil {
+			return nil, err
+		}
+		changelogs = append(changelogs, newConventionalChangelog(base.tagScope(scope), next.String(), commits))
+	}
+	return changelogs, nil
+}
+
+// changelogCommits returns the commits of the release of scope since the base tag counted towards
+// the bump, newest first. With the "scope-conventional" scheme only the commits of the scope are
+// returned. Of the commits with the same normalized subject, see ChangelogSubjectNormalizer, only
+// the newest is returned.
+func (r *GitRepo) changelogCommits(scope string, baseTag *git.Commit) ([]ChangelogCommit, error) {
+	tip, err := r.branchCommit()
+	if err != nil {
+		return nil, err
+	}
+	commits, err := r.repo.RevList(r.historyRange(baseTag, tip.ID.String()))
+	if err != nil {
+		return nil, fmt.Errorf("error loading the commits of the changelog: %s", err)
+	}
+	cancelled := r.cancelledCommits(commits)
+	normalize := r.subjectNormalizer
+	if normalize == nil {
+		normalize = NormalizeChangelogSubject
+	}
+
+	out := []ChangelogCommit{}
+	seen := make(map[string]bool)
+	for _, c := range commits {
+		if !r.includeCommit(c) || cancelled[c.ID.String()] {
+			continue
+		}
+		if r.scheme == "scope-conventional" {
+			if _, ok := r.commitScopes(c.Message)[r.normalizeScope(scope)]; !ok {
+				continue
+			}
+		}
+		cc := parseChangelogCommit(r.commitRex, c.ID.String(), c.Message)
+		key := normalize(cc.Subject)
+		if seen[key] {
+			r.debugf("skipping duplicate changelog commit %s\n", c.ID)
+			continue
+		}
+		seen[key] = true
+		out = append(out, cc)
+	}
+	return out, nil
+}
+
+// commitScopes returns the normalized scopes of the headers of msg
+func (r *GitRepo) commitScopes(msg string) map[string]bool {
+	scopes := make(map[string]bool)
+	for s := range r.scopeDecisions(msg) {
+		scopes[r.normalizeScope(s)] = true
+	}
+	return scopes
+}
@@ -0,0 +1,118 @@
+package autotag
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/assert"
+)
+
+func TestParseChangelogCommit(t *testing.T) {
+	msg := "feat(api)!: add login, see #7\n\nLogin with a token, thanks @jane.\n\nBREAKING CHANGE: the session cookie is gone\nCloses #12, acme/web#13\n"
+	c := parseChangelogCommit(conventionalCommitRex, "1a2b3c4", msg)
+	out, err := json.Marshal(c)
+	checkFatal(t, err)
+	assert.Equal(t, `{"type":"feat","scope":"api","subject":"add login, see #7","header":"feat(api)!: add login, see #7",`+
+		`"body":"Login with a token, thanks @jane.","footer":"BREAKING CHANGE: the session cookie is gone\nCloses #12, acme/web#13",`+
+		`"notes":[{"title":"BREAKING CHANGE","text":"the session cookie is gone"}],`+
+		`"references":[{"action":null,"owner":null,"repository":null,"issue":"7","raw":"#7","prefix":"#"},`+
+		`{"action":"Closes","owner":null,"repository":null,"issue":"12","raw":"#12","prefix":"#"},`+
+		`{"action":"Closes","owner":"acme","repository":"web","issue":"13","raw":"acme/web#13","prefix":"#"}],`+
+		`"mentions":["jane"],"revert":null,"hash":"1a2b3c4"}`, string(out))
+
+	c = parseChangelogCommit(conventionalCommitRex, "5d6e7f8", "Revert \"feat: add login\"\n\nThis reverts commit 1a2b3c4.")
+	assert.Equal(t, "", c.Type)
+	assert.Nil(t, c.Footer)
+	assert.Equal(t, &ChangelogRevert{Header: "feat: add login", Hash: "1a2b3c4"}, c.Revert)
+}
+
+func TestConventionalChangelogs(t *testing.T) {
+	dir := newRepoDir(t, seedCommit("v1.0.0"))
+	for _, msg := range []string{
+		"feat: add login\n\nCloses #12",
+		"fix: correct typo",
+		"chore(deps): bump go-version",
+		"refactor!: drop the v1 API",
+	} {
+		commitAs(t, dir, "Jane Doe <jane@example.com>", msg)
+	}
+	commitAs(t, dir, "dependabot[bot] <bot@example.com>", "feat: ignored")
+
+	r, err := NewRepo(GitRepoConfig{RepoPath: dir, Branch: "master", Scheme: "conventional", IgnoreAuthors: []string{"dependabot[bot]"}})
+	checkFatal(t, err)
+	changelogs, err := r.ConventionalChangelogs()
+	checkFatal(t, err)
+	assert.Equal(t, 1, len(changelogs))
+	cl := changelogs[0]
+	assert.Equal(t, "2.0.0", cl.Version)
+
+	var titles []string
+	for _, g := range cl.CommitGroups {
+		titles = append(titles, g.Title)
+		assert.Equal(t, 1, len(g.Commits), g.Title)
+	}
+	assert.Equal(t, []string{"Features", "Bug Fixes", "Code Refactoring", "chore"}, titles)
+	assert.Equal(t, "12", cl.CommitGroups[0].Commits[0].References[0].Issue)
+	assert.Equal(t, []ChangelogNoteGroup{{Title: "BREAKING CHANGE", Notes: []ChangelogNote{{Title: "BREAKING CHANGE", Text: "drop the v1 API"}}}}, cl.NoteGroups)
+
+	// the JSON round-trips
+	out, err := json.Marshal(changelogs)
+	checkFatal(t, err)
+	var decoded []ConventionalChangelog
+	checkFatal(t, json.Unmarshal(out, &decoded))
+	assert.Equal(t, changelogs, decoded)
+}
+
+func TestConventionalChangelogsScopes(t *testing.T) {
+	dir := newRepoDir(t,
+		seedCommit("api-v1.0.0", "web-v1.0.0", "docs-v1.0.0"),
+		testCommit{msg: "feat(api): add login"},
+		testCommit{msg: "fix(web): correct typo"},
+		testCommit{msg: "fix(api): handle expired tokens"},
+	)
+	r, err := NewRepo(GitRepoConfig{RepoPath: dir, Branch: "master", Scheme: "scope-conventional", AllScopes: true})
+	checkFatal(t, err)
+	changelogs, err := r.ConventionalChangelogs()
+	checkFatal(t, err)
+	assert.Equal(t, 2, len(changelogs))
+	assert.Equal(t, "api", changelogs[0].Scope)
+	assert.Equal(t, "1.1.0", changelogs[0].Version)
+	assert.Equal(t, 2, len(changelogs[0].CommitGroups))
+	assert.Equal(t, "web", changelogs[1].Scope)
+	assert.Equal(t, "correct typo", changelogs[1].CommitGroups[0].Commits[0].Subject)
+}
+
+func TestChangelogDedupesSubjects(t *testing.T) {
+	dir := newRepoDir(t, seedCommit("v1.0.0"))
+	for _, msg := range []string{
+		"feat: add login",
+		"feat: Add login (#12)",
+		"feat:  add login ",
+		"feat: add logout",
+		"fix: correct typo",
+		"fix: correct typo in the README",
+	} {
+		commitAs(t, dir, "Jane Doe <jane@example.com>", msg)
+	}
+
+	subjects := func(cfg GitRepoConfig) []string {
+		cfg.RepoPath, cfg.Branch, cfg.Scheme = dir, "master", "conventional"
+		r, err := NewRepo(cfg)
+		checkFatal(t, err)
+		changelogs, err := r.ConventionalChangelogs()
+		checkFatal(t, err)
+		var out []string
+		for _, g := range changelogs[0].CommitGroups {
+			for _, c := range g.Commits {
+				out = append(out, c.Subject)
+			}
+		}
+		return out
+	}
+	assert.Equal(t, []string{"add logout", "add login", "correct typo in the README", "correct typo"}, subjects(GitRepoConfig{}))
+
+	// a hook keeping only the first word collapses the fixes too
+	firstWord := func(subject string) string { return strings.ToLower(strings.Fields(subject)[0]) }
+	assert.Equal(t, []string{"add logout", "correct typo in the README"}, subjects(GitRepoConfig{ChangelogSubjectNormalizer: firstWord}))
+}
@@ -0,0 +1,27 @@
+package autotag
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrEventNotAllowed is returned instead of creating tags when the CI event isn't one of the
+// AllowedEvents
+var ErrEventNotAllowed = errors.New("event not allowed to create tags")
+
+// checkEvent returns ErrEventNotAllowed if AllowedEvents are set and the Event isn't one of them
+func (r *GitRepo) checkEvent() error {
+	if len(r.allowedEvents) == 0 {
+		return nil
+	}
+	for _, event := range r.allowedEvents {
+		if event == r.event {
+			return nil
+		}
+	}
+	if r.event == "" {
+		return fmt.Errorf("%w: no event, tags are only created on %s", ErrEventNotAllowed, strings.Join(r.allowedEvents, ", "))
+	}
+	return fmt.Errorf("%w: '%s', tags are only created on %s", ErrEventNotAllowed, r.event, strings.Join(r.allowedEvents, ", "))
+}
@@ -0,0 +1,75 @@
+package autotag
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/alecthomas/assert"
+)
+
+func TestAllowedEvents(t *testing.T) {
+	tests := []struct {
+		name          string
+		allowedEvents []string
+		event         string
+		allowed       bool
+	}{
+		{
+			name:    "no restriction",
+			event:   "pull_request",
+			allowed: true,
+		},
+		{
+			name:          "allowed event",
+			allowedEvents: []string{"push", "workflow_dispatch"},
+			event:         "workflow_dispatch",
+			allowed:       true,
+		},
+		{
+			name:          "disallowed event",
+			allowedEvents: []string{"push"},
+			event:         "pull_request",
+		},
+		{
+			name:          "no event",
+			allowedEvents: []string{"push"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tagger := &fakeTagger{}
+			r := newRepoFixture(t, GitRepoConfig{Scheme: "conventional", Prefix: true, Tagger: tagger, AllowedEvents: tc.allowedEvents, Event: tc.event},
+				seedCommit("v1.0.0"),
+				testCommit{msg: "feat: add login"},
+			)
+			// the version is calculated on every event
+			assert.Equal(t, "v1.1.0", r.LatestVersion())
+
+			err := r.AutoTag()
+			if tc.allowed {
+				checkFatal(t, err)
+				assert.Equal(t, 1, len(tagger.created))
+				return
+			}
+			assert.True(t, errors.Is(err, ErrEventNotAllowed), "error: %v", err)
+			assert.Equal(t, 0, len(tagger.created))
+		})
+	}
+}
+
+func TestAllowedEventsScopes(t *testing.T) {
+	tagger := &fakeTagger{}
+	r := newRepoFixture(t, GitRepoConfig{Scheme: "scope-conventional", AllScopes: true, Tagger: tagger, AllowedEvents: []string{"push"}, Event: "pull_request"},
+		seedCommit("api-v1.0.0"),
+		testCommit{msg: "feat(api): add login"},
+	)
+	_, err := r.AutoTagScopes()
+	assert.True(t, errors.Is(err, ErrEventNotAllowed), "error: %v", err)
+
+	results, err := r.Preview()
+	checkFatal(t, err)
+	err = r.CreateTagsFromResults(results)
+	assert.True(t, errors.Is(err, ErrEventNotAllowed), "error: %v", err)
+	assert.Equal(t, 0, len(tagger.created))
+}
@@ -0,0 +1,132 @@
+package autotag
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/hashicorp/go-version"
+)
+
+// ErrVersionExists is returned by AutoTag when the new version is already tagged: a tag with the
+// same core version and pre-release exists. Build metadata doesn't take part, so `1.2.3+b`
+// collides with `1.2.3+a`.
+var ErrVersionExists = errors.New("version is already tagged")
+
+// ErrVersionReleased is returned by AutoTag when the core version of the new pre-release is
+// already released as a stable version, eg: `1.2.3-rc.1` after `1.2.3`
+var ErrVersionReleased = errors.New("version is already released")
+
+// ErrAlreadyTagged is returned by AutoTag when the new version is stable and the branch commit
+// already has a stable version tag of the scope, eg: when a build is run again. A pre-release is
+// still tagged on such a commit, so a rebuild can iterate `1.2.3-rc.1` to `1.2.3-rc.2`.
+var ErrAlreadyTagged = errors.New("commit is already tagged")
+
+// sameTagVersion reports whether the versions collide as tags: the core version and the pre-release
+// take part, build metadata doesn't, as it doesn't in semver precedence
+func sameTagVersion(a, b *version.Version) bool {
+	return sameCoreVersion(a, b) && a.Prerelease() == b.Prerelease()
+}
+
+// sameCoreVersion reports whether the versions have the same major.minor.patch core, ignoring the
+// pre-release and build metadata
+func sameCoreVersion(a, b *version.Version) bool {
+	return coreSegments(a) == coreSegments(b)
+}
+
+// checkVersionCollision returns ErrVersionExists if a version tag of scope collides with v, or
+// ErrVersionReleased if v is a pre-release of a stable version tag of scope
+func (r *GitRepo) checkVersionCollision(scope string, v *version.Version) error {
+	tags, err := r.loadTags()
+	if err != nil {
+		return err
+	}
+
+	var released string
+	for existing, ref := range tags[r.normalizeScope(scope)] {
+		if sameTagVersion(existing, v) && !r.metadataRebuild(existing, v) {
+			return fmt.Errorf("%w: %s by %s", ErrVersionExists, v, ref.name)
+		}
+		if existing.Prerelease() == "" && v.Prerelease() != "" && sameCoreVersion(existing, v) {
+			released = ref.name
+		}
+	}
+	if released != "" {
+		return fmt.Errorf("%w: %s by %s", ErrVersionReleased, v, released)
+	}
+	return nil
+}
+
+// skipExistingVersion decorates next, bumped from base, see decorateVersion, and while the result
+// collides with a version tag of scope bumps next again by the same level, see SkipExisting. A
+// version without a bump from base, eg: a promoted pre-release, is returned as is.
+func (r *GitRepo) skipExistingVersion(scope string, base, next *version.Version, typ string) (*version.Version, error) {
+	level := NewVersionDelta(base, next).Level
+	for {
+		v, err := r.decorateVersion(scope, base, next, typ)
+		if err != nil || level == BumpNone {
+			return v, err
+		}
+		err = r.checkVersionCollision(scope, v)
+		if err == nil {
+			return v, nil
+		}
+		if !errors.Is(err, ErrVersionExists) && !errors.Is(err, ErrVersionReleased) {
+			return nil, err
+		}
+
+		skipped := next
+		if next, err = r.bumpVersion(level.bumper(), skipped); err != nil {
+			return nil, err
+		}
+		if next == nil || !next.GreaterThan(skipped) {
+			return nil, fmt.Errorf("%w: %s can't be skipped", ErrVersionExists, v)
+		}
+		if next, err = r.avoidReserved(base, next); err != nil {
+			return nil, err
+		}
+		r.infof("skipping existing version %s, using %s\n", v, next)
+	}
+}
+
+// checkAlreadyTagged returns ErrAlreadyTagged if v is stable and the branch commit has a stable
+// version tag of scope
+func (r *GitRepo) checkAlreadyTagged(scope string, v *version.Version) error {
+	if v.Prerelease() != "" {
+		return nil
+	}
+	tags, err := r.loadTags()
+	if err != nil {
+		return err
+	}
+
+	for existing, ref := range tags[r.normalizeScope(scope)] {
+		if existing.Prerelease() != "" || ref.preRelease || r.metadataRebuild(existing, v) {
+			continue
+		}
+		c, err := r.tagRefCommit(ref)
+		if err != nil {
+			return err
+		}
+		if c != nil && c.ID.String() == r.branchID {
+			return fmt.Errorf("%w: %s has %s, not tagging %s", ErrAlreadyTagged, r.branchID, ref.name, v)
+		}
+	}
+	return nil
+}
+
+// metadataRebuild reports whether v re-tags the version existing with other build metadata, which
+// AllowMetadataOnlyBump allows, eg: `1.2.3+ci.43` of `1.2.3+ci.42`
+func (r *GitRepo) metadataRebuild(existing, v *version.Version) bool {
+	return r.allowMetadataOnly && v.Metadata() != "" && sameTagVersion(existing, v) && existing.Metadata() != v.Metadata()
+}
+
+// metadataOnlyVersion returns the core version of base to be tagged again with the BuildMetadata
+// when no commit bumps it, see AllowMetadataOnlyBump. It returns nil if that isn't allowed or the
+// build metadata doesn't differ from the metadata of base.
+func (r *GitRepo) metadataOnlyVersion(base *version.Version) *version.Version {
+	if !r.allowMetadataOnly || r.buildMetadata == "" || r.buildMetadata == base.Metadata() || base.Prerelease() != "" {
+		return nil
+	}
+	core := coreSegments(base)
+	return version.Must(version.NewVersion(fmt.Sprintf("%d.%d.%d", core[0], core[1], core[2])))
+}
@@ -0,0 +1,268 @@
+package autotag
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/alecthomas/assert"
+	"github.com/hashicorp/go-version"
+)
+
+func TestVersionCollision(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		sameTag  bool
+		sameCore bool
+	}{
+		{a: "1.2.3", b: "1.2.3", sameTag: true, sameCore: true},
+		{a: "1.2.3", b: "1.2.3+a", sameTag: true, sameCore: true},
+		{a: "1.2.3+a", b: "1.2.3+b", sameTag: true, sameCore: true},
+		{a: "1.2.3", b: "1.2.3-rc.1", sameCore: true},
+		{a: "1.2.3-rc.1", b: "1.2.3-rc.1+a", sameTag: true, sameCore: true},
+		{a: "1.2.3-rc.1", b: "1.2.3-rc.2", sameCore: true},
+		{a: "1.2.3", b: "1.2.4"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.a+" "+tc.b, func(t *testing.T) {
+			a, b := version.Must(version.NewVersion(tc.a)), version.Must(version.NewVersion(tc.b))
+			assert.Equal(t, tc.sameTag, sameTagVersion(a, b))
+			assert.Equal(t, tc.sameTag, sameTagVersion(b, a))
+			assert.Equal(t, tc.sameCore, sameCoreVersion(a, b))
+		})
+	}
+}
+
+func TestAutoTagVersionCollision(t *testing.T) {
+	tests := []struct {
+		name          string
+		cfg           GitRepoConfig
+		tag           string
+		expectedTag   string
+		expectedError error
+	}{
+		{
+			name:          "same version",
+			cfg:           GitRepoConfig{Scheme: "conventional"},
+			tag:           "v1.2.3",
+			expectedError: ErrVersionExists,
+		},
+		{
+			name:          "other build metadata",
+			cfg:           GitRepoConfig{Scheme: "conventional", BuildMetadata: "b"},
+			tag:           "v1.2.3+a",
+			expectedError: ErrVersionExists,
+		},
+		{
+			name:          "pre-release of a released version",
+			cfg:           GitRepoConfig{Scheme: "conventional", PreReleaseName: "rc.1"},
+			tag:           "v1.2.3",
+			expectedError: ErrVersionReleased,
+		},
+		{
+			name:        "stable release of a pre-release",
+			cfg:         GitRepoConfig{Scheme: "conventional"},
+			tag:         "v1.2.3-rc.1",
+			expectedTag: "v1.2.3",
+		},
+		{
+			name:          "scope",
+			cfg:           GitRepoConfig{Scheme: "scope-conventional"},
+			tag:           "api-v1.2.3",
+			expectedError: ErrVersionExists,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tagger := &fakeTagger{}
+			scope := ""
+			if tc.cfg.Scheme == "scope-conventional" {
+				scope = "api"
+			}
+			// the base version tracked outside of git is behind the tags
+			tc.cfg.Prefix, tc.cfg.Tagger = true, tagger
+			tc.cfg.CurrentVersions = map[string]*version.Version{scope: version.Must(version.NewVersion("1.2.2"))}
+			r := newRepoFixture(t, tc.cfg,
+				seedCommit(tc.tag),
+				testCommit{msg: "fix(api): correct typo"},
+			)
+
+			err := r.AutoTag()
+			if tc.expectedError != nil {
+				assert.True(t, errors.Is(err, tc.expectedError), "expected %v, got %v", tc.expectedError, err)
+				assert.Equal(t, 0, len(tagger.created))
+				return
+			}
+			checkFatal(t, err)
+			assert.Equal(t, tc.expectedTag, tagger.created[0].name)
+		})
+	}
+}
+
+func TestAutoTagAlreadyTagged(t *testing.T) {
+	tests := []struct {
+		name          string
+		cfg           GitRepoConfig
+		tags          []string
+		expectedTag   string
+		expectedError error
+	}{
+		{
+			name:        "rebuild of a pre-release",
+			cfg:         GitRepoConfig{Scheme: "conventional", PreReleaseName: "rc.2"},
+			tags:        []string{"v1.1.0-rc.1"},
+			expectedTag: "v1.1.0-rc.2",
+		},
+		{
+			name:        "release of a pre-release",
+			cfg:         GitRepoConfig{Scheme: "conventional"},
+			tags:        []string{"v1.1.0-rc.1"},
+			expectedTag: "v1.1.0",
+		},
+		{
+			name:          "duplicate stable release",
+			cfg:           GitRepoConfig{Scheme: "conventional"},
+			tags:          []string{"v1.1.0"},
+			expectedError: ErrAlreadyTagged,
+		},
+		{
+			name:          "duplicate stable release of a scope",
+			cfg:           GitRepoConfig{Scheme: "scope-conventional"},
+			tags:          []string{"api-v1.1.0"},
+			expectedError: ErrAlreadyTagged,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tagger := &fakeTagger{}
+			tc.cfg.Prefix, tc.cfg.Tagger = true, tagger
+			r := newRepoFixture(t, tc.cfg,
+				seedCommit("v1.0.0", "api-v1.0.0"),
+				testCommit{msg: "feat(api): add login", tags: tc.tags},
+			)
+
+			err := r.AutoTag()
+			if tc.expectedError != nil {
+				assert.True(t, errors.Is(err, tc.expectedError), "expected %v, got %v", tc.expectedError, err)
+				assert.Equal(t, 0, len(tagger.created))
+				return
+			}
+			checkFatal(t, err)
+			assert.Equal(t, tc.expectedTag, tagger.created[0].name)
+		})
+	}
+}
+
+func TestSkipExisting(t *testing.T) {
+	tests := []struct {
+		name          string
+		cfg           GitRepoConfig
+		commit        string
+		tags          []string
+		expectedTag   string
+		expectedError error
+	}{
+		{
+			name:        "run of patch tags",
+			cfg:         GitRepoConfig{Scheme: "conventional", SkipExisting: true},
+			commit:      "fix: correct typo",
+			tags:        []string{"v1.2.4", "v1.2.5", "v1.2.7"},
+			expectedTag: "v1.2.6",
+		},
+		{
+			name:          "disabled",
+			cfg:           GitRepoConfig{Scheme: "conventional"},
+			commit:        "fix: correct typo",
+			tags:          []string{"v1.2.4", "v1.2.5"},
+			expectedError: ErrVersionExists,
+		},
+		{
+			name:        "minor",
+			cfg:         GitRepoConfig{Scheme: "conventional", SkipExisting: true},
+			commit:      "feat: add login",
+			tags:        []string{"v1.3.0", "v1.3.1"},
+			expectedTag: "v1.4.0",
+		},
+		{
+			name:        "pre-release of a released version",
+			cfg:         GitRepoConfig{Scheme: "conventional", SkipExisting: true, PreReleaseName: "rc.1"},
+			commit:      "fix: correct typo",
+			tags:        []string{"v1.2.4", "v1.2.5-rc.1"},
+			expectedTag: "v1.2.6-rc.1",
+		},
+		{
+			name:        "reserved version",
+			cfg:         GitRepoConfig{Scheme: "conventional", SkipExisting: true, ReservedVersions: []string{"1.2.5"}},
+			commit:      "fix: correct typo",
+			tags:        []string{"v1.2.4"},
+			expectedTag: "v1.2.6",
+		},
+		{
+			name:        "scope",
+			cfg:         GitRepoConfig{Scheme: "scope-conventional", SkipExisting: true},
+			commit:      "fix(api): correct typo",
+			tags:        []string{"api-v1.2.4", "web-v1.2.5"},
+			expectedTag: "api-v1.2.5",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tagger := &fakeTagger{}
+			scope := ""
+			if tc.cfg.Scheme == "scope-conventional" {
+				scope = "api"
+			}
+			// the base version tracked outside of git is behind the tags created out of band
+			tc.cfg.Prefix, tc.cfg.Tagger = true, tagger
+			tc.cfg.CurrentVersions = map[string]*version.Version{scope: version.Must(version.NewVersion("1.2.3"))}
+			r := newRepoFixture(t, tc.cfg,
+				seedCommit(tc.tags...),
+				testCommit{msg: tc.commit},
+			)
+
+			err := r.AutoTag()
+			if tc.expectedError != nil {
+				assert.True(t, errors.Is(err, tc.expectedError), "expected %v, got %v", tc.expectedError, err)
+				assert.Equal(t, 0, len(tagger.created))
+				return
+			}
+			checkFatal(t, err)
+			assert.Equal(t, tc.expectedTag, tagger.created[0].name)
+		})
+	}
+}
+
+func TestAllowMetadataOnlyBump(t *testing.T) {
+	cfg := GitRepoConfig{
+		Scheme:        "scope-conventional",
+		Prefix:        true,
+		BumpRules:     BumpRules{"build": BumpNone},
+		BuildMetadata: "ci.43",
+	}
+	dir := newRepoDir(t,
+		seedCommit("api-v1.2.2"),
+		// the rebuild of the tagged commit, eg: with a new toolchain
+		testCommit{msg: "build(api): rebuild the image", tags: []string{"api-v1.2.3+ci.42"}},
+	)
+	cfg.RepoPath, cfg.Branch = dir, "master"
+
+	_, err := NewRepo(cfg)
+	assert.True(t, errors.Is(err, ErrNoBump), "expected %v, got %v", ErrNoBump, err)
+
+	tagger := &fakeTagger{}
+	cfg.AllowMetadataOnlyBump, cfg.Tagger = true, tagger
+	r, err := NewRepo(cfg)
+	checkFatal(t, err)
+	assert.Equal(t, "api-v1.2.3+ci.43", r.LatestVersion())
+	assert.Contains(t, r.Result().BumpReason, "tagged again with build metadata ci.43")
+	checkFatal(t, r.AutoTag())
+	assert.Equal(t, "api-v1.2.3+ci.43", tagger.created[0].name)
+
+	// the same build metadata doesn't make a new tag
+	cfg.BuildMetadata = "ci.42"
+	_, err = NewRepo(cfg)
+	assert.True(t, errors.Is(err, ErrNoBump), "expected %v, got %v", ErrNoBump, err)
+}
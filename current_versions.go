@@ -0,0 +1,60 @@
+package autotag
+
+import (
+	"fmt"
+
+	"github.com/gogs/git-module"
+	"github.com/hashicorp/go-version"
+)
+
+// SetCurrentVersions replaces the base versions tracked outside of git, see
+// GitRepoConfig.CurrentVersions, and calculates the next version again.
+func (r *GitRepo) SetCurrentVersions(versions map[string]*version.Version) error {
+	if err := validateCurrentVersions(r.scheme, versions); err != nil {
+		return err
+	}
+	r.currentVersions = copyVersions(versions)
+	return r.calculate()
+}
+
+// validateCurrentVersions checks the base versions set with CurrentVersions: scopes other than ""
+// require the "scope-conventional" scheme
+func validateCurrentVersions(scheme string, versions map[string]*version.Version) error {
+	for scope, v := range versions {
+		if v == nil {
+			return fmt.Errorf("current version of scope '%s' is nil", scope)
+		}
+		if scope != "" && scheme != "scope-conventional" {
+			return fmt.Errorf("current version of scope '%s' requires the scope-conventional scheme", scope)
+		}
+	}
+	return nil
+}
+
+// injectedBase returns the base version of scope set with CurrentVersions and the commit of its tag,
+// nil if the tag doesn't exist. It returns false if no version is set for scope. The tags are not
+// read, only the tag of the version is looked up.
+func (r *GitRepo) injectedBase(scope string) (*version.Version, *git.Commit, bool) {
+	v, ok := r.currentVersions[r.normalizeScope(scope)]
+	if !ok {
+		return nil, nil, false
+	}
+	tag, err := r.tagCommit(r.FormatTag(scope, v))
+	if err != nil {
+		r.infof("no tag of the current version %s of scope '%s', parsing all commits\n", v, scope)
+		return v, nil, true
+	}
+	return v, tag, true
+}
+
+// copyVersions returns a copy of the versions map, so the caller's map isn't aliased
+func copyVersions(versions map[string]*version.Version) map[string]*version.Version {
+	if len(versions) == 0 {
+		return nil
+	}
+	c := make(map[string]*version.Version, len(versions))
+	for scope, v := range versions {
+		c[scope] = v
+	}
+	return c
+}
@@ -0,0 +1,98 @@
+package autotag
+
+import (
+	"testing"
+
+	"github.com/alecthomas/assert"
+	"github.com/hashicorp/go-version"
+)
+
+func TestCurrentVersions(t *testing.T) {
+	tests := []struct {
+		name        string
+		tags        []string
+		current     string
+		expectedTag string
+	}{
+		{
+			name:        "without tags",
+			current:     "1.4.0",
+			expectedTag: "v1.5.0",
+		},
+		{
+			name:        "overrides the tags",
+			tags:        []string{"v1.0.0"},
+			current:     "2.3.0",
+			expectedTag: "v2.4.0",
+		},
+		{
+			name:        "commits since the tag of the version",
+			tags:        []string{"v1.0.0"},
+			current:     "1.0.0",
+			expectedTag: "v1.0.1",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := GitRepoConfig{
+				Scheme:          "conventional",
+				Prefix:          true,
+				CurrentVersions: map[string]*version.Version{"": version.Must(version.NewVersion(tc.current))},
+			}
+			r := newRepoFixture(t, cfg,
+				testCommit{msg: "feat: add login"},
+				seedCommit(tc.tags...),
+				testCommit{msg: "fix: correct typo"},
+			)
+			assert.Equal(t, tc.expectedTag, r.LatestVersion())
+		})
+	}
+}
+
+func TestScopeSchemeCurrentVersions(t *testing.T) {
+	cfg := GitRepoConfig{
+		Scheme:          "scope-conventional",
+		Prefix:          true,
+		CurrentVersions: map[string]*version.Version{"worker": version.Must(version.NewVersion("3.1.0"))},
+	}
+	r := newRepoFixture(t, cfg,
+		seedCommit("api-v1.0.0", "worker-v1.0.0"),
+		testCommit{msg: "feat(api): add login"},
+		testCommit{msg: "fix(worker): correct typo"},
+	)
+	assert.Equal(t, "worker-v3.1.1", r.LatestVersion())
+
+	versions, err := r.NextScopeVersions()
+	assert.NoError(t, err)
+	got := make(map[string]string)
+	for scope, v := range versions {
+		got[scope] = v.String()
+	}
+	assert.Equal(t, map[string]string{"api": "1.1.0", "worker": "3.1.1"}, got)
+
+	v, err := r.NextScopeVersion("worker")
+	assert.NoError(t, err)
+	assert.Equal(t, "3.1.1", v.String())
+
+	// the tags are the fallback again
+	assert.NoError(t, r.SetCurrentVersions(map[string]*version.Version{"api": version.Must(version.NewVersion("0.9.0"))}))
+	assert.Equal(t, "worker-v1.0.1", r.LatestVersion())
+	v, err = r.NextScopeVersion("api")
+	assert.NoError(t, err)
+	assert.Equal(t, "0.10.0", v.String())
+}
+
+func TestSetCurrentVersionsInvalid(t *testing.T) {
+	r := newRepoFixture(t, GitRepoConfig{Scheme: "conventional", Prefix: true},
+		seedCommit("v1.0.0"),
+		testCommit{msg: "feat: add login"},
+	)
+
+	assert.Error(t, r.SetCurrentVersions(map[string]*version.Version{"": nil}))
+	assert.Error(t, r.SetCurrentVersions(map[string]*version.Version{"api": version.Must(version.NewVersion("1.0.0"))}))
+	assert.Equal(t, "v1.1.0", r.LatestVersion())
+
+	assert.NoError(t, r.SetCurrentVersions(map[string]*version.Version{"": version.Must(version.NewVersion("2.0.0"))}))
+	assert.Equal(t, "v2.1.0", r.LatestVersion())
+}
@@ -0,0 +1,84 @@
+package autotag
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/go-version"
+)
+
+// VersionDelta is the semantic difference between two versions, eg: for release notes like
+// `minor release 1.2.3 -> 1.3.0`. It only compares the versions, independent of how the new one was
+// calculated.
+type VersionDelta struct {
+	// From is the version before, nil if there is none
+	From *version.Version
+	// To is the version after, nil if there is none
+	To *version.Version
+	// Level is the highest changed segment of the major.minor.patch core, BumpNone if the core
+	// didn't change, eg: when only the pre-release is promoted or iterated
+	Level BumpLevel
+	// PreReleaseChanged is set when the pre-release parts differ, eg: `1.3.0-rc.1 -> 1.3.0-rc.2`
+	// or `1.3.0-rc.1 -> 1.3.0`
+	PreReleaseChanged bool
+	// MetadataChanged is set when the build metadata differs
+	MetadataChanged bool
+}
+
+// NewVersionDelta returns the delta between the versions from and to, either may be nil
+func NewVersionDelta(from, to *version.Version) VersionDelta {
+	d := VersionDelta{From: from, To: to}
+	if from == nil || to == nil {
+		return d
+	}
+
+	fromCore, toCore := coreSegments(from), coreSegments(to)
+	switch {
+	case fromCore[0] != toCore[0]:
+		d.Level = BumpMajor
+	case fromCore[1] != toCore[1]:
+		d.Level = BumpMinor
+	case fromCore[2] != toCore[2]:
+		d.Level = BumpPatch
+	}
+	d.PreReleaseChanged = from.Prerelease() != to.Prerelease()
+	d.MetadataChanged = from.Metadata() != to.Metadata()
+	return d
+}
+
+// coreSegments returns the major, minor and patch segments of v, missing segments are 0
+func coreSegments(v *version.Version) [3]int {
+	var core [3]int
+	copy(core[:], v.Segments())
+	return core
+}
+
+// String describes the delta, eg: `minor 1.2.3 -> 1.3.0` or `pre-release 1.3.0-rc.1 -> 1.3.0`
+func (d VersionDelta) String() string {
+	if d.From == nil || d.To == nil {
+		return fmt.Sprintf("%s -> %s", versionString(d.From), versionString(d.To))
+	}
+
+	kind := d.Level.String()
+	if d.Level == BumpNone {
+		switch {
+		case d.PreReleaseChanged:
+			kind = "pre-release"
+		case d.MetadataChanged:
+			kind = "metadata"
+		}
+	}
+	return fmt.Sprintf("%s %s -> %s", kind, d.From, d.To)
+}
+
+// versionString returns v as a string, `none` if v is nil
+func versionString(v *version.Version) string {
+	if v == nil {
+		return "none"
+	}
+	return v.String()
+}
+
+// Delta returns the delta from the current to the next version of the result
+func (res Result) Delta() VersionDelta {
+	return NewVersionDelta(res.Current, res.Next)
+}
@@ -0,0 +1,53 @@
+package autotag
+
+import (
+	"testing"
+
+	"github.com/alecthomas/assert"
+	"github.com/hashicorp/go-version"
+)
+
+func TestNewVersionDelta(t *testing.T) {
+	tests := []struct {
+		from, to          string
+		level             BumpLevel
+		preReleaseChanged bool
+		metadataChanged   bool
+		str               string
+	}{
+		{from: "1.2.3", to: "2.0.0", level: BumpMajor, str: "major 1.2.3 -> 2.0.0"},
+		{from: "1.2.3", to: "1.3.0", level: BumpMinor, str: "minor 1.2.3 -> 1.3.0"},
+		{from: "1.2.3", to: "1.2.4", level: BumpPatch, str: "patch 1.2.3 -> 1.2.4"},
+		{from: "1.2.3", to: "1.3.0-rc.1", level: BumpMinor, preReleaseChanged: true, str: "minor 1.2.3 -> 1.3.0-rc.1"},
+		{from: "1.3.0-rc.1", to: "1.3.0-rc.2", preReleaseChanged: true, str: "pre-release 1.3.0-rc.1 -> 1.3.0-rc.2"},
+		{from: "1.3.0-rc.1", to: "1.3.0", preReleaseChanged: true, str: "pre-release 1.3.0-rc.1 -> 1.3.0"},
+		{from: "1.3.0+build.1", to: "1.3.0+build.2", metadataChanged: true, str: "metadata 1.3.0+build.1 -> 1.3.0+build.2"},
+		{from: "1.3.0", to: "1.3.0", str: "none 1.3.0 -> 1.3.0"},
+		{from: "1.3", to: "1.3.1", level: BumpPatch, str: "patch 1.3.0 -> 1.3.1"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.str, func(t *testing.T) {
+			d := NewVersionDelta(version.Must(version.NewVersion(tc.from)), version.Must(version.NewVersion(tc.to)))
+			assert.Equal(t, tc.level, d.Level)
+			assert.Equal(t, tc.preReleaseChanged, d.PreReleaseChanged)
+			assert.Equal(t, tc.metadataChanged, d.MetadataChanged)
+			assert.Equal(t, tc.str, d.String())
+		})
+	}
+
+	d := NewVersionDelta(nil, version.Must(version.NewVersion("1.0.0")))
+	assert.Equal(t, BumpNone, d.Level)
+	assert.Equal(t, "none -> 1.0.0", d.String())
+}
+
+func TestResultDelta(t *testing.T) {
+	r := newRepoFixture(t, GitRepoConfig{Scheme: "conventional", Prefix: true, PreReleaseName: "rc"},
+		seedCommit("v1.2.3"),
+		testCommit{msg: "feat: add login"},
+	)
+	d := r.Result().Delta()
+	assert.Equal(t, BumpMinor, d.Level)
+	assert.True(t, d.PreReleaseChanged)
+	assert.Equal(t, "minor 1.2.3 -> 1.3.0-rc", d.String())
+}
@@ -0,0 +1,25 @@
+package autotag
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gogs/git-module"
+)
+
+// ErrEmptyRepo is returned by NewRepo for a repository without commits, eg: one just initialized
+// by a scaffolding step
+var ErrEmptyRepo = errors.New("repository has no commits")
+
+// checkEmptyRepo returns ErrEmptyRepo if no ref of repo points to a commit
+func checkEmptyRepo(repo *git.Repository) error {
+	out, err := git.NewCommand("rev-list", "-n", "1", "--all").RunInDir(repo.Path())
+	if err != nil {
+		return fmt.Errorf("error reading the commits: %s", err)
+	}
+	if strings.TrimSpace(string(out)) == "" {
+		return fmt.Errorf("%w: %s", ErrEmptyRepo, repo.Path())
+	}
+	return nil
+}
@@ -0,0 +1,23 @@
+package autotag
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/alecthomas/assert"
+)
+
+func TestEmptyRepo(t *testing.T) {
+	dir := t.TempDir()
+	runGit(t, dir, "init", "-q")
+
+	for _, cfg := range []GitRepoConfig{
+		{},
+		{Branch: "master"},
+		{Branch: "master", Scheme: "scope-conventional", AllScopes: true},
+	} {
+		cfg.RepoPath = dir
+		_, err := NewRepo(cfg)
+		assert.True(t, errors.Is(err, ErrEmptyRepo), "%+v: %v", cfg, err)
+	}
+}
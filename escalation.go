@@ -0,0 +1,91 @@
+package autotag
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// EscalationRule raises the bump level of the commits it matches to at least Level, eg: a `fix`
+// closing a security issue to a minor bump for visibility. A commit matches when it matches all
+// the set conditions of Type, Scope and Footer, at least one must be set.
+type EscalationRule struct {
+	// Type is the conventional commit type to match, eg: `fix`, like the types of BumpRules
+	Type string
+	// Scope is the conventional commit scope to match, eg: `security`, matched case insensitive
+	Scope string
+	// Footer is the footer the body must contain, eg: `Security: true`. The key is matched case
+	// insensitive like with BumpFooters, the value as well.
+	Footer string
+	// Level is the minimum bump level of a matching commit
+	Level BumpLevel
+}
+
+// escalation is an EscalationRule prepared for matching
+type escalation struct {
+	rule   EscalationRule
+	typ    string
+	footer *regexp.Regexp
+}
+
+// newEscalations prepares the escalation rules, the types are normalized like the BumpRules
+func newEscalations(rules []EscalationRule, strictCase bool) []escalation {
+	escalations := make([]escalation, 0, len(rules))
+	for _, rule := range rules {
+		e := escalation{rule: rule, typ: normalizeType(rule.Type, strictCase)}
+		if key, value, ok := strings.Cut(rule.Footer, ":"); ok {
+			e.footer = regexp.MustCompile(`(?im)^` + regexp.QuoteMeta(key) + `:[ \t]*` + regexp.QuoteMeta(strings.TrimSpace(value)) + `[ \t]*$`)
+		}
+		escalations = append(escalations, e)
+	}
+	return escalations
+}
+
+// validateEscalationRule returns an error if the rule matches every commit or can't match any
+func validateEscalationRule(rule EscalationRule) error {
+	if rule.Type == "" && rule.Scope == "" && rule.Footer == "" {
+		return fmt.Errorf("escalation rule to %s matches every commit; set a type, scope or footer", rule.Level)
+	}
+	if rule.Type != "" && !commitTypeRex.MatchString(rule.Type) {
+		return fmt.Errorf("escalation rule type '%s' is not a valid commit type", rule.Type)
+	}
+	if rule.Footer != "" {
+		key, value, ok := strings.Cut(rule.Footer, ":")
+		if !ok || !footerKeyRex.MatchString(key) || strings.TrimSpace(value) == "" {
+			return fmt.Errorf("escalation rule footer '%s' is not a valid footer; must be 'Key: value'", rule.Footer)
+		}
+	}
+	if rule.Level < BumpPatch || rule.Level > BumpMajor {
+		return fmt.Errorf("escalation rule level %d is not valid", rule.Level)
+	}
+	return nil
+}
+
+// matches reports whether the commit message msg with the parsed header m matches the rule
+func (e escalation) matches(m CommitMessage, msg string, strictCase bool) bool {
+	if e.typ != "" && normalizeType(m.ype, strictCase) != e.typ {
+		return false
+	}
+	if e.rule.Scope != "" && !strings.EqualFold(m.scope, e.rule.Scope) {
+		return false
+	}
+	if e.footer != nil {
+		_, body, _ := strings.Cut(msg, "\n")
+		if !e.footer.MatchString(body) {
+			return false
+		}
+	}
+	return true
+}
+
+// escalate raises the level of d of the commit message msg with the parsed header m to the highest
+// Level of the EscalationRules it matches
+func (r *GitRepo) escalate(d bumpDecision, m CommitMessage, msg string) bumpDecision {
+	for _, e := range r.escalations {
+		if e.rule.Level > d.level && e.matches(m, msg, r.strictTypeCase) {
+			d.level = e.rule.Level
+			d.signal = fmt.Sprintf("%s, escalated to %s by an escalation rule", d.signal, e.rule.Level)
+		}
+	}
+	return d
+}
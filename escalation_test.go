@@ -0,0 +1,63 @@
+package autotag
+
+import (
+	"testing"
+
+	"github.com/alecthomas/assert"
+)
+
+func TestBumpEscalation(t *testing.T) {
+	rules := []EscalationRule{
+		{Type: "fix", Scope: "security", Level: BumpMinor},
+		{Footer: "Security: true", Level: BumpMinor},
+	}
+	tests := []struct {
+		name        string
+		commit      string
+		expectedTag string
+	}{
+		{name: "security fix", commit: "fix(security): escape the login form", expectedTag: "v1.1.0"},
+		{name: "plain fix", commit: "fix: correct typo", expectedTag: "v1.0.1"},
+		{name: "fix of another scope", commit: "fix(api): correct typo", expectedTag: "v1.0.1"},
+		{name: "security footer", commit: "fix: escape the login form\n\nsecurity: True", expectedTag: "v1.1.0"},
+		{name: "footer value", commit: "fix: escape the login form\n\nSecurity: false", expectedTag: "v1.0.1"},
+		{name: "never lowers", commit: "feat(security)!: drop md5", expectedTag: "v2.0.0"},
+		{name: "bump footer wins", commit: "fix(security): escape the login form\n\nRelease-As: patch", expectedTag: "v1.0.1"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := newRepoFixture(t, GitRepoConfig{Scheme: "conventional", Prefix: true, BumpEscalation: rules},
+				seedCommit("v1.0.0"),
+				testCommit{msg: tc.commit},
+			)
+			assert.Equal(t, tc.expectedTag, r.LatestVersion())
+		})
+	}
+}
+
+func TestBumpEscalationReason(t *testing.T) {
+	r := newRepoFixture(t, GitRepoConfig{
+		Scheme:         "conventional",
+		Prefix:         true,
+		BumpEscalation: []EscalationRule{{Type: "fix", Scope: "security", Level: BumpMinor}},
+	},
+		seedCommit("v1.0.0"),
+		testCommit{msg: "fix(security): escape the login form"},
+	)
+	assert.Contains(t, r.Result().BumpReason, "escalated to minor")
+}
+
+func TestValidateBumpEscalation(t *testing.T) {
+	dir := newRepoDir(t, seedCommit("v1.0.0"))
+	for _, rule := range []EscalationRule{
+		{Level: BumpMinor},
+		{Type: "fix me", Level: BumpMinor},
+		{Footer: "Security", Level: BumpMinor},
+		{Footer: "Security:", Level: BumpMinor},
+		{Type: "fix", Level: BumpNone},
+	} {
+		_, err := NewRepo(GitRepoConfig{RepoPath: dir, Branch: "master", Scheme: "conventional", BumpEscalation: []EscalationRule{rule}})
+		assert.Error(t, err, "rule %+v", rule)
+	}
+}
@@ -0,0 +1,44 @@
+package autotag
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// defaultBumpFooters are the footer keys that always override the bump level of a commit, the
+// BumpFooters are added, eg: `Release-As: minor` as used by release-please
+var defaultBumpFooters = []string{"Release-As"}
+
+// footerKeyRex matches a footer key: a word of alphanumerics and hyphens, eg: `Release-As`
+var footerKeyRex = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9-]*$`)
+
+// bumpFooterRegex returns the regex matching the footer key in a commit message body. Keys are
+// matched case insensitive, like git trailers, the value is the captured word.
+func bumpFooterRegex(key string) *regexp.Regexp {
+	return regexp.MustCompile(`(?im)^` + regexp.QuoteMeta(key) + `:[ \t]*(\S+)[ \t]*$`)
+}
+
+// footerDecision returns the decision of the bump footers of msg, see BumpFooters. Of several
+// footers the highest level wins. A footer whose value isn't a bump level, eg: the version of
+// `Release-As: 2.0.0`, is ignored.
+func (r *GitRepo) footerDecision(msg string) (bumpDecision, bool) {
+	_, body, _ := strings.Cut(msg, "\n")
+	var d bumpDecision
+	found := false
+	for _, rex := range r.bumpFooters {
+		for _, m := range rex.FindAllStringSubmatch(body, -1) {
+			level, err := ParseBumpLevel(strings.ToLower(m[1]))
+			if err != nil {
+				r.debugf("ignoring footer %s: %s\n", strings.TrimSpace(m[0]), err)
+				continue
+			}
+			if !found || level > d.level {
+				key, _, _ := strings.Cut(strings.TrimSpace(m[0]), ":")
+				d = bumpDecision{level: level, signal: fmt.Sprintf("has a %s: %s footer", key, m[1])}
+				found = true
+			}
+		}
+	}
+	return d, found
+}
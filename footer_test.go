@@ -0,0 +1,88 @@
+package autotag
+
+import (
+	"testing"
+
+	"github.com/alecthomas/assert"
+)
+
+func TestBumpFooters(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         GitRepoConfig
+		tags        []string
+		commit      string
+		expectedTag string
+	}{
+		{
+			name:        "Release-As major on a fix",
+			cfg:         GitRepoConfig{Scheme: "conventional"},
+			tags:        []string{"v1.0.0"},
+			commit:      "fix: correct typo\n\nRelease-As: major",
+			expectedTag: "v2.0.0",
+		},
+		{
+			name:        "lowers the level of a breaking change",
+			cfg:         GitRepoConfig{Scheme: "conventional"},
+			tags:        []string{"v1.0.0"},
+			commit:      "feat!: drop the old API\n\nrelease-as: Minor",
+			expectedTag: "v1.1.0",
+		},
+		{
+			name:        "version value is ignored",
+			cfg:         GitRepoConfig{Scheme: "conventional"},
+			tags:        []string{"v1.0.0"},
+			commit:      "fix: correct typo\n\nRelease-As: 2.0.0",
+			expectedTag: "v1.0.1",
+		},
+		{
+			name:        "not a footer in the header",
+			cfg:         GitRepoConfig{Scheme: "conventional"},
+			tags:        []string{"v1.0.0"},
+			commit:      "Release-As: major",
+			expectedTag: "v1.0.1",
+		},
+		{
+			name:        "configured footer",
+			cfg:         GitRepoConfig{Scheme: "conventional", BumpFooters: []string{"Bump"}},
+			tags:        []string{"v1.0.0"},
+			commit:      "fix: correct typo\n\nBump: minor",
+			expectedTag: "v1.1.0",
+		},
+		{
+			name:        "scope",
+			cfg:         GitRepoConfig{Scheme: "scope-conventional"},
+			tags:        []string{"api-v1.0.0"},
+			commit:      "fix(api): correct typo\n\nRelease-As: major",
+			expectedTag: "api-v2.0.0",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.cfg.Prefix = true
+			r := newRepoFixture(t, tc.cfg,
+				seedCommit(tc.tags...),
+				testCommit{msg: tc.commit},
+			)
+			assert.Equal(t, tc.expectedTag, r.LatestVersion())
+		})
+	}
+}
+
+func TestBumpFooterReason(t *testing.T) {
+	r := newRepoFixture(t, GitRepoConfig{Scheme: "conventional", Prefix: true},
+		seedCommit("v1.0.0"),
+		testCommit{msg: "fix: correct typo\n\nRelease-As: major"},
+	)
+	res := r.Result()
+	assert.Contains(t, res.BumpReason, "has a Release-As: major footer")
+}
+
+func TestValidateBumpFooters(t *testing.T) {
+	dir := newRepoDir(t, seedCommit("v1.0.0"))
+	for _, key := range []string{"", "Release As", "Release-As:"} {
+		_, err := NewRepo(GitRepoConfig{RepoPath: dir, Branch: "master", Scheme: "conventional", BumpFooters: []string{key}})
+		assert.Error(t, err, "footer %q", key)
+	}
+}
@@ -7,12 +7,14 @@ import (
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gogs/git-module"
 )
 
-func checkFatal(t *testing.T, err error) {
+func checkFatal(t testing.TB, err error) {
 	if err == nil {
 		return
 	}
@@ -25,7 +27,7 @@ func checkFatal(t *testing.T, err error) {
 	t.Fatalf("Fail at %v:%v; %v", file, line, err)
 }
 
-func createTestRepo(t *testing.T, branch string) string {
+func createTestRepo(t testing.TB, branch string) string {
 	// figure out where we can create the test repo
 	tmp := t.TempDir()
 	path := filepath.Join(tmp, "autoTagTest")
@@ -51,7 +53,7 @@ func createTestRepo(t *testing.T, branch string) string {
 	return path
 }
 
-func cleanupTestRepo(t *testing.T, r *git.Repository) {
+func cleanupTestRepo(t testing.TB, r *git.Repository) {
 	var err error
 	root := repoRoot(r)
 	fmt.Println("Cleaning up test repo:", root)
@@ -78,6 +80,37 @@ func makeCommit(r *git.Repository, msg string) {
 	}
 }
 
+// commitFiles writes the files, relative to the repo root, and commits them with msg
+func commitFiles(t testing.TB, r *git.Repository, msg string, files ...string) {
+	p := repoRoot(r)
+	for _, f := range files {
+		path := filepath.Join(p, filepath.FromSlash(f))
+		checkFatal(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		checkFatal(t, os.WriteFile(path, []byte(msg), 0o644))
+	}
+	makeCommit(r, msg)
+}
+
+// makeCommitAt commits all changes with msg, using when as the author and committer date
+func makeCommitAt(t *testing.T, r *git.Repository, msg string, when time.Time) {
+	p := repoRoot(r)
+	err := os.WriteFile(filepath.Join(p, "README"), []byte(msg), 0o644)
+	checkFatal(t, err)
+
+	cmd := exec.Command("git", "add", "-A")
+	cmd.Dir = p
+	checkFatal(t, cmd.Run())
+
+	date := when.Format(time.RFC3339)
+	cmd = exec.Command("git", "commit", "-m", msg)
+	cmd.Dir = p
+	cmd.Env = append(os.Environ(), "GIT_AUTHOR_DATE="+date, "GIT_COMMITTER_DATE="+date)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("make commit failed: %s: %s", err, out)
+	}
+}
+
 func makeTag(r *git.Repository, tag string) {
 	p := repoRoot(r)
 	cmd := exec.Command("git", "tag", tag)
@@ -89,6 +122,45 @@ func makeTag(r *git.Repository, tag string) {
 	}
 }
 
+// makeMerge commits msgs on a new branch and merges it back with a merge commit carrying mergeMsg
+func makeMerge(t *testing.T, r *git.Repository, branch string, msgs []string, mergeMsg string) {
+	p := repoRoot(r)
+	run := func(args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = p
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v failed: %s: %s", args, err, out)
+		}
+	}
+
+	cmd := exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD")
+	cmd.Dir = p
+	out, err := cmd.Output()
+	checkFatal(t, err)
+	base := strings.TrimSpace(string(out))
+
+	run("checkout", "-b", branch)
+	for i, msg := range msgs {
+		err := os.WriteFile(filepath.Join(p, fmt.Sprintf("%s-%d", strings.ReplaceAll(branch, "/", "-"), i)), []byte(msg), 0o644)
+		checkFatal(t, err)
+		makeCommit(r, msg)
+	}
+	run("checkout", base)
+	run("merge", "--no-ff", "-m", mergeMsg, branch)
+}
+
+// makeAnnotatedTagAt creates an annotated tag on HEAD, using when as the tagger date
+func makeAnnotatedTagAt(t *testing.T, r *git.Repository, tag string, when time.Time) {
+	cmd := exec.Command("git", "tag", "-a", tag, "-m", "release "+tag)
+	cmd.Dir = repoRoot(r)
+	cmd.Env = append(os.Environ(), "GIT_COMMITTER_DATE="+when.Format(time.RFC3339))
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("tag creation failed: %s: %s", err, out)
+	}
+}
+
 func seedTestRepo(t *testing.T, tag string, repo *git.Repository) {
 	f := repoRoot(repo) + "/README"
 	err := exec.Command("touch", f).Run()
@@ -121,3 +193,76 @@ func repoRoot(r *git.Repository) string {
 	}
 	return p
 }
+
+// testCommit is a commit of a repo fixture: its message, the files it writes relative to the repo
+// root (the README if none) and the tags created on it
+type testCommit struct {
+	msg   string
+	files []string
+	tags  []string
+}
+
+// seedCommit is the first commit of a repo fixture, with the tags of the released versions
+func seedCommit(tags ...string) testCommit {
+	return testCommit{msg: "this is a commit", tags: tags}
+}
+
+// newRepoDir creates a test repo with the commits, oldest first, on the master branch and returns
+// the path of its work tree. The repo is removed when the test ends.
+func newRepoDir(t testing.TB, commits ...testCommit) string {
+	t.Helper()
+	tr := createTestRepo(t, "")
+	repo, err := git.Open(tr)
+	checkFatal(t, err)
+	t.Cleanup(func() { cleanupTestRepo(t, repo) })
+
+	for _, c := range commits {
+		files := c.files
+		if len(files) == 0 {
+			files = []string{"README"}
+		}
+		commitFiles(t, repo, c.msg, files...)
+		for _, tag := range c.tags {
+			cmd := exec.Command("git", "tag", tag)
+			cmd.Dir = tr
+			if out, err := cmd.CombinedOutput(); err != nil {
+				t.Fatalf("tag creation failed: %s: %s", err, out)
+			}
+		}
+	}
+	return tr
+}
+
+// previewTags returns the tags of the Preview of r by scope, empty for the scopes without a
+// release, and the errors of the scopes that failed, nil if none did
+func previewTags(t testing.TB, r *GitRepo) (map[string]string, map[string]error) {
+	t.Helper()
+	results, err := r.Preview()
+	checkFatal(t, err)
+	tags := make(map[string]string, len(results))
+	var errs map[string]error
+	for _, res := range results {
+		if res.Err != nil {
+			if errs == nil {
+				errs = make(map[string]error)
+			}
+			errs[res.Scope] = res.Err
+			continue
+		}
+		tags[res.Scope] = res.Tag
+	}
+	return tags, errs
+}
+
+// newRepoFixture creates a test repo with the commits like newRepoDir and opens it with cfg, on
+// the master branch unless cfg sets another
+func newRepoFixture(t testing.TB, cfg GitRepoConfig, commits ...testCommit) *GitRepo {
+	t.Helper()
+	cfg.RepoPath = newRepoDir(t, commits...)
+	if cfg.Branch == "" {
+		cfg.Branch = "master"
+	}
+	r, err := NewRepo(cfg)
+	checkFatal(t, err)
+	return r
+}
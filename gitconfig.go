@@ -0,0 +1,116 @@
+package autotag
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gogs/git-module"
+)
+
+// gitConfigPrefix is the namespace of the git config keys read with ReadGitConfig
+const gitConfigPrefix = "autotag."
+
+// gitConfigKeys map the git config keys, without the `autotag.` prefix and lowercased as git
+// reports them, to the option they set. An option is only set if it has no explicit value, the
+// keys of list options may be given several times.
+var gitConfigKeys = map[string]func(cfg *GitRepoConfig, value string) error{
+	"branch":         setString(func(cfg *GitRepoConfig) *string { return &cfg.Branch }),
+	"scheme":         setString(func(cfg *GitRepoConfig) *string { return &cfg.Scheme }),
+	"prerelease":     setString(func(cfg *GitRepoConfig) *string { return &cfg.PreReleaseName }),
+	"timestamp":      setString(func(cfg *GitRepoConfig) *string { return &cfg.PreReleaseTimestampLayout }),
+	"buildmetadata":  setString(func(cfg *GitRepoConfig) *string { return &cfg.BuildMetadata }),
+	"tagformat":      setString(func(cfg *GitRepoConfig) *string { return &cfg.TagFormat }),
+	"initialversion": setString(func(cfg *GitRepoConfig) *string { return &cfg.InitialVersion }),
+	"prefix":         setBool(func(cfg *GitRepoConfig) *bool { return &cfg.Prefix }),
+	"stablebranch": func(cfg *GitRepoConfig, value string) error {
+		cfg.StableBranches = append(cfg.StableBranches, value)
+		return nil
+	},
+	"ignoredtype": func(cfg *GitRepoConfig, value string) error {
+		cfg.IgnoredTypes = append(cfg.IgnoredTypes, value)
+		return nil
+	},
+	"bumprule": func(cfg *GitRepoConfig, value string) error {
+		typ, name, ok := strings.Cut(value, ":")
+		if !ok {
+			return fmt.Errorf("bump rule '%s' is not valid; must be <type>:<level>", value)
+		}
+		level, err := ParseBumpLevel(name)
+		if err != nil {
+			return err
+		}
+		if cfg.BumpRules == nil {
+			cfg.BumpRules = make(BumpRules)
+		}
+		cfg.BumpRules[typ] = level
+		return nil
+	},
+}
+
+// setString returns the setter of the string option field, which keeps an explicit value
+func setString(field func(cfg *GitRepoConfig) *string) func(cfg *GitRepoConfig, value string) error {
+	return func(cfg *GitRepoConfig, value string) error {
+		if s := field(cfg); *s == "" {
+			*s = value
+		}
+		return nil
+	}
+}
+
+// setBool returns the setter of the bool option field. Like the CLI flags git config can only turn
+// an option on, an explicit true is kept.
+func setBool(field func(cfg *GitRepoConfig) *bool) func(cfg *GitRepoConfig, value string) error {
+	return func(cfg *GitRepoConfig, value string) error {
+		switch strings.ToLower(value) {
+		case "true", "yes", "on", "1", "":
+			*field(cfg) = true
+		case "false", "no", "off", "0":
+		default:
+			return fmt.Errorf("'%s' is not a boolean", value)
+		}
+		return nil
+	}
+}
+
+// applyGitConfig sets the options of cfg without an explicit value from the `autotag.*` keys of the
+// git config of the repo, see ReadGitConfig. The list options of cfg, eg: StableBranches, are only
+// read from git config if they are empty.
+func applyGitConfig(cfg GitRepoConfig) (GitRepoConfig, error) {
+	gitDir, err := generateGitDirPath(cfg.RepoPath)
+	if err != nil {
+		return cfg, err
+	}
+	out, err := git.NewCommand("config", "--get-regexp", `^autotag\.`).RunInDir(gitDir)
+	if err != nil {
+		// exit code 1: none of the keys is set
+		if strings.Contains(err.Error(), "exit status 1") {
+			return cfg, nil
+		}
+		return cfg, fmt.Errorf("error reading the git config: %s", err)
+	}
+
+	explicit := GitRepoConfig{StableBranches: cfg.StableBranches, IgnoredTypes: cfg.IgnoredTypes, BumpRules: cfg.BumpRules}
+	cfg.StableBranches, cfg.IgnoredTypes, cfg.BumpRules = nil, nil, nil
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		key, value, _ := strings.Cut(line, " ")
+		name := strings.TrimPrefix(key, gitConfigPrefix)
+		set, ok := gitConfigKeys[name]
+		if !ok {
+			return cfg, fmt.Errorf("git config key '%s' is not a known autotag option", key)
+		}
+		if err := set(&cfg, value); err != nil {
+			return cfg, fmt.Errorf("git config key '%s': %s", key, err)
+		}
+		logf(cfg.Verbosity, VerbosityDebug, "read option %s from git config", key)
+	}
+	if explicit.StableBranches != nil {
+		cfg.StableBranches = explicit.StableBranches
+	}
+	if explicit.IgnoredTypes != nil {
+		cfg.IgnoredTypes = explicit.IgnoredTypes
+	}
+	if explicit.BumpRules != nil {
+		cfg.BumpRules = explicit.BumpRules
+	}
+	return cfg, nil
+}
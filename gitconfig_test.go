@@ -0,0 +1,51 @@
+package autotag
+
+import (
+	"testing"
+
+	"github.com/alecthomas/assert"
+)
+
+func TestReadGitConfig(t *testing.T) {
+	dir := newRepoDir(t,
+		seedCommit("v1.0.0"),
+		testCommit{msg: "perf: cache the login"},
+	)
+	runGit(t, dir, "config", "autotag.scheme", "conventional")
+	runGit(t, dir, "config", "autotag.prerelease", "rc")
+	runGit(t, dir, "config", "--add", "autotag.bumpRule", "perf:minor")
+	runGit(t, dir, "config", "--add", "autotag.bumpRule", "docs:none")
+
+	tests := []struct {
+		name        string
+		cfg         GitRepoConfig
+		expectedTag string
+	}{
+		{name: "without", cfg: GitRepoConfig{Scheme: "conventional"}, expectedTag: "v1.0.1"},
+		{name: "overrides the defaults", cfg: GitRepoConfig{ReadGitConfig: true}, expectedTag: "v1.1.0-rc"},
+		{name: "explicit options win", cfg: GitRepoConfig{ReadGitConfig: true, PreReleaseName: "beta", BumpRules: BumpRules{"perf": BumpMajor}}, expectedTag: "v2.0.0-beta"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.cfg.RepoPath, tc.cfg.Branch, tc.cfg.Prefix = dir, "master", true
+			r, err := NewRepo(tc.cfg)
+			checkFatal(t, err)
+			assert.Equal(t, tc.expectedTag, r.LatestVersion())
+		})
+	}
+}
+
+func TestReadGitConfigErrors(t *testing.T) {
+	for _, kv := range [][2]string{{"autotag.prerelase", "rc"}, {"autotag.bumprule", "perf"}, {"autotag.prefix", "maybe"}} {
+		dir := newRepoDir(t, seedCommit("v1.0.0"))
+		runGit(t, dir, "config", kv[0], kv[1])
+		_, err := NewRepo(GitRepoConfig{RepoPath: dir, Branch: "master", ReadGitConfig: true})
+		assert.Error(t, err, kv[0])
+	}
+
+	// no autotag keys at all
+	dir := newRepoDir(t, seedCommit("v1.0.0"))
+	_, err := NewRepo(GitRepoConfig{RepoPath: dir, Branch: "master", ReadGitConfig: true})
+	checkFatal(t, err)
+}
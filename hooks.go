@@ -0,0 +1,29 @@
+package autotag
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrPostTagHook is returned by AutoTag when one of the PostTagHooks fails, unless PostTagHookWarn
+// is set. The tag is created (and pushed) already.
+var ErrPostTagHook = errors.New("post tag hook failed")
+
+// PostTagHook is called with the result of a tag once it is created (and pushed with RemoteTags),
+// eg: to send a notification or call a webhook
+type PostTagHook func(res Result) error
+
+// runPostTagHooks calls the PostTagHooks with res in order. The first failing hook stops the others
+// and its error is returned, with PostTagHookWarn all hooks run and the errors are only logged.
+func (r *GitRepo) runPostTagHooks(res Result) error {
+	for i, hook := range r.postTagHooks {
+		if err := hook(res); err != nil {
+			if r.postTagHookWarn {
+				r.infof("post tag hook %d of %s failed: %s\n", i, res.Tag, err)
+				continue
+			}
+			return fmt.Errorf("%w: hook %d of %s: %s", ErrPostTagHook, i, res.Tag, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package autotag
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/alecthomas/assert"
+)
+
+func TestPostTagHooks(t *testing.T) {
+	var calls []string
+	hook := func(name string) PostTagHook {
+		return func(res Result) error {
+			calls = append(calls, name+" "+res.Tag+" "+res.Current.String())
+			return nil
+		}
+	}
+
+	tagger := &fakeTagger{}
+	r := newRepoFixture(t, GitRepoConfig{Scheme: "conventional", Prefix: true, Tagger: tagger, PostTagHooks: []PostTagHook{hook("a"), hook("b")}},
+		seedCommit("v1.0.0"),
+		testCommit{msg: "feat: add login"},
+	)
+	assert.Equal(t, 0, len(calls))
+	checkFatal(t, r.AutoTag())
+	assert.Equal(t, []string{"a v1.1.0 1.0.0", "b v1.1.0 1.0.0"}, calls)
+}
+
+func TestPostTagHookErrors(t *testing.T) {
+	failing := func(Result) error { return errors.New("webhook unavailable") }
+
+	tests := []struct {
+		name          string
+		warn          bool
+		expectedCalls int
+		expectedError error
+	}{
+		{name: "fatal", expectedCalls: 0, expectedError: ErrPostTagHook},
+		{name: "warning", warn: true, expectedCalls: 1},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			calls := 0
+			counting := func(Result) error { calls++; return nil }
+			tagger := &fakeTagger{}
+			r := newRepoFixture(t, GitRepoConfig{
+				Scheme:          "conventional",
+				Tagger:          tagger,
+				PostTagHooks:    []PostTagHook{failing, counting},
+				PostTagHookWarn: tc.warn,
+			},
+				seedCommit("v1.0.0"),
+				testCommit{msg: "fix: correct typo"},
+			)
+
+			err := r.AutoTag()
+			if tc.expectedError != nil {
+				assert.True(t, errors.Is(err, tc.expectedError), "expected %v, got %v", tc.expectedError, err)
+			} else {
+				checkFatal(t, err)
+			}
+			// the tag is created either way
+			assert.Equal(t, 1, len(tagger.created))
+			assert.Equal(t, tc.expectedCalls, calls)
+		})
+	}
+}
+
+func TestPostTagHooksAllScopes(t *testing.T) {
+	var tags []string
+	hook := func(res Result) error {
+		tags = append(tags, res.Tag)
+		return nil
+	}
+
+	tagger := &fakeTagger{err: errors.New("tag exists"), failOn: "worker-1.0.1"}
+	r := newRepoFixture(t, GitRepoConfig{
+		Scheme:       "scope-conventional",
+		AllScopes:    true,
+		Tagger:       tagger,
+		PostTagHooks: []PostTagHook{hook},
+		OnScopeError: func(string, error) ScopeErrorAction { return ScopeErrorSkip },
+	},
+		seedCommit("api-v1.0.0", "web-v1.0.0", "worker-v1.0.0"),
+		testCommit{msg: "fix(web): correct typo"},
+		testCommit{msg: "fix(worker): correct typo"},
+		testCommit{msg: "feat(api): add login"},
+	)
+	_, err := r.AutoTagScopes()
+	checkFatal(t, err)
+	assert.Equal(t, []string{"api-1.1.0", "web-1.0.1"}, tags)
+}
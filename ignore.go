@@ -0,0 +1,124 @@
+package autotag
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/gogs/git-module"
+)
+
+// IgnoreFile is the file in the repository root listing commits excluded from the version bump,
+// one per line: a commit SHA (or a prefix of it) or `author: <pattern>`. Empty lines and lines
+// starting with `#` are skipped.
+const IgnoreFile = ".autotagignore"
+
+// commitSHARex matches a (prefix of a) commit SHA
+var commitSHARex = regexp.MustCompile(`^[0-9a-fA-F]{4,64}$`)
+
+// readIgnoreFile returns the commits and author patterns listed in the ignore file of the work
+// tree. A missing file ignores nothing.
+func readIgnoreFile(workTree string) (commits, authors []string, err error) {
+	f, err := os.Open(filepath.Join(workTree, IgnoreFile))
+	if os.IsNotExist(err) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	s := bufio.NewScanner(f)
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if author, ok := strings.CutPrefix(line, "author:"); ok {
+			authors = append(authors, strings.TrimSpace(author))
+			continue
+		}
+		if !commitSHARex.MatchString(line) {
+			return nil, nil, fmt.Errorf("%s:%d: '%s' is neither a commit SHA nor an author pattern", IgnoreFile, n, line)
+		}
+		commits = append(commits, line)
+	}
+	return commits, authors, s.Err()
+}
+
+// authorPatternRex compiles an author pattern: the name or email of the author, case insensitive,
+// where `*` matches any characters, eg: `dependabot[bot]` or `*@bots.example.com`
+func authorPatternRex(pattern string) *regexp.Regexp {
+	quoted := strings.ReplaceAll(regexp.QuoteMeta(pattern), `\*`, ".*")
+	return regexp.MustCompile(`(?i)^` + quoted + `$`)
+}
+
+// emailPatternRex compiles an email pattern of AuthorEmailFilter like an author pattern, a domain
+// without `@` matches the emails of the domain, eg: `example.com` is `*@example.com`
+func emailPatternRex(pattern string) *regexp.Regexp {
+	if !strings.Contains(pattern, "@") {
+		pattern = "*@" + pattern
+	}
+	return authorPatternRex(pattern)
+}
+
+// allowedEmail reports whether the author or committer of commit has an email of the
+// AuthorEmailFilter, all commits are allowed without a filter
+func (r *GitRepo) allowedEmail(commit *git.Commit) bool {
+	if len(r.allowedEmails) == 0 {
+		return true
+	}
+	for _, sig := range []*git.Signature{commit.Author, commit.Committer} {
+		if sig == nil {
+			continue
+		}
+		for _, rex := range r.allowedEmails {
+			if rex.MatchString(sig.Email) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ignoredCommit reports whether commit is excluded from the version bump by its SHA, its author,
+// the AuthorEmailFilter or, with RequireSignedCommits, a signature that doesn't verify
+func (r *GitRepo) ignoredCommit(commit *git.Commit) bool {
+	if r.ignoredByRules(commit) {
+		return true
+	}
+	if r.signatures != nil && !r.signedCommit(commit) {
+		r.debugf("skipping commit %s without a verified signature\n", commit.ID)
+		return true
+	}
+	return false
+}
+
+// ignoredByRules reports whether commit is excluded from the version bump by its SHA, its author or
+// the AuthorEmailFilter
+func (r *GitRepo) ignoredByRules(commit *git.Commit) bool {
+	id := commit.ID.String()
+	for _, sha := range r.ignoreCommits {
+		if strings.HasPrefix(id, sha) {
+			r.debugf("skipping ignored commit %s\n", commit.ID)
+			return true
+		}
+	}
+	if !r.allowedEmail(commit) {
+		r.debugf("skipping commit %s of an email outside the author email filter\n", commit.ID)
+		return true
+	}
+	if commit.Author == nil {
+		return false
+	}
+	for _, rex := range r.ignoreAuthors {
+		if rex.MatchString(commit.Author.Name) || rex.MatchString(commit.Author.Email) {
+			r.debugf("skipping commit %s of ignored author %s\n", commit.ID, commit.Author.Name)
+			return true
+		}
+	}
+	return false
+}
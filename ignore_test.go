@@ -0,0 +1,269 @@
+package autotag
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/assert"
+	"github.com/gogs/git-module"
+)
+
+const botAuthor = "dependabot[bot] <49699333+dependabot[bot]@users.noreply.github.com>"
+
+// commitAs commits a change of the README of the work tree dir with msg, authored by author
+func commitAs(t *testing.T, dir, author, msg string) string {
+	checkFatal(t, os.WriteFile(filepath.Join(dir, "README"), []byte(msg), 0o644))
+	runGit(t, dir, "add", "-A")
+	runGit(t, dir, "commit", "--author", author, "-m", msg)
+
+	cmd := exec.Command("git", "rev-parse", "HEAD")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	checkFatal(t, err)
+	return strings.TrimSpace(string(out))
+}
+
+func TestIgnoredCommits(t *testing.T) {
+	tests := []struct {
+		name          string
+		ignoreAuthors []string
+		ignoreCommit  bool
+		ignoreFile    string
+		expectedTag   string
+	}{
+		{
+			name:        "bot commits count by default",
+			expectedTag: "v1.1.0",
+		},
+		{
+			name:          "ignored author",
+			ignoreAuthors: []string{"dependabot[bot]"},
+			expectedTag:   "v1.0.1",
+		},
+		{
+			name:          "ignored author email pattern",
+			ignoreAuthors: []string{"*@USERS.noreply.github.com"},
+			expectedTag:   "v1.0.1",
+		},
+		{
+			name:         "ignored commit",
+			ignoreCommit: true,
+			expectedTag:  "v1.0.1",
+		},
+		{
+			name:        "ignore file",
+			ignoreFile:  "# bots\n\nauthor: *[bot]\n",
+			expectedTag: "v1.0.1",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tr := createTestRepo(t, "")
+			repo, err := git.Open(tr)
+			checkFatal(t, err)
+			defer cleanupTestRepo(t, repo)
+
+			seedTestRepo(t, "v1.0.0", repo)
+			sha := commitAs(t, repoRoot(repo), botAuthor, "feat: bump dependencies")
+			updateReadme(t, repo, "fix: correct typo")
+			if tc.ignoreFile != "" {
+				checkFatal(t, os.WriteFile(filepath.Join(tr, IgnoreFile), []byte(tc.ignoreFile), 0o644))
+			}
+
+			cfg := GitRepoConfig{
+				RepoPath:      repo.Path(),
+				Branch:        "master",
+				Scheme:        "conventional",
+				Prefix:        true,
+				IgnoreAuthors: tc.ignoreAuthors,
+			}
+			if tc.ignoreCommit {
+				cfg.IgnoreCommits = []string{sha[:8]}
+			}
+			r, err := NewRepo(cfg)
+			checkFatal(t, err)
+			assert.Equal(t, tc.expectedTag, r.LatestVersion())
+		})
+	}
+}
+
+func TestScopeSchemeIgnoredCommit(t *testing.T) {
+	tr := createTestRepo(t, "")
+	repo, err := git.Open(tr)
+	checkFatal(t, err)
+	defer cleanupTestRepo(t, repo)
+
+	seedTestRepo(t, "api-v1.0.0", repo)
+	commitAs(t, repoRoot(repo), botAuthor, "feat(api): bump dependencies")
+
+	_, err = NewRepo(GitRepoConfig{
+		RepoPath:      repo.Path(),
+		Branch:        "master",
+		Scheme:        "scope-conventional",
+		IgnoreAuthors: []string{"dependabot[bot]"},
+	})
+	assert.True(t, errors.Is(err, ErrIgnoredCommit), "expected ErrIgnoredCommit, got %v", err)
+}
+
+func TestSkipEmptyRelease(t *testing.T) {
+	tests := []struct {
+		name          string
+		fix           bool
+		ignoredTypes  []string
+		override      string
+		bump          BumpLevel
+		expectedTag   string
+		expectedError error
+	}{
+		{
+			name:          "all commits filtered",
+			expectedError: ErrNoBump,
+		},
+		{
+			name:        "qualifying commit left",
+			fix:         true,
+			expectedTag: "v1.0.1",
+		},
+		{
+			name:          "ignored types",
+			fix:           true,
+			ignoredTypes:  []string{"fix"},
+			expectedError: ErrNoBump,
+		},
+		{
+			name:        "qualifying pending commit",
+			override:    "fix: correct typo",
+			expectedTag: "v1.0.1",
+		},
+		{
+			name:        "forced bump",
+			bump:        BumpMinor,
+			expectedTag: "v1.1.0",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tr := createTestRepo(t, "")
+			repo, err := git.Open(tr)
+			checkFatal(t, err)
+			defer cleanupTestRepo(t, repo)
+
+			seedTestRepo(t, "v1.0.0", repo)
+			commitAs(t, repoRoot(repo), botAuthor, "feat: bump dependencies")
+			if tc.fix {
+				updateReadme(t, repo, "fix: correct typo")
+			}
+
+			r, err := NewRepo(GitRepoConfig{
+				RepoPath:         repo.Path(),
+				Branch:           "master",
+				Scheme:           "conventional",
+				Prefix:           true,
+				IgnoreAuthors:    []string{"dependabot[bot]"},
+				IgnoredTypes:     tc.ignoredTypes,
+				OverrideMessage:  tc.override,
+				Bump:             tc.bump,
+				SkipEmptyRelease: true,
+			})
+			if tc.expectedError != nil {
+				assert.True(t, errors.Is(err, tc.expectedError), "expected %v, got %v", tc.expectedError, err)
+				return
+			}
+			checkFatal(t, err)
+			assert.Equal(t, tc.expectedTag, r.LatestVersion())
+		})
+	}
+}
+
+func TestReadIgnoreFile(t *testing.T) {
+	dir := t.TempDir()
+	commits, authors, err := readIgnoreFile(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(commits)+len(authors))
+
+	checkFatal(t, os.WriteFile(filepath.Join(dir, IgnoreFile), []byte("1a2b3c4d\n# comment\nauthor: renovate[bot]\n"), 0o644))
+	commits, authors, err = readIgnoreFile(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1a2b3c4d"}, commits)
+	assert.Equal(t, []string{"renovate[bot]"}, authors)
+
+	checkFatal(t, os.WriteFile(filepath.Join(dir, IgnoreFile), []byte("renovate[bot]\n"), 0o644))
+	_, _, err = readIgnoreFile(dir)
+	assert.Error(t, err)
+}
+
+// commitByExternal commits a change of the README with msg, authored by an external contributor
+// and committed by committer
+func commitByExternal(t *testing.T, r *git.Repository, committer, msg string) {
+	p := repoRoot(r)
+	checkFatal(t, os.WriteFile(filepath.Join(p, "README"), []byte(msg), 0o644))
+	runGit(t, p, "add", "-A")
+	cmd := exec.Command("git", "commit", "--author", "Jane Doe <jane@external.org>", "-m", msg)
+	cmd.Dir = p
+	cmd.Env = append(os.Environ(), "GIT_COMMITTER_NAME=committer", "GIT_COMMITTER_EMAIL="+committer)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("commit failed: %s: %s", err, out)
+	}
+}
+
+func TestAuthorEmailFilter(t *testing.T) {
+	tests := []struct {
+		name        string
+		filter      []string
+		committer   string
+		expectedTag string
+	}{
+		{
+			name:        "no filter",
+			committer:   "jane@external.org",
+			expectedTag: "v1.1.0",
+		},
+		{
+			name:        "external domain excluded",
+			filter:      []string{"example.com"},
+			committer:   "jane@external.org",
+			expectedTag: "v1.0.1",
+		},
+		{
+			name:        "merged by a maintainer",
+			filter:      []string{"example.com"},
+			committer:   "maintainer@EXAMPLE.com",
+			expectedTag: "v1.1.0",
+		},
+		{
+			name:        "email pattern",
+			filter:      []string{"*@example.com", "jane@external.org"},
+			committer:   "jane@external.org",
+			expectedTag: "v1.1.0",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tr := createTestRepo(t, "")
+			repo, err := git.Open(tr)
+			checkFatal(t, err)
+			defer cleanupTestRepo(t, repo)
+
+			seedTestRepo(t, "v1.0.0", repo)
+			commitByExternal(t, repo, tc.committer, "feat: add login")
+			commitAs(t, repoRoot(repo), "Maintainer <maintainer@example.com>", "fix: correct typo")
+
+			r, err := NewRepo(GitRepoConfig{
+				RepoPath:          repo.Path(),
+				Branch:            "master",
+				Scheme:            "conventional",
+				Prefix:            true,
+				AuthorEmailFilter: tc.filter,
+			})
+			checkFatal(t, err)
+			assert.Equal(t, tc.expectedTag, r.LatestVersion())
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package autotag
+
+import (
+	"sort"
+
+	"github.com/gogs/git-module"
+	"github.com/hashicorp/go-version"
+)
+
+// InventoryTag is a version tag of the repo, see TagInventory
+type InventoryTag struct {
+	// Scope of the tag with the "scope-conventional" scheme, empty otherwise
+	Scope string
+	// Version parsed from the tag
+	Version *version.Version
+	// Tag is the name of the tag, relative to the version ref namespace
+	Tag string
+	// Commit is the SHA of the tagged commit, empty for a release without a tag in the repo
+	Commit string
+	// Tagger is who created the tag with ReportTaggers, see GitRepoConfig.ReportTaggers, nil
+	// otherwise
+	Tagger *git.Signature
+}
+
+// TagInventory returns the version tags of the repo, sorted by scope, then by version from the
+// highest, then by tag name, so the output is the same on every run, eg: for golden files. The
+// tags that aren't version tags are left out.
+func (r *GitRepo) TagInventory() ([]InventoryTag, error) {
+	tags, err := r.loadTags()
+	if err != nil {
+		return nil, err
+	}
+
+	var inventory []InventoryTag
+	for _, versions := range tags {
+		for v, ref := range versions {
+			tag := InventoryTag{Scope: ref.scope, Version: v, Tag: ref.name}
+			c, err := r.tagRefCommit(ref)
+			if err != nil {
+				return nil, err
+			}
+			if c != nil {
+				tag.Commit = c.ID.String()
+			}
+			if r.reportTaggers {
+				if tag.Tagger, err = r.tagCreator(ref); err != nil {
+					return nil, err
+				}
+			}
+			inventory = append(inventory, tag)
+		}
+	}
+	sort.Slice(inventory, func(i, j int) bool {
+		a, b := inventory[i], inventory[j]
+		if a.Scope != b.Scope {
+			return a.Scope < b.Scope
+		}
+		if c := a.Version.Compare(b.Version); c != 0 {
+			return c > 0
+		}
+		return a.Tag < b.Tag
+	})
+	return inventory, nil
+}
+
+// Scopes returns the scopes of the version tags of the "scope-conventional" scheme, sorted by
+// name. It is empty for the other schemes.
+func (r *GitRepo) Scopes() ([]string, error) {
+	inventory, err := r.TagInventory()
+	if err != nil {
+		return nil, err
+	}
+
+	scopes := []string{}
+	for _, tag := range inventory {
+		if tag.Scope != "" && (len(scopes) == 0 || scopes[len(scopes)-1] != tag.Scope) {
+			scopes = append(scopes, tag.Scope)
+		}
+	}
+	return scopes, nil
+}
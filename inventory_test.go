@@ -0,0 +1,97 @@
+package autotag
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alecthomas/assert"
+)
+
+func TestTagInventory(t *testing.T) {
+	r := newRepoFixture(t, GitRepoConfig{Scheme: "scope-conventional", AllScopes: true},
+		seedCommit("worker-v1.0.0", "api-v1.0.0", "api-v1.10.0", "nightly"),
+		testCommit{msg: "fix(api): handle nil", tags: []string{"api-v1.2.0", "billing-v0.1.0", "api-v1.2.0-rc.1"}},
+	)
+
+	expected := []string{"api-v1.10.0", "api-v1.2.0", "api-v1.2.0-rc.1", "api-v1.0.0", "billing-v0.1.0", "worker-v1.0.0"}
+	// the tags are listed from maps, the order must not depend on their iteration order
+	for i := 0; i < 10; i++ {
+		r.RefreshTags()
+		inventory, err := r.TagInventory()
+		checkFatal(t, err)
+		names := make([]string, 0, len(inventory))
+		for _, tag := range inventory {
+			names = append(names, tag.Tag)
+		}
+		assert.Equal(t, expected, names)
+
+		scopes, err := r.Scopes()
+		checkFatal(t, err)
+		assert.Equal(t, []string{"api", "billing", "worker"}, scopes)
+	}
+
+	inventory, err := r.TagInventory()
+	checkFatal(t, err)
+	assert.Equal(t, "api", inventory[0].Scope)
+	assert.Equal(t, "1.10.0", inventory[0].Version.String())
+	assert.Equal(t, 40, len(inventory[0].Commit))
+}
+
+func TestTagInventoryWithoutScopes(t *testing.T) {
+	r := newRepoFixture(t, GitRepoConfig{Scheme: "conventional", Prefix: true},
+		seedCommit("v1.0.0", "v0.9.0"),
+		testCommit{msg: "fix: handle nil", tags: []string{"v1.1.0"}},
+		testCommit{msg: "fix: correct typo"},
+	)
+	inventory, err := r.TagInventory()
+	checkFatal(t, err)
+	assert.Equal(t, 3, len(inventory))
+	assert.Equal(t, "v1.1.0", inventory[0].Tag)
+	assert.Equal(t, "v0.9.0", inventory[2].Tag)
+
+	scopes, err := r.Scopes()
+	checkFatal(t, err)
+	assert.Equal(t, 0, len(scopes))
+}
+
+func TestTagInventoryTaggers(t *testing.T) {
+	dir := newRepoDir(t, seedCommit())
+	tagAs := func(name, email, tag string) {
+		runGit(t, dir, "-c", "user.name="+name, "-c", "user.email="+email, "tag", "-a", tag, "-m", "release "+tag)
+	}
+	tagAs("Alice", "alice@example.com", "v1.0.0")
+	runGit(t, dir, "commit", "--allow-empty", "--author", "Carol <carol@example.com>", "-m", "fix: handle nil")
+	tagAs("Bob", "bob@example.com", "v1.0.1")
+	runGit(t, dir, "commit", "--allow-empty", "--author", "Carol <carol@example.com>", "-m", "feat: add search")
+	// a lightweight tag reports the author of its commit
+	runGit(t, dir, "tag", "v1.1.0")
+
+	cfg := GitRepoConfig{RepoPath: dir, Branch: "master", Scheme: "conventional", Prefix: true}
+	r, err := NewRepo(cfg)
+	checkFatal(t, err)
+	inventory, err := r.TagInventory()
+	checkFatal(t, err)
+	for _, tag := range inventory {
+		assert.Nil(t, tag.Tagger, tag.Tag)
+	}
+
+	cfg.ReportTaggers = true
+	r, err = NewRepo(cfg)
+	checkFatal(t, err)
+	inventory, err = r.TagInventory()
+	checkFatal(t, err)
+	taggers := map[string]string{}
+	for _, tag := range inventory {
+		assert.False(t, tag.Tagger.When.IsZero(), tag.Tag)
+		taggers[tag.Tag] = tag.Tagger.Name + " <" + tag.Tagger.Email + ">"
+	}
+	assert.Equal(t, map[string]string{
+		"v1.0.0": "Alice <alice@example.com>",
+		"v1.0.1": "Bob <bob@example.com>",
+		"v1.1.0": "Carol <carol@example.com>",
+	}, taggers)
+
+	stats, err := r.ReleaseStats(time.Time{}, time.Date(3000, 1, 1, 0, 0, 0, 0, time.UTC))
+	checkFatal(t, err)
+	assert.Equal(t, map[string]int{"Bob <bob@example.com>": 1, "Carol <carol@example.com>": 1}, stats[""].Releasers)
+}
@@ -0,0 +1,46 @@
+package autotag
+
+import (
+	"fmt"
+	"log"
+)
+
+// Verbosity is the level of the messages a GitRepo writes to the standard logger
+type Verbosity int
+
+const (
+	// VerbosityQuiet logs nothing
+	VerbosityQuiet Verbosity = iota - 1
+	// VerbosityInfo logs the meaningful events: the selected base version, the calculated version,
+	// the tags written and the errors that don't abort the calculation. It is the default.
+	VerbosityInfo
+	// VerbosityDebug also logs the routine steps, eg: every skipped tag and parsed commit, which is
+	// noisy for repos with thousands of tags
+	VerbosityDebug
+)
+
+// logf logs the message if the verbosity includes level
+func logf(verbosity, level Verbosity, format string, args ...interface{}) {
+	if verbosity >= level {
+		log.Print(fmt.Sprintf(format, args...))
+	}
+}
+
+// infof logs a meaningful event, see VerbosityInfo
+func (r *GitRepo) infof(format string, args ...interface{}) {
+	logf(r.verbosity, VerbosityInfo, format, args...)
+}
+
+// warnf reports a warning to the OnWarning callback, or logs it as a meaningful event without one
+func (r *GitRepo) warnf(format string, args ...interface{}) {
+	if r.onWarning != nil {
+		r.onWarning(fmt.Sprintf(format, args...))
+		return
+	}
+	r.infof("warning: "+format+"\n", args...)
+}
+
+// debugf logs a routine step, see VerbosityDebug
+func (r *GitRepo) debugf(format string, args ...interface{}) {
+	logf(r.verbosity, VerbosityDebug, format, args...)
+}
@@ -0,0 +1,57 @@
+package autotag
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/assert"
+)
+
+func TestVerbosity(t *testing.T) {
+	tests := []struct {
+		name      string
+		verbosity Verbosity
+		logged    []string
+		notLogged []string
+	}{
+		{
+			name:      "quiet",
+			verbosity: VerbosityQuiet,
+			notLogged: []string{"currentVersion: 1.0.0", "calculated version 1.1.0", "skipping non version tag", "Parsing"},
+		},
+		{
+			name:      "info",
+			verbosity: VerbosityInfo,
+			logged:    []string{"currentVersion: 1.0.0", "calculated version 1.1.0 from 1.0.0: minor"},
+			notLogged: []string{"skipping non version tag", "Parsing"},
+		},
+		{
+			name:      "debug",
+			verbosity: VerbosityDebug,
+			logged:    []string{"currentVersion: 1.0.0", "calculated version 1.1.0 from 1.0.0: minor", "skipping non version tag: release", "Parsing"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			log.SetOutput(&buf)
+			defer log.SetOutput(os.Stderr)
+
+			newRepoFixture(t, GitRepoConfig{Scheme: "conventional", Verbosity: tc.verbosity},
+				seedCommit("v1.0.0", "release"),
+				testCommit{msg: "feat: add a flag"},
+			)
+			out := buf.String()
+			for _, s := range tc.logged {
+				assert.True(t, strings.Contains(out, s), "expected %q in the log:\n%s", s, out)
+			}
+			for _, s := range tc.notLogged {
+				assert.False(t, strings.Contains(out, s), "unexpected %q in the log:\n%s", s, out)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package autotag
+
+import (
+	"sort"
+	"time"
+
+	"github.com/hashicorp/go-version"
+)
+
+// majorLine returns the versions of keys, sorted from highest, in the major line of the most recent
+// stable version tagged in the history of the branch, see BasePerMajor. The keys are returned
+// unchanged if no stable version tag is in the history.
+func (r *GitRepo) majorLine(keys []*version.Version, versions map[*version.Version]tagRef) []*version.Version {
+	stable := make([]*version.Version, 0, len(keys))
+	dates := make(map[*version.Version]time.Time, len(keys))
+	for _, key := range keys {
+		if key.Prerelease() == "" && !versions[key].preRelease {
+			stable = append(stable, key)
+			dates[key] = r.tagDate(versions[key])
+		}
+	}
+	// a stable sort keeps the SemVer order for tags of the same date
+	sort.SliceStable(stable, func(i, j int) bool {
+		return dates[stable[i]].After(dates[stable[j]])
+	})
+
+	for _, latest := range stable {
+		if !r.onBranch(versions[latest]) {
+			continue
+		}
+		major := coreSegments(latest)[0]
+		r.infof("selecting the base version in major line %d of tag %s\n", major, versions[latest].name)
+		line := make([]*version.Version, 0, len(keys))
+		for _, key := range keys {
+			if coreSegments(key)[0] == major {
+				line = append(line, key)
+			} else {
+				r.debugf("skipping tag %s of another major line", versions[key].name)
+			}
+		}
+		return line
+	}
+	return keys
+}
@@ -0,0 +1,102 @@
+package autotag
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// ManifestVersion is the version of the result manifest format written by SaveResults. LoadResults
+// only reads manifests of this version.
+const ManifestVersion = 1
+
+// resultManifest is the JSON object of a result manifest file
+type resultManifest struct {
+	Version int      `json:"version"`
+	Results []Result `json:"results"`
+}
+
+// SaveResults writes the results to the manifest file at path, eg: in the stage of a pipeline that
+// calculates the versions, so later stages can read them with LoadResults instead of calculating
+// them again. The results are encoded like with StreamResults.
+func SaveResults(path string, results []Result) error {
+	if results == nil {
+		results = []Result{}
+	}
+	out, err := json.MarshalIndent(resultManifest{Version: ManifestVersion, Results: results}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding the result manifest: %s", err)
+	}
+	if err := os.WriteFile(path, append(out, '\n'), 0o644); err != nil {
+		return fmt.Errorf("error writing result manifest '%s': %s", path, err)
+	}
+	return nil
+}
+
+// LoadResults reads the results of the manifest file at path written by SaveResults. The errors of
+// the results are restored as plain errors with the same message.
+func LoadResults(path string) ([]Result, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading result manifest '%s': %s", path, err)
+	}
+	var manifest resultManifest
+	if err := json.Unmarshal(content, &manifest); err != nil {
+		return nil, fmt.Errorf("error parsing result manifest '%s': %s", path, err)
+	}
+	if manifest.Version != ManifestVersion {
+		return nil, fmt.Errorf("result manifest '%s' has version %d; only version %d is supported", path, manifest.Version, ManifestVersion)
+	}
+	if manifest.Results == nil {
+		return []Result{}, nil
+	}
+	return manifest.Results, nil
+}
+
+// CreateTagsFromResults creates the tags of the results, eg: loaded with LoadResults, on the branch
+// commit without calculating the versions again. Results without a version, with an error or a
+// skipped or failed status are left out. The tags are checked like with AutoTag, so a version
+// tagged meanwhile fails. It is atomic: when a tag can't be created the tags created so far are
+// deleted again. The PostTagHooks are called once all tags are created.
+func (r *GitRepo) CreateTagsFromResults(results []Result) error {
+	if err := r.checkEvent(); err != nil {
+		return err
+	}
+	if err := r.checkUpToDate(); err != nil {
+		return err
+	}
+	if err := r.checkCleanTree(); err != nil {
+		return err
+	}
+
+	var tagged []Result
+	var created []string
+	for _, res := range results {
+		if res.Next == nil || res.Tag == "" || res.Err != nil || res.Status == StatusSkipped || res.Status == StatusError {
+			continue
+		}
+		err := r.checkVersionCollision(res.Scope, res.Next)
+		if err == nil {
+			err = r.checkAlreadyTagged(res.Scope, res.Next)
+		}
+		if err == nil {
+			err = r.checkRemoteTag(res.Tag)
+		}
+		if err == nil {
+			err = r.createTag(res)
+		}
+		if err != nil {
+			r.deleteTags(created)
+			return fmt.Errorf("error creating tag %s: %w", res.Tag, err)
+		}
+		res.Status = StatusTagged
+		tagged, created = append(tagged, res), append(created, res.Tag)
+	}
+
+	for _, res := range tagged {
+		if err := r.runPostTagHooks(res); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package autotag
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alecthomas/assert"
+)
+
+func TestSaveLoadResults(t *testing.T) {
+	r := newRepoFixture(t, GitRepoConfig{Scheme: "scope-conventional", Prefix: true, AllScopes: true},
+		seedCommit("api-v1.0.0", "web-v1.0.0"),
+		testCommit{msg: "feat(api): add login"},
+	)
+	results, err := r.AutoTagScopeResults()
+	checkFatal(t, err)
+
+	path := filepath.Join(t.TempDir(), "results.json")
+	checkFatal(t, SaveResults(path, results))
+	loaded, err := LoadResults(path)
+	checkFatal(t, err)
+
+	assert.Equal(t, len(results), len(loaded))
+	for i, res := range results {
+		assert.Equal(t, res.Scope, loaded[i].Scope)
+		assert.Equal(t, res.Tag, loaded[i].Tag)
+		assert.Equal(t, res.Status, loaded[i].Status)
+		assert.Equal(t, res.Current.String(), loaded[i].Current.String())
+		assert.Equal(t, res.Err == nil, loaded[i].Err == nil)
+	}
+	assert.Equal(t, StatusSkipped, loaded[1].Status)
+	assert.Equal(t, results[1].Err.Error(), loaded[1].Err.Error())
+}
+
+func TestLoadResultsVersion(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"missing": `{"results":[]}`,
+		"newer":   `{"version":2,"results":[]}`,
+		"invalid": `{"version":1,"results":[{"scope":"api","status":"done"}]}`,
+	} {
+		path := filepath.Join(dir, name+".json")
+		checkFatal(t, os.WriteFile(path, []byte(content), 0o644))
+		_, err := LoadResults(path)
+		assert.Error(t, err, name)
+	}
+}
+
+func TestCreateTagsFromResults(t *testing.T) {
+	dir := newRepoDir(t,
+		seedCommit("api-v1.0.0", "web-v1.0.0"),
+		testCommit{msg: "feat(api): add login"},
+		testCommit{msg: "fix(web): correct typo"},
+	)
+	cfg := GitRepoConfig{RepoPath: dir, Branch: "master", Scheme: "scope-conventional", Prefix: true, AllScopes: true}
+	planned, err := NewRepo(cfg)
+	checkFatal(t, err)
+	results, err := planned.Preview()
+	checkFatal(t, err)
+	path := filepath.Join(t.TempDir(), "results.json")
+	checkFatal(t, SaveResults(path, results))
+
+	// a later stage applies the manifest although the history moved on
+	commitAs(t, dir, "Jane Doe <jane@example.com>", "feat(web): add dark mode")
+	loaded, err := LoadResults(path)
+	checkFatal(t, err)
+	tagger := &fakeTagger{}
+	cfg.Tagger = tagger
+	r, err := NewRepo(cfg)
+	checkFatal(t, err)
+	checkFatal(t, r.CreateTagsFromResults(loaded))
+
+	var created []string
+	for _, tag := range tagger.created {
+		created = append(created, tag.name)
+	}
+	assert.Equal(t, []string{"api-v1.1.0", "web-v1.0.1"}, created)
+}
+
+func TestCreateTagsFromResultsExisting(t *testing.T) {
+	tagger := &fakeTagger{}
+	r := newRepoFixture(t, GitRepoConfig{Scheme: "scope-conventional", Prefix: true, AllScopes: true, Tagger: tagger},
+		seedCommit("api-v1.0.0", "web-v1.0.0"),
+		testCommit{msg: "feat(api): add login"},
+		testCommit{msg: "fix(web): correct typo", tags: []string{"web-v1.0.1"}},
+	)
+	results, err := r.Preview()
+	checkFatal(t, err)
+	results = append(results, Result{Scope: "web", Next: results[0].Current, Tag: "web-v1.0.0"})
+
+	assert.Error(t, r.CreateTagsFromResults(results))
+	assert.Equal(t, []string{"api-v1.1.0"}, tagger.deleted)
+}
@@ -0,0 +1,24 @@
+package autotag
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/hashicorp/go-version"
+)
+
+// ErrMajorCap is returned when the next version is above the MaxMajor version
+var ErrMajorCap = errors.New("major version above the cap")
+
+// checkMaxMajor returns ErrMajorCap if the major version of next, bumped from base, is above
+// MaxMajor
+func (r *GitRepo) checkMaxMajor(scope string, base, next *version.Version) error {
+	if r.maxMajor == 0 || next.Segments64()[0] <= int64(r.maxMajor) {
+		return nil
+	}
+	what := "the version"
+	if scope != "" {
+		what = "the version of scope " + scope
+	}
+	return fmt.Errorf("%w: %s would be bumped from %s to %s, the highest major is %d", ErrMajorCap, what, base, next, r.maxMajor)
+}
@@ -0,0 +1,48 @@
+package autotag
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/alecthomas/assert"
+)
+
+func TestMaxMajor(t *testing.T) {
+	dir := newRepoDir(t,
+		seedCommit("v1.0.0"),
+		testCommit{msg: "feat!: drop the v1 API", tags: []string{"v2.0.0"}},
+		testCommit{msg: "feat!: drop the v2 API"},
+	)
+	cfg := GitRepoConfig{RepoPath: dir, Branch: "master", Scheme: "conventional", Prefix: true, MaxMajor: 2}
+	_, err := NewRepo(cfg)
+	assert.True(t, errors.Is(err, ErrMajorCap), "error: %v", err)
+
+	cfg.MaxMajor = 3
+	r, err := NewRepo(cfg)
+	checkFatal(t, err)
+	assert.Equal(t, "v3.0.0", r.LatestVersion())
+
+	// a forced bump is capped too
+	cfg.Bump = BumpMajor
+	_, err = NewRepo(cfg)
+	checkFatal(t, err)
+	cfg.MaxMajor = 2
+	_, err = NewRepo(cfg)
+	assert.True(t, errors.Is(err, ErrMajorCap), "error: %v", err)
+
+	cfg.Bump, cfg.MaxMajor = BumpNone, -1
+	_, err = NewRepo(cfg)
+	assert.Error(t, err)
+}
+
+func TestMaxMajorScopes(t *testing.T) {
+	r := newRepoFixture(t, GitRepoConfig{Scheme: "scope-conventional", AllScopes: true, Prefix: true, MaxMajor: 1},
+		seedCommit("api-v1.0.0", "web-v1.0.0"),
+		testCommit{msg: "feat(api)!: drop the v1 API"},
+		testCommit{msg: "feat(web): add search"},
+	)
+	tags, errs := previewTags(t, r)
+	assert.Equal(t, 1, len(errs))
+	assert.True(t, errors.Is(errs["api"], ErrMajorCap), "error: %v", errs["api"])
+	assert.Equal(t, map[string]string{"web": "web-v1.1.0"}, tags)
+}
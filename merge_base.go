@@ -0,0 +1,19 @@
+package autotag
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gogs/git-module"
+)
+
+// mergeBase returns the SHA of the best common ancestor of the refs, see TagMergeBase
+func (r *GitRepo) mergeBase(refs [2]string) (string, error) {
+	out, err := git.NewCommand("merge-base", refs[0], refs[1]).RunInDir(r.repo.Path())
+	if err != nil {
+		return "", fmt.Errorf("error resolving the merge-base of '%s' and '%s': %s", refs[0], refs[1], err)
+	}
+	sha := strings.TrimSpace(string(out))
+	r.infof("releasing the merge-base %s of %s and %s\n", sha, refs[0], refs[1])
+	return sha, nil
+}
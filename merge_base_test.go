@@ -0,0 +1,44 @@
+package autotag
+
+import (
+	"testing"
+
+	"github.com/alecthomas/assert"
+)
+
+func TestTagMergeBase(t *testing.T) {
+	dir := newRepoDir(t,
+		seedCommit("v1.0.0"),
+		testCommit{msg: "fix: correct typo"},
+	)
+	runGit(t, dir, "checkout", "-b", "integration")
+	runGit(t, dir, "commit", "--allow-empty", "-m", "feat: add login")
+	runGit(t, dir, "checkout", "master")
+	runGit(t, dir, "commit", "--allow-empty", "-m", "fix: handle empty input")
+	base := runGit(t, dir, "rev-parse", "HEAD")
+	runGit(t, dir, "checkout", "integration")
+	runGit(t, dir, "merge", "--no-ff", "-m", "chore: merge master", "master")
+	runGit(t, dir, "checkout", "master")
+	runGit(t, dir, "commit", "--allow-empty", "-m", "feat!: drop the old API")
+
+	tagger := &fakeTagger{}
+	r, err := NewRepo(GitRepoConfig{RepoPath: dir, Branch: "master", Scheme: "conventional", Prefix: true,
+		TagMergeBase: [2]string{"master", "integration"}, Tagger: tagger})
+	checkFatal(t, err)
+	assert.Equal(t, "v1.0.1", r.LatestVersion())
+	checkFatal(t, r.AutoTag())
+	assert.Equal(t, []fakeTag{{name: "v1.0.1", target: base}}, tagger.created)
+}
+
+func TestTagMergeBaseValidation(t *testing.T) {
+	dir := newRepoDir(t, seedCommit("v1.0.0"))
+	for _, cfg := range []GitRepoConfig{
+		{TagMergeBase: [2]string{"master", ""}},
+		{TagMergeBase: [2]string{"master", "master"}, Rev: "v1.0.0"},
+		{TagMergeBase: [2]string{"master", "integration-404"}},
+	} {
+		cfg.RepoPath, cfg.Branch, cfg.Scheme = dir, "master", "conventional"
+		_, err := NewRepo(cfg)
+		assert.Error(t, err, "merge-base %v", cfg.TagMergeBase)
+	}
+}
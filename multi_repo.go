@@ -0,0 +1,71 @@
+package autotag
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/hashicorp/go-version"
+)
+
+// ErrLockstep is returned by CheckLockstep when repos released in lockstep would end up on
+// different versions
+var ErrLockstep = errors.New("repos are not in lockstep")
+
+// MultiRepoCalc combines the results of several repos, eg: the repos of a product released in
+// lockstep, in order of repos. The Repo of every result is the path of its repository. A repo with
+// AllScopes or Submodules contributes the results of its Preview, the others their Result.
+func MultiRepoCalc(repos []*GitRepo) ([]Result, error) {
+	results := []Result{}
+	for _, r := range repos {
+		var repoResults []Result
+		if r.allScopes || r.submoduleCfg != nil {
+			var err error
+			if repoResults, err = r.Preview(); err != nil {
+				return nil, fmt.Errorf("repo %s: %w", r.workTree, err)
+			}
+		} else {
+			repoResults = []Result{r.Result()}
+		}
+		for _, res := range repoResults {
+			res.Repo = r.workTree
+			results = append(results, res)
+		}
+	}
+	return results, nil
+}
+
+// CheckLockstep fails with ErrLockstep if the results, eg: of MultiRepoCalc, don't agree on the
+// version: the Next version of the result or, without a bump, its Current version. Build metadata
+// may differ. Results without a version, eg: of a failed scope, are not compared.
+func CheckLockstep(results []Result) error {
+	var first *Result
+	var coordinated *version.Version
+	for i, res := range results {
+		v := res.Next
+		if v == nil {
+			v = res.Current
+		}
+		if v == nil || res.Err != nil {
+			continue
+		}
+		if coordinated == nil {
+			first, coordinated = &results[i], v
+			continue
+		}
+		if !v.Equal(coordinated) {
+			return fmt.Errorf("%w: %s is at %s, %s at %s", ErrLockstep, resultName(*first), coordinated, resultName(res), v)
+		}
+	}
+	return nil
+}
+
+// resultName names the result by its repo and scope, eg: `/src/api (worker)`
+func resultName(res Result) string {
+	switch {
+	case res.Scope == "":
+		return res.Repo
+	case res.Repo == "":
+		return res.Scope
+	}
+	return fmt.Sprintf("%s (%s)", res.Repo, res.Scope)
+}
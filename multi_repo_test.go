@@ -0,0 +1,46 @@
+package autotag
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/alecthomas/assert"
+)
+
+func TestMultiRepoCalc(t *testing.T) {
+	bumping := newRepoFixture(t, GitRepoConfig{Scheme: "conventional", Prefix: true},
+		seedCommit("v1.0.0"),
+		testCommit{msg: "feat: add login"},
+	)
+	unchanged := newRepoFixture(t, GitRepoConfig{Scheme: "scope-conventional", Prefix: true, AllScopes: true},
+		seedCommit("api-v1.0.0"),
+	)
+
+	results, err := MultiRepoCalc([]*GitRepo{bumping, unchanged})
+	checkFatal(t, err)
+	assert.Equal(t, 2, len(results))
+	assert.Equal(t, repoRoot(bumping.repo), results[0].Repo)
+	assert.Equal(t, "v1.1.0", results[0].Tag)
+	assert.Equal(t, repoRoot(unchanged.repo), results[1].Repo)
+	assert.Equal(t, "api", results[1].Scope)
+	assert.Equal(t, "1.0.0", results[1].Current.String())
+	assert.Nil(t, results[1].Next)
+
+	err = CheckLockstep(results)
+	assert.True(t, errors.Is(err, ErrLockstep), "error: %v", err)
+}
+
+func TestCheckLockstep(t *testing.T) {
+	api := newRepoFixture(t, GitRepoConfig{Scheme: "conventional", Prefix: true},
+		seedCommit("v1.0.0"),
+		testCommit{msg: "feat: add login"},
+	)
+	web := newRepoFixture(t, GitRepoConfig{Scheme: "conventional", Prefix: true, BuildMetadata: "ci.42"},
+		seedCommit("v1.0.0"),
+		testCommit{msg: "fix: correct typo"},
+		testCommit{msg: "feat: add search"},
+	)
+	results, err := MultiRepoCalc([]*GitRepo{api, web})
+	checkFatal(t, err)
+	checkFatal(t, CheckLockstep(results))
+}
@@ -0,0 +1,114 @@
+package autotag
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ownerRule is a line of an owners file: the paths matched by the pattern and the scope of their
+// owner, empty if the pattern has no owner
+type ownerRule struct {
+	pattern string
+	rex     *regexp.Regexp
+	scope   string
+}
+
+// readOwnersFile parses a CODEOWNERS style file: `<pattern> <owner>...` per line, empty lines and
+// lines starting with `#` are skipped. Relative paths are relative to the work tree.
+func readOwnersFile(workTree, name string) ([]ownerRule, error) {
+	if !filepath.IsAbs(name) {
+		name = filepath.Join(workTree, name)
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("error reading owners file: %s", err)
+	}
+	defer f.Close()
+
+	var rules []ownerRule
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		rule := ownerRule{pattern: fields[0], rex: ownersPatternRex(fields[0])}
+		if len(fields) > 1 {
+			rule.scope = ownerScope(fields[1])
+		}
+		rules = append(rules, rule)
+	}
+	return rules, s.Err()
+}
+
+// ownerScope returns the scope of an owner: the team or user without the `@` and the organization,
+// eg: `@acme/billing` is `billing`. Email owners are used as is.
+func ownerScope(owner string) string {
+	if !strings.HasPrefix(owner, "@") {
+		return owner
+	}
+	owner = strings.TrimPrefix(owner, "@")
+	if i := strings.LastIndex(owner, "/"); i >= 0 {
+		return owner[i+1:]
+	}
+	return owner
+}
+
+// ownersPatternRex compiles a CODEOWNERS pattern, which follows the gitignore rules: a pattern with
+// a leading or inner `/` is relative to the root, otherwise it matches at any depth. `*` matches
+// within a path segment, `**` across segments. A pattern matches the files below a matching
+// directory too, except a trailing `/*` that only matches the files directly in the directory.
+func ownersPatternRex(pattern string) *regexp.Regexp {
+	anchored := strings.Contains(strings.TrimSuffix(pattern, "/"), "/")
+	directOnly := strings.HasSuffix(pattern, "/*") && !strings.HasSuffix(pattern, "/**/*")
+	dirOnly := strings.HasSuffix(pattern, "/")
+	pattern = strings.Trim(pattern, "/")
+
+	var b strings.Builder
+	b.WriteString("^")
+	if !anchored {
+		b.WriteString("(?:.*/)?")
+	}
+	for i := 0; i < len(pattern); i++ {
+		switch {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case pattern[i] == '*':
+			b.WriteString("[^/]*")
+		case pattern[i] == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		}
+	}
+	switch {
+	case directOnly:
+	case dirOnly:
+		b.WriteString("/.*")
+	default:
+		b.WriteString("(?:/.*)?")
+	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String())
+}
+
+// matchOwnerScope returns the scope of the owner of file, the last matching rule wins like in
+// CODEOWNERS. It returns false if no rule matches or the matching rule has no owner.
+func matchOwnerScope(rules []ownerRule, file string) (string, bool) {
+	file = normalizeScopePath(file)
+	for i := len(rules) - 1; i >= 0; i-- {
+		if rules[i].rex.MatchString(file) {
+			return rules[i].scope, rules[i].scope != ""
+		}
+	}
+	return "", false
+}
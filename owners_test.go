@@ -0,0 +1,143 @@
+package autotag
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alecthomas/assert"
+	"github.com/gogs/git-module"
+)
+
+func TestOwnersPatternRex(t *testing.T) {
+	tests := []struct {
+		pattern string
+		file    string
+		match   bool
+	}{
+		{"*", "services/api/main.go", true},
+		{"*.js", "web/app.js", true},
+		{"*.js", "web/app.go", false},
+		{"/docs/", "docs/guide/index.md", true},
+		{"/docs/", "src/docs/index.md", false},
+		{"docs/", "src/docs/index.md", true},
+		{"docs/*", "docs/index.md", true},
+		{"docs/*", "docs/guide/index.md", false},
+		{"/services/api", "services/api/main.go", true},
+		{"/services/api", "services/api-extra/main.go", false},
+		{"services/api", "x/services/api/main.go", false},
+		{"apps/**/config", "apps/web/prod/config/app.yaml", true},
+		{"**/logs", "deep/nested/logs/app.log", true},
+		{"/build/logs/", "build/logs/2024/app.log", true},
+		{"file?.txt", "file1.txt", true},
+		{"file?.txt", "dir/file10.txt", false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.pattern+" "+tc.file, func(t *testing.T) {
+			assert.Equal(t, tc.match, ownersPatternRex(tc.pattern).MatchString(tc.file))
+		})
+	}
+}
+
+func TestOwnerScope(t *testing.T) {
+	assert.Equal(t, "billing", ownerScope("@acme/billing"))
+	assert.Equal(t, "jane", ownerScope("@jane"))
+	assert.Equal(t, "jane@example.com", ownerScope("jane@example.com"))
+}
+
+func TestMatchOwnerScope(t *testing.T) {
+	f := filepath.Join(t.TempDir(), "CODEOWNERS")
+	owners := `# default owners
+*                @acme/platform
+/services/api/   @acme/api @jane
+/services/api/vendor/
+/services/*.md   @acme/docs
+`
+	checkFatal(t, os.WriteFile(f, []byte(owners), 0o644))
+	rules, err := readOwnersFile("", f)
+	checkFatal(t, err)
+	assert.Equal(t, 4, len(rules))
+
+	tests := []struct {
+		file  string
+		scope string
+		ok    bool
+	}{
+		{file: "README", scope: "platform", ok: true},
+		{file: "services/api/main.go", scope: "api", ok: true},
+		// the last match wins, the vendored files have no owner
+		{file: "services/api/vendor/lib.go", ok: false},
+		{file: `services\README.md`, scope: "docs", ok: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.file, func(t *testing.T) {
+			scope, ok := matchOwnerScope(rules, tc.file)
+			assert.Equal(t, tc.ok, ok)
+			assert.Equal(t, tc.scope, scope)
+		})
+	}
+
+	_, err = readOwnersFile("", filepath.Join(t.TempDir(), "missing"))
+	assert.Error(t, err)
+}
+
+func TestScopeSchemeOwnersFile(t *testing.T) {
+	f := filepath.Join(t.TempDir(), "CODEOWNERS")
+	checkFatal(t, os.WriteFile(f, []byte("/services/api/ @acme/api\n/services/web/ @acme/web\n"), 0o644))
+
+	tests := []struct {
+		name        string
+		pathScopes  map[string]string
+		files       []string
+		expectedTag string
+		expectedErr error
+	}{
+		{
+			name:        "scope of the owner",
+			files:       []string{"services/api/main.go"},
+			expectedTag: "api-v1.0.1",
+		},
+		{
+			name:        "path scopes take precedence",
+			pathScopes:  map[string]string{"services/api": "worker"},
+			files:       []string{"services/api/main.go"},
+			expectedTag: "worker-v1.0.1",
+		},
+		{
+			name:        "files of different owners",
+			files:       []string{"services/api/main.go", "services/web/main.go"},
+			expectedErr: ErrNoScope,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tr := createTestRepo(t, "")
+			repo, err := git.Open(tr)
+			checkFatal(t, err)
+			defer cleanupTestRepo(t, repo)
+
+			seedTestRepo(t, "api-v1.0.0", repo)
+			makeTag(repo, "web-v1.0.0")
+			makeTag(repo, "worker-v1.0.0")
+			commitFiles(t, repo, "fix: correct typo", tc.files...)
+
+			r, err := NewRepo(GitRepoConfig{
+				RepoPath:            repo.Path(),
+				Branch:              "master",
+				Scheme:              "scope-conventional",
+				Prefix:              true,
+				PathScopes:          tc.pathScopes,
+				ScopeFromOwnersFile: f,
+			})
+			if tc.expectedErr != nil {
+				assert.True(t, errors.Is(err, tc.expectedErr), "expected %v, got %v", tc.expectedErr, err)
+				return
+			}
+			checkFatal(t, err)
+			assert.Equal(t, tc.expectedTag, r.LatestVersion())
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package autotag
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/go-version"
+)
+
+// PR title modes decide how the PRTitle is combined with the commit messages.
+const (
+	// PRTitleReplace decides the bump by the PR title alone, the commit messages are ignored (default)
+	PRTitleReplace = "replace"
+	// PRTitleMerge evaluates the PR title in addition to the commit messages, the highest bump wins
+	PRTitleMerge = "merge"
+)
+
+// parsePRTitle bumps the current version according to the PR title, like a commit message
+func (r *GitRepo) parsePRTitle() (*version.Version, bumpDecision, error) {
+	r.debugf("Parsing PR title: %s\n", r.prTitle)
+	return r.parseMessage(r.prTitle)
+}
+
+// decidePRTitle records the PR title as the reason of the bump, see decideBump
+func (r *GitRepo) decidePRTitle(d bumpDecision) {
+	r.decideBump(d, "")
+	r.bumpReason = fmt.Sprintf("%s because the PR title %s", d.level, d.signal)
+}
+
+// prTitleReplaces reports whether the PR title is evaluated instead of the commit messages
+func (r *GitRepo) prTitleReplaces() bool {
+	return r.prTitle != "" && r.prTitleMode != PRTitleMerge
+}
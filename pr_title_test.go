@@ -0,0 +1,93 @@
+package autotag
+
+import (
+	"testing"
+
+	"github.com/alecthomas/assert"
+)
+
+func TestPRTitle(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         GitRepoConfig
+		tags        []string
+		commit      string
+		expectedTag string
+	}{
+		{
+			name:        "overrides a non-conventional tip commit",
+			cfg:         GitRepoConfig{Scheme: "conventional", PRTitle: "feat: add login"},
+			tags:        []string{"v1.0.0"},
+			commit:      "Merge branch 'login' (#12)",
+			expectedTag: "v1.1.0",
+		},
+		{
+			name:        "replaces the commits",
+			cfg:         GitRepoConfig{Scheme: "conventional", PRTitle: "fix: correct typo"},
+			tags:        []string{"v1.0.0"},
+			commit:      "feat!: drop the old API",
+			expectedTag: "v1.0.1",
+		},
+		{
+			name:        "merged with the commits",
+			cfg:         GitRepoConfig{Scheme: "conventional", PRTitle: "fix: correct typo", PRTitleMode: PRTitleMerge},
+			tags:        []string{"v1.0.0"},
+			commit:      "feat!: drop the old API",
+			expectedTag: "v2.0.0",
+		},
+		{
+			name:        "scope",
+			cfg:         GitRepoConfig{Scheme: "scope-conventional", PRTitle: "feat(api): add login"},
+			tags:        []string{"api-v1.0.0", "web-v1.0.0"},
+			commit:      "wip",
+			expectedTag: "api-v1.1.0",
+		},
+		{
+			name:        "scope merged with a title without scope",
+			cfg:         GitRepoConfig{Scheme: "scope-conventional", PRTitle: "feat: add login", PRTitleMode: PRTitleMerge},
+			tags:        []string{"api-v1.0.0", "web-v1.0.0"},
+			commit:      "fix(web): correct typo",
+			expectedTag: "web-v1.1.0",
+		},
+		{
+			name:        "scope merged with the commit of another scope",
+			cfg:         GitRepoConfig{Scheme: "scope-conventional", PRTitle: "fix(api): correct typo", PRTitleMode: PRTitleMerge},
+			tags:        []string{"api-v1.0.0", "web-v1.0.0"},
+			commit:      "feat(web)!: drop the old pages",
+			expectedTag: "api-v1.0.1",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.cfg.Prefix = true
+			r := newRepoFixture(t, tc.cfg,
+				seedCommit(tc.tags...),
+				testCommit{msg: tc.commit},
+			)
+			assert.Equal(t, tc.expectedTag, r.LatestVersion())
+		})
+	}
+}
+
+func TestPRTitleReason(t *testing.T) {
+	r := newRepoFixture(t, GitRepoConfig{Scheme: "conventional", Prefix: true, PRTitle: "feat: add login"},
+		seedCommit("v1.0.0"),
+		testCommit{msg: "wip"},
+	)
+	res := r.Result()
+	assert.Equal(t, "minor because the PR title has type 'feat'", res.BumpReason)
+	assert.Equal(t, "", res.DecidingCommit)
+}
+
+func TestPRTitleValidation(t *testing.T) {
+	dir := newRepoDir(t, seedCommit("v1.0.0"))
+	for _, cfg := range []GitRepoConfig{
+		{PRTitle: "feat: add login", PRTitleMode: "append"},
+		{PRTitle: "feat: add login", OverrideMessage: "fix: correct typo"},
+	} {
+		cfg.RepoPath, cfg.Branch, cfg.Scheme = dir, "master", "conventional"
+		_, err := NewRepo(cfg)
+		assert.Error(t, err)
+	}
+}
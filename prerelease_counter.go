@@ -0,0 +1,49 @@
+package autotag
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+)
+
+// preReleaseCounter returns the counter appended to the pre-release channel of the core version of
+// scope, from PreReleaseCounter if set, otherwise from the pre-release tags of scope
+func (r *GitRepo) preReleaseCounter(scope string, core *version.Version, channel string) (int, error) {
+	if r.preReleaseCounterFunc == nil {
+		return r.tagPreReleaseCounter(scope, core, channel)
+	}
+
+	n, err := r.preReleaseCounterFunc(core, channel)
+	if err != nil {
+		return 0, fmt.Errorf("error getting the pre-release counter of %s-%s: %s", core, channel, err)
+	}
+	if n < 0 {
+		return 0, fmt.Errorf("pre-release counter %d of %s-%s is negative", n, core, channel)
+	}
+	return n, nil
+}
+
+// tagPreReleaseCounter returns one more than the highest counter of the pre-release tags of scope
+// with the core version and channel, 1 if there is none, eg: 3 after `1.2.0-rc.1` and `1.2.0-rc.2`.
+// The counter is the numeric identifier right after the channel, so `1.2.0-rc.2.1499308568` with a
+// timestamp counts as well.
+func (r *GitRepo) tagPreReleaseCounter(scope string, core *version.Version, channel string) (int, error) {
+	tags, err := r.loadTags()
+	if err != nil {
+		return 0, err
+	}
+
+	highest := 0
+	for v := range tags[r.normalizeScope(scope)] {
+		if !sameCoreVersion(v, core) || !strings.HasPrefix(v.Prerelease(), channel+".") {
+			continue
+		}
+		id, _, _ := strings.Cut(strings.TrimPrefix(v.Prerelease(), channel+"."), ".")
+		if n, err := strconv.Atoi(id); err == nil && n > highest {
+			highest = n
+		}
+	}
+	return highest + 1, nil
+}
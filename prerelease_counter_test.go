@@ -0,0 +1,82 @@
+package autotag
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/alecthomas/assert"
+	"github.com/hashicorp/go-version"
+)
+
+func TestPreReleaseIncrement(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         GitRepoConfig
+		tags        []string
+		expectedTag string
+	}{
+		{
+			name:        "first pre-release",
+			cfg:         GitRepoConfig{Scheme: "conventional", PreReleaseName: "rc"},
+			tags:        []string{"v1.0.0"},
+			expectedTag: "v1.1.0-rc.1",
+		},
+		{
+			name:        "highest tag of the channel",
+			cfg:         GitRepoConfig{Scheme: "conventional", PreReleaseName: "rc"},
+			tags:        []string{"v1.0.0", "v1.1.0-rc.1", "v1.1.0-rc.10", "v1.1.0-beta.12", "v1.0.1-rc.20"},
+			expectedTag: "v1.1.0-rc.11",
+		},
+		{
+			name:        "scope",
+			cfg:         GitRepoConfig{Scheme: "scope-conventional", PreReleaseName: "rc"},
+			tags:        []string{"api-v1.0.0", "api-v1.1.0-rc.2", "web-v1.1.0-rc.7"},
+			expectedTag: "api-v1.1.0-rc.3",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.cfg.Prefix, tc.cfg.PreReleaseIncrement = true, true
+			r := newRepoFixture(t, tc.cfg,
+				seedCommit(tc.tags...),
+				testCommit{msg: "feat(api): add login"},
+			)
+			assert.Equal(t, tc.expectedTag, r.LatestVersion())
+		})
+	}
+}
+
+func TestPreReleaseCounter(t *testing.T) {
+	var gotCore, gotChannel string
+	r := newRepoFixture(t, GitRepoConfig{
+		Scheme:         "conventional",
+		Prefix:         true,
+		PreReleaseName: "rc",
+		PreReleaseCounter: func(core *version.Version, channel string) (int, error) {
+			gotCore, gotChannel = core.String(), channel
+			return 4711, nil
+		},
+	},
+		seedCommit("v1.0.0", "v1.1.0-rc.1"),
+		testCommit{msg: "feat: add login"},
+	)
+	assert.Equal(t, "v1.1.0-rc.4711", r.LatestVersion())
+	assert.Equal(t, "1.1.0", gotCore)
+	assert.Equal(t, "rc", gotChannel)
+
+	dir := newRepoDir(t,
+		seedCommit("v1.0.0"),
+		testCommit{msg: "feat: add login"},
+	)
+	_, err := NewRepo(GitRepoConfig{
+		RepoPath:       dir,
+		Branch:         "master",
+		Scheme:         "conventional",
+		PreReleaseName: "rc",
+		PreReleaseCounter: func(core *version.Version, channel string) (int, error) {
+			return 0, errors.New("no build number")
+		},
+	})
+	assert.Error(t, err)
+}
@@ -0,0 +1,36 @@
+package autotag
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/hashicorp/go-version"
+)
+
+// PreviewBump returns the next version of scope for the bump level, regardless of the commits since
+// its base version, eg: for release planning where a human picks the level. The base version is
+// selected like for the release, and the pre-release and metadata are appended. Nothing is tagged.
+// Without the "scope-conventional" scheme the scope must be empty.
+func (r *GitRepo) PreviewBump(scope string, level BumpLevel) (*version.Version, error) {
+	if level.bumper() == nil {
+		return nil, errors.New("a bump level other than none is required")
+	}
+	if scope != "" && r.scheme != "scope-conventional" {
+		return nil, fmt.Errorf("scope '%s' requires the scope-conventional scheme", scope)
+	}
+	base, _, ok, err := r.baseVersionOf(scope)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("no stable (non pre-release) version of scope '%s' to bump from", scope)
+	}
+	next, err := r.bumpVersion(level.bumper(), base)
+	if err != nil {
+		return nil, err
+	}
+	if next == nil || !next.GreaterThan(base) {
+		return nil, fmt.Errorf("%w: %s stays at %s", ErrNoBump, scope, base)
+	}
+	return r.finishVersion(scope, base, next, "", false)
+}
@@ -0,0 +1,76 @@
+package autotag
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/alecthomas/assert"
+)
+
+func TestPreviewBump(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      GitRepoConfig
+		scope    string
+		level    BumpLevel
+		expected string
+	}{
+		{name: "patch", cfg: GitRepoConfig{Scheme: "conventional"}, level: BumpPatch, expected: "1.2.4"},
+		{name: "minor", cfg: GitRepoConfig{Scheme: "conventional"}, level: BumpMinor, expected: "1.3.0"},
+		{name: "major", cfg: GitRepoConfig{Scheme: "conventional"}, level: BumpMajor, expected: "2.0.0"},
+		{
+			name:     "pre-release",
+			cfg:      GitRepoConfig{Scheme: "conventional", PreReleaseName: "rc", BuildMetadata: "build.1"},
+			level:    BumpMinor,
+			expected: "1.3.0-rc+build.1",
+		},
+		{
+			name:     "scope",
+			cfg:      GitRepoConfig{Scheme: "scope-conventional", AllScopes: true},
+			scope:    "api",
+			level:    BumpMinor,
+			expected: "0.5.0",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// the fix commit would bump the patch version, the level of the preview is used instead
+			r := newRepoFixture(t, tc.cfg,
+				seedCommit("v1.2.3", "api-v0.4.1"),
+				testCommit{msg: "fix(api): correct typo"},
+			)
+			v, err := r.PreviewBump(tc.scope, tc.level)
+			checkFatal(t, err)
+			assert.Equal(t, tc.expected, v.String())
+		})
+	}
+}
+
+func TestPreviewBumpScopes(t *testing.T) {
+	r := newRepoFixture(t, GitRepoConfig{Scheme: "scope-conventional", AllScopes: true, Prefix: true},
+		seedCommit("api-v1.0.0", "web-v1.0.0"),
+		testCommit{msg: "fix(api): correct typo"},
+		testCommit{msg: "feat(web): add search"},
+	)
+	v, err := r.PreviewBump("api", BumpMajor)
+	checkFatal(t, err)
+	assert.Equal(t, "2.0.0", v.String())
+
+	// the preview of a level leaves the release plan of the commits as it is
+	tags, errs := previewTags(t, r)
+	assert.Nil(t, errs)
+	assert.Equal(t, map[string]string{"api": "api-v1.0.1", "web": "web-v1.1.0"}, tags)
+}
+
+func TestPreviewBumpErrors(t *testing.T) {
+	r := newRepoFixture(t, GitRepoConfig{Scheme: "conventional"}, seedCommit("v1.2.3"))
+	_, err := r.PreviewBump("", BumpNone)
+	assert.Error(t, err)
+	_, err = r.PreviewBump("api", BumpMinor)
+	assert.Error(t, err)
+
+	r = newRepoFixture(t, GitRepoConfig{Scheme: "conventional", MaxMajor: 1}, seedCommit("v1.2.3"))
+	_, err = r.PreviewBump("", BumpMajor)
+	assert.True(t, errors.Is(err, ErrMajorCap), "error: %v", err)
+}
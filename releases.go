@@ -0,0 +1,77 @@
+package autotag
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/go-version"
+)
+
+// Release is a release tracked outside of git, eg: a GitHub release with its `tag_name` and
+// `prerelease` flag, see GitRepoConfig.Releases
+type Release struct {
+	// Tag is the name of the tag of the release, in the tag format of the scheme, eg: `v1.2.3` or
+	// `api-v1.2.3`
+	Tag string
+
+	// PreRelease marks the release as a pre-release even if its version has no pre-release, so it
+	// is only selected as base version with BaseOnPreRelease
+	PreRelease bool
+}
+
+// SetReleases replaces the releases the base versions are selected from, see
+// GitRepoConfig.Releases, and calculates the next version again. With no releases the version
+// tags are read again.
+func (r *GitRepo) SetReleases(releases []Release) error {
+	if err := validateReleases(releases); err != nil {
+		return err
+	}
+	r.releases = copyReleases(releases)
+	r.RefreshTags()
+	return r.calculate()
+}
+
+// validateReleases checks the releases set with Releases: every release needs a tag
+func validateReleases(releases []Release) error {
+	for i, release := range releases {
+		if release.Tag == "" {
+			return fmt.Errorf("release %d has no tag", i)
+		}
+	}
+	return nil
+}
+
+// loadReleases returns the tag index of the releases, in place of the version tags. The commit of
+// a release is nil if its tag doesn't exist in the repo, all commits are parsed from such a base.
+func (r *GitRepo) loadReleases() tagIndex {
+	index := make(tagIndex)
+	for _, release := range r.releases {
+		scope, v, ok := r.parseTagName(release.Tag)
+		if !ok {
+			r.debugf("skipping release of non version tag: %s", release.Tag)
+			continue
+		}
+		tagScope := scope
+		scope = r.normalizeScope(scope)
+
+		// the tag is resolved right away, a release without a tag in the repo has no target
+		var target *tagTarget
+		if c, err := r.tagCommit(release.Tag); err == nil {
+			target = &tagTarget{resolved: true, commit: c}
+		} else {
+			r.infof("no tag of the release %s, parsing all commits from it\n", release.Tag)
+		}
+		if index[scope] == nil {
+			index[scope] = make(map[*version.Version]tagRef)
+		}
+		index[scope][v] = tagRef{name: release.Tag, scope: tagScope, target: target, preRelease: release.PreRelease}
+	}
+	return index
+}
+
+// copyReleases returns a copy of the releases, so the caller's slice isn't aliased
+func copyReleases(releases []Release) []Release {
+	if len(releases) == 0 {
+		return nil
+	}
+	return append([]Release(nil), releases...)
+}
@@ -0,0 +1,100 @@
+package autotag
+
+import (
+	"testing"
+
+	"github.com/alecthomas/assert"
+)
+
+func TestReleases(t *testing.T) {
+	tests := []struct {
+		name        string
+		tags        []string
+		releases    []Release
+		baseOnPre   bool
+		expectedTag string
+	}{
+		{
+			name:        "stable release",
+			tags:        []string{"v1.0.0"},
+			releases:    []Release{{Tag: "v1.0.0"}},
+			expectedTag: "v1.0.1",
+		},
+		{
+			name:        "overrides the tags",
+			tags:        []string{"v1.0.0", "v2.0.0"},
+			releases:    []Release{{Tag: "v1.0.0"}},
+			expectedTag: "v1.0.1",
+		},
+		{
+			name:        "skips releases flagged as pre-release",
+			tags:        []string{"v1.0.0", "v1.1.0"},
+			releases:    []Release{{Tag: "v1.0.0"}, {Tag: "v1.1.0", PreRelease: true}},
+			expectedTag: "v1.0.1",
+		},
+		{
+			name:        "base on pre-release",
+			tags:        []string{"v1.0.0-rc.1"},
+			releases:    []Release{{Tag: "v1.0.0-rc.1", PreRelease: true}},
+			baseOnPre:   true,
+			expectedTag: "v1.0.0",
+		},
+		{
+			name:        "release without a tag",
+			releases:    []Release{{Tag: "v1.4.0"}},
+			expectedTag: "v1.5.0",
+		},
+		{
+			name:        "non version releases",
+			tags:        []string{"v1.0.0"},
+			releases:    []Release{{Tag: "nightly"}, {Tag: "v1.0.0"}},
+			expectedTag: "v1.0.1",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := GitRepoConfig{
+				Scheme:           "conventional",
+				Prefix:           true,
+				Releases:         tc.releases,
+				BaseOnPreRelease: tc.baseOnPre,
+			}
+			r := newRepoFixture(t, cfg,
+				testCommit{msg: "feat: add login"},
+				seedCommit(tc.tags...),
+				testCommit{msg: "fix: correct typo"},
+			)
+			assert.Equal(t, tc.expectedTag, r.LatestVersion())
+		})
+	}
+}
+
+func TestScopeSchemeReleases(t *testing.T) {
+	cfg := GitRepoConfig{
+		Scheme:   "scope-conventional",
+		Prefix:   true,
+		Releases: []Release{{Tag: "api-v1.2.0"}, {Tag: "api-v1.3.0-beta.1", PreRelease: true}},
+	}
+	r := newRepoFixture(t, cfg,
+		seedCommit("api-v1.2.0", "api-v1.5.0"),
+		testCommit{msg: "fix(api): handle nil"},
+	)
+	assert.Equal(t, "api-v1.2.1", r.Result().Tag)
+}
+
+func TestSetReleases(t *testing.T) {
+	r := newRepoFixture(t, GitRepoConfig{Scheme: "conventional", Prefix: true},
+		seedCommit("v1.0.0", "v2.0.0"),
+		testCommit{msg: "fix: correct typo"},
+	)
+	assert.Equal(t, "v2.0.1", r.LatestVersion())
+
+	checkFatal(t, r.SetReleases([]Release{{Tag: "v1.0.0"}, {Tag: "v2.0.0", PreRelease: true}}))
+	assert.Equal(t, "v1.0.1", r.LatestVersion())
+
+	checkFatal(t, r.SetReleases(nil))
+	assert.Equal(t, "v2.0.1", r.LatestVersion())
+
+	assert.Error(t, r.SetReleases([]Release{{PreRelease: true}}))
+}
@@ -0,0 +1,159 @@
+package autotag
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gogs/git-module"
+)
+
+// ErrRemoteTagExists is returned by AutoTag when the tag of the new version already exists on the
+// checked remote, eg: because the local tags are stale, so it fails before a push gets rejected
+var ErrRemoteTagExists = errors.New("tag already exists on the remote")
+
+// ErrBranchBehind is returned by AutoTag with RequireUpToDate when the upstream of the branch has
+// commits the branch doesn't have, so the tag would be stale right away
+var ErrBranchBehind = errors.New("branch is behind its upstream")
+
+// checkRemoteTag returns ErrRemoteTagExists if the ref of tagName exists on the configured remote.
+// Nothing is checked if no remote is configured.
+func (r *GitRepo) checkRemoteTag(tagName string) error {
+	if r.checkRemote == "" {
+		return nil
+	}
+
+	ref := r.refNamespace + "/" + tagName
+	exists, err := r.remoteRefExists(r.checkRemote, ref)
+	if err != nil {
+		return fmt.Errorf("error checking remote '%s' for tag %s: %s", r.checkRemote, tagName, err)
+	}
+	if exists {
+		return fmt.Errorf("%w: %s on %s", ErrRemoteTagExists, tagName, r.checkRemote)
+	}
+	return nil
+}
+
+// remoteRefExists reports whether ref exists on remote, using `git ls-remote`. Its patterns match
+// the tail of refs, so only an exact match counts.
+func (r *GitRepo) remoteRefExists(remote, ref string) (bool, error) {
+	out, err := git.NewCommand("ls-remote", "--refs", remote, ref).RunInDir(r.repo.Path())
+	if err != nil {
+		return false, err
+	}
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[1] == ref {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// remoteVersionTags returns the commit SHAs of the version tags on the TagRemote by tag name,
+// relative to the version ref namespace, using `git ls-remote`. Annotated tags are peeled to the
+// tagged commit. The tags are cached until RefreshTags is called.
+func (r *GitRepo) remoteVersionTags() (map[string]string, error) {
+	if r.remoteTagCommits != nil {
+		return r.remoteTagCommits, nil
+	}
+
+	var out []byte
+	err := r.retryGit(func() (err error) {
+		out, err = git.NewCommand("ls-remote", r.tagRemote).RunInDir(r.repo.Path())
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing the tags of remote '%s': %s", r.tagRemote, err)
+	}
+
+	prefix := r.refNamespace + "/"
+	commits := make(map[string]string)
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 || !strings.HasPrefix(fields[1], prefix) {
+			continue
+		}
+		name := strings.TrimPrefix(fields[1], prefix)
+		// the peeled ref of an annotated tag points to the commit instead of the tag object
+		if tag, ok := strings.CutSuffix(name, "^{}"); ok {
+			commits[tag] = fields[0]
+		} else if _, ok := commits[name]; !ok {
+			commits[name] = fields[0]
+		}
+	}
+	r.remoteTagCommits = commits
+	return commits, nil
+}
+
+// remoteTagCommit returns the commit the version tag name on the TagRemote points to. The commit
+// has to be in the local repo.
+func (r *GitRepo) remoteTagCommit(name string) (*git.Commit, error) {
+	commits, err := r.remoteVersionTags()
+	if err != nil {
+		return nil, err
+	}
+	id, ok := commits[name]
+	if !ok {
+		return nil, fmt.Errorf("tag %s doesn't exist on remote '%s'", name, r.tagRemote)
+	}
+	c, err := r.repo.CommitByRevision(id)
+	if err != nil {
+		return nil, fmt.Errorf("commit %s of tag %s on remote '%s' is missing, fetch the history of the branch: %s", id, name, r.tagRemote, err)
+	}
+	return c, nil
+}
+
+// pushRemoteTag pushes the tag tagName to the TagRemote with RemoteTags
+func (r *GitRepo) pushRemoteTag(tagName string) error {
+	if !r.remoteTags {
+		return nil
+	}
+
+	ref := r.refNamespace + "/" + tagName
+	r.infof("Pushing Tag %s to %s", tagName, r.tagRemote)
+	err := r.retryGit(func() error {
+		_, err := git.NewCommand("push", r.tagRemote, ref+":"+ref).RunInDir(r.repo.Path())
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("error pushing tag %s to remote '%s': %s", tagName, r.tagRemote, err)
+	}
+	return nil
+}
+
+// trackingRemote returns the remote the branch tracks, ie: the `branch.<name>.remote` config set
+// by `git push -u` or `git branch --set-upstream-to`. It fails if the branch tracks no remote.
+func trackingRemote(repoPath, branch string) (string, error) {
+	out, err := git.NewCommand("config", "--get", "branch."+branch+".remote").RunInDir(repoPath)
+	remote := strings.TrimSpace(string(out))
+	// `.` is the local repo, for a branch tracking another local branch
+	if err != nil || remote == "" || remote == "." {
+		return "", fmt.Errorf("branch '%s' has no upstream remote, set the tag remote explicitly", branch)
+	}
+	return remote, nil
+}
+
+// checkUpToDate returns ErrBranchBehind if the upstream of the branch is ahead of it, when
+// RequireUpToDate is set. The upstream is the remote-tracking branch git tracks for the branch, as
+// of the last fetch.
+func (r *GitRepo) checkUpToDate() error {
+	if !r.requireUpToDate {
+		return nil
+	}
+
+	out, err := git.NewCommand("rev-parse", "--symbolic-full-name", r.branch+"@{upstream}").RunInDir(r.repo.Path())
+	if err != nil {
+		return fmt.Errorf("branch '%s' has no upstream configured: %s", r.branch, err)
+	}
+	upstream := strings.TrimSpace(string(out))
+
+	behind, err := r.repo.RevListCount([]string{r.branchID + ".." + upstream})
+	if err != nil {
+		return fmt.Errorf("error comparing branch '%s' with %s: %s", r.branch, upstream, err)
+	}
+	if behind > 0 {
+		return fmt.Errorf("%w: %s is %d commit(s) ahead of %s", ErrBranchBehind, upstream, behind, r.branch)
+	}
+	return nil
+}
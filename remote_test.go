@@ -0,0 +1,317 @@
+package autotag
+
+import (
+	"errors"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/assert"
+	"github.com/gogs/git-module"
+)
+
+// runGit runs a git command in dir and returns its trimmed output
+func runGit(t *testing.T, dir string, args ...string) string {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v failed: %s: %s", args, err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func TestCheckRemote(t *testing.T) {
+	tests := []struct {
+		name        string
+		remoteTags  []string
+		checkRemote string
+		shouldErr   bool
+		expectedErr error
+	}{
+		{
+			name:        "tag exists on the remote",
+			remoteTags:  []string{"v1.1.0"},
+			checkRemote: "origin",
+			shouldErr:   true,
+			expectedErr: ErrRemoteTagExists,
+		},
+		{
+			name:        "other tags on the remote",
+			remoteTags:  []string{"v1.0.1", "api-v1.1.0"},
+			checkRemote: "origin",
+		},
+		{
+			name:       "check disabled",
+			remoteTags: []string{"v1.1.0"},
+		},
+		{
+			name:        "unknown remote",
+			checkRemote: "upstream",
+			shouldErr:   true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tr := createTestRepo(t, "")
+			repo, err := git.Open(tr)
+			checkFatal(t, err)
+			defer cleanupTestRepo(t, repo)
+
+			seedTestRepo(t, "v1.0.0", repo)
+			updateReadme(t, repo, "[minor] add login")
+
+			// the remote has the tags the local repo doesn't know about yet
+			remote := filepath.Join(t.TempDir(), "remote.git")
+			runGit(t, tr, "clone", "--bare", tr, remote)
+			for _, tag := range tc.remoteTags {
+				runGit(t, remote, "tag", tag)
+			}
+			runGit(t, tr, "remote", "add", "origin", remote)
+
+			r, err := NewRepo(GitRepoConfig{
+				RepoPath:    repo.Path(),
+				Branch:      "master",
+				Prefix:      true,
+				CheckRemote: tc.checkRemote,
+			})
+			checkFatal(t, err)
+
+			err = r.AutoTag()
+			if !tc.shouldErr {
+				assert.NoError(t, err)
+				return
+			}
+			assert.Error(t, err)
+			if tc.expectedErr != nil {
+				assert.True(t, errors.Is(err, tc.expectedErr), "expected %v, got %v", tc.expectedErr, err)
+			}
+
+			tags, err := repo.Tags()
+			checkFatal(t, err)
+			assert.NotContains(t, tags, "v1.1.0")
+		})
+	}
+}
+
+func TestRequireUpToDate(t *testing.T) {
+	tests := []struct {
+		name            string
+		upstream        string
+		requireUpToDate bool
+		shouldErr       bool
+		expectedErr     error
+	}{
+		{
+			name:            "up to date",
+			upstream:        "HEAD",
+			requireUpToDate: true,
+		},
+		{
+			name:            "ahead of the upstream",
+			upstream:        "HEAD~1",
+			requireUpToDate: true,
+		},
+		{
+			name:            "behind the upstream",
+			upstream:        "newer",
+			requireUpToDate: true,
+			shouldErr:       true,
+			expectedErr:     ErrBranchBehind,
+		},
+		{
+			name:            "no upstream",
+			requireUpToDate: true,
+			shouldErr:       true,
+		},
+		{
+			name:     "check disabled",
+			upstream: "newer",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := newRepoFixture(t, GitRepoConfig{Prefix: true, RequireUpToDate: tc.requireUpToDate},
+				seedCommit("v1.0.0"),
+				testCommit{msg: "[minor] add login"},
+			)
+			dir := r.repo.Path()
+
+			if tc.upstream != "" {
+				upstream := tc.upstream
+				if upstream == "newer" {
+					// a commit on top of the branch that was fetched but not pulled
+					upstream = runGit(t, dir, "-c", "user.name=autotag", "-c", "user.email=autotag@example.com",
+						"commit-tree", "-p", "HEAD", "-m", "fix: pushed by someone else", "HEAD^{tree}")
+				}
+				runGit(t, dir, "remote", "add", "origin", filepath.Join(t.TempDir(), "remote.git"))
+				runGit(t, dir, "update-ref", "refs/remotes/origin/master", upstream)
+				runGit(t, dir, "config", "branch.master.remote", "origin")
+				runGit(t, dir, "config", "branch.master.merge", "refs/heads/master")
+			}
+
+			err := r.AutoTag()
+			if !tc.shouldErr {
+				assert.NoError(t, err)
+				return
+			}
+			assert.Error(t, err)
+			if tc.expectedErr != nil {
+				assert.True(t, errors.Is(err, tc.expectedErr), "expected %v, got %v", tc.expectedErr, err)
+			}
+
+			tags, err := r.repo.Tags()
+			checkFatal(t, err)
+			assert.NotContains(t, tags, "v1.1.0")
+		})
+	}
+}
+
+func TestRemoteTags(t *testing.T) {
+	tests := []struct {
+		name        string
+		scheme      string
+		remoteTags  []string
+		annotated   bool
+		remote      string
+		tagRemote   string
+		expectedTag string
+	}{
+		{
+			name:        "lightweight tags",
+			scheme:      "conventional",
+			remoteTags:  []string{"v1.0.0", "v1.1.0"},
+			expectedTag: "v1.2.0",
+		},
+		{
+			name:        "annotated tags",
+			scheme:      "conventional",
+			remoteTags:  []string{"v1.0.0", "v1.1.0"},
+			annotated:   true,
+			expectedTag: "v1.2.0",
+		},
+		{
+			name:        "scopes",
+			scheme:      "scope-conventional",
+			remoteTags:  []string{"api-v1.0.0", "web-v2.0.0"},
+			expectedTag: "api-v1.1.0",
+		},
+		{
+			name:        "other remote",
+			scheme:      "conventional",
+			remoteTags:  []string{"v3.0.0"},
+			remote:      "fork",
+			tagRemote:   "fork",
+			expectedTag: "v3.1.0",
+		},
+		{
+			name:        "tracked remote",
+			scheme:      "conventional",
+			remoteTags:  []string{"v3.0.0"},
+			remote:      "upstream",
+			expectedTag: "v3.1.0",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tr := createTestRepo(t, "")
+			repo, err := git.Open(tr)
+			checkFatal(t, err)
+			defer cleanupTestRepo(t, repo)
+
+			seedTestRepo(t, "v0.1.0", repo)
+			remoteName := tc.remote
+			if remoteName == "" {
+				remoteName = "origin"
+			}
+			remote := filepath.Join(t.TempDir(), "remote.git")
+			runGit(t, tr, "clone", "--bare", tr, remote)
+			for _, tag := range tc.remoteTags {
+				if tc.annotated {
+					runGit(t, remote, "tag", "-a", tag, "-m", "release "+tag)
+				} else {
+					runGit(t, remote, "tag", tag)
+				}
+			}
+			runGit(t, tr, "remote", "add", remoteName, remote)
+			if tc.tagRemote == "" {
+				// the remote of the tags is the one the branch tracks, origin has none of them
+				runGit(t, tr, "config", "branch.master.remote", remoteName)
+				if remoteName != "origin" {
+					runGit(t, tr, "remote", "add", "origin", filepath.Join(t.TempDir(), "missing.git"))
+				}
+			}
+			// the local repo has no (version) tags
+			runGit(t, tr, "tag", "-d", "v0.1.0")
+			updateReadme(t, repo, "feat(api): add login")
+
+			r, err := NewRepo(GitRepoConfig{
+				RepoPath:   repo.Path(),
+				Branch:     "master",
+				Scheme:     tc.scheme,
+				Prefix:     true,
+				RemoteTags: true,
+				TagRemote:  tc.tagRemote,
+			})
+			checkFatal(t, err)
+			assert.Equal(t, tc.expectedTag, r.Result().Tag)
+
+			checkFatal(t, r.AutoTag())
+			assert.Equal(t, tc.expectedTag, runGit(t, remote, "tag", "--list", tc.expectedTag))
+		})
+	}
+}
+
+func TestRemoteTagsMissingCommit(t *testing.T) {
+	tr := createTestRepo(t, "")
+	repo, err := git.Open(tr)
+	checkFatal(t, err)
+	defer cleanupTestRepo(t, repo)
+
+	seedTestRepo(t, "v0.1.0", repo)
+	remote := filepath.Join(t.TempDir(), "remote.git")
+	runGit(t, tr, "clone", "--bare", tr, remote)
+	runGit(t, tr, "remote", "add", "origin", remote)
+	runGit(t, tr, "config", "branch.master.remote", "origin")
+
+	// a tag of a commit the local repo doesn't have
+	work := filepath.Join(t.TempDir(), "work")
+	runGit(t, tr, "clone", remote, work)
+	runGit(t, work, "commit", "--allow-empty", "-m", "fix: elsewhere")
+	runGit(t, work, "tag", "v1.0.0")
+	runGit(t, work, "push", "origin", "v1.0.0")
+
+	_, err = NewRepo(GitRepoConfig{
+		RepoPath:   repo.Path(),
+		Branch:     "master",
+		Prefix:     true,
+		RemoteTags: true,
+	})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "is missing")
+}
+
+func TestRemoteTagsWithoutUpstream(t *testing.T) {
+	tr := createTestRepo(t, "")
+	repo, err := git.Open(tr)
+	checkFatal(t, err)
+	defer cleanupTestRepo(t, repo)
+
+	seedTestRepo(t, "v0.1.0", repo)
+	remote := filepath.Join(t.TempDir(), "remote.git")
+	runGit(t, tr, "clone", "--bare", tr, remote)
+	runGit(t, tr, "remote", "add", "origin", remote)
+
+	_, err = NewRepo(GitRepoConfig{
+		RepoPath:   repo.Path(),
+		Branch:     "master",
+		Prefix:     true,
+		RemoteTags: true,
+	})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "has no upstream remote")
+}
@@ -0,0 +1,62 @@
+package autotag
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+
+	"github.com/hashicorp/go-version"
+)
+
+// sampleResult is rendered when a result template is parsed, so references to unknown fields
+// fail before any real result is rendered
+var sampleResult = Result{
+	Scope:   "scope",
+	Current: version.Must(version.NewVersion("1.0.0")),
+	Next:    version.Must(version.NewVersion("1.1.0")),
+	Tag:     "scope-v1.1.0",
+}
+
+// Level is the bump level of the result, the highest changed segment of the major.minor.patch core
+// from Current to Next. It is BumpNone if Next is nil.
+func (res Result) Level() BumpLevel {
+	return res.Delta().Level
+}
+
+// ParseResultTemplate parses tmpl, a text/template rendering a Result, eg:
+// `{{.Scope}}: {{.Current}} -> {{.Next}} ({{.Level}})`. The fields and methods of Result are
+// available in the template. Unknown fields are reported here rather than when rendering.
+func ParseResultTemplate(tmpl string) (*template.Template, error) {
+	t, err := template.New("result").Parse(tmpl)
+	if err != nil {
+		return nil, fmt.Errorf("invalid result template: %s", err)
+	}
+	if err := t.Execute(io.Discard, sampleResult); err != nil {
+		return nil, fmt.Errorf("invalid result template: %s", err)
+	}
+	return t, nil
+}
+
+// Render renders the result with the template t, see ParseResultTemplate
+func (res Result) Render(t *template.Template) (string, error) {
+	var b strings.Builder
+	if err := t.Execute(&b, res); err != nil {
+		return "", fmt.Errorf("error rendering the result of %s: %s", res.Tag, err)
+	}
+	return b.String(), nil
+}
+
+// RenderResult renders the Result of the repo with the text/template tmpl, see
+// ParseResultTemplate. It fails with AllScopes, which calculates no version of the repo; render
+// the results of Preview instead.
+func (r *GitRepo) RenderResult(tmpl string) (string, error) {
+	t, err := ParseResultTemplate(tmpl)
+	if err != nil {
+		return "", err
+	}
+	if r.newVersion == nil {
+		return "", fmt.Errorf("no version calculated to render")
+	}
+	return r.Result().Render(t)
+}
@@ -0,0 +1,92 @@
+package autotag
+
+import (
+	"testing"
+
+	"github.com/alecthomas/assert"
+)
+
+func TestParseResultTemplate(t *testing.T) {
+	tests := []struct {
+		name      string
+		tmpl      string
+		shouldErr bool
+	}{
+		{name: "fields and methods", tmpl: "{{.Scope}}: {{.Current}} -> {{.Next}} ({{.Level}}, {{.Delta}})"},
+		{name: "conditionals", tmpl: "{{if .PreRelease}}beta{{else}}stable{{end}} {{.Tag}}"},
+		{name: "syntax error", tmpl: "{{.Scope", shouldErr: true},
+		{name: "unknown field", tmpl: "{{.Version}}", shouldErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParseResultTemplate(tc.tmpl)
+			assert.Equal(t, tc.shouldErr, err != nil, "error: %v", err)
+		})
+	}
+}
+
+func TestRenderResult(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      GitRepoConfig
+		tags     []string
+		msg      string
+		tmpl     string
+		expected string
+	}{
+		{
+			name:     "conventional",
+			cfg:      GitRepoConfig{Scheme: "conventional", Prefix: true},
+			tags:     []string{"v1.0.0"},
+			msg:      "feat: add a flag",
+			tmpl:     "{{.Current}} -> {{.Next}} ({{.Level}}) {{.Tag}}",
+			expected: "1.0.0 -> 1.1.0 (minor) v1.1.0",
+		},
+		{
+			name:     "scope",
+			cfg:      GitRepoConfig{Scheme: "scope-conventional"},
+			tags:     []string{"api-v1.2.3"},
+			msg:      "fix(api): handle nil",
+			tmpl:     "{{.Scope}}: {{.Current}} -> {{.Next}} ({{.Level}})",
+			expected: "api: 1.2.3 -> 1.2.4 (patch)",
+		},
+		{
+			name:     "pre-release",
+			cfg:      GitRepoConfig{Scheme: "conventional", Prefix: true, PreReleaseName: "rc"},
+			tags:     []string{"v1.0.0"},
+			msg:      "feat!: drop the old API",
+			tmpl:     "{{if .PreRelease}}beta{{else}}stable{{end}} {{.Next}}",
+			expected: "beta 2.0.0-rc",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := newRepoFixture(t, tc.cfg,
+				seedCommit(tc.tags...),
+				testCommit{msg: tc.msg},
+			)
+			out, err := r.RenderResult(tc.tmpl)
+			checkFatal(t, err)
+			assert.Equal(t, tc.expected, out)
+		})
+	}
+}
+
+func TestRenderResultAllScopes(t *testing.T) {
+	r := newRepoFixture(t, GitRepoConfig{Scheme: "scope-conventional", AllScopes: true},
+		seedCommit("api-v1.0.0"),
+		testCommit{msg: "fix(api): handle nil"},
+	)
+	_, err := r.RenderResult("{{.Tag}}")
+	assert.Error(t, err)
+
+	results, err := r.Preview()
+	checkFatal(t, err)
+	tmpl, err := ParseResultTemplate("{{.Scope}}: {{.Current}} -> {{.Next}} ({{.Level}})")
+	checkFatal(t, err)
+	out, err := results[0].Render(tmpl)
+	checkFatal(t, err)
+	assert.Equal(t, "api: 1.0.0 -> 1.0.1 (patch)", out)
+}
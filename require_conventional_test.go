@@ -0,0 +1,115 @@
+package autotag
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/alecthomas/assert"
+)
+
+func TestRequireConventional(t *testing.T) {
+	tests := []struct {
+		name        string
+		messages    []string
+		require     bool
+		expectedTag string
+		expectedErr error
+	}{
+		{
+			name:        "free-form commit is a patch by default",
+			messages:    []string{"fixed the bug"},
+			expectedTag: "v1.0.1",
+		},
+		{
+			name:        "free-form commit with a bump footer",
+			messages:    []string{"fixed the bug\n\nRelease-As: major"},
+			expectedTag: "v2.0.0",
+		},
+		{
+			name:        "free-form commits are ignored",
+			messages:    []string{"fixed the bug", "update the docs\n\nRelease-As: major"},
+			require:     true,
+			expectedErr: ErrNoBump,
+		},
+		{
+			name:        "conventional commits still bump",
+			messages:    []string{"added search\n\nRelease-As: major", "feat: add login", "Fix: correct typo"},
+			require:     true,
+			expectedTag: "v1.1.0",
+		},
+		{
+			name:        "unknown types are conventional",
+			messages:    []string{"fixed the bug\n\nRelease-As: major", "fixed: the bug"},
+			require:     true,
+			expectedTag: "v1.0.1",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			commits := []testCommit{seedCommit("v1.0.0")}
+			for _, msg := range tc.messages {
+				commits = append(commits, testCommit{msg: msg})
+			}
+			r, err := NewRepo(GitRepoConfig{
+				RepoPath:            newRepoDir(t, commits...),
+				Branch:              "master",
+				Scheme:              "conventional",
+				Prefix:              true,
+				RequireConventional: tc.require,
+				SkipEmptyRelease:    true,
+			})
+			if tc.expectedErr != nil {
+				assert.True(t, errors.Is(err, tc.expectedErr), "expected %v, got %v", tc.expectedErr, err)
+				return
+			}
+			checkFatal(t, err)
+			assert.Equal(t, tc.expectedTag, r.LatestVersion())
+		})
+	}
+}
+
+func TestRequireConventionalMixed(t *testing.T) {
+	// a free-form commit next to a conventional one leaves the bump of the conventional one as it is
+	tests := []struct {
+		messages    []string
+		expectedTag string
+	}{
+		{messages: []string{"reworked the login", "feat: add login"}, expectedTag: "v1.1.0"},
+		{messages: []string{"fix: correct typo", "reworked the login"}, expectedTag: "v1.0.1"},
+	}
+
+	for _, tc := range tests {
+		for _, require := range []bool{false, true} {
+			commits := []testCommit{seedCommit("v1.0.0")}
+			for _, msg := range tc.messages {
+				commits = append(commits, testCommit{msg: msg})
+			}
+			r := newRepoFixture(t, GitRepoConfig{Scheme: "conventional", Prefix: true, RequireConventional: require}, commits...)
+			assert.Equal(t, tc.expectedTag, r.LatestVersion(), "messages: %q, require: %v", tc.messages, require)
+		}
+	}
+}
+
+func TestRequireConventionalScopes(t *testing.T) {
+	for _, require := range []bool{false, true} {
+		r := newRepoFixture(t, GitRepoConfig{Scheme: "scope-conventional", AllScopes: true, Prefix: true, RequireConventional: require},
+			seedCommit("api-v1.0.0", "web-v1.0.0"),
+			testCommit{msg: "feat(api) add login"},
+			testCommit{msg: "fix(web): correct the layout"},
+		)
+		tags, errs := previewTags(t, r)
+		assert.Nil(t, errs)
+		// without a colon the header isn't conventional
+		expected := map[string]string{"api": "api-v1.1.0", "web": "web-v1.0.1"}
+		if require {
+			expected = map[string]string{"api": "", "web": "web-v1.0.1"}
+		}
+		assert.Equal(t, expected, tags, "require: %v", require)
+	}
+}
+
+func TestRequireConventionalScheme(t *testing.T) {
+	err := validateConfig(GitRepoConfig{Scheme: "autotag", RequireConventional: true})
+	assert.Error(t, err)
+}
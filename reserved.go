@@ -0,0 +1,67 @@
+package autotag
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/hashicorp/go-version"
+)
+
+// ErrReservedVersion is returned with the ReservedError policy when the next version is one of the
+// ReservedVersions
+var ErrReservedVersion = errors.New("version is reserved")
+
+// Reserved version policies decide what happens when the next version is reserved, see
+// GitRepoConfig.ReservedVersions.
+const (
+	// ReservedSkip bumps the reserved version again by the same level (default)
+	ReservedSkip = "skip"
+	// ReservedError fails with ErrReservedVersion
+	ReservedError = "error"
+)
+
+// parseReservedVersions parses the ReservedVersions
+func parseReservedVersions(versions []string) ([]*version.Version, error) {
+	reserved := make([]*version.Version, 0, len(versions))
+	for _, s := range versions {
+		v, err := parseVersion(s)
+		if err != nil {
+			return nil, fmt.Errorf("reserved version '%s' is not valid: %s", s, err)
+		}
+		reserved = append(reserved, v)
+	}
+	return reserved, nil
+}
+
+// isReserved reports whether the core version of v is reserved
+func (r *GitRepo) isReserved(v *version.Version) bool {
+	for _, reserved := range r.reservedVersions {
+		if sameCoreVersion(reserved, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// avoidReserved returns next, bumped from base, unless it is reserved: with the ReservedSkip policy
+// the version is bumped again by the same level until it isn't, eg: 1.3.0 to 1.5.0 if 1.4.0 is
+// reserved, with ReservedError ErrReservedVersion is returned.
+func (r *GitRepo) avoidReserved(base, next *version.Version) (*version.Version, error) {
+	level := NewVersionDelta(base, next).Level
+	for r.isReserved(next) {
+		if r.reservedPolicy == ReservedError || level == BumpNone {
+			return nil, fmt.Errorf("%w: %s", ErrReservedVersion, next)
+		}
+		skipped := next
+		v, err := r.bumpVersion(level.bumper(), skipped)
+		if err != nil {
+			return nil, err
+		}
+		if v == nil || !v.GreaterThan(skipped) {
+			return nil, fmt.Errorf("%w: %s can't be skipped", ErrReservedVersion, skipped)
+		}
+		next = v
+		r.infof("skipping reserved version %s, using %s\n", skipped, next)
+	}
+	return next, nil
+}
@@ -0,0 +1,89 @@
+package autotag
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/alecthomas/assert"
+	"github.com/gogs/git-module"
+)
+
+func TestReservedVersions(t *testing.T) {
+	tests := []struct {
+		name          string
+		cfg           GitRepoConfig
+		msg           string
+		expectedTag   string
+		expectedError error
+	}{
+		{
+			name:        "minor bump skips the reserved version",
+			cfg:         GitRepoConfig{ReservedVersions: []string{"1.4.0"}},
+			msg:         "feat: add login",
+			expectedTag: "v1.5.0",
+		},
+		{
+			name:        "consecutive reserved versions",
+			cfg:         GitRepoConfig{ReservedVersions: []string{"v1.4.0", "1.5.0"}},
+			msg:         "feat: add login",
+			expectedTag: "v1.6.0",
+		},
+		{
+			name:        "reserved major version",
+			cfg:         GitRepoConfig{ReservedVersions: []string{"2.0.0"}},
+			msg:         "feat!: drop the v1 api",
+			expectedTag: "v3.0.0",
+		},
+		{
+			name:        "pre-release of a reserved version",
+			cfg:         GitRepoConfig{ReservedVersions: []string{"1.4.0"}, PreReleaseName: "rc"},
+			msg:         "feat: add login",
+			expectedTag: "v1.5.0-rc",
+		},
+		{
+			name:        "other versions are not affected",
+			cfg:         GitRepoConfig{ReservedVersions: []string{"1.4.0"}},
+			msg:         "fix: correct typo",
+			expectedTag: "v1.3.1",
+		},
+		{
+			name:          "error policy",
+			cfg:           GitRepoConfig{ReservedVersions: []string{"1.4.0"}, ReservedVersionPolicy: ReservedError},
+			msg:           "feat: add login",
+			expectedError: ErrReservedVersion,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tr := createTestRepo(t, "")
+			repo, err := git.Open(tr)
+			checkFatal(t, err)
+			defer cleanupTestRepo(t, repo)
+
+			seedTestRepo(t, "v1.3.0", repo)
+			updateReadme(t, repo, tc.msg)
+
+			tc.cfg.RepoPath, tc.cfg.Branch, tc.cfg.Scheme, tc.cfg.Prefix = repo.Path(), "master", "conventional", true
+			r, err := NewRepo(tc.cfg)
+			if tc.expectedError != nil {
+				assert.True(t, errors.Is(err, tc.expectedError), "expected %v, got %v", tc.expectedError, err)
+				return
+			}
+			checkFatal(t, err)
+			assert.Equal(t, tc.expectedTag, r.LatestVersion())
+		})
+	}
+}
+
+func TestScopeSchemeReservedVersions(t *testing.T) {
+	r := newRepoFixture(t, GitRepoConfig{Scheme: "scope-conventional", AllScopes: true, ReservedVersions: []string{"1.1.0"}},
+		seedCommit("api-v1.0.0", "web-v1.0.0"),
+		testCommit{msg: "feat(api): add login"},
+		testCommit{msg: "fix(web): correct typo"},
+	)
+	next, err := r.NextScopeVersions()
+	checkFatal(t, err)
+	assert.Equal(t, "1.2.0", next["api"].String())
+	assert.Equal(t, "1.0.1", next["web"].String())
+}
@@ -0,0 +1,95 @@
+package autotag
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gogs/git-module"
+)
+
+// GitRetry retries the git operations that fail transiently, eg: on a locked index or ref while a
+// concurrent fetch runs in CI. Logical errors, eg: a missing branch, are never retried.
+type GitRetry struct {
+	// Attempts is the number of tries of an operation, including the first one. 0 or 1 (default)
+	// don't retry.
+	Attempts int
+
+	// Backoff is the delay before the first retry, doubled for every further retry.
+	Backoff time.Duration
+}
+
+// transientErrors are the messages of git errors that go away when the operation is repeated
+var transientErrors = []string{
+	".lock': file exists",
+	"unable to create '",
+	"cannot lock ref",
+	"another git process seems to be running",
+	"resource temporarily unavailable",
+}
+
+// transientGitError reports whether err is a git error worth retrying
+func transientGitError(err error) bool {
+	if errors.Is(err, git.ErrExecTimeout) {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	for _, transient := range transientErrors {
+		if strings.Contains(msg, transient) {
+			return true
+		}
+	}
+	return false
+}
+
+// validate returns an error if the retry settings are negative
+func (g GitRetry) validate() error {
+	if g.Attempts < 0 {
+		return fmt.Errorf("number of git retry attempts must not be negative, got %d", g.Attempts)
+	}
+	if g.Backoff < 0 {
+		return fmt.Errorf("git retry backoff must not be negative, got %s", g.Backoff)
+	}
+	return nil
+}
+
+// retryGit runs op until it succeeds, fails with an error that isn't transient or the attempts of
+// the GitRetry are used up. The error of the last attempt is returned.
+func (r *GitRepo) retryGit(op func() error) error {
+	delay := r.gitRetry.Backoff
+	for attempt := 1; ; attempt++ {
+		err := op()
+		if err == nil || attempt >= r.gitRetry.Attempts || !transientGitError(err) {
+			return err
+		}
+		r.infof("retrying git operation after attempt %d of %d failed: %s\n", attempt, r.gitRetry.Attempts, err)
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// branchCommit returns the latest commit of the branch, or the commit of Rev
+func (r *GitRepo) branchCommit() (*git.Commit, error) {
+	var commit *git.Commit
+	err := r.retryGit(func() (err error) {
+		if r.rev != "" {
+			commit, err = r.repo.CommitByRevision(r.rev + "^{commit}")
+			return err
+		}
+		commit, err = r.repo.BranchCommit(r.branch)
+		return err
+	})
+	if err != nil && r.rev != "" {
+		return nil, fmt.Errorf("revision '%s' not found: %s", r.rev, err)
+	}
+	return commit, err
+}
+
+// tipRev is the revision the history is read from: Rev, or the head of the branch
+func (r *GitRepo) tipRev() string {
+	if r.rev != "" {
+		return r.rev + "^{commit}"
+	}
+	return "refs/heads/" + r.branch
+}
@@ -0,0 +1,102 @@
+package autotag
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/alecthomas/assert"
+	"github.com/gogs/git-module"
+)
+
+var errLockedRef = errors.New("cannot lock ref 'refs/tags/v1.0.1': Unable to create '.git/refs/tags/v1.0.1.lock': File exists")
+
+// flakyTagger fails the first failures tag creations with err, then creates the tags
+type flakyTagger struct {
+	fakeTagger
+	err      error
+	failures int
+	calls    int
+}
+
+func (f *flakyTagger) Create(name, target, message string, annotated bool) error {
+	f.calls++
+	if f.calls <= f.failures {
+		return f.err
+	}
+	return f.fakeTagger.Create(name, target, message, annotated)
+}
+
+func TestTransientGitError(t *testing.T) {
+	tests := []struct {
+		err       error
+		transient bool
+	}{
+		{err: errLockedRef, transient: true},
+		{err: errors.New("fatal: Unable to create '/repo/.git/index.lock': File exists."), transient: true},
+		{err: errors.New("Another git process seems to be running in this repository"), transient: true},
+		{err: fmt.Errorf("listing tags: %w", git.ErrExecTimeout), transient: true},
+		{err: git.ErrRevisionNotExist, transient: false},
+		{err: errors.New("fatal: Needed a single revision"), transient: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.err.Error(), func(t *testing.T) {
+			assert.Equal(t, tc.transient, transientGitError(tc.err))
+		})
+	}
+}
+
+func TestRetryGit(t *testing.T) {
+	tests := []struct {
+		name          string
+		attempts      int
+		failures      int
+		err           error
+		expectedCalls int
+		expectedError bool
+	}{
+		{name: "no retry by default", failures: 1, err: errLockedRef, expectedCalls: 1, expectedError: true},
+		{name: "transient failures", attempts: 3, failures: 2, err: errLockedRef, expectedCalls: 3},
+		{name: "attempts used up", attempts: 2, failures: 2, err: errLockedRef, expectedCalls: 2, expectedError: true},
+		{name: "logical error", attempts: 3, failures: 2, err: git.ErrRevisionNotExist, expectedCalls: 1, expectedError: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &GitRepo{gitRetry: GitRetry{Attempts: tc.attempts}}
+			calls := 0
+			err := r.retryGit(func() error {
+				calls++
+				if calls <= tc.failures {
+					return tc.err
+				}
+				return nil
+			})
+			assert.Equal(t, tc.expectedCalls, calls)
+			assert.Equal(t, tc.expectedError, err != nil)
+		})
+	}
+}
+
+func TestAutoTagGitRetry(t *testing.T) {
+	for _, attempts := range []int{0, 3} {
+		t.Run(fmt.Sprintf("%d attempts", attempts), func(t *testing.T) {
+			tagger := &flakyTagger{err: errLockedRef, failures: 2}
+			r := newRepoFixture(t, GitRepoConfig{Prefix: true, Tagger: tagger, GitRetry: GitRetry{Attempts: attempts}},
+				seedCommit("v1.0.0"),
+				testCommit{msg: "fix: correct typo"},
+			)
+
+			err := r.AutoTag()
+			if attempts == 0 {
+				assert.Error(t, err)
+				assert.Equal(t, 0, len(tagger.created))
+				return
+			}
+			checkFatal(t, err)
+			assert.Equal(t, 3, tagger.calls)
+			assert.Equal(t, "v1.0.1", tagger.created[0].name)
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package autotag
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gogs/git-module"
+	"github.com/hashicorp/go-version"
+)
+
+// ErrReusedVersion is returned with AvoidReusedVersions when the next version was already used by a
+// tag that has been deleted since, eg: after the latest tag was deleted and the next-highest tag
+// became the base again
+var ErrReusedVersion = errors.New("version was used by a deleted tag")
+
+// checkReusedVersion returns ErrReusedVersion if v of scope was used by a deleted tag, when
+// AvoidReusedVersions is set
+func (r *GitRepo) checkReusedVersion(scope string, v *version.Version) error {
+	if !r.avoidReusedVersions || v == nil {
+		return nil
+	}
+
+	deleted, err := r.deletedTags()
+	if err != nil {
+		return err
+	}
+	for _, ref := range deleted[r.normalizeScope(scope)] {
+		if sameTagVersion(ref.version, v) {
+			return fmt.Errorf("%w: %s (tag object %s)", ErrReusedVersion, ref.name, shortID(ref.object))
+		}
+	}
+	return nil
+}
+
+// deletedTag is a version tag that no longer exists, and the tag object it is recorded in
+type deletedTag struct {
+	name    string
+	version *version.Version
+	object  string
+}
+
+// deletedTags returns the deleted version tags by scope, cached after the first call. git drops the
+// reflog of a deleted ref along with it, so the record of the deleted tags are the annotated tag
+// objects they leave behind: `git fsck` reports them as dangling until they are garbage collected.
+// Deleted lightweight tags leave no trace and can't be detected.
+func (r *GitRepo) deletedTags() (map[string][]deletedTag, error) {
+	if r.deleted != nil {
+		return r.deleted, nil
+	}
+
+	out, err := git.NewCommand("fsck", "--dangling", "--no-progress").RunInDir(r.repo.Path())
+	if err != nil {
+		return nil, fmt.Errorf("error looking for deleted tags: %s", err)
+	}
+
+	deleted := make(map[string][]deletedTag)
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 3 || fields[0] != "dangling" || fields[1] != "tag" {
+			continue
+		}
+		object := fields[2]
+		name, err := r.tagObjectName(object)
+		if err != nil {
+			return nil, err
+		}
+		scope, v, ok := r.parseTagName(name)
+		if !ok {
+			continue
+		}
+		r.debugf("found deleted version tag %s in tag object %s\n", name, object)
+		scope = r.normalizeScope(scope)
+		deleted[scope] = append(deleted[scope], deletedTag{name: name, version: v, object: object})
+	}
+	r.deleted = deleted
+	return deleted, nil
+}
+
+// tagObjectName returns the tag name recorded in the annotated tag object with the id
+func (r *GitRepo) tagObjectName(id string) (string, error) {
+	out, err := git.NewCommand("cat-file", "tag", id).RunInDir(r.repo.Path())
+	if err != nil {
+		return "", fmt.Errorf("error reading tag object %s: %s", id, err)
+	}
+	for _, line := range strings.Split(string(out), "\n") {
+		if name, ok := strings.CutPrefix(line, "tag "); ok {
+			return name, nil
+		}
+		if line == "" {
+			// the headers end at the first blank line
+			break
+		}
+	}
+	return "", fmt.Errorf("tag object %s has no tag name", id)
+}
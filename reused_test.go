@@ -0,0 +1,127 @@
+package autotag
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/alecthomas/assert"
+)
+
+func TestAvoidReusedVersions(t *testing.T) {
+	tests := []struct {
+		name                string
+		scheme              string
+		commit              string
+		deletedTag          string
+		annotated           bool
+		avoidReusedVersions bool
+		expectedTag         string
+		shouldErr           bool
+	}{
+		{
+			name:                "deleted annotated tag",
+			commit:              "[minor] add login",
+			deletedTag:          "v1.1.0",
+			annotated:           true,
+			avoidReusedVersions: true,
+			shouldErr:           true,
+		},
+		{
+			name:                "deleted annotated tag of another version",
+			commit:              "[minor] add login",
+			deletedTag:          "v1.2.0",
+			annotated:           true,
+			avoidReusedVersions: true,
+			expectedTag:         "v1.1.0",
+		},
+		{
+			name:                "deleted lightweight tag is not detected",
+			commit:              "[minor] add login",
+			deletedTag:          "v1.1.0",
+			avoidReusedVersions: true,
+			expectedTag:         "v1.1.0",
+		},
+		{
+			name:        "check disabled",
+			commit:      "[minor] add login",
+			deletedTag:  "v1.1.0",
+			annotated:   true,
+			expectedTag: "v1.1.0",
+		},
+		{
+			name:                "deleted scope tag",
+			scheme:              "scope-conventional",
+			commit:              "feat(api): add login",
+			deletedTag:          "api-v1.1.0",
+			annotated:           true,
+			avoidReusedVersions: true,
+			shouldErr:           true,
+		},
+		{
+			name:                "deleted tag of another scope",
+			scheme:              "scope-conventional",
+			commit:              "feat(api): add login",
+			deletedTag:          "worker-v1.1.0",
+			annotated:           true,
+			avoidReusedVersions: true,
+			expectedTag:         "api-v1.1.0",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := newRepoDir(t,
+				seedCommit("v1.0.0", "api-v1.0.0", "worker-v1.0.0"),
+				testCommit{msg: tc.commit},
+			)
+			if tc.annotated {
+				runGit(t, dir, "-c", "user.name=autotag", "-c", "user.email=autotag@example.com",
+					"tag", "-a", "-m", "released by mistake", tc.deletedTag)
+			} else {
+				runGit(t, dir, "tag", tc.deletedTag)
+			}
+			runGit(t, dir, "tag", "-d", tc.deletedTag)
+
+			r, err := NewRepo(GitRepoConfig{
+				RepoPath:            dir,
+				Branch:              "master",
+				Scheme:              tc.scheme,
+				Prefix:              true,
+				AvoidReusedVersions: tc.avoidReusedVersions,
+			})
+			if tc.shouldErr {
+				assert.Error(t, err)
+				assert.True(t, errors.Is(err, ErrReusedVersion), "expected %v, got %v", ErrReusedVersion, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedTag, r.LatestVersion())
+		})
+	}
+}
+
+func TestNextScopeVersionsReusedVersion(t *testing.T) {
+	cfg := GitRepoConfig{Scheme: "scope-conventional", Prefix: true, AvoidReusedVersions: true, AllScopes: true}
+	r := newRepoFixture(t, cfg,
+		seedCommit("api-v1.0.0", "worker-v1.0.0"),
+		testCommit{msg: "feat(api): add login"},
+		testCommit{msg: "fix(worker): correct typo"},
+	)
+	runGit(t, r.repo.Path(), "-c", "user.name=autotag", "-c", "user.email=autotag@example.com",
+		"tag", "-a", "-m", "released by mistake", "worker-v1.0.1")
+	runGit(t, r.repo.Path(), "tag", "-d", "worker-v1.0.1")
+	r.RefreshTags()
+
+	_, err := r.NextScopeVersions()
+	assert.True(t, errors.Is(err, ErrReusedVersion), "expected %v, got %v", ErrReusedVersion, err)
+
+	results, err := r.Preview()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(results))
+	assert.NoError(t, results[0].Err)
+	assert.Equal(t, "api-v1.1.0", results[0].Tag)
+	assert.True(t, errors.Is(results[1].Err, ErrReusedVersion))
+
+	_, err = r.NextScopeVersion("worker")
+	assert.True(t, errors.Is(err, ErrReusedVersion))
+}
@@ -0,0 +1,77 @@
+package autotag
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/gogs/git-module"
+)
+
+// revertHeaderRex matches the header of a revert and captures the reverted header: `Revert
+// "<header>"` as written by git revert or the conventional `revert: <header>`
+var revertHeaderRex = regexp.MustCompile(`^(?:Revert "(.+)"|(?i:revert)(?:\([^)]*\))?!?: (.+))$`)
+
+// revertedSHARex matches the reverted commit in the body of a revert: `This reverts commit <sha>.`
+// as written by git revert or a conventional `Refs: <sha>` footer
+var revertedSHARex = regexp.MustCompile(`(?im)^(?:This reverts commit|Refs:)\s+([0-9a-f]{7,40})\b`)
+
+// parseRevert returns the reverted header and, if the message names it, the SHA of the reverted
+// commit of a revert message, false if msg isn't a revert
+func parseRevert(msg string) (header, sha string, ok bool) {
+	first, body, _ := strings.Cut(msg, "\n")
+	m := revertHeaderRex.FindStringSubmatch(strings.TrimSpace(first))
+	if m == nil {
+		return "", "", false
+	}
+	header = m[1] + m[2]
+	if s := revertedSHARex.FindStringSubmatch(body); s != nil {
+		sha = strings.ToLower(s[1])
+	}
+	return header, sha, true
+}
+
+// revertPairs maps the reverts among commits to the commit they revert, if it is one of the
+// commits too, see CancelReverts. commits are ordered newest first like RevList. A revert is
+// matched by the SHA of the reverted commit or else by its header, to the newest older commit with
+// that header. A reverted revert doesn't cancel its own reverted commit, so a commit that is
+// reverted and re-applied by reverting the revert counts again.
+func revertPairs(commits []*git.Commit) map[string]string {
+	pairs := make(map[string]string)
+	reverted := make(map[string]bool)
+	for i, c := range commits {
+		id := c.ID.String()
+		if reverted[id] {
+			continue
+		}
+		header, sha, ok := parseRevert(c.Message)
+		if !ok {
+			continue
+		}
+		for _, older := range commits[i+1:] {
+			oid := older.ID.String()
+			if reverted[oid] {
+				continue
+			}
+			olderHeader, _, _ := strings.Cut(older.Message, "\n")
+			if sha != "" && strings.HasPrefix(oid, sha) || sha == "" && strings.TrimSpace(olderHeader) == header {
+				pairs[id], reverted[oid] = oid, true
+				break
+			}
+		}
+	}
+	return pairs
+}
+
+// cancelledCommits returns the commits among commits cancelled by a revert, both the reverts and
+// the reverted commits, nil without CancelReverts
+func (r *GitRepo) cancelledCommits(commits []*git.Commit) map[string]bool {
+	if !r.cancelReverts {
+		return nil
+	}
+	cancelled := make(map[string]bool)
+	for revert, reverted := range revertPairs(commits) {
+		r.debugf("skipping commit %s reverted by %s\n", reverted, revert)
+		cancelled[revert], cancelled[reverted] = true, true
+	}
+	return cancelled
+}
@@ -0,0 +1,130 @@
+package autotag
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/alecthomas/assert"
+)
+
+func TestParseRevert(t *testing.T) {
+	tests := []struct {
+		msg    string
+		header string
+		sha    string
+		ok     bool
+	}{
+		{msg: "Revert \"feat: add login\"\n\nThis reverts commit 1A2B3C4D5E6F.\n", header: "feat: add login", sha: "1a2b3c4d5e6f", ok: true},
+		{msg: "revert: feat(api): add login\n\nRefs: 1a2b3c4", header: "feat(api): add login", sha: "1a2b3c4", ok: true},
+		{msg: "revert(api): add login", header: "add login", ok: true},
+		{msg: "feat: revert the login", ok: false},
+	}
+	for _, tc := range tests {
+		header, sha, ok := parseRevert(tc.msg)
+		assert.Equal(t, tc.ok, ok, tc.msg)
+		assert.Equal(t, tc.header, header, tc.msg)
+		assert.Equal(t, tc.sha, sha, tc.msg)
+	}
+}
+
+// revertCommit commits a revert of the commit sha with header like git revert writes it
+func revertCommit(t *testing.T, dir, sha, header string) string {
+	runGit(t, dir, "commit", "--allow-empty", "-m", "Revert \""+header+"\"", "-m", "This reverts commit "+sha+".")
+	return runGit(t, dir, "rev-parse", "HEAD")
+}
+
+func TestCancelReverts(t *testing.T) {
+	tests := []struct {
+		name string
+		// commits are the messages of the commits since v1.0.0, a number reverts that commit
+		commits     []string
+		cfg         GitRepoConfig
+		expectedTag string
+	}{
+		{
+			name:        "feat and its revert net to a patch",
+			commits:     []string{"feat: add login", "0"},
+			cfg:         GitRepoConfig{CancelReverts: true},
+			expectedTag: "v1.0.1",
+		},
+		{
+			name:        "revert counts without CancelReverts",
+			commits:     []string{"feat: add login", "0"},
+			expectedTag: "v1.1.0",
+		},
+		{
+			name:        "feat without a revert stays minor",
+			commits:     []string{"feat: add login", "fix: correct typo"},
+			cfg:         GitRepoConfig{CancelReverts: true},
+			expectedTag: "v1.1.0",
+		},
+		{
+			name:        "only the reverted feat is cancelled",
+			commits:     []string{"feat: add login", "feat: add search", "0"},
+			cfg:         GitRepoConfig{CancelReverts: true},
+			expectedTag: "v1.1.0",
+		},
+		{
+			name:        "matched by the header",
+			commits:     []string{"feat: add login", "revert: feat: add login"},
+			cfg:         GitRepoConfig{CancelReverts: true},
+			expectedTag: "v1.0.1",
+		},
+		{
+			name:        "reverted revert re-applies the feat",
+			commits:     []string{"feat: add login", "0", "1"},
+			cfg:         GitRepoConfig{CancelReverts: true},
+			expectedTag: "v1.1.0",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := newRepoDir(t, seedCommit("v1.0.0"))
+			var shas, headers []string
+			for _, msg := range tc.commits {
+				sha, header := "", msg
+				if n, err := strconv.Atoi(msg); err == nil {
+					header = "Revert \"" + headers[n] + "\""
+					sha = revertCommit(t, dir, shas[n], headers[n])
+				} else {
+					sha = commitAs(t, dir, "Jane Doe <jane@example.com>", msg)
+				}
+				shas, headers = append(shas, sha), append(headers, header)
+			}
+
+			tc.cfg.RepoPath, tc.cfg.Branch, tc.cfg.Scheme, tc.cfg.Prefix = dir, "master", "conventional", true
+			r, err := NewRepo(tc.cfg)
+			checkFatal(t, err)
+			assert.Equal(t, tc.expectedTag, r.LatestVersion())
+		})
+	}
+}
+
+func TestCancelRevertsSkipEmptyRelease(t *testing.T) {
+	dir := newRepoDir(t, seedCommit("v1.0.0"))
+	revertCommit(t, dir, commitAs(t, dir, "Jane Doe <jane@example.com>", "feat: add login"), "feat: add login")
+
+	_, err := NewRepo(GitRepoConfig{RepoPath: dir, Branch: "master", Scheme: "conventional", CancelReverts: true, SkipEmptyRelease: true})
+	assert.True(t, errors.Is(err, ErrNoBump), "error: %v", err)
+}
+
+func TestCancelRevertsScopes(t *testing.T) {
+	dir := newRepoDir(t,
+		seedCommit("api-v1.0.0", "web-v1.0.0"),
+		testCommit{msg: "feat(web): add search", tags: []string{"web-v1.1.0"}},
+	)
+	revertCommit(t, dir, commitAs(t, dir, "Jane Doe <jane@example.com>", "feat(api): add login"), "feat(api): add login")
+	// a revert of a released commit still counts
+	commitAs(t, dir, "Jane Doe <jane@example.com>", "revert(web): feat(web): add search")
+
+	r, err := NewRepo(GitRepoConfig{RepoPath: dir, Branch: "master", Scheme: "scope-conventional", Prefix: true, AllScopes: true, CancelReverts: true})
+	checkFatal(t, err)
+	results, err := r.Preview()
+	checkFatal(t, err)
+	assert.Equal(t, 2, len(results))
+	assert.Equal(t, "api", results[0].Scope)
+	assert.Nil(t, results[0].Next)
+	assert.Equal(t, "web-v1.1.1", results[1].Tag)
+}
@@ -0,0 +1,282 @@
+package autotag
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+)
+
+// DefaultScopeRegistry is the conventional location of the ScopeRegistry in the repo
+const DefaultScopeRegistry = ".autotag/scopes.yaml"
+
+// ErrAmbiguousTagFormat is returned by NewRepo when a tag could follow the tag formats of two
+// scopes, so its scope can't be told
+var ErrAmbiguousTagFormat = errors.New("ambiguous tag formats")
+
+// versionCharsRex matches the characters a version of a tag can consist of
+var versionCharsRex = regexp.MustCompile(`^[0-9A-Za-z.+-]*$`)
+
+// ScopeSettings are the settings of a scope in the ScopeRegistry. Unset settings fall back to the
+// global options.
+type ScopeSettings struct {
+	// TagFormat is the format of the tags of the scope, eg: `api/v{version}`, see TagFormat. The
+	// `{scope}` placeholder is optional.
+	TagFormat string
+	// InitialVersion is the base version of the scope without tags, see InitialVersion
+	InitialVersion string
+	// PreRelease is the pre-release channel of the scope, eg: `beta`, see PreReleaseName
+	PreRelease string
+	// AllowedTypes are the commit types that bump the scope, commits of other types are ignored
+	AllowedTypes []string
+}
+
+// scopeConfig are the parsed ScopeSettings of a scope
+type scopeConfig struct {
+	tagFormat      string
+	tagRex         *regexp.Regexp
+	initialVersion *version.Version
+	preRelease     string
+	allowedTypes   []string
+}
+
+// LoadScopeRegistry reads the settings of the scopes from the registry file, a YAML map of the
+// scopes, optionally under a `scopes:` key, eg:
+//
+//	scopes:
+//	  api:
+//	    tag-format: api/v{version}
+//	    initial-version: 0.1.0
+//	    pre-release: beta
+//	    allowed-types: [feat, fix]
+//
+// allowed-types can also be a list of `- type` lines. Comments and blank lines are skipped, values
+// may be quoted.
+func LoadScopeRegistry(path string) (map[string]ScopeSettings, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading scope registry: %s", err)
+	}
+	registry, err := parseScopeRegistry(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing scope registry '%s': %s", path, err)
+	}
+	return registry, nil
+}
+
+// parseScopeRegistry parses the YAML of a scope registry, see LoadScopeRegistry
+func parseScopeRegistry(content string) (map[string]ScopeSettings, error) {
+	registry := make(map[string]ScopeSettings)
+	var scope string
+	var settings ScopeSettings
+	// scopeIndent is the indentation of the scope keys, the settings are indented further
+	scopeIndent, listKey := -1, ""
+	s := bufio.NewScanner(strings.NewReader(content))
+	for n := 1; s.Scan(); n++ {
+		text := s.Text()
+		line := strings.TrimSpace(text)
+		indent := len(text) - len(strings.TrimLeft(text, " \t"))
+		if line == "" || strings.HasPrefix(line, "#") || line == "scopes:" && indent == 0 && scopeIndent < 0 {
+			continue
+		}
+		if item := strings.TrimPrefix(line, "-"); item != line {
+			if listKey == "" {
+				return nil, fmt.Errorf("line %d: unexpected list item", n)
+			}
+			settings.AllowedTypes = append(settings.AllowedTypes, unquoteYAML(item))
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected a scope or a setting", n)
+		}
+		key, value, listKey = unquoteYAML(key), strings.TrimSpace(value), ""
+
+		if scopeIndent < 0 || indent <= scopeIndent {
+			if value != "" || key == "" {
+				return nil, fmt.Errorf("line %d: expected a scope", n)
+			}
+			if scope != "" {
+				registry[scope] = settings
+			}
+			if _, ok := registry[key]; ok {
+				return nil, fmt.Errorf("line %d: scope '%s' is listed twice", n, key)
+			}
+			scope, settings, scopeIndent = key, ScopeSettings{}, indent
+			continue
+		}
+		switch key {
+		case "tag-format":
+			settings.TagFormat = unquoteYAML(value)
+		case "initial-version":
+			settings.InitialVersion = unquoteYAML(value)
+		case "pre-release":
+			settings.PreRelease = unquoteYAML(value)
+		case "allowed-types":
+			if value == "" {
+				listKey = key
+				continue
+			}
+			for _, typ := range strings.Split(strings.Trim(value, "[]"), ",") {
+				if typ = unquoteYAML(typ); typ != "" {
+					settings.AllowedTypes = append(settings.AllowedTypes, typ)
+				}
+			}
+		default:
+			return nil, fmt.Errorf("line %d: unknown setting '%s' of scope '%s'", n, key, scope)
+		}
+	}
+	if scope != "" {
+		registry[scope] = settings
+	}
+	return registry, s.Err()
+}
+
+// unquoteYAML returns the trimmed value without its quotes
+func unquoteYAML(value string) string {
+	return strings.Trim(strings.TrimSpace(value), `"'`)
+}
+
+// readScopeRegistry loads the registry file, relative to the work tree, and parses the settings of
+// its scopes
+func readScopeRegistry(workTree, name string) (map[string]*scopeConfig, error) {
+	if !filepath.IsAbs(name) {
+		name = filepath.Join(workTree, name)
+	}
+	registry, err := LoadScopeRegistry(name)
+	if err != nil {
+		return nil, err
+	}
+
+	configs := make(map[string]*scopeConfig, len(registry))
+	for scope, settings := range registry {
+		c := &scopeConfig{preRelease: settings.PreRelease, allowedTypes: settings.AllowedTypes}
+		if settings.TagFormat != "" {
+			if strings.Count(settings.TagFormat, tagFormatVersion) != 1 || strings.Count(settings.TagFormat, tagFormatScope) > 1 {
+				return nil, fmt.Errorf("scope registry: tag format '%s' of scope '%s' must contain %s exactly once and %s at most once",
+					settings.TagFormat, scope, tagFormatVersion, tagFormatScope)
+			}
+			c.tagFormat = strings.ReplaceAll(settings.TagFormat, tagFormatScope, scope)
+			c.tagRex = tagFormatRegex(c.tagFormat)
+		}
+		if settings.InitialVersion != "" {
+			if c.initialVersion, err = version.NewVersion(strings.TrimPrefix(settings.InitialVersion, "v")); err != nil {
+				return nil, fmt.Errorf("scope registry: initial version '%s' of scope '%s' is not valid: %s", settings.InitialVersion, scope, err)
+			}
+		}
+		if c.preRelease != "" && !validateSemVerPreReleaseName(preReleaseTypeName(c.preRelease, "type")) {
+			return nil, fmt.Errorf("scope registry: '%s' of scope '%s' is not valid SemVer pre-release name", c.preRelease, scope)
+		}
+		configs[scope] = c
+	}
+	return configs, nil
+}
+
+// scopeSettings returns the registry settings of scope, or of its normalized scope, nil if it has
+// none
+func (r *GitRepo) scopeSettings(scope string) *scopeConfig {
+	if c, ok := r.registry[scope]; ok {
+		return c
+	}
+	return r.registry[r.normalizeScope(scope)]
+}
+
+// scopeInitialVersion returns the initial version of scope: of the registry, otherwise the
+// configured InitialVersion
+func (r *GitRepo) scopeInitialVersion(scope string) *version.Version {
+	if c := r.scopeSettings(scope); c != nil && c.initialVersion != nil {
+		return c.initialVersion
+	}
+	return r.initialVersion
+}
+
+// scopeTypeAllowed reports whether commits of typ bump scope, see ScopeSettings.AllowedTypes
+func (r *GitRepo) scopeTypeAllowed(scope, typ string) bool {
+	c := r.scopeSettings(scope)
+	if c == nil || len(c.allowedTypes) == 0 {
+		return true
+	}
+	typ = normalizeType(typ, r.strictTypeCase)
+	for _, allowed := range c.allowedTypes {
+		if normalizeType(allowed, r.strictTypeCase) == typ {
+			return true
+		}
+	}
+	return false
+}
+
+// checkAmbiguousFormats returns ErrAmbiguousTagFormat if a tag could follow the tag formats of two
+// scopes of the registry, or the format of a scope of the registry and the global tag format of
+// another scope. Two formats are ambiguous when their literal parts don't tell them apart: the
+// prefix before the version of one starts with the other's and the rest consists of version
+// characters, and likewise for the suffixes, eg: `a-v{version}` and `a-v-v{version}` could both be
+// read from `a-v-v1.0.0`.
+func (r *GitRepo) checkAmbiguousFormats() error {
+	for i, a := range r.formattedScopes {
+		format := r.registry[a].tagFormat
+		for _, b := range r.formattedScopes[i+1:] {
+			if tag, ok := ambiguousTagFormats(format, r.registry[b].tagFormat); ok {
+				return fmt.Errorf("%w: '%s' of scope %s and '%s' of scope %s, eg: both match '%s'",
+					ErrAmbiguousTagFormat, format, a, r.registry[b].tagFormat, b, tag)
+			}
+		}
+		tag := strings.Replace(format, tagFormatVersion, "1.0.0", 1)
+		if scope, _, ok := r.splitScopeTag(tag); ok && scope != a {
+			return fmt.Errorf("%w: '%s' of scope %s and the tag format '%s' of scope %s, eg: both match '%s'",
+				ErrAmbiguousTagFormat, format, a, r.tagFormat, scope, tag)
+		}
+	}
+	return nil
+}
+
+// ambiguousTagFormats reports whether a tag could follow both formats, with a `{version}` but no
+// `{scope}` placeholder, and returns such a tag
+func ambiguousTagFormats(a, b string) (string, bool) {
+	prefixA, suffixA, _ := strings.Cut(a, tagFormatVersion)
+	prefixB, suffixB, _ := strings.Cut(b, tagFormatVersion)
+	if len(prefixA) > len(prefixB) {
+		prefixA, prefixB, suffixA, suffixB = prefixB, prefixA, suffixB, suffixA
+	}
+	// the longer prefix is part of the version of the shorter one
+	if !strings.HasPrefix(prefixB, prefixA) || !versionCharsRex.MatchString(prefixB[len(prefixA):]) {
+		return "", false
+	}
+	// as is the longer suffix
+	short, long := suffixA, suffixB
+	if len(short) > len(long) {
+		short, long = long, short
+	}
+	if !strings.HasSuffix(long, short) || !versionCharsRex.MatchString(long[:len(long)-len(short)]) {
+		return "", false
+	}
+	return prefixB + "1.0.0" + long, true
+}
+
+// formattedScopes returns the scopes of the registry with a tag format, in order
+func formattedScopes(registry map[string]*scopeConfig) []string {
+	var scopes []string
+	for scope, c := range registry {
+		if c.tagRex != nil {
+			scopes = append(scopes, scope)
+		}
+	}
+	sort.Strings(scopes)
+	return scopes
+}
+
+// registryScopeTag returns the scope and version of tagName if it matches the tag format of a scope
+// of the registry. The scopes are tried in order of their names.
+func (r *GitRepo) registryScopeTag(tagName string) (scope, ver string, ok bool) {
+	for _, s := range r.formattedScopes {
+		if ver := findNamedMatches(r.registry[s].tagRex, tagName)["version"]; ver != "" {
+			return s, ver, true
+		}
+	}
+	return "", "", false
+}
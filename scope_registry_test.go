@@ -0,0 +1,158 @@
+package autotag
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/assert"
+)
+
+const scopeRegistryYAML = `# the monorepo config of record
+scopes:
+  api:
+    tag-format: api/v{version}
+    initial-version: 0.1.0
+  web:
+    tag-format: "{scope}@{version}"
+    initial-version: '1.0.0'
+    pre-release: beta
+    allowed-types: [feat, fix]
+`
+
+func TestLoadScopeRegistry(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"scopes.yaml": scopeRegistryYAML,
+		"unnested.yaml": "api:\n  tag-format: api/v{version}\n  initial-version: 0.1.0\n\n" +
+			"web:\n  tag-format: \"{scope}@{version}\"\n  initial-version: 1.0.0\n  pre-release: beta\n  allowed-types:\n    - feat\n    - fix\n",
+	} {
+		path := filepath.Join(dir, name)
+		checkFatal(t, os.WriteFile(path, []byte(content), 0o644))
+		registry, err := LoadScopeRegistry(path)
+		checkFatal(t, err)
+		assert.Equal(t, map[string]ScopeSettings{
+			"api": {TagFormat: "api/v{version}", InitialVersion: "0.1.0"},
+			"web": {TagFormat: "{scope}@{version}", InitialVersion: "1.0.0", PreRelease: "beta", AllowedTypes: []string{"feat", "fix"}},
+		}, registry, name)
+	}
+
+	for name, content := range map[string]string{
+		"unknown.yaml": "api:\n  tag-prefix: api/\n",
+		"twice.yaml":   "api:\n  pre-release: beta\napi:\n  pre-release: rc\n",
+		"item.yaml":    "api:\n  - feat\n",
+		"value.yaml":   "api: beta\n",
+	} {
+		path := filepath.Join(dir, name)
+		checkFatal(t, os.WriteFile(path, []byte(content), 0o644))
+		_, err := LoadScopeRegistry(path)
+		assert.Error(t, err, name)
+	}
+}
+
+func TestScopeRegistry(t *testing.T) {
+	dir := newRepoDir(t,
+		seedCommit("api/v0.1.0", "api-v5.0.0"),
+		testCommit{msg: "feat(api): add login"},
+		testCommit{msg: "fix(web): correct typo"},
+		testCommit{msg: "chore(web): bump the dependencies"},
+	)
+	checkFatal(t, os.MkdirAll(filepath.Join(dir, ".autotag"), 0o755))
+	checkFatal(t, os.WriteFile(filepath.Join(dir, DefaultScopeRegistry), []byte(scopeRegistryYAML), 0o644))
+
+	r, err := NewRepo(GitRepoConfig{RepoPath: dir, Branch: "master", Scheme: "scope-conventional", Prefix: true, AllScopes: true, ScopeRegistry: DefaultScopeRegistry})
+	checkFatal(t, err)
+	results, err := r.Preview()
+	checkFatal(t, err)
+
+	var tags []string
+	for _, res := range results {
+		checkFatal(t, res.Err)
+		tags = append(tags, res.Tag)
+	}
+	// the api-v5.0.0 tag doesn't follow the format of the api scope
+	assert.Equal(t, []string{"api/v0.2.0", "web@1.0.1-beta"}, tags)
+
+	scope, v, err := r.ParseTag("web@1.0.1-beta")
+	checkFatal(t, err)
+	assert.Equal(t, "web", scope)
+	assert.Equal(t, "1.0.1-beta", v.String())
+}
+
+func TestScopeRegistryAllowedTypes(t *testing.T) {
+	dir := newRepoDir(t,
+		seedCommit("web@1.0.0"),
+		testCommit{msg: "chore(web): bump the dependencies"},
+	)
+	checkFatal(t, os.WriteFile(filepath.Join(dir, "scopes.yaml"), []byte(scopeRegistryYAML), 0o644))
+
+	_, err := NewRepo(GitRepoConfig{RepoPath: dir, Branch: "master", Scheme: "scope-conventional", ScopeRegistry: "scopes.yaml"})
+	assert.True(t, errors.Is(err, ErrIgnoredType), "error: %v", err)
+
+	r, err := NewRepo(GitRepoConfig{RepoPath: dir, Branch: "master", Scheme: "scope-conventional", AllScopes: true, ScopeRegistry: "scopes.yaml"})
+	checkFatal(t, err)
+	results, err := r.Preview()
+	checkFatal(t, err)
+	assert.Equal(t, 1, len(results))
+	assert.Equal(t, "1.0.0", results[0].Current.String())
+	assert.Nil(t, results[0].Next)
+}
+
+func TestScopeRegistryValidation(t *testing.T) {
+	dir := newRepoDir(t, seedCommit("v1.0.0"))
+	for name, content := range map[string]string{
+		"format.yaml":      "api:\n  tag-format: api/v\n",
+		"version.yaml":     "api:\n  initial-version: one\n",
+		"pre-release.yaml": "api:\n  pre-release: bad_name\n",
+	} {
+		checkFatal(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+		_, err := NewRepo(GitRepoConfig{RepoPath: dir, Branch: "master", Scheme: "scope-conventional", AllScopes: true, ScopeRegistry: name})
+		assert.Error(t, err, name)
+	}
+	_, err := NewRepo(GitRepoConfig{RepoPath: dir, Branch: "master", Scheme: "conventional", ScopeRegistry: "format.yaml"})
+	assert.Error(t, err)
+}
+
+func TestAmbiguousTagFormats(t *testing.T) {
+	tests := []struct {
+		a, b      string
+		ambiguous bool
+	}{
+		{a: "a-v{version}", b: "a-v-v{version}", ambiguous: true},
+		{a: "api/v{version}", b: "api/v{version}", ambiguous: true},
+		{a: "web-{version}", b: "web-{version}-rc", ambiguous: true},
+		{a: "api/v{version}", b: "api/v2/v{version}"},
+		{a: "api/v{version}", b: "web/v{version}"},
+		{a: "api@{version}", b: "api@{version}/final"},
+	}
+	for _, tc := range tests {
+		tag, ambiguous := ambiguousTagFormats(tc.a, tc.b)
+		assert.Equal(t, tc.ambiguous, ambiguous, "%s and %s", tc.a, tc.b)
+		if ambiguous {
+			// the example tag has the literal parts of both formats
+			for _, format := range []string{tc.a, tc.b} {
+				prefix, suffix, _ := strings.Cut(format, tagFormatVersion)
+				assert.True(t, strings.HasPrefix(tag, prefix) && strings.HasSuffix(tag, suffix), "%s of %s", tag, format)
+			}
+		}
+	}
+}
+
+func TestScopeRegistryAmbiguousFormats(t *testing.T) {
+	dir := newRepoDir(t, seedCommit("a-v1.0.0"))
+	for name, content := range map[string]string{
+		"registry.yaml": "a:\n  tag-format: a-v{version}\na-v:\n  tag-format: a-v-v{version}\n",
+		// the tags of web follow the default format of the scope web-v
+		"global.yaml": "web:\n  tag-format: web-v-v{version}\n",
+	} {
+		checkFatal(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+		_, err := NewRepo(GitRepoConfig{RepoPath: dir, Branch: "master", Scheme: "scope-conventional", AllScopes: true, ScopeRegistry: name})
+		assert.True(t, errors.Is(err, ErrAmbiguousTagFormat), "%s: %v", name, err)
+	}
+
+	checkFatal(t, os.WriteFile(filepath.Join(dir, "distinct.yaml"), []byte(scopeRegistryYAML), 0o644))
+	_, err := NewRepo(GitRepoConfig{RepoPath: dir, Branch: "master", Scheme: "scope-conventional", AllScopes: true, ScopeRegistry: "distinct.yaml"})
+	checkFatal(t, err)
+}
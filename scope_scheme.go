@@ -1,11 +1,15 @@
 package autotag
 
 import (
+	"errors"
 	"fmt"
-	"log"
+	"path"
 	"regexp"
 	"sort"
 	"strings"
+	"sync"
+	"time"
+	"unicode"
 
 	"github.com/gogs/git-module"
 	"github.com/hashicorp/go-version"
@@ -16,144 +20,1164 @@ var (
 	// https://regex101.com/r/rhHnSO/1
 	scopeVersionRex = regexp.MustCompile(`^(.*)-v?([\d]+\.?.*)`)
 
-	// scope conventional commit message scheme:
-	// https://regex101.com/r/XciTmT/2
-	scopeConventionalCommitRex = regexp.MustCompile(`^\s*(?P<type>\w+)(?P<scope>(?:\([^()\r\n]*\)|\()?(?P<breaking>!)?)(?P<subject>:.*)?`)
+	// scopeTagVersionRex matches the complete version part of a scope tag, eg: `v1.0.0-rc-1`
+	scopeTagVersionRex = regexp.MustCompile(`^v?\d+\.\d+\.\d+(?:[-+][0-9A-Za-z.+-]*)?$`)
 )
 
+// The "scope-conventional" scheme returns these errors from NewRepo when the latest commit doesn't
+// warrant a tag, so callers can tell the reasons apart, eg: accept ErrNoBump but warn on
+// ErrNoScope. Use errors.Is to check them, some carry additional context.
+var (
+	// ErrNoScope is returned when neither the commit message nor the branch name has a scope
+	ErrNoScope = errors.New("no scope")
+
+	// ErrIgnoredType is returned when the commit type is one of the configured IgnoredTypes
+	ErrIgnoredType = errors.New("ignored type")
+
+	// ErrEmptyCommitMessage is returned when the latest commit has an empty (or whitespace only)
+	// message and no scope could be derived otherwise, eg: for imported or grafted commits. It
+	// wraps ErrNoScope.
+	ErrEmptyCommitMessage = fmt.Errorf("empty commit message: %w", ErrNoScope)
+
+	// ErrMalformedScope is returned when the header of the latest commit has nested or unbalanced
+	// parentheses around the scope, eg: `feat(api(v2)): ...`. It wraps ErrNoScope.
+	ErrMalformedScope = fmt.Errorf("malformed scope: %w", ErrNoScope)
+
+	// ErrNoBump is returned when the bump doesn't increase the version, eg: a custom BumpFunc
+	// that returns nil or the current version, or SkipEmptyRelease finds no qualifying commit
+	ErrNoBump = errors.New("no bump")
+
+	// ErrIgnoredCommit is returned when the latest commit is excluded by IgnoreCommits,
+	// IgnoreAuthors or the IgnoreFile
+	ErrIgnoredCommit = errors.New("ignored commit")
+)
+
+// ErrNonASCIIScope is returned with ASCIIScopes for a scope with non-ASCII characters. Scopes are
+// unicode by default, eg: `feat(支付): ...` is tagged `支付-v1.1.0`.
+var ErrNonASCIIScope = errors.New("scope has non-ASCII characters")
+
+// CommitMessage is the parsed header of a scope conventional commit message:
+// `<type>(<scope>)!: <subject>`
 type CommitMessage struct {
-	ype      string
-	scope    string
-	breaking string
-	subject  string
+	ype        string
+	scope      string
+	breaking   string
+	subject    string
+	rawSubject string
+	malformed  bool
+}
+
+// Type returns the commit type, eg: `feat`
+func (m CommitMessage) Type() string {
+	return m.ype
+}
+
+// Scope returns the commit scope without parentheses, eg: `api`
+func (m CommitMessage) Scope() string {
+	return m.scope
+}
+
+// Breaking reports whether the header marks a breaking change with `!`
+func (m CommitMessage) Breaking() bool {
+	return m.breaking == "!"
+}
+
+// Subject returns the human readable description, without the separating colon and surrounding
+// whitespace, eg: `add login`
+func (m CommitMessage) Subject() string {
+	return m.subject
+}
+
+// RawSubject returns the subject as matched in the header, including the separating colon,
+// eg: `: add login`
+func (m CommitMessage) RawSubject() string {
+	return m.rawSubject
+}
+
+// Malformed reports whether the header has a type followed by nested or unbalanced parentheses
+// and a colon, eg: `feat(api(v2)): x` or `feat(api: x`. A malformed header has no scope and isn't
+// a conventional commit message. An empty scope, eg: `feat(): x`, is no scope rather than
+// malformed.
+func (m CommitMessage) Malformed() bool {
+	return m.malformed
+}
+
+// conventional reports whether the header has a type and a subject, eg: `Merge branch 'feature'`
+// isn't a conventional commit message
+func (m CommitMessage) conventional() bool {
+	return m.ype != "" && m.rawSubject != ""
+}
+
+// ParseCommitMessage parses the header of a scope conventional commit message
+func ParseCommitMessage(msg string) CommitMessage {
+	return parseCommitMessage(lenientCommitRex, msg)
+}
+
+// parseCommitMessage parses the header of a commit message with rex, which provides the named
+// capture groups of conventionalCommitRex
+func parseCommitMessage(rex *regexp.Regexp, msg string) CommitMessage {
+	matches := findNamedMatches(rex, msg)
+	scope := matches["scope"]
+	if malformedScope(rex, msg, matches) {
+		return CommitMessage{ype: matches["type"], malformed: true}
+	}
+	if _, after, ok := strings.Cut(scope, "("); ok {
+		scope, _, _ = strings.Cut(after, ")")
+	} else {
+		scope = strings.TrimSuffix(scope, matches["breaking"])
+	}
+	return CommitMessage{
+		ype:        matches["type"],
+		scope:      strings.TrimSpace(scope),
+		breaking:   matches["breaking"],
+		subject:    strings.TrimSpace(strings.TrimPrefix(matches["subject"], ":")),
+		rawSubject: matches["subject"],
+	}
+}
+
+// malformedScope reports whether the parentheses around the scope of the header don't pair up,
+// ie: the scope isn't closed or is followed by another parenthesis, and the header has a colon
+func malformedScope(rex *regexp.Regexp, msg string, matches map[string]string) bool {
+	scope := matches["scope"]
+	if !strings.HasPrefix(scope, "(") || matches["subject"] != "" {
+		return false
+	}
+	header, _, _ := strings.Cut(msg, "\n")
+	if !strings.Contains(header, ":") {
+		return false
+	}
+	if !strings.Contains(scope, ")") {
+		return true
+	}
+	loc := rex.FindStringSubmatchIndex(msg)
+	i := rex.SubexpIndex("scope")
+	rest := strings.TrimLeft(msg[loc[2*i+1]:], " \t")
+	return strings.HasPrefix(rest, "(") || strings.HasPrefix(rest, ")")
+}
+
+// splitScopeTag splits a tag name like `account-v1.0.0` into its scope and version parts. The
+// version starts at the rightmost `-v<digit>` or `-<digit>` boundary followed by a complete
+// version, so `srv-v-v1.0.0` is scope `srv-v` and `api-v1-1.0.0` is scope `api-v1`, while
+// `api-1.0.0-rc-1` keeps its pre-release. It returns false if the tag doesn't follow the scope tag
+// format.
+func splitScopeTag(tagName string) (scope, ver string, ok bool) {
+	for i := strings.LastIndex(tagName, "-"); i > 0; i = strings.LastIndex(tagName[:i], "-") {
+		if rest := tagName[i+1:]; scopeTagVersionRex.MatchString(rest) {
+			return tagName[:i], strings.TrimPrefix(rest, "v"), true
+		}
+	}
+
+	// incomplete versions, eg: `account-v1.0`
+	m := scopeVersionRex.FindStringSubmatch(tagName)
+	if len(m) < 3 {
+		return "", "", false
+	}
+	return m[1], m[2], true
 }
 
 func (r *GitRepo) scopeSchemeCalcVersion() error {
 	var (
 		latestCommit        *git.Commit
 		latestCommitMessage CommitMessage
+		message             string
+		decidingCommit      string
 		err                 error
+		// otherMessage is also evaluated for the level with PRTitleMerge: the commit message or
+		// the PR title, whichever doesn't name the scope
+		otherMessage string
+		otherCommit  string
 	)
 
+	r.changedPaths = nil
 	// 设置最新提交id branchID
 	if err = r.retrieveBranchInfo(); err != nil {
 		return err
 	}
 	// latestCommit: 最新的提交
-	latestCommit, err = r.repo.BranchCommit(r.branch)
+	latestCommit, err = r.branchCommit()
 	if err != nil {
 		return err
 	}
-	// 解析commit message
-	matches := findNamedMatches(scopeConventionalCommitRex, latestCommit.Message)
-	_, after, _ := strings.Cut(matches["scope"], "(")
-	before, _, _ := strings.Cut(after, ")")
-	latestCommitMessage = CommitMessage{
-		ype:      matches["type"],
-		scope:    before,
-		breaking: matches["breaking"],
-		subject:  matches["subject"],
-	}
-	// 提交信息不包含Scope，将不设置tag
-	if latestCommitMessage.scope == "" {
-		return nil
+	if r.releaseScope != "" && r.releaseBump != BumpNone {
+		// 手动发布：指定 Scope 和版本级别时不解析提交信息
+		r.infof("releasing scope '%s' with a %s bump\n", r.releaseScope, r.releaseBump)
+		r.scope = r.releaseScope
+	} else {
+		// 解析commit message
+		message = latestCommit.Message
+		decidingCommit = latestCommit.ID.String()
+		if r.overrideMessage == "" && !r.prTitleReplaces() {
+			if err := r.checkSignedCommits([]*git.Commit{latestCommit}); err != nil {
+				return err
+			}
+			if r.ignoredCommit(latestCommit) {
+				return fmt.Errorf("%w: %s", ErrIgnoredCommit, latestCommit.ID)
+			}
+		}
+		if r.overrideMessage != "" {
+			message, decidingCommit = r.overrideMessage, ""
+		} else if r.followMergeParent && latestCommit.ParentsCount() > 1 && !parseCommitMessage(r.commitRex, message).conventional() {
+			merged, err := r.mergedConventionalCommit(latestCommit)
+			if err != nil {
+				return err
+			}
+			message, decidingCommit = merged.Message, merged.ID.String()
+		}
+		// PR 标题取代最新提交信息（PRTitleMerge 时两者都计入版本级别）
+		if r.prTitle != "" {
+			if r.prTitleMode == PRTitleMerge {
+				otherMessage, otherCommit = message, decidingCommit
+			}
+			message, decidingCommit = r.prTitle, ""
+			if r.prTitleMode == PRTitleMerge && parseCommitMessage(r.commitRex, message).scope == "" {
+				// PR 标题不包含Scope时，由提交信息决定 Scope
+				message, decidingCommit, otherMessage, otherCommit = otherMessage, otherCommit, message, decidingCommit
+			}
+		}
+		latestCommitMessage = parseCommitMessage(r.commitRex, message)
+		if latestCommitMessage.malformed && r.releaseScope == "" {
+			header, _, _ := strings.Cut(message, "\n")
+			return fmt.Errorf("%w: %s", ErrMalformedScope, strings.TrimSpace(header))
+		}
+		if r.releaseScope != "" {
+			latestCommitMessage.scope = r.releaseScope
+		}
+		// 提交信息不包含Scope时，尝试从修改的文件路径中获取
+		if latestCommitMessage.scope == "" {
+			if latestCommitMessage.scope, r.changedPaths, err = r.scopeFromPaths(latestCommit); err != nil {
+				return err
+			}
+		}
+		// 提交信息不包含Scope时，尝试从分支名中获取
+		if latestCommitMessage.scope == "" {
+			latestCommitMessage.scope = r.scopeFromBranch()
+		}
+		// 提交信息不包含Scope，将不设置tag
+		if latestCommitMessage.scope == "" {
+			if strings.TrimSpace(message) == "" {
+				r.infof("commit %s has an empty message\n", latestCommit.ID)
+				return ErrEmptyCommitMessage
+			}
+			return ErrNoScope
+		}
+		r.scope = latestCommitMessage.scope
+		if err = r.checkScope(r.scope); err != nil {
+			return err
+		}
+
+		if r.ignoredType(latestCommitMessage.ype) {
+			return fmt.Errorf("%w: %s", ErrIgnoredType, latestCommitMessage.ype)
+		}
+		if !r.scopeTypeAllowed(r.scope, latestCommitMessage.ype) {
+			return fmt.Errorf("%w: %s isn't an allowed type of scope %s", ErrIgnoredType, latestCommitMessage.ype, r.scope)
+		}
 	}
-	r.scope = latestCommitMessage.scope
 
-	versions := make(map[*version.Version]*git.Commit)
-	tagNames, err := r.repo.Tags()
+	ok, err := r.selectCurrentVersion(r.scope)
 	if err != nil {
-		return fmt.Errorf("failed to fetch tags: %s", err.Error())
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("no stable (non pre-release) version %s tags found", r.scope)
+	}
+	if r.currentTag != nil {
+		r.infof("currentVersion: %s, currentTagCommit: %s\n", r.currentVersion.String(), r.currentTag.Message)
+	} else {
+		r.infof("currentVersion: %s, no current tag\n", r.currentVersion.String())
 	}
 
-	for _, tagName := range tagNames {
+	// 提交信息包含`Scope`，提交头中包含`!`（`Scope`之后）则被认为是`BREAKING CHANGE:`，将自增`Scope Major`版本
+	level := r.releaseBump
+	graduate := false
+	if level == BumpNone {
+		// 提交信息体中相同 Scope 的提交头也计入（ScanBodyHeaders）
+		d := r.headerDecision(message)
+		if scoped, ok := r.scopeDecisions(message)[r.scope]; ok && scoped.level > d.level {
+			scoped.graduate = scoped.graduate || d.graduate
+			d = scoped
+		}
+		// 指定 Scope 时，由该 Scope 自基础版本以来的提交决定版本级别，没有则使用最新提交
+		if r.releaseScope != "" && r.overrideMessage == "" && !r.prTitleReplaces() {
+			scoped, id, err := r.scopeHistoryDecision(r.scope, r.currentTag)
+			if err != nil {
+				return err
+			}
+			if scoped.level != BumpNone {
+				d, decidingCommit = scoped, id
+			}
+		}
+		byTitle := r.prTitle != "" && message == r.prTitle && decidingCommit == ""
+		if otherMessage != "" {
+			// 没有 Scope 的 PR 标题计入提交的 Scope，提交信息只计入相同的 Scope
+			other, ok := r.headerDecision(otherMessage), otherCommit == ""
+			if !ok {
+				other, ok = r.scopeDecisions(otherMessage)[r.scope]
+			}
+			if ok && other.level > d.level {
+				d, decidingCommit, byTitle = other, otherCommit, otherCommit == ""
+			}
+		}
+		r.decideBump(d, decidingCommit)
+		if byTitle {
+			r.decidePRTitle(d)
+		}
+		level, graduate = d.level, d.graduate
+	} else {
+		r.bumpReason = fmt.Sprintf("%s because the bump is forced", level)
+	}
+	if r.newVersion, err = r.bumpVersion(level.bumper(), r.currentVersion); err != nil {
+		return err
+	}
+	metadataOnly := false
+	if r.newVersion == nil && !r.graduates(r.currentVersion, graduate) {
+		// 没有版本级别时只以新的构建元数据重新打标签（AllowMetadataOnlyBump）
+		r.newVersion = r.metadataOnlyVersion(r.currentVersion)
+		if metadataOnly = r.newVersion != nil; metadataOnly {
+			r.bumpReason = fmt.Sprintf("%s, tagged again with build metadata %s", r.bumpReason, r.buildMetadata)
+		}
+	}
+	if r.graduates(r.currentVersion, graduate) {
+		// `0.x` 版本毕业为 1.0.0，不论提交的版本级别
+		r.bumpReason, level = "major because the version graduates to 1.0.0", BumpMajor
+	} else if !metadataOnly && (r.newVersion == nil || !r.newVersion.GreaterThan(r.currentVersion)) {
+		return fmt.Errorf("%w: %s stays at %s", ErrNoBump, r.scope, r.currentVersion)
+	}
+	// 分支的版本级别上限（BranchBumpCap）
+	r.bumpReason = r.cappedReason(level, r.bumpReason)
+	r.newVersion, err = r.finishVersion(r.scope, r.currentVersion, r.newVersion, r.bumpType, graduate)
+	return err
+}
+
+// checkScope returns ErrNonASCIIScope if scope has non-ASCII characters and ASCIIScopes is set
+func (r *GitRepo) checkScope(scope string) error {
+	if r.asciiScopes {
+		return validateASCIIScope(scope)
+	}
+	return nil
+}
+
+// validateASCIIScope returns ErrNonASCIIScope if scope has non-ASCII characters
+func validateASCIIScope(scope string) error {
+	for _, c := range scope {
+		if c > unicode.MaxASCII {
+			return fmt.Errorf("%w: %s", ErrNonASCIIScope, scope)
+		}
+	}
+	return nil
+}
+
+// ignoredType reports whether typ is one of the configured ignored types
+func (r *GitRepo) ignoredType(typ string) bool {
+	typ = normalizeType(typ, r.strictTypeCase)
+	for _, ignored := range r.ignoredTypes {
+		if normalizeType(ignored, r.strictTypeCase) == typ {
+			return true
+		}
+	}
+	return false
+}
+
+// scopeFromBranch derives the scope from the branch name using the configured branch scope
+// pattern. An empty string is returned if no pattern is configured or the branch doesn't match.
+func (r *GitRepo) scopeFromBranch() string {
+	if r.branchScopeRex == nil {
+		return ""
+	}
+	scope := findNamedMatches(r.branchScopeRex, r.branch)["scope"]
+	if scope != "" {
+		r.infof("derived scope '%s' from branch '%s'\n", scope, r.branch)
+	}
+	return scope
+}
+
+// mergedConventionalCommit returns the newest conventional commit of the branch merged by the
+// merge commit, ie: the commits reachable from the second parent but not the first. The merge
+// commit is returned if none is found.
+func (r *GitRepo) mergedConventionalCommit(merge *git.Commit) (*git.Commit, error) {
+	first, err := merge.ParentID(0)
+	if err != nil {
+		return nil, err
+	}
+	second, err := merge.ParentID(1)
+	if err != nil {
+		return nil, err
+	}
+	commits, err := r.repo.RevList([]string{fmt.Sprintf("%s..%s", first, second)})
+	if err != nil {
+		return nil, fmt.Errorf("error loading the commits merged by %s: %s", merge.ID, err)
+	}
+	for _, c := range commits {
+		if !r.ignoredCommit(c) && parseCommitMessage(r.commitRex, c.Message).conventional() {
+			r.debugf("using the message of %s merged by %s\n", c.ID, merge.ID)
+			return c, nil
+		}
+	}
+	return merge, nil
+}
+
+// scopeFromPaths derives the scope from the files changed by commit using the configured path
+// scopes and owners file, and returns the sorted files of the scope. An empty string is returned if
+// neither is configured, no file matches or the files belong to different scopes.
+func (r *GitRepo) scopeFromPaths(commit *git.Commit) (string, []string, error) {
+	if len(r.pathScopes) == 0 && len(r.ownerRules) == 0 {
+		return "", nil, nil
+	}
+	status, err := commit.ShowNameStatus()
+	if err != nil {
+		return "", nil, fmt.Errorf("error reading the files changed by %s: %s", commit.ID, err)
+	}
+
+	scope := ""
+	var paths []string
+	for _, files := range [][]string{status.Added, status.Removed, status.Modified} {
+		for _, file := range files {
+			s, ok := matchPathScope(r.pathScopes, file)
+			if !ok {
+				if s, ok = matchOwnerScope(r.ownerRules, file); !ok {
+					continue
+				}
+			}
+			if scope != "" && s != scope {
+				r.debugf("commit %s changes files of scopes '%s' and '%s', no scope derived\n", commit.ID, scope, s)
+				return "", nil, nil
+			}
+			scope = s
+			paths = append(paths, file)
+		}
+	}
+	if scope != "" {
+		r.infof("derived scope '%s' from the files changed by %s\n", scope, commit.ID)
+	}
+	sort.Strings(paths)
+	return scope, paths, nil
+}
+
+// normalizeScopePath normalizes a directory of the path scopes to forward slashes without leading
+// or trailing slashes, eg: `.\services\api\` becomes `services/api`
+func normalizeScopePath(dir string) string {
+	dir = strings.Trim(path.Clean(strings.ReplaceAll(dir, `\`, "/")), "/")
+	if dir == "." {
+		return ""
+	}
+	return dir
+}
+
+// matchPathScope returns the scope of the deepest directory of pathScopes containing file. The
+// directories must be normalized with normalizeScopePath. Directory boundaries are respected, so
+// `services/api` doesn't match `services/api-extra/main.go`.
+func matchPathScope(pathScopes map[string]string, file string) (string, bool) {
+	file = normalizeScopePath(file)
+	match := ""
+	for dir := range pathScopes {
+		if file != dir && !strings.HasPrefix(file, dir+"/") {
+			continue
+		}
+		if len(dir) > len(match) {
+			match = dir
+		}
+	}
+	if match == "" {
+		return "", false
+	}
+	return pathScopes[match], true
+}
+
+// scopeVersions returns the versions parsed from the tags of scope with the commits they point to
+func (r *GitRepo) scopeVersions(scope string) (map[*version.Version]tagRef, error) {
+	scope = r.normalizeScope(scope)
+	scopes, err := r.scopeTagVersions(func(s string) bool { return s == scope })
+	if err != nil {
+		return nil, err
+	}
+	if scopes[scope] == nil {
+		return make(map[*version.Version]tagRef), nil
+	}
+	return scopes[scope], nil
+}
+
+// scopeTagVersions returns the versions parsed from the scope tags, with the commits they point to,
+// grouped by scope. Only the scopes accepted by match are returned.
+func (r *GitRepo) scopeTagVersions(match func(scope string) bool) (map[string]map[*version.Version]tagRef, error) {
+	tags, err := r.loadTags()
+	if err != nil {
+		return nil, err
+	}
+
+	scopes := make(map[string]map[*version.Version]tagRef)
+	for scope, versions := range tags {
 		// 过滤出此 scope 版本号
-		var (
-			tagScope   string
-			tagVersion string
-			v          *version.Version
-			c          *git.Commit
-		)
-		if scopeVersionRex.MatchString(tagName) {
-			m := scopeVersionRex.FindStringSubmatch(tagName)
-			if len(m) >= 2 {
-				tagScope = m[1]
-				tagVersion = m[2]
-			}
-		} else {
+		if match(scope) {
+			scopes[scope] = versions
 			continue
 		}
-		if tagScope != latestCommitMessage.scope {
-			log.Println("no scope find, skipping new version")
+		for _, ref := range versions {
+			r.skipTag(ref.name, SkipReasonScope)
+		}
+	}
+	return scopes, nil
+}
+
+// NextScopeVersion calculates the next version of scope from all commits of the scope since its
+// base tag, the biggest bump of the commits wins. The base version is selected as for the
+// "scope-conventional" scheme. It returns nil if the scope has no commits since its base tag.
+func (r *GitRepo) NextScopeVersion(scope string) (*version.Version, error) {
+	base, baseTag, ok, err := r.baseVersionOf(scope)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("no stable (non pre-release) version %s tags found", scope)
+	}
+
+	best, _, err := r.scopeHistoryDecision(scope, baseTag)
+	if err != nil {
+		return nil, err
+	}
+	if best.level == BumpNone {
+		return nil, nil
+	}
+
+	next, err := r.bumpVersion(best.level.bumper(), base)
+	if err != nil {
+		return nil, err
+	}
+	if next, err = r.finishVersion(scope, base, next, best.typ, best.graduate); err != nil {
+		return nil, err
+	}
+	if err = r.checkReusedVersion(scope, next); err != nil {
+		return nil, err
+	}
+	return next, nil
+}
+
+// scopeHistoryDecision returns the biggest bump decision of the commits of scope since baseTag (the
+// whole history if nil) and the commit that decided it, the most recent of the commits with that
+// level. The decision is graduating if any commit of the scope has a `Graduate: true` footer.
+func (r *GitRepo) scopeHistoryDecision(scope string, baseTag *git.Commit) (bumpDecision, string, error) {
+	tip, err := r.branchCommit()
+	if err != nil {
+		return bumpDecision{}, "", err
+	}
+	commits, err := r.repo.RevList(r.historyRange(baseTag, tip.ID.String()))
+	if err != nil {
+		return bumpDecision{}, "", fmt.Errorf("error loading history of scope %s: %s", scope, err)
+	}
+	if err := r.checkSignedCommits(commits); err != nil {
+		return bumpDecision{}, "", err
+	}
+	cancelled := r.cancelledCommits(commits)
+
+	var (
+		best     bumpDecision
+		id       string
+		graduate bool
+	)
+	for _, c := range commits {
+		if !r.includeCommit(c) || cancelled[c.ID.String()] {
 			continue
 		}
+		for s, d := range r.scopeDecisions(c.Message) {
+			if s == "" || r.normalizeScope(s) != r.normalizeScope(scope) {
+				continue
+			}
+			graduate = graduate || d.graduate
+			if d.level > best.level {
+				best, id = d, c.ID.String()
+			}
+		}
+	}
+	best.graduate = graduate
+	return best, id, nil
+}
 
-		v, err = maybeVersionFromTag(tagVersion)
-		if err != nil {
-			log.Println("skipping non version tag: ", tagName)
+// NextScopeVersions calculates the next version of every scope with commits since its base tag, as
+// NextScopeVersion does for a single scope. The history of the branch is traversed only once and
+// the commits are bucketed by scope, so it is much faster than calculating each scope on its own
+// for a wide range of commits. Scopes without a base version are skipped.
+//
+// The scopes and their versions are returned as a map, scopes without commits are left out.
+func (r *GitRepo) NextScopeVersions() (map[string]*version.Version, error) {
+	results, err := r.nextScopeResults()
+	if err != nil {
+		return nil, err
+	}
+
+	next := make(map[string]*version.Version, len(results))
+	for scope, res := range results {
+		next[scope] = res.Next
+	}
+	return next, nil
+}
+
+// nextScopeResults returns the results of the next versions of NextScopeVersions by scope
+func (r *GitRepo) nextScopeResults() (map[string]Result, error) {
+	bases, err := r.scopeBases()
+	if err != nil {
+		return nil, err
+	}
+
+	results := make(map[string]Result)
+	for _, scope := range sortedScopes(bases) {
+		base := bases[scope]
+		if base.err != nil || base.level == BumpNone {
 			continue
 		}
-		if v == nil {
-			log.Println("skipping non version tag: ", tagName)
+		res, err := r.nextScopeResult(scope, base)
+		if err != nil {
+			if err = r.scopeError(scope, err); err != nil {
+				return nil, err
+			}
 			continue
 		}
+		if res.CommitsSinceBase, err = r.countCommitsSinceBase(scope, base.version, base.tag); err != nil {
+			return nil, err
+		}
+		results[res.Scope] = res
+	}
+	return results, nil
+}
+
+// nextScopeResult returns the result of the next version of scope bumped from its base
+func (r *GitRepo) nextScopeResult(scope string, base *scopeBase) (Result, error) {
+	v, err := r.nextScopeVersion(scope, base)
+	if err != nil {
+		return Result{}, err
+	}
+	res := r.result(base.tagScope(scope), base.version, v)
+	res.BumpReason, res.DecidingCommit = r.cappedReason(base.level, base.reason), base.decidingCommit
+	return res, nil
+}
+
+// scopeBase is the base version of a scope and the highest bump level of its commits since then.
+// err is set if the scope has tags but no usable base version.
+type scopeBase struct {
+	version *version.Version
+	level   BumpLevel
+	err     error
+
+	// reason and decidingCommit tell which commit decided the level and why, typ is its type. Of
+	// the commits with the highest level the most recent by committer date, decidedAt, decides.
+	reason         string
+	decidingCommit string
+	typ            string
+	decidedAt      time.Time
+
+	// graduate is set if a commit of the scope has a `Graduate: true` footer
+	graduate bool
+
+	// partition is the scope of the most recent commit of the scope, which names the new tag, see
+	// ScopeNormalizer
+	partition string
+
+	// tag is the commit of the base tag, untagged is set if the base has none, so the commits
+	// before SinceRef don't count
+	tag      *git.Commit
+	untagged bool
+}
+
+// tagScope returns the scope the new tag of the base of scope is named after
+func (b *scopeBase) tagScope(scope string) string {
+	if b.partition != "" {
+		return b.partition
+	}
+	return scope
+}
 
-		c, err = r.repo.CommitByRevision(tagName)
+// scopeBases returns the base of every scope with tags, or commits with an initial version, in a
+// single traversal of the branch history
+func (r *GitRepo) scopeBases() (map[string]*scopeBase, error) {
+	scopeVersions, err := r.scopeTagVersions(func(string) bool { return true })
+	if err != nil {
+		return nil, err
+	}
+
+	bases := make(map[string]*scopeBase)
+
+	// below holds, per commit, the scopes with a base tag on the commit or one of its descendants:
+	// the commit is part of the history of those base tags and doesn't count for them.
+	below := make(map[string]map[string]bool)
+	addBase := func(scope string, base *version.Version, baseTag *git.Commit) {
+		bases[scope] = &scopeBase{version: base, tag: baseTag, untagged: baseTag == nil}
+		if baseTag != nil {
+			id := baseTag.ID.String()
+			if below[id] == nil {
+				below[id] = make(map[string]bool)
+			}
+			below[id][scope] = true
+		}
+	}
+	for scope := range r.currentVersions {
+		if scope != "" {
+			base, baseTag, _ := r.injectedBase(scope)
+			addBase(scope, base, baseTag)
+		}
+	}
+	for scope, versions := range scopeVersions {
+		if _, ok := r.currentVersions[scope]; ok {
+			continue
+		}
+		base, baseTag, ok, err := r.selectBaseVersion(scope, versions)
 		if err != nil {
-			return fmt.Errorf("error reading tag '%s':  %s", tagName, err)
+			return nil, err
+		}
+		if !ok {
+			r.infof("no base version of scope %s found, skipping\n", scope)
+			bases[scope] = &scopeBase{err: fmt.Errorf("no stable (non pre-release) version %s tags found", scope)}
+			continue
+		}
+		addBase(scope, base, baseTag)
+	}
+
+	tip, err := r.branchCommit()
+	if err != nil {
+		return nil, err
+	}
+	// the topological order lists every commit before its parents, so the scopes of a commit are
+	// complete once it is visited
+	commits, err := r.repo.RevList([]string{tip.ID.String()}, git.RevListOptions{
+		CommandOptions: git.CommandOptions{Args: []string{"--topo-order"}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error loading history of branch '%s': %s", r.branch, err)
+	}
+	decisions, unsigned := r.commitsScopeDecisions(commits)
+	before, err := r.beforeSince()
+	if err != nil {
+		return nil, err
+	}
+
+	// decide applies the decisions of the commit at k to the bases of its scopes not covered by a
+	// base tag
+	decide := func(k int, covered map[string]bool) {
+		c := commits[k]
+		id := c.ID.String()
+		for partition, d := range decisions[k] {
+			scope := r.normalizeScope(partition)
+			if scope == "" || covered[scope] {
+				continue
+			}
+			base, ok := bases[scope]
+			if ok && base.err != nil || before[id] && (!ok || base.untagged) {
+				continue
+			}
+			if !ok {
+				// a scope without tags starts from the initial version, if configured
+				initial := r.scopeInitialVersion(scope)
+				if initial == nil {
+					continue
+				}
+				base = &scopeBase{version: initial, untagged: true}
+				bases[scope] = base
+			}
+			if unsigned[k] {
+				// 未验证签名的提交计入 Scope 时报告为该 Scope 的错误
+				base.err = fmt.Errorf("%w: %s", ErrUnsignedCommit, id)
+				continue
+			}
+			base.graduate = base.graduate || d.graduate
+			// the topological order visits the most recent commit of the scope first
+			if base.partition == "" && d.level != BumpNone {
+				base.partition = partition
+			}
+			// the topological order visits the later commit first, it wins a tie on the date
+			tie := d.level == base.level && d.level != BumpNone && c.Committer.When.After(base.decidedAt)
+			if d.level > base.level || tie {
+				base.level = d.level
+				base.reason, base.decidingCommit, base.typ = d.reason(id), id, d.typ
+				base.decidedAt = c.Committer.When
+			}
+		}
+	}
+
+	// with CancelReverts a revert is applied once the commit it reverts is visited: it cancels the
+	// reverted commit, unless that is part of an earlier release of one of its scopes
+	var pairs map[string]string
+	if r.cancelReverts {
+		pairs = revertPairs(commits)
+	}
+	type pendingRevert struct {
+		index   int
+		covered map[string]bool
+	}
+	pending := make(map[string]pendingRevert)
+
+	for i, c := range commits {
+		id := c.ID.String()
+		covered := below[id]
+		delete(below, id)
+		if len(covered) > 0 {
+			for i := 0; i < c.ParentsCount(); i++ {
+				pid, err := c.ParentID(i)
+				if err != nil {
+					return nil, err
+				}
+				parentScopes := below[pid.String()]
+				if parentScopes == nil {
+					parentScopes = make(map[string]bool, len(covered))
+					below[pid.String()] = parentScopes
+				}
+				for scope := range covered {
+					parentScopes[scope] = true
+				}
+			}
+		}
+
+		if reverted, ok := pairs[id]; ok {
+			pending[reverted] = pendingRevert{index: i, covered: covered}
+			continue
+		}
+		if revert, ok := pending[id]; ok {
+			released := false
+			for partition := range decisions[i] {
+				released = released || covered[r.normalizeScope(partition)]
+			}
+			if released {
+				decide(revert.index, revert.covered)
+			} else {
+				r.debugf("skipping commit %s reverted by %s\n", id, commits[revert.index].ID)
+			}
+			continue
 		}
-		versions[v] = c
+		decide(i, covered)
 	}
 
-	keys := make([]*version.Version, 0, len(versions))
-	for key := range versions {
-		keys = append(keys, key)
+	// 工作区清单的每个包都是一个 Scope，没有基础版本（也没有初始版本）的包报告为错误
+	for _, name := range r.workspaceScopes {
+		scope := r.normalizeScope(name)
+		switch {
+		case bases[scope] != nil:
+		case r.scopeInitialVersion(scope) != nil:
+			bases[scope] = &scopeBase{version: r.scopeInitialVersion(scope), untagged: true}
+		default:
+			r.infof("no base version of workspace package %s found\n", name)
+			bases[scope] = &scopeBase{err: fmt.Errorf("no stable (non pre-release) version %s tags found for the workspace package", scope)}
+		}
 	}
-	sort.Sort(sort.Reverse(version.Collection(keys)))
-	for _, v := range keys {
-		if len(v.Prerelease()) == 0 {
-			r.currentVersion = v
-			r.currentTag = versions[v]
-			break
+
+	for scope, base := range bases {
+		if err := r.checkScope(scope); err != nil {
+			base.err = err
 		}
-		log.Printf("skipping pre-release tag version: %s\n", v.String())
 	}
-	if r.currentVersion == nil || r.currentTag == nil {
-		return fmt.Errorf("no stable (non pre-release) version %s tags found", latestCommitMessage.scope)
+	return bases, nil
+}
+
+// commitsScopeDecisions returns the scopeDecisions of each of the commits, nil for the commits
+// excluded from the bump, and which commits fail with the UnsignedError policy, their decisions
+// tell the scopes that fail. Workers goroutines parse the messages concurrently, each writes only
+// the results of its commits, everything else is read-only during the parse.
+func (r *GitRepo) commitsScopeDecisions(commits []*git.Commit) ([]map[string]bumpDecision, []bool) {
+	decisions := make([]map[string]bumpDecision, len(commits))
+	unsigned := make([]bool, len(commits))
+	parse := func(i int) {
+		if r.includeCommit(commits[i]) {
+			decisions[i] = r.scopeDecisions(commits[i].Message)
+		} else if unsigned[i] = r.unsignedError(commits[i]); unsigned[i] {
+			decisions[i] = r.scopeDecisions(commits[i].Message)
+		}
 	}
-	log.Printf("currentVersion: %s, currentTagCommit: %s\n", r.currentVersion.String(), r.currentTag.Message)
 
-	// 提交信息包含`Scope`，提交头中包含`!`（`Scope`之后）则被认为是`BREAKING CHANGE:`，将自增`Scope Major`版本
-	if latestCommitMessage.breaking == "!" {
-		r.newVersion, err = r.MajorBump()
-		if err != nil {
+	if r.workers <= 1 || len(commits) < 2 {
+		for i := range commits {
+			parse(i)
+		}
+		return decisions, unsigned
+	}
+
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < r.workers && w < len(commits); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				parse(i)
+			}
+		}()
+	}
+	for i := range commits {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	return decisions, unsigned
+}
+
+// scopeDecisions returns the highest decision of the headers of msg per scope, see commitHeaders.
+// Without ScanBodyHeaders it's the decision of msg for its scope.
+func (r *GitRepo) scopeDecisions(msg string) map[string]bumpDecision {
+	decisions := make(map[string]bumpDecision)
+	for _, header := range r.commitHeaders(msg) {
+		m := parseCommitMessage(r.commitRex, header)
+		scope := m.scope
+		if !r.scopeTypeAllowed(scope, m.ype) || !r.conventionalHeader(header) {
+			continue
+		}
+		if best, ok := decisions[scope]; ok {
+			if d := r.headerDecision(header); d.level > best.level {
+				d.graduate = d.graduate || best.graduate
+				decisions[scope] = d
+			}
+			continue
+		}
+		decisions[scope] = r.headerDecision(header)
+	}
+	return decisions
+}
+
+// Preview is a read-only release plan: it returns the result of every scope, in order, including
+// the scopes without commits since their base version (with a nil Next). Nothing is tagged. Errors
+// of a scope don't abort the preview, they are reported in the Err of its result, unless
+// OnScopeError returns ScopeErrorSkip for it which leaves the scope out. With Submodules it returns
+// the SubmoduleResults.
+func (r *GitRepo) Preview() ([]Result, error) {
+	if r.submoduleCfg != nil {
+		return r.SubmoduleResults()
+	}
+	results := []Result{}
+	err := r.previewScopes(func(res Result) error {
+		results = append(results, res)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// previewScopes passes the result of every scope of the Preview to emit as soon as it is
+// calculated, in order, and stops at the first error of emit
+func (r *GitRepo) previewScopes(emit func(Result) error) error {
+	bases, err := r.scopeBases()
+	if err != nil {
+		return err
+	}
+
+	for _, scope := range sortedScopes(bases) {
+		base := bases[scope]
+		res := Result{Scope: scope, Current: base.version, Err: base.err}
+		if res.Err == nil && base.level != BumpNone {
+			var next *version.Version
+			if next, res.Err = r.nextScopeVersion(scope, base); res.Err == nil {
+				res = r.result(base.tagScope(scope), base.version, next)
+				res.BumpReason, res.DecidingCommit = r.cappedReason(base.level, base.reason), base.decidingCommit
+				res.CommitsSinceBase, res.Err = r.countCommitsSinceBase(scope, base.version, base.tag)
+			}
+			if res.Err == nil && res.Next != nil {
+				// 预览时也渲染tag的message，模板的错误在创建tag之前报告
+				_, res.Err = r.TagMessage(res)
+			}
+		}
+		if res.Err != nil && r.scopeError(scope, res.Err) == nil {
+			continue
+		}
+		if err := emit(res); err != nil {
 			return err
 		}
-	} else if latestCommitMessage.ype == "feat" {
-		r.newVersion, err = r.MinorBump()
+	}
+	return nil
+}
+
+// nextScopeVersion bumps the base version of scope by its level and appends the pre-release and
+// metadata. It returns ErrNoBump if the bump doesn't increase the version.
+func (r *GitRepo) nextScopeVersion(scope string, base *scopeBase) (*version.Version, error) {
+	v, err := r.bumpVersion(base.level.bumper(), base.version)
+	if err != nil {
+		return nil, err
+	}
+	metadataOnly := false
+	if v == nil && !r.graduates(base.version, base.graduate) {
+		v = r.metadataOnlyVersion(base.version)
+		metadataOnly = v != nil
+	}
+	if !metadataOnly && !r.graduates(base.version, base.graduate) && (v == nil || !v.GreaterThan(base.version)) {
+		return nil, fmt.Errorf("%w: %s stays at %s", ErrNoBump, scope, base.version)
+	}
+	if v, err = r.finishVersion(scope, base.version, v, base.typ, base.graduate); err != nil {
+		return nil, err
+	}
+	if err = r.checkReusedVersion(scope, v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// ScopeErrorAction tells the batch operations over all scopes how to continue after an error of a
+// scope, see GitRepoConfig.OnScopeError
+type ScopeErrorAction int
+
+const (
+	// ScopeErrorAbort stops the batch and returns the error, it's the default
+	ScopeErrorAbort ScopeErrorAction = iota
+	// ScopeErrorSkip leaves the scope out and continues with the other scopes
+	ScopeErrorSkip
+)
+
+// scopeError returns err unless the OnScopeError callback decides to skip the scope
+func (r *GitRepo) scopeError(scope string, err error) error {
+	if r.onScopeError != nil && r.onScopeError(scope, err) == ScopeErrorSkip {
+		r.infof("skipping scope %s: %s\n", scope, err)
+		return nil
+	}
+	return fmt.Errorf("scope %s: %w", scope, err)
+}
+
+// sortedScopes returns the keys of a map of scopes in order
+func sortedScopes[V any](m map[string]V) []string {
+	scopes := make([]string, 0, len(m))
+	for scope := range m {
+		scopes = append(scopes, scope)
+	}
+	sort.Strings(scopes)
+	return scopes
+}
+
+// AutoTagScopes creates the tags of the next versions of all scopes, as calculated by
+// NextScopeVersions, and returns the tagged versions. It is atomic by default: when a tag can't be
+// created the tags created so far are deleted again and the error is returned. When OnScopeError
+// returns ScopeErrorSkip the scope is left out instead and the other scopes are still tagged.
+// The PostTagHooks are called for the created tags once all of them are created. See
+// AutoTagScopeResults for the status of every scope.
+func (r *GitRepo) AutoTagScopes() (map[string]*version.Version, error) {
+	results, err := r.AutoTagScopeResults()
+	if results == nil {
+		return nil, err
+	}
+
+	next := make(map[string]*version.Version, len(results))
+	for _, res := range results {
+		if res.Status == StatusTagged {
+			next[res.Scope] = res.Next
+		}
+	}
+	return next, err
+}
+
+// deleteTags removes the tags created by an aborted batch, failures are only logged since the
+// error that aborted the batch is the one reported
+func (r *GitRepo) deleteTags(tagNames []string) {
+	for _, tagName := range tagNames {
+		r.infof("Deleting Tag %s", tagName)
+		if err := r.tagger.Delete(tagName); err != nil {
+			r.infof("error deleting tag %s: %s\n", tagName, err)
+		}
+	}
+}
+
+// ProgressionError reports a pair of tags of a scope where the version decreases along the
+// history: Later points at a descendant of the commit of Earlier but has a lower (or equal)
+// version.
+type ProgressionError struct {
+	Scope   string
+	Earlier string
+	Later   string
+}
+
+func (e *ProgressionError) Error() string {
+	return fmt.Sprintf("scope %s: tag %s is on a later commit than %s but doesn't have a higher version", e.Scope, e.Later, e.Earlier)
+}
+
+// ValidateProgression verifies that the versions of the scope tags only ever increase along the
+// history, ie: no tag has a lower version than a tag on one of its ancestor commits. The first
+// violating pair is returned as a *ProgressionError. It is purely diagnostic and doesn't create
+// tags; each ancestor-related pair of tags is checked, so it is meant for occasional use.
+func (r *GitRepo) ValidateProgression(scope string) error {
+	versions, err := r.scopeVersions(scope)
+	if err != nil {
+		return err
+	}
+
+	// sort the tags topologically: an ancestor always has fewer commits in its history
+	type progressionTag struct {
+		version *version.Version
+		ref     tagRef
+		commit  *git.Commit
+		depth   int64
+	}
+	tags := make([]progressionTag, 0, len(versions))
+	for v, ref := range versions {
+		c, err := r.tagRefCommit(ref)
 		if err != nil {
 			return err
 		}
-	} else {
-		r.newVersion, err = r.PatchBump()
+		if c == nil {
+			// a release without a tag in the repo has no place in the history
+			continue
+		}
+		depth, err := c.CommitsCount()
 		if err != nil {
-			return err
+			return fmt.Errorf("error counting commits of tag '%s': %s", ref.name, err)
 		}
+		tags = append(tags, progressionTag{version: v, ref: ref, commit: c, depth: depth})
 	}
+	sort.Slice(tags, func(i, j int) bool {
+		if tags[i].depth != tags[j].depth {
+			return tags[i].depth < tags[j].depth
+		}
+		return tags[i].ref.name < tags[j].ref.name
+	})
 
-	// append pre-release-name and/or pre-release-timestamp to the version
-	if len(r.preReleaseName) > 0 || len(r.preReleaseTimestampLayout) > 0 {
-		if r.newVersion, err = preReleaseVersion(r.newVersion, r.preReleaseName, r.preReleaseTimestampLayout); err != nil {
-			return err
+	for j := range tags {
+		for i := 0; i < j; i++ {
+			earlier, later := tags[i], tags[j]
+			if earlier.depth == later.depth || later.version.GreaterThan(earlier.version) {
+				continue
+			}
+			ancestor, err := r.isAncestor(earlier.commit, later.commit)
+			if err != nil {
+				return err
+			}
+			if ancestor {
+				return &ProgressionError{Scope: scope, Earlier: earlier.ref.name, Later: later.ref.name}
+			}
 		}
 	}
+	return nil
+}
 
-	// append optional build metadata
-	if r.buildMetadata != "" {
-		if r.newVersion, err = version.NewVersion(fmt.Sprintf("%s+%s", r.newVersion.String(), r.buildMetadata)); err != nil {
-			return err
+// isAncestor reports whether commit a is an ancestor of commit b
+func (r *GitRepo) isAncestor(a, b *git.Commit) (bool, error) {
+	base, err := r.repo.MergeBase(a.ID.String(), b.ID.String())
+	if err == git.ErrNoMergeBase {
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("error finding merge base of %s and %s: %s", a.ID, b.ID, err)
+	}
+	return base == a.ID.String(), nil
+}
+
+// StaleScopes returns the scopes that have version tags but were not referenced by any of the
+// last sinceCommits commit messages on the branch, eg: to find scopes that can be archived. The
+// scopes are sorted by name.
+func (r *GitRepo) StaleScopes(sinceCommits int) ([]string, error) {
+	if sinceCommits <= 0 {
+		return nil, fmt.Errorf("number of commits must be positive, got %d", sinceCommits)
+	}
+
+	tags, err := r.loadTags()
+	if err != nil {
+		return nil, err
+	}
+
+	tagScopes := make(map[string]bool)
+	for scope := range tags {
+		if scope != "" {
+			tagScopes[scope] = true
 		}
 	}
 
-	return nil
+	commits, err := r.repo.Log(r.tipRev(), git.LogOptions{MaxCount: sinceCommits})
+	if err != nil {
+		return nil, fmt.Errorf("error reading commits of branch '%s': %s", r.branch, err)
+	}
+	for _, c := range commits {
+		delete(tagScopes, r.normalizeScope(parseCommitMessage(r.commitRex, c.Message).scope))
+	}
+
+	stale := make([]string, 0, len(tagScopes))
+	for scope := range tagScopes {
+		stale = append(stale, scope)
+	}
+	sort.Strings(stale)
+	return stale, nil
 }
@@ -0,0 +1,1739 @@
+package autotag
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/alecthomas/assert"
+	"github.com/gogs/git-module"
+	"github.com/hashicorp/go-version"
+)
+
+func TestScopeScheme(t *testing.T) {
+	tests := []struct {
+		name            string
+		setup           testRepoSetup
+		expectedVersion string
+	}{
+		{
+			name: "feat with scope is a minor bump",
+			setup: testRepoSetup{
+				scheme:     "scope-conventional",
+				initialTag: "api-v1.0.0",
+				nextCommit: "feat(api): add login",
+			},
+			expectedVersion: "api-v1.1.0",
+		},
+		{
+			name: "breaking change with scope is a major bump",
+			setup: testRepoSetup{
+				scheme:     "scope-conventional",
+				initialTag: "api-v1.0.0",
+				nextCommit: "refactor(api)!: drop v1 endpoints",
+			},
+			expectedVersion: "api-v2.0.0",
+		},
+		{
+			name: "capitalized feat is a minor bump",
+			setup: testRepoSetup{
+				scheme:     "scope-conventional",
+				initialTag: "api-v1.0.0",
+				nextCommit: "Feat(api): add login",
+			},
+			expectedVersion: "api-v1.1.0",
+		},
+		{
+			name: "uppercase feat is a minor bump",
+			setup: testRepoSetup{
+				scheme:     "scope-conventional",
+				initialTag: "api-v1.0.0",
+				nextCommit: "FEAT(api): add login",
+			},
+			expectedVersion: "api-v1.1.0",
+		},
+		{
+			name: "strict type case only accepts lowercase feat",
+			setup: testRepoSetup{
+				scheme:         "scope-conventional",
+				initialTag:     "api-v1.0.0",
+				nextCommit:     "Feat(api): add login",
+				strictTypeCase: true,
+			},
+			expectedVersion: "api-v1.0.1",
+		},
+		{
+			name: "strict type case with lowercase feat",
+			setup: testRepoSetup{
+				scheme:         "scope-conventional",
+				initialTag:     "api-v1.0.0",
+				nextCommit:     "feat(api): add login",
+				strictTypeCase: true,
+			},
+			expectedVersion: "api-v1.1.0",
+		},
+		{
+			name: "custom commit regex",
+			setup: testRepoSetup{
+				scheme:      "scope-conventional",
+				initialTag:  "api-v1.0.0",
+				nextCommit:  "feat[api]: add login",
+				commitRegex: regexp.MustCompile(`^(?P<type>\w+)(?:\[(?P<scope>[^\]]+)\])?(?P<breaking>!)?(?P<subject>:.*)?`),
+			},
+			expectedVersion: "api-v1.1.0",
+		},
+		{
+			name: "custom commit regex breaking change",
+			setup: testRepoSetup{
+				scheme:      "scope-conventional",
+				initialTag:  "api-v1.0.0",
+				nextCommit:  "refactor[api]!: drop v1 endpoints",
+				commitRegex: regexp.MustCompile(`^(?P<type>\w+)(?:\[(?P<scope>[^\]]+)\])?(?P<breaking>!)?(?P<subject>:.*)?`),
+			},
+			expectedVersion: "api-v2.0.0",
+		},
+		{
+			name: "pending commit message replaces the latest commit",
+			setup: testRepoSetup{
+				scheme:          "scope-conventional",
+				initialTag:      "api-v1.0.0",
+				nextCommit:      "fix(api): correct typo",
+				overrideMessage: "feat(api)!: drop v1 endpoints",
+			},
+			expectedVersion: "api-v2.0.0",
+		},
+		{
+			name: "blank before the scope",
+			setup: testRepoSetup{
+				scheme:     "scope-conventional",
+				initialTag: "api-v1.0.0",
+				nextCommit: "feat (api): add login",
+			},
+			expectedVersion: "api-v1.1.0",
+		},
+		{
+			name: "blank before the colon of a breaking change",
+			setup: testRepoSetup{
+				scheme:     "scope-conventional",
+				initialTag: "api-v1.0.0",
+				nextCommit: "refactor(api)! : drop v1 endpoints",
+			},
+			expectedVersion: "api-v2.0.0",
+		},
+		{
+			name: "fix with scope is a patch bump",
+			setup: testRepoSetup{
+				scheme:     "scope-conventional",
+				initialTag: "api-v1.0.0",
+				nextCommit: "fix(api): correct typo",
+			},
+			expectedVersion: "api-v1.0.1",
+		},
+		{
+			name: "only tags of the commit scope are considered",
+			setup: testRepoSetup{
+				scheme:     "scope-conventional",
+				initialTag: "api-v1.0.0",
+				extraTags:  []string{"worker-v3.0.0"},
+				nextCommit: "feat(api): add login",
+			},
+			expectedVersion: "api-v1.1.0",
+		},
+		{
+			name: "scope derived from branch name",
+			setup: testRepoSetup{
+				scheme:             "scope-conventional",
+				branch:             "release/api",
+				branchScopePattern: `^release/(?P<scope>[^/]+)$`,
+				initialTag:         "api-v1.0.0",
+				nextCommit:         "feat: add login",
+			},
+			expectedVersion: "api-v1.1.0",
+		},
+		{
+			name: "commit scope takes precedence over branch name",
+			setup: testRepoSetup{
+				scheme:             "scope-conventional",
+				branch:             "release/api",
+				branchScopePattern: `^release/(?P<scope>[^/]+)$`,
+				initialTag:         "worker-v1.0.0",
+				extraTags:          []string{"api-v1.0.0"},
+				nextCommit:         "fix(worker): handle retries",
+			},
+			expectedVersion: "worker-v1.0.1",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := newTestRepo(t, tc.setup)
+			defer cleanupTestRepo(t, r.repo)
+
+			assert.Equal(t, tc.expectedVersion, r.LatestVersion())
+		})
+	}
+}
+
+func TestScopeSchemeNoOp(t *testing.T) {
+	noBump := func(v *version.Version) (*version.Version, error) { return v, nil }
+
+	tests := []struct {
+		name               string
+		branch             string
+		branchScopePattern string
+		commit             string
+		ignoredTypes       []string
+		patchBump          BumpFunc
+		bumpRules          BumpRules
+		strictCommitFormat bool
+		expectedErr        error
+	}{
+		{
+			name:        "no scope in commit or branch",
+			commit:      "feat: add login",
+			expectedErr: ErrNoScope,
+		},
+		{
+			name:               "branch not matching the pattern",
+			branch:             "feature/login",
+			branchScopePattern: `^release/(?P<scope>[^/]+)$`,
+			commit:             "feat: add login",
+			expectedErr:        ErrNoScope,
+		},
+		{
+			name:         "ignored type",
+			commit:       "chore(api): bump dependencies",
+			ignoredTypes: []string{"chore", "docs"},
+			expectedErr:  ErrIgnoredType,
+		},
+		{
+			name:         "ignored type is case insensitive",
+			commit:       "Docs(api): fix typo",
+			ignoredTypes: []string{"docs"},
+			expectedErr:  ErrIgnoredType,
+		},
+		{
+			name:        "bump func without change",
+			commit:      "fix(api): correct typo",
+			patchBump:   noBump,
+			expectedErr: ErrNoBump,
+		},
+		{
+			name:        "bump rule without bump",
+			commit:      "docs(api): fix typo",
+			bumpRules:   BumpRules{"docs": BumpNone},
+			expectedErr: ErrNoBump,
+		},
+		{
+			name:               "strict commit format rejects a blank before the scope",
+			commit:             "feat (api): add login",
+			strictCommitFormat: true,
+			expectedErr:        ErrNoScope,
+		},
+		{
+			name:         "type not ignored",
+			commit:       "fix(api): correct typo",
+			ignoredTypes: []string{"chore"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tr := createTestRepo(t, tc.branch)
+			repo, err := git.Open(tr)
+			checkFatal(t, err)
+			defer cleanupTestRepo(t, repo)
+
+			branch := tc.branch
+			if branch == "" {
+				branch = "master"
+			}
+			seedTestRepo(t, "api-v1.0.0", repo)
+			updateReadme(t, repo, tc.commit)
+
+			_, err = NewRepo(GitRepoConfig{
+				RepoPath:           repo.Path(),
+				Branch:             branch,
+				Scheme:             "scope-conventional",
+				BranchScopePattern: tc.branchScopePattern,
+				IgnoredTypes:       tc.ignoredTypes,
+				PatchBump:          tc.patchBump,
+				BumpRules:          tc.bumpRules,
+				StrictCommitFormat: tc.strictCommitFormat,
+			})
+			if tc.expectedErr == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.True(t, errors.Is(err, tc.expectedErr), "expected %v, got %v", tc.expectedErr, err)
+		})
+	}
+}
+
+func TestScopeSchemePreReleaseOnly(t *testing.T) {
+	tests := []struct {
+		name            string
+		setup           testRepoSetup
+		expectedVersion string
+	}{
+		{
+			name: "fix releases the core version of the highest rc",
+			setup: testRepoSetup{
+				scheme:           "scope-conventional",
+				initialTag:       "api-v1.0.0-rc.1",
+				extraTags:        []string{"api-v1.0.0-rc.2"},
+				nextCommit:       "fix(api): correct typo",
+				baseOnPreRelease: true,
+			},
+			expectedVersion: "api-v1.0.0",
+		},
+		{
+			name: "breaking change past the rc core version",
+			setup: testRepoSetup{
+				scheme:           "scope-conventional",
+				initialTag:       "api-v1.2.0-rc.1",
+				nextCommit:       "feat(api)!: new protocol",
+				baseOnPreRelease: true,
+			},
+			expectedVersion: "api-v2.0.0",
+		},
+		{
+			name: "initial version fallback",
+			setup: testRepoSetup{
+				scheme:         "scope-conventional",
+				initialTag:     "api-v1.0.0-rc.1",
+				nextCommit:     "feat(api): add login",
+				initialVersion: "0.0.0",
+			},
+			expectedVersion: "api-v0.1.0",
+		},
+		{
+			name: "stable tag is preferred over pre-releases",
+			setup: testRepoSetup{
+				scheme:           "scope-conventional",
+				initialTag:       "api-v1.0.0",
+				extraTags:        []string{"api-v1.1.0-rc.1"},
+				nextCommit:       "fix(api): correct typo",
+				baseOnPreRelease: true,
+			},
+			expectedVersion: "api-v1.0.1",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := newTestRepo(t, tc.setup)
+			defer cleanupTestRepo(t, r.repo)
+
+			assert.Equal(t, tc.expectedVersion, r.LatestVersion())
+		})
+	}
+}
+
+func TestScopeSchemePreReleaseOnlyError(t *testing.T) {
+	tr := createTestRepo(t, "")
+	repo, err := git.Open(tr)
+	checkFatal(t, err)
+	defer cleanupTestRepo(t, repo)
+
+	seedTestRepo(t, "api-v1.0.0-rc.1", repo)
+	updateReadme(t, repo, "feat(api): add login")
+
+	_, err = NewRepo(GitRepoConfig{
+		RepoPath: repo.Path(),
+		Branch:   "master",
+		Scheme:   "scope-conventional",
+	})
+	assert.Error(t, err)
+}
+
+func TestScopeSchemeBaseByRecency(t *testing.T) {
+	tests := []struct {
+		name            string
+		baseByRecency   bool
+		expectedVersion string
+	}{
+		{
+			name:            "highest version is the base by default",
+			expectedVersion: "api-v2.0.1",
+		},
+		{
+			name:            "most recent tag is the base",
+			baseByRecency:   true,
+			expectedVersion: "api-v1.5.1",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tr := createTestRepo(t, "")
+			repo, err := git.Open(tr)
+			checkFatal(t, err)
+			defer cleanupTestRepo(t, repo)
+
+			// api-v2.0.0 was reverted and the scope was re-released as api-v1.5.0 later on
+			start := time.Now().Add(-time.Hour)
+			makeCommitAt(t, repo, "feat(api)!: new protocol", start)
+			makeTag(repo, "api-v2.0.0")
+			makeCommitAt(t, repo, "revert(api): new protocol", start.Add(time.Minute))
+			makeTag(repo, "api-v1.5.0")
+			makeCommitAt(t, repo, "fix(api): correct typo", start.Add(2*time.Minute))
+
+			r, err := NewRepo(GitRepoConfig{
+				RepoPath:      repo.Path(),
+				Branch:        "master",
+				Scheme:        "scope-conventional",
+				Prefix:        true,
+				BaseByRecency: tc.baseByRecency,
+			})
+			checkFatal(t, err)
+
+			assert.Equal(t, tc.expectedVersion, r.LatestVersion())
+		})
+	}
+}
+
+func TestStaleScopes(t *testing.T) {
+	r := newTestRepo(t, testRepoSetup{
+		scheme:     "scope-conventional",
+		initialTag: "api-v1.0.0",
+		extraTags:  []string{"worker-v1.2.0", "billing-v0.1.0", "not-a-version"},
+		commitList: []string{
+			"feat(worker): retry jobs",
+			"chore: bump deps",
+			"fix(api): correct typo",
+		},
+	})
+	defer cleanupTestRepo(t, r.repo)
+
+	stale, err := r.StaleScopes(3)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"billing"}, stale)
+
+	// the worker commit is outside of the last two commits
+	stale, err = r.StaleScopes(2)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"billing", "worker"}, stale)
+
+	_, err = r.StaleScopes(0)
+	assert.Error(t, err)
+}
+
+func TestParseCommitMessage(t *testing.T) {
+	tests := []struct {
+		msg        string
+		typ        string
+		scope      string
+		breaking   bool
+		subject    string
+		rawSubject string
+	}{
+		{"feat(api): add login", "feat", "api", false, "add login", ": add login"},
+		{"feat(api):add login", "feat", "api", false, "add login", ":add login"},
+		{"fix(api)!:   drop v1  \n\nbody", "fix", "api", true, "drop v1", ":   drop v1  "},
+		{"feat: no scope", "feat", "", false, "no scope", ": no scope"},
+		{"feat(api)", "feat", "api", false, "", ""},
+		{"feat (api): add login", "feat", "api", false, "add login", ": add login"},
+		{"feat(api) : add login", "feat", "api", false, "add login", ": add login"},
+		{"feat (api) : add login", "feat", "api", false, "add login", ": add login"},
+		{"feat\t(api):\tadd login", "feat", "api", false, "add login", ":\tadd login"},
+		{"feat( api ): add login", "feat", "api", false, "add login", ": add login"},
+		{"fix(api) !: drop v1", "fix", "api", true, "drop v1", ": drop v1"},
+		{"fix (api) ! : drop v1", "fix", "api", true, "drop v1", ": drop v1"},
+		{"fix !: drop v1", "fix", "", true, "drop v1", ": drop v1"},
+		{"feat :no scope", "feat", "", false, "no scope", ":no scope"},
+		{"Merge branch 'feature (api)'", "Merge", "", false, "", ""},
+		{"feat\n(api): next line", "feat", "", false, "", ""},
+		{"feat(支付): 添加退款", "feat", "支付", false, "添加退款", ": 添加退款"},
+		{"fix(café)!: accents", "fix", "café", true, "accents", ": accents"},
+		{"fonctionnalité(api): unicode type", "fonctionnalité", "api", false, "unicode type", ": unicode type"},
+		{"fonctionnalite\u0301(api): combining accent", "fonctionnalite\u0301", "api", false, "combining accent", ": combining accent"},
+		{"bug-fix(api): correct typo", "bug-fix", "api", false, "correct typo", ": correct typo"},
+		{"hot-fix!: drop v1", "hot-fix", "", true, "drop v1", ": drop v1"},
+		{"fix-(api): trailing hyphen", "fix", "", false, "", ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.msg, func(t *testing.T) {
+			m := ParseCommitMessage(tc.msg)
+			assert.Equal(t, tc.typ, m.Type())
+			assert.Equal(t, tc.scope, m.Scope())
+			assert.Equal(t, tc.breaking, m.Breaking())
+			assert.Equal(t, tc.subject, m.Subject())
+			assert.Equal(t, tc.rawSubject, m.RawSubject())
+		})
+	}
+}
+
+func TestParseCommitMessageStrict(t *testing.T) {
+	tests := []struct {
+		msg      string
+		scope    string
+		breaking bool
+		subject  string
+	}{
+		{"feat(api): add login", "api", false, "add login"},
+		{"fix(api)!: drop v1", "api", true, "drop v1"},
+		{"feat (api): add login", "", false, ""},
+		{"feat(api) : add login", "api", false, ""},
+		{"fix(api) !: drop v1", "api", false, ""},
+		{"fix !: drop v1", "", false, ""},
+		{"feat :no scope", "", false, ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.msg, func(t *testing.T) {
+			m := parseCommitMessage(conventionalCommitRex, tc.msg)
+			assert.Equal(t, tc.scope, m.Scope())
+			assert.Equal(t, tc.breaking, m.Breaking())
+			assert.Equal(t, tc.subject, m.Subject())
+		})
+	}
+}
+
+func TestParseCommitMessageMalformedScope(t *testing.T) {
+	tests := []struct {
+		msg       string
+		scope     string
+		malformed bool
+	}{
+		{msg: "feat(): add login"},
+		{msg: "feat():add login"},
+		{msg: "feat( ): add login"},
+		{msg: "feat(api(v2)): add login", malformed: true},
+		{msg: "feat((api)): add login", malformed: true},
+		{msg: "feat(api)): add login", malformed: true},
+		{msg: "feat(api: add login", malformed: true},
+		{msg: "feat(api)(v2): add login", malformed: true},
+		{msg: "feat(api) (v2): add login", malformed: true},
+		{msg: "feat(api)", scope: "api"},
+		{msg: "feat(api) without colon", scope: "api"},
+		{msg: "Merge branch 'feature (api)': conflicts"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.msg, func(t *testing.T) {
+			for _, rex := range []*regexp.Regexp{conventionalCommitRex, lenientCommitRex} {
+				m := parseCommitMessage(rex, tc.msg)
+				assert.Equal(t, tc.scope, m.Scope())
+				assert.Equal(t, tc.malformed, m.Malformed())
+				assert.False(t, m.malformed && m.conventional())
+			}
+		})
+	}
+}
+
+func TestExplicitScope(t *testing.T) {
+	tests := []struct {
+		name        string
+		commits     []string
+		expectedTag string
+	}{
+		{
+			name:        "tip of another scope",
+			commits:     []string{"feat(api): add login", "fix(web): handle nil"},
+			expectedTag: "api-v1.1.0",
+		},
+		{
+			name:        "scopeless tip",
+			commits:     []string{"fix(api): handle nil", "chore: update dependencies"},
+			expectedTag: "api-v1.0.1",
+		},
+		{
+			name:        "biggest bump of the scope",
+			commits:     []string{"feat(api)!: drop v1", "fix(api): handle nil", "feat(web): add page"},
+			expectedTag: "api-v2.0.0",
+		},
+		{
+			name:        "no commits of the scope",
+			commits:     []string{"feat(web): add page"},
+			expectedTag: "api-v1.1.0",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			commits := []testCommit{seedCommit("api-v1.0.0", "web-v1.0.0")}
+			for _, msg := range tc.commits {
+				commits = append(commits, testCommit{msg: msg})
+			}
+			r := newRepoFixture(t, GitRepoConfig{Scheme: "scope-conventional", Prefix: true, Scope: "api"}, commits...)
+
+			res := r.Result()
+			assert.Equal(t, tc.expectedTag, res.Tag)
+			assert.Contains(t, res.BumpReason, "commit")
+			// the first commit after the tags decides in every case
+			decided, err := r.repo.CommitByRevision(fmt.Sprintf("master~%d", len(tc.commits)-1))
+			checkFatal(t, err)
+			assert.Equal(t, decided.ID.String(), res.DecidingCommit)
+		})
+	}
+}
+
+func TestScopeSchemeMalformedScope(t *testing.T) {
+	tests := []struct {
+		message      string
+		malformed    bool
+		releaseScope string
+		expectedTag  string
+	}{
+		{message: "feat(): add login"},
+		{message: "feat(api(v2)): add login", malformed: true},
+		{message: "feat(api: add login", malformed: true},
+		{message: "feat(api(v2)): add login", releaseScope: "api", expectedTag: "api-1.1.0"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.message, func(t *testing.T) {
+			tr := createTestRepo(t, "")
+			repo, err := git.Open(tr)
+			checkFatal(t, err)
+			defer cleanupTestRepo(t, repo)
+
+			seedTestRepo(t, "api-v1.0.0", repo)
+			updateReadme(t, repo, tc.message)
+
+			r, err := NewRepo(GitRepoConfig{
+				RepoPath: repo.Path(),
+				Branch:   "master",
+				Scheme:   "scope-conventional",
+				Scope:    tc.releaseScope,
+			})
+			if tc.expectedTag != "" {
+				checkFatal(t, err)
+				assert.Equal(t, tc.expectedTag, r.Result().Tag)
+				return
+			}
+			assert.True(t, errors.Is(err, ErrNoScope), "expected ErrNoScope, got %v", err)
+			assert.Equal(t, tc.malformed, errors.Is(err, ErrMalformedScope), "error: %v", err)
+		})
+	}
+}
+
+func TestScopeSchemeTagDateSource(t *testing.T) {
+	tests := []struct {
+		name            string
+		tagDateSource   string
+		expectedVersion string
+	}{
+		{
+			name:            "commit date by default",
+			expectedVersion: "api-v1.5.1",
+		},
+		{
+			name:            "commit date",
+			tagDateSource:   TagDateCommit,
+			expectedVersion: "api-v1.5.1",
+		},
+		{
+			name:            "annotated tag date",
+			tagDateSource:   TagDateTag,
+			expectedVersion: "api-v2.0.1",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tr := createTestRepo(t, "")
+			repo, err := git.Open(tr)
+			checkFatal(t, err)
+			defer cleanupTestRepo(t, repo)
+
+			// api-v2.0.0 is on the older commit, but was tagged after api-v1.5.0
+			start := time.Now().Add(-time.Hour)
+			makeCommitAt(t, repo, "feat(api)!: new protocol", start)
+			makeAnnotatedTagAt(t, repo, "api-v2.0.0", start.Add(10*time.Minute))
+			makeCommitAt(t, repo, "revert(api): new protocol", start.Add(time.Minute))
+			makeAnnotatedTagAt(t, repo, "api-v1.5.0", start.Add(2*time.Minute))
+			makeCommitAt(t, repo, "fix(api): correct typo", start.Add(3*time.Minute))
+
+			r, err := NewRepo(GitRepoConfig{
+				RepoPath:      repo.Path(),
+				Branch:        "master",
+				Scheme:        "scope-conventional",
+				Prefix:        true,
+				BaseByRecency: true,
+				TagDateSource: tc.tagDateSource,
+			})
+			checkFatal(t, err)
+
+			assert.Equal(t, tc.expectedVersion, r.LatestVersion())
+		})
+	}
+}
+
+func TestValidateProgression(t *testing.T) {
+	tr := createTestRepo(t, "")
+	repo, err := git.Open(tr)
+	checkFatal(t, err)
+	defer cleanupTestRepo(t, repo)
+
+	seedTestRepo(t, "api-v1.0.0", repo)
+	updateReadme(t, repo, "feat(api): add login")
+	makeTag(repo, "api-v1.1.0")
+	makeTag(repo, "worker-v0.1.0")
+
+	updateReadme(t, repo, "fix(worker): handle retries")
+
+	r, err := NewRepo(GitRepoConfig{
+		RepoPath: repo.Path(),
+		Branch:   "master",
+		Scheme:   "scope-conventional",
+	})
+	checkFatal(t, err)
+
+	assert.NoError(t, r.ValidateProgression("api"))
+	assert.NoError(t, r.ValidateProgression("worker"))
+	assert.NoError(t, r.ValidateProgression("unknown"))
+
+	// a later commit tagged with a lower version
+	makeTag(repo, "api-v0.9.0")
+	r.RefreshTags()
+	err = r.ValidateProgression("api")
+	assert.Error(t, err)
+
+	var perr *ProgressionError
+	assert.True(t, errors.As(err, &perr))
+	assert.Equal(t, "api", perr.Scope)
+	assert.Equal(t, "api-v0.9.0", perr.Later)
+	assert.Equal(t, "api-v1.0.0", perr.Earlier)
+}
+
+func TestNextScopeVersions(t *testing.T) {
+	tr := createTestRepo(t, "")
+	repo, err := git.Open(tr)
+	checkFatal(t, err)
+	defer cleanupTestRepo(t, repo)
+
+	run := func(args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repoRoot(repo)
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v failed: %s: %s", args, err, out)
+		}
+	}
+
+	seedTestRepo(t, "api-v1.0.0", repo)
+	makeTag(repo, "worker-v0.1.0")
+
+	// a branch started before the api tag and merged after it still counts for api
+	run("checkout", "-b", "side")
+	checkFatal(t, os.WriteFile(filepath.Join(repoRoot(repo), "side"), []byte("side"), 0o644))
+	makeCommit(repo, "feat(api): side feature")
+	run("checkout", "master")
+
+	updateReadme(t, repo, "fix(api): early fix")
+	makeTag(repo, "api-v1.0.1")
+	updateReadme(t, repo, "docs(web): no tags for web")
+	run("merge", "--no-ff", "-m", "Merge branch 'side'", "side")
+	updateReadme(t, repo, "fix(worker): retry")
+
+	r, err := NewRepo(GitRepoConfig{
+		RepoPath: repo.Path(),
+		Branch:   "master",
+		Scheme:   "scope-conventional",
+	})
+	checkFatal(t, err)
+
+	versions, err := r.NextScopeVersions()
+	assert.NoError(t, err)
+
+	got := make(map[string]string)
+	for scope, v := range versions {
+		got[scope] = v.String()
+	}
+	assert.Equal(t, map[string]string{"api": "1.1.0", "worker": "0.1.1"}, got)
+
+	// the same result as calculating each scope on its own
+	for _, scope := range []string{"api", "worker"} {
+		v, err := r.NextScopeVersion(scope)
+		assert.NoError(t, err)
+		assert.Equal(t, got[scope], v.String())
+	}
+
+	_, err = r.NextScopeVersion("web")
+	assert.Error(t, err)
+}
+
+func TestScopeSchemeBumpRules(t *testing.T) {
+	tests := []struct {
+		name            string
+		commits         []string
+		expectedVersion string
+	}{
+		{
+			name:            "perf outranks fix",
+			commits:         []string{"fix(api): correct typo", "perf(api): cache lookups", "fix(api): handle nil"},
+			expectedVersion: "1.1.0",
+		},
+		{
+			name:            "feat and perf are both minor",
+			commits:         []string{"perf(api): cache lookups", "feat(api): add login", "fix(api): correct typo"},
+			expectedVersion: "1.1.0",
+		},
+		{
+			name:            "breaking change is always major",
+			commits:         []string{"fix(api): correct typo", "perf(api): cache lookups\n\nBREAKING CHANGE: new cache format", "feat(api): add login"},
+			expectedVersion: "2.0.0",
+		},
+		{
+			name:            "fix only",
+			commits:         []string{"docs(api): fix typo", "fix(api): correct typo"},
+			expectedVersion: "1.0.1",
+		},
+		{
+			name:            "commits of other scopes are ignored",
+			commits:         []string{"docs(api): fix typo", "feat(worker): add retries"},
+			expectedVersion: "",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tr := createTestRepo(t, "")
+			repo, err := git.Open(tr)
+			checkFatal(t, err)
+			defer cleanupTestRepo(t, repo)
+
+			seedTestRepo(t, "api-v1.0.0", repo)
+			makeTag(repo, "worker-v1.0.0")
+			for _, c := range tc.commits {
+				updateReadme(t, repo, c)
+			}
+
+			r, err := NewRepo(GitRepoConfig{
+				RepoPath:  repo.Path(),
+				Branch:    "master",
+				Scheme:    "scope-conventional",
+				BumpRules: BumpRules{"perf": BumpMinor, "docs": BumpNone},
+			})
+			checkFatal(t, err)
+
+			v, err := r.NextScopeVersion("api")
+			assert.NoError(t, err)
+			versions, err := r.NextScopeVersions()
+			assert.NoError(t, err)
+			if tc.expectedVersion == "" {
+				assert.True(t, v == nil, "expected no version, got %v", v)
+				assert.True(t, versions["api"] == nil, "expected no version, got %v", versions["api"])
+				return
+			}
+			assert.Equal(t, tc.expectedVersion, v.String())
+			assert.Equal(t, tc.expectedVersion, versions["api"].String())
+		})
+	}
+}
+
+func TestHyphenatedCommitTypes(t *testing.T) {
+	tests := []struct {
+		scheme      string
+		commit      string
+		expectedTag string
+	}{
+		{scheme: "scope-conventional", commit: "bug-fix(api): correct typo", expectedTag: "api-v1.0.1"},
+		{scheme: "scope-conventional", commit: "new-feature(api): add login", expectedTag: "api-v1.1.0"},
+		{scheme: "scope-conventional", commit: "new-feature(api)!: drop v1", expectedTag: "api-v2.0.0"},
+		{scheme: "conventional", commit: "New-Feature: add login", expectedTag: "v1.1.0"},
+		{scheme: "conventional", commit: "bug-fix: correct typo", expectedTag: "v1.0.1"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.commit, func(t *testing.T) {
+			r := newRepoFixture(t, GitRepoConfig{
+				Scheme:    tc.scheme,
+				Prefix:    true,
+				BumpRules: BumpRules{"bug-fix": BumpPatch, "new-feature": BumpMinor},
+			},
+				seedCommit("v1.0.0", "api-v1.0.0"),
+				testCommit{msg: tc.commit},
+			)
+			assert.Equal(t, tc.expectedTag, r.Result().Tag)
+		})
+	}
+}
+
+func TestScopeSchemeExplicitScope(t *testing.T) {
+	tests := []struct {
+		name           string
+		tags           []string
+		commit         string
+		bump           BumpLevel
+		initialVersion string
+		shouldErr      bool
+		expectedTag    string
+	}{
+		{
+			name:        "explicit scope and bump ignore the commit",
+			tags:        []string{"api-v1.0.0", "worker-v1.2.0"},
+			commit:      "docs: update readme",
+			bump:        BumpMinor,
+			expectedTag: "worker-v1.3.0",
+		},
+		{
+			name:        "explicit scope keeps the commit level",
+			tags:        []string{"api-v1.0.0", "worker-v1.2.0"},
+			commit:      "feat(api): add login",
+			expectedTag: "worker-v1.3.0",
+		},
+		{
+			name:           "scope without tags uses the initial version",
+			tags:           []string{"api-v1.0.0"},
+			commit:         "fix(api): correct typo",
+			bump:           BumpPatch,
+			initialVersion: "0.0.0",
+			expectedTag:    "worker-v0.0.1",
+		},
+		{
+			name:      "scope without tags",
+			tags:      []string{"api-v1.0.0"},
+			commit:    "fix(api): correct typo",
+			bump:      BumpPatch,
+			shouldErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tr := createTestRepo(t, "")
+			repo, err := git.Open(tr)
+			checkFatal(t, err)
+			defer cleanupTestRepo(t, repo)
+
+			seedTestRepo(t, tc.tags[0], repo)
+			for _, tag := range tc.tags[1:] {
+				makeTag(repo, tag)
+			}
+			updateReadme(t, repo, tc.commit)
+
+			r, err := NewRepo(GitRepoConfig{
+				RepoPath:       repo.Path(),
+				Branch:         "master",
+				Scheme:         "scope-conventional",
+				Prefix:         true,
+				Scope:          "worker",
+				Bump:           tc.bump,
+				InitialVersion: tc.initialVersion,
+			})
+			if tc.shouldErr {
+				assert.Error(t, err)
+				return
+			}
+			checkFatal(t, err)
+			checkFatal(t, r.AutoTag())
+
+			tags, err := repo.Tags()
+			checkFatal(t, err)
+			assert.Contains(t, tags, tc.expectedTag)
+		})
+	}
+}
+
+func TestAutoTagScopes(t *testing.T) {
+	tests := []struct {
+		name            string
+		action          ScopeErrorAction
+		shouldErr       bool
+		expectedCreated []string
+		expectedDeleted []string
+	}{
+		{
+			name:            "abort deletes the created tags",
+			action:          ScopeErrorAbort,
+			shouldErr:       true,
+			expectedCreated: []string{"api-v1.0.1"},
+			expectedDeleted: []string{"api-v1.0.1"},
+		},
+		{
+			name:            "skip tags the other scopes",
+			action:          ScopeErrorSkip,
+			expectedCreated: []string{"api-v1.0.1", "worker-v1.1.0"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tr := createTestRepo(t, "")
+			repo, err := git.Open(tr)
+			checkFatal(t, err)
+			defer cleanupTestRepo(t, repo)
+
+			seedTestRepo(t, "api-v1.0.0", repo)
+			makeTag(repo, "web-v1.0.0")
+			makeTag(repo, "worker-v1.0.0")
+			updateReadme(t, repo, "fix(api): correct typo")
+			updateReadme(t, repo, "fix(web): correct typo")
+			updateReadme(t, repo, "feat(worker): add retries")
+
+			tagger := &fakeTagger{err: fmt.Errorf("tag exists"), failOn: "web-v1.0.1"}
+			var failed []string
+			r, err := NewRepo(GitRepoConfig{
+				RepoPath: repo.Path(),
+				Branch:   "master",
+				Scheme:   "scope-conventional",
+				Prefix:   true,
+				Tagger:   tagger,
+				OnScopeError: func(scope string, err error) ScopeErrorAction {
+					failed = append(failed, scope)
+					return tc.action
+				},
+			})
+			checkFatal(t, err)
+
+			tagged, err := r.AutoTagScopes()
+			assert.Equal(t, []string{"web"}, failed)
+			var created []string
+			for _, tag := range tagger.created {
+				created = append(created, tag.name)
+			}
+			assert.Equal(t, tc.expectedCreated, created)
+			assert.Equal(t, tc.expectedDeleted, tagger.deleted)
+			if tc.shouldErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, 2, len(tagged))
+			assert.Equal(t, "1.1.0", tagged["worker"].String())
+		})
+	}
+}
+
+func TestPreview(t *testing.T) {
+	tests := []struct {
+		name     string
+		action   ScopeErrorAction
+		expected []string
+	}{
+		{
+			name:     "errors are reported",
+			action:   ScopeErrorAbort,
+			expected: []string{"api 1.0.0 api-v1.1.0", "web 1.0.0 -", "worker error"},
+		},
+		{
+			name:     "skipped scopes are left out",
+			action:   ScopeErrorSkip,
+			expected: []string{"api 1.0.0 api-v1.1.0", "web 1.0.0 -"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tr := createTestRepo(t, "")
+			repo, err := git.Open(tr)
+			checkFatal(t, err)
+			defer cleanupTestRepo(t, repo)
+
+			seedTestRepo(t, "api-v1.0.0", repo)
+			makeTag(repo, "web-v1.0.0")
+			makeTag(repo, "worker-v1.0.0-rc.1")
+			updateReadme(t, repo, "feat(api): add login")
+			updateReadme(t, repo, "fix(worker): retry")
+			// the latest commit doesn't need a scope
+			updateReadme(t, repo, "docs: update readme")
+
+			r, err := NewRepo(GitRepoConfig{
+				RepoPath:     repo.Path(),
+				Branch:       "master",
+				Scheme:       "scope-conventional",
+				Prefix:       true,
+				AllScopes:    true,
+				OnScopeError: func(string, error) ScopeErrorAction { return tc.action },
+			})
+			checkFatal(t, err)
+
+			results, err := r.Preview()
+			assert.NoError(t, err)
+			var got []string
+			for _, res := range results {
+				switch {
+				case res.Err != nil:
+					got = append(got, res.Scope+" error")
+				case res.Next == nil:
+					got = append(got, res.Scope+" "+res.Current.String()+" -")
+				default:
+					got = append(got, res.Scope+" "+res.Current.String()+" "+res.Tag)
+					assert.Equal(t, "minor because commit "+res.DecidingCommit[:7]+" has type 'feat'", res.BumpReason)
+				}
+			}
+			assert.Equal(t, tc.expected, got)
+
+			// nothing is tagged
+			tags, err := repo.Tags()
+			checkFatal(t, err)
+			assert.Equal(t, 3, len(tags))
+		})
+	}
+}
+
+func TestNextScopeVersionsNoBump(t *testing.T) {
+	tr := createTestRepo(t, "")
+	repo, err := git.Open(tr)
+	checkFatal(t, err)
+	defer cleanupTestRepo(t, repo)
+
+	seedTestRepo(t, "api-v1.0.0", repo)
+	makeTag(repo, "worker-v1.0.0")
+	updateReadme(t, repo, "feat(api): add login")
+	updateReadme(t, repo, "fix(worker): retry")
+
+	// the custom minor bump doesn't increase the version
+	minorBump := func(v *version.Version) (*version.Version, error) { return v, nil }
+	newRepo := func(onScopeError func(string, error) ScopeErrorAction) *GitRepo {
+		r, err := NewRepo(GitRepoConfig{
+			RepoPath:     repo.Path(),
+			Branch:       "master",
+			Scheme:       "scope-conventional",
+			MinorBump:    minorBump,
+			OnScopeError: onScopeError,
+		})
+		checkFatal(t, err)
+		return r
+	}
+
+	_, err = newRepo(nil).NextScopeVersions()
+	assert.True(t, errors.Is(err, ErrNoBump), "expected ErrNoBump, got %v", err)
+
+	versions, err := newRepo(func(string, error) ScopeErrorAction { return ScopeErrorSkip }).NextScopeVersions()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(versions))
+	assert.Equal(t, "1.0.1", versions["worker"].String())
+}
+
+// monorepoCommits returns n conventional commits spread over the scopes, after a first commit with
+// the tags of the scopes at 1.0.0
+func monorepoCommits(n int, scopes ...string) []testCommit {
+	tags := make([]string, len(scopes))
+	for i, scope := range scopes {
+		tags[i] = scope + "-v1.0.0"
+	}
+	commits := []testCommit{seedCommit(tags...)}
+	types := []string{"fix", "feat", "chore", "fix"}
+	for i := 0; i < n; i++ {
+		msg := fmt.Sprintf("%s(%s): change %d", types[i%len(types)], scopes[i%len(scopes)], i)
+		if i%17 == 16 {
+			msg += "\n\nBREAKING CHANGE: change " + fmt.Sprint(i)
+		}
+		commits = append(commits, testCommit{msg: msg})
+	}
+	return commits
+}
+
+func TestNextScopeVersionsWorkers(t *testing.T) {
+	cfg := GitRepoConfig{Scheme: "scope-conventional", Prefix: true, AllScopes: true}
+	sequential := newRepoFixture(t, cfg, monorepoCommits(60, "api", "web", "worker", "billing", "auth")...)
+	expected, err := sequential.NextScopeVersions()
+	checkFatal(t, err)
+	expectedPreview, err := sequential.Preview()
+	checkFatal(t, err)
+	assert.Equal(t, 5, len(expected))
+
+	for _, workers := range []int{1, 4, 16, 100} {
+		t.Run(fmt.Sprint(workers), func(t *testing.T) {
+			cfg := cfg
+			cfg.RepoPath, cfg.Branch, cfg.Workers = repoRoot(sequential.repo), "master", workers
+			r, err := NewRepo(cfg)
+			checkFatal(t, err)
+
+			versions, err := r.NextScopeVersions()
+			assert.NoError(t, err)
+			assert.Equal(t, len(expected), len(versions))
+			for scope, v := range expected {
+				assert.Equal(t, v.String(), versions[scope].String(), scope)
+			}
+
+			preview, err := r.Preview()
+			assert.NoError(t, err)
+			assert.Equal(t, expectedPreview, preview)
+		})
+	}
+}
+
+func TestPreReleaseNameFunc(t *testing.T) {
+	channels := func(scope, branch string) string {
+		if branch != "master" {
+			return ""
+		}
+		return map[string]string{"api": "beta", "experimental": "alpha"}[scope]
+	}
+
+	tests := []struct {
+		name      string
+		cfg       GitRepoConfig
+		expected  map[string]string
+		latest    string
+		shouldErr bool
+	}{
+		{
+			name:     "channel of each scope",
+			cfg:      GitRepoConfig{AllScopes: true, PreReleaseNameFunc: channels},
+			expected: map[string]string{"api": "1.1.0-beta", "experimental": "0.2.0-alpha", "web": "2.0.1"},
+		},
+		{
+			name:   "scope of the branch commit",
+			cfg:    GitRepoConfig{PreReleaseNameFunc: channels},
+			latest: "experimental-v0.2.0-alpha",
+		},
+		{
+			name:     "static name",
+			cfg:      GitRepoConfig{AllScopes: true, PreReleaseName: "rc"},
+			expected: map[string]string{"api": "1.1.0-rc", "experimental": "0.2.0-rc", "web": "2.0.1-rc"},
+		},
+		{
+			name:      "stable version on a pre-release branch",
+			cfg:       GitRepoConfig{AllScopes: true, PreReleaseNameFunc: channels, StableBranches: []string{"main"}},
+			shouldErr: true,
+		},
+		{
+			name:      "invalid name",
+			cfg:       GitRepoConfig{AllScopes: true, PreReleaseNameFunc: func(string, string) string { return "be_ta" }},
+			shouldErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.cfg.Scheme, tc.cfg.Prefix = "scope-conventional", true
+			r := newRepoFixture(t, tc.cfg,
+				seedCommit("api-v1.0.0", "experimental-v0.1.0", "web-v2.0.0"),
+				testCommit{msg: "fix(web): handle nil"},
+				testCommit{msg: "feat(api): add login"},
+				testCommit{msg: "feat(experimental): add search"},
+			)
+			if tc.latest != "" {
+				assert.Equal(t, tc.latest, r.LatestVersion())
+				return
+			}
+
+			versions, err := r.NextScopeVersions()
+			if tc.shouldErr {
+				assert.Error(t, err)
+				return
+			}
+			checkFatal(t, err)
+			actual := make(map[string]string)
+			for scope, v := range versions {
+				actual[scope] = v.String()
+			}
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func BenchmarkNextScopeVersions(b *testing.B) {
+	cfg := GitRepoConfig{Scheme: "scope-conventional", Prefix: true, AllScopes: true}
+	dir := newRepoDir(b, monorepoCommits(300, "api", "web", "worker", "billing", "auth")...)
+
+	for _, workers := range []int{0, 4} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			cfg := cfg
+			cfg.RepoPath, cfg.Branch, cfg.Workers = dir, "master", workers
+			r, err := NewRepo(cfg)
+			checkFatal(b, err)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := r.NextScopeVersions(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestScopeSchemeScanBodyHeaders(t *testing.T) {
+	squash := "fix(api): login fixes (#42)\n\n* feat(api): remember me\n* feat(worker)!: new queue\n* fix(web): typo"
+	r := newRepoFixture(t, GitRepoConfig{Scheme: "scope-conventional", Prefix: true, ScanBodyHeaders: true},
+		seedCommit("api-v1.0.0", "worker-v1.0.0", "web-v1.0.0"),
+		testCommit{msg: squash},
+	)
+	assert.Equal(t, "api-v1.1.0", r.LatestVersion())
+
+	versions, err := r.NextScopeVersions()
+	assert.NoError(t, err)
+	got := make(map[string]string)
+	for scope, v := range versions {
+		got[scope] = v.String()
+	}
+	assert.Equal(t, map[string]string{"api": "1.1.0", "web": "1.0.1", "worker": "2.0.0"}, got)
+
+	v, err := r.NextScopeVersion("worker")
+	assert.NoError(t, err)
+	assert.Equal(t, "2.0.0", v.String())
+}
+
+func TestScopeSchemeUnicodeScope(t *testing.T) {
+	tests := []struct {
+		name        string
+		tagFormat   string
+		tags        []string
+		commit      string
+		expectedTag string
+	}{
+		{
+			name:        "CJK scope",
+			tags:        []string{"支付-v1.0.0", "api-v1.2.0"},
+			commit:      "feat(支付): 添加退款",
+			expectedTag: "支付-v1.1.0",
+		},
+		{
+			name:        "accented scope",
+			tags:        []string{"café-v1.0.0", "cafe-v2.0.0"},
+			commit:      "fix(café): accents",
+			expectedTag: "café-v1.0.1",
+		},
+		{
+			name:        "tag format",
+			tagFormat:   "{scope}@{version}",
+			tags:        []string{"café@1.0.0"},
+			commit:      "feat(café): accents",
+			expectedTag: "café@1.1.0",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := newRepoFixture(t, GitRepoConfig{Scheme: "scope-conventional", Prefix: true, TagFormat: tc.tagFormat},
+				seedCommit(tc.tags...),
+				testCommit{msg: tc.commit},
+			)
+			assert.Equal(t, tc.expectedTag, r.Result().Tag)
+			assert.NoError(t, r.AutoTag())
+
+			// the created tag is found again
+			tags, err := r.loadTags()
+			assert.NoError(t, err)
+			scope := parseCommitMessage(r.commitRex, tc.commit).scope
+			var names []string
+			for _, ref := range tags[scope] {
+				names = append(names, ref.name)
+			}
+			assert.Contains(t, names, tc.expectedTag)
+		})
+	}
+}
+
+func TestScopeSchemeASCIIScopes(t *testing.T) {
+	cfg := GitRepoConfig{Scheme: "scope-conventional", Prefix: true, ASCIIScopes: true}
+	tr := createTestRepo(t, "")
+	repo, err := git.Open(tr)
+	checkFatal(t, err)
+	defer cleanupTestRepo(t, repo)
+	seedTestRepo(t, "支付-v1.0.0", repo)
+	updateReadme(t, repo, "feat(支付): 添加退款")
+
+	cfg.RepoPath, cfg.Branch = repo.Path(), "master"
+	_, err = NewRepo(cfg)
+	assert.True(t, errors.Is(err, ErrNonASCIIScope), "expected %v, got %v", ErrNonASCIIScope, err)
+
+	cfg.Scope, cfg.Bump = "café", BumpMinor
+	_, err = NewRepo(cfg)
+	assert.True(t, errors.Is(err, ErrNonASCIIScope), "expected %v, got %v", ErrNonASCIIScope, err)
+
+	cfg.Scope, cfg.Bump, cfg.AllScopes = "", BumpNone, true
+	r, err := NewRepo(cfg)
+	checkFatal(t, err)
+	results, err := r.Preview()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(results))
+	assert.True(t, errors.Is(results[0].Err, ErrNonASCIIScope))
+}
+
+func TestSplitScopeTag(t *testing.T) {
+	tests := []struct {
+		tag     string
+		scope   string
+		version string
+		ok      bool
+	}{
+		{"srv-v1.0.0", "srv", "1.0.0", true},
+		{"srv-1.0.0", "srv", "1.0.0", true},
+		{"srv-v-v1.0.0", "srv-v", "1.0.0", true},
+		{"srv-v-1.0.0", "srv-v", "1.0.0", true},
+		{"api-v1-v2.0.0", "api-v1", "2.0.0", true},
+		{"api-v1-2.0.0", "api-v1", "2.0.0", true},
+		{"api-v2-beta-v1.0.0-rc.1", "api-v2-beta", "1.0.0-rc.1", true},
+		{"api-1.0.0-rc-1", "api", "1.0.0-rc-1", true},
+		{"api-v1.0.0+build-2", "api", "1.0.0+build-2", true},
+		{"api-v1.0", "api", "1.0", true},
+		{"v1.0.0", "", "", false},
+		{"api", "", "", false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.tag, func(t *testing.T) {
+			scope, ver, ok := splitScopeTag(tc.tag)
+			assert.Equal(t, tc.ok, ok)
+			assert.Equal(t, tc.scope, scope)
+			assert.Equal(t, tc.version, ver)
+		})
+	}
+}
+
+func TestScopeSchemeScopeEndingInV(t *testing.T) {
+	r := newRepoFixture(t, GitRepoConfig{Scheme: "scope-conventional", Prefix: true},
+		seedCommit("srv-v-v1.0.0", "srv-v2.0.0"),
+		testCommit{msg: "fix(srv-v): correct typo"},
+	)
+	assert.Equal(t, "srv-v-v1.0.1", r.LatestVersion())
+}
+
+func TestMatchPathScope(t *testing.T) {
+	pathScopes := make(map[string]string)
+	for dir, scope := range map[string]string{
+		"services/api":          "api",
+		`services\api\internal`: "api-internal",
+		"./services/worker/":    "worker",
+		"libs":                  "libs",
+	} {
+		pathScopes[normalizeScopePath(dir)] = scope
+	}
+
+	tests := []struct {
+		file          string
+		expectedScope string
+		expectedOk    bool
+	}{
+		{"services/api/main.go", "api", true},
+		{"services/api", "api", true},
+		{`services\api\handler.go`, "api", true},
+		{"services/api/internal/db.go", "api-internal", true},
+		{"services/api/internalx/db.go", "api", true},
+		{"services/api-extra/main.go", "", false},
+		{"services/apix", "", false},
+		{"services/worker/retry.go", "worker", true},
+		{"services/main.go", "", false},
+		{"libs/strings/strings.go", "libs", true},
+		{"README", "", false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.file, func(t *testing.T) {
+			scope, ok := matchPathScope(pathScopes, tc.file)
+			assert.Equal(t, tc.expectedOk, ok)
+			assert.Equal(t, tc.expectedScope, scope)
+		})
+	}
+}
+
+func TestNormalizeScopePath(t *testing.T) {
+	assert.Equal(t, "services/api", normalizeScopePath(`.\services\api\`))
+	assert.Equal(t, "services/api", normalizeScopePath("/services//api/"))
+	assert.Equal(t, ".github", normalizeScopePath(".github"))
+	assert.Equal(t, "", normalizeScopePath("."))
+}
+
+func TestScopeSchemePathScopes(t *testing.T) {
+	pathScopes := map[string]string{
+		`services\api`:          "api",
+		"services/api/internal": "internal",
+		"services/worker":       "worker",
+	}
+
+	tests := []struct {
+		name            string
+		commit          string
+		files           []string
+		expectedVersion string
+		expectedPaths   []string
+		expectedErr     error
+	}{
+		{
+			name:            "scope of the changed directory",
+			commit:          "fix: correct typo",
+			files:           []string{"services/api/main.go", "services/api/handler/login.go"},
+			expectedVersion: "api-v1.0.1",
+			expectedPaths:   []string{"services/api/handler/login.go", "services/api/main.go"},
+		},
+		{
+			name:            "nested directory",
+			commit:          "feat: add a cache",
+			files:           []string{"services/api/internal/cache.go"},
+			expectedVersion: "internal-v1.1.0",
+			expectedPaths:   []string{"services/api/internal/cache.go"},
+		},
+		{
+			name:            "files outside of the scopes are ignored",
+			commit:          "feat: add retries",
+			files:           []string{"services/worker/retry.go", "README"},
+			expectedVersion: "worker-v1.1.0",
+			expectedPaths:   []string{"services/worker/retry.go"},
+		},
+		{
+			name:            "commit scope takes precedence",
+			commit:          "fix(worker): correct typo",
+			files:           []string{"services/api/main.go"},
+			expectedVersion: "worker-v1.0.1",
+		},
+		{
+			name:        "sibling directory doesn't match",
+			commit:      "fix: correct typo",
+			files:       []string{"services/api-extra/main.go"},
+			expectedErr: ErrNoScope,
+		},
+		{
+			name:        "files of several scopes",
+			commit:      "fix: correct typo",
+			files:       []string{"services/api/main.go", "services/worker/main.go"},
+			expectedErr: ErrNoScope,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tr := createTestRepo(t, "")
+			repo, err := git.Open(tr)
+			checkFatal(t, err)
+			defer cleanupTestRepo(t, repo)
+
+			seedTestRepo(t, "api-v1.0.0", repo)
+			makeTag(repo, "internal-v1.0.0")
+			makeTag(repo, "worker-v1.0.0")
+			commitFiles(t, repo, tc.commit, tc.files...)
+
+			r, err := NewRepo(GitRepoConfig{
+				RepoPath:   repo.Path(),
+				Branch:     "master",
+				Scheme:     "scope-conventional",
+				Prefix:     true,
+				PathScopes: pathScopes,
+			})
+			if tc.expectedErr != nil {
+				assert.True(t, errors.Is(err, tc.expectedErr), "expected %v, got %v", tc.expectedErr, err)
+				return
+			}
+			checkFatal(t, err)
+			assert.Equal(t, tc.expectedVersion, r.LatestVersion())
+			assert.Equal(t, tc.expectedPaths, r.Result().ChangedPaths)
+		})
+	}
+}
+
+func TestScopeSchemeFollowMergeParent(t *testing.T) {
+	tests := []struct {
+		name              string
+		branchCommits     []string
+		mergeMessage      string
+		followMergeParent bool
+		expectedVersion   string
+		expectedErr       error
+	}{
+		{
+			name:              "newest conventional commit of the merged branch",
+			branchCommits:     []string{"fix(api): correct typo", "feat(api): add login", "wip"},
+			mergeMessage:      "Merge branch 'feature/login'",
+			followMergeParent: true,
+			expectedVersion:   "api-v1.1.0",
+		},
+		{
+			name:              "conventional merge message is used as is",
+			branchCommits:     []string{"feat(api): add login"},
+			mergeMessage:      "fix(worker): merge the retries",
+			followMergeParent: true,
+			expectedVersion:   "worker-v1.0.1",
+		},
+		{
+			name:              "no conventional commit on the merged branch",
+			branchCommits:     []string{"wip", "more wip"},
+			mergeMessage:      "Merge branch 'feature/login'",
+			followMergeParent: true,
+			expectedErr:       ErrNoScope,
+		},
+		{
+			name:          "merge parent isn't followed by default",
+			branchCommits: []string{"feat(api): add login"},
+			mergeMessage:  "Merge branch 'feature/login'",
+			expectedErr:   ErrNoScope,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tr := createTestRepo(t, "")
+			repo, err := git.Open(tr)
+			checkFatal(t, err)
+			defer cleanupTestRepo(t, repo)
+
+			seedTestRepo(t, "api-v1.0.0", repo)
+			makeTag(repo, "worker-v1.0.0")
+			// a conventional commit on the main branch, before the merge
+			updateReadme(t, repo, "feat(worker)!: new protocol")
+			makeMerge(t, repo, "feature/login", tc.branchCommits, tc.mergeMessage)
+
+			r, err := NewRepo(GitRepoConfig{
+				RepoPath:          repo.Path(),
+				Branch:            "master",
+				Scheme:            "scope-conventional",
+				Prefix:            true,
+				FollowMergeParent: tc.followMergeParent,
+			})
+			if tc.expectedErr != nil {
+				assert.True(t, errors.Is(err, tc.expectedErr), "expected %v, got %v", tc.expectedErr, err)
+				return
+			}
+			checkFatal(t, err)
+			assert.Equal(t, tc.expectedVersion, r.LatestVersion())
+		})
+	}
+}
+
+func TestScopeSchemeEmptyCommitMessage(t *testing.T) {
+	tests := []struct {
+		name               string
+		branch             string
+		branchScopePattern string
+		message            string
+		expectedErr        error
+	}{
+		{
+			name:        "empty message",
+			message:     "",
+			expectedErr: ErrEmptyCommitMessage,
+		},
+		{
+			name:        "whitespace only message",
+			message:     " \n\t\n",
+			expectedErr: ErrEmptyCommitMessage,
+		},
+		{
+			name:               "scope from the branch name",
+			branch:             "release/api",
+			branchScopePattern: `^release/(?P<scope>[^/]+)$`,
+			message:            "",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tr := createTestRepo(t, tc.branch)
+			repo, err := git.Open(tr)
+			checkFatal(t, err)
+			defer cleanupTestRepo(t, repo)
+
+			branch := tc.branch
+			if branch == "" {
+				branch = "master"
+			}
+			seedTestRepo(t, "api-v1.0.0", repo)
+			cmd := exec.Command("git", "commit", "--allow-empty", "--allow-empty-message", "--cleanup=verbatim", "-m", tc.message)
+			cmd.Dir = repoRoot(repo)
+			if out, err := cmd.CombinedOutput(); err != nil {
+				t.Fatalf("commit failed: %s: %s", err, out)
+			}
+
+			_, err = NewRepo(GitRepoConfig{
+				RepoPath:           repo.Path(),
+				Branch:             branch,
+				Scheme:             "scope-conventional",
+				BranchScopePattern: tc.branchScopePattern,
+			})
+			if tc.expectedErr == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.True(t, errors.Is(err, tc.expectedErr), "expected %v, got %v", tc.expectedErr, err)
+			// still a no scope no-op for callers not aware of empty messages
+			assert.True(t, errors.Is(err, ErrNoScope))
+		})
+	}
+}
+
+func TestScopeNormalizer(t *testing.T) {
+	yearRex := regexp.MustCompile(`-\d{4}$`)
+	byYear := func(scope string) string { return yearRex.ReplaceAllString(scope, "") }
+
+	tests := []struct {
+		name            string
+		normalizer      func(string) string
+		msg             string
+		expectedTag     string
+		expectedCurrent string
+	}{
+		{
+			name:            "partitions are separate scopes by default",
+			msg:             "feat(api-2024): add login",
+			expectedTag:     "api-2024-1.1.0",
+			expectedCurrent: "1.0.0",
+		},
+		{
+			name:            "base across the partitions",
+			normalizer:      byYear,
+			msg:             "feat(api-2024): add login",
+			expectedTag:     "api-2024-1.3.0",
+			expectedCurrent: "1.2.0",
+		},
+		{
+			name:            "normalized scope",
+			normalizer:      byYear,
+			msg:             "fix(api): correct typo",
+			expectedTag:     "api-1.2.1",
+			expectedCurrent: "1.2.0",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := newRepoFixture(t, GitRepoConfig{Scheme: "scope-conventional", ScopeNormalizer: tc.normalizer},
+				seedCommit("api-2023-v1.2.0", "api-2024-v1.0.0", "web-v0.1.0"),
+				testCommit{msg: tc.msg},
+			)
+			res := r.Result()
+			assert.Equal(t, tc.expectedTag, res.Tag)
+			assert.Equal(t, tc.expectedCurrent, res.Current.String())
+		})
+	}
+}
+
+func TestScopeNormalizerAllScopes(t *testing.T) {
+	yearRex := regexp.MustCompile(`-\d{4}$`)
+	r := newRepoFixture(t, GitRepoConfig{
+		Scheme:          "scope-conventional",
+		AllScopes:       true,
+		ScopeNormalizer: func(scope string) string { return yearRex.ReplaceAllString(scope, "") },
+	},
+		seedCommit("api-2023-v1.2.0", "web-v0.1.0"),
+		testCommit{msg: "fix(api-2023): correct typo"},
+		testCommit{msg: "feat(api-2024): add login"},
+	)
+
+	next, err := r.NextScopeVersions()
+	checkFatal(t, err)
+	assert.Equal(t, 1, len(next))
+	assert.Equal(t, "1.3.0", next["api-2024"].String())
+
+	results, err := r.Preview()
+	checkFatal(t, err)
+	assert.Equal(t, "api-2024-1.3.0", results[0].Tag)
+	assert.Equal(t, "web", results[1].Scope)
+}
+
+func TestUnscoped(t *testing.T) {
+	tests := []struct {
+		name        string
+		commit      string
+		expectedTag string
+	}{
+		{name: "feature", commit: "feat: add login", expectedTag: "v1.3.0"},
+		{name: "breaking change", commit: "refactor!: drop the old API", expectedTag: "v2.0.0"},
+		{name: "fix", commit: "fix: correct typo", expectedTag: "v1.2.4"},
+		{name: "scope is ignored", commit: "feat(api): add login", expectedTag: "v1.3.0"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := newRepoFixture(t, GitRepoConfig{Scheme: "scope-conventional", Unscoped: true, Prefix: true},
+				seedCommit("v1.2.3", "api-v5.0.0"),
+				testCommit{msg: tc.commit},
+			)
+			assert.Equal(t, tc.expectedTag, r.LatestVersion())
+		})
+	}
+
+	dir := newRepoDir(t, seedCommit("v1.2.3"))
+	_, err := NewRepo(GitRepoConfig{RepoPath: dir, Branch: "master", Scheme: "scope", Unscoped: true, AllScopes: true})
+	assert.Error(t, err)
+}
@@ -0,0 +1,73 @@
+package autotag
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// shellVarSuffix ends the name of the variable of a scope, eg: `API_VERSION`
+const shellVarSuffix = "VERSION"
+
+// ExportShellVars writes the versions as `eval`-able shell exports to w, eg: `export
+// API_VERSION=1.3.0`, one per scope in order. The variable of a scope is prefixed by the scope
+// uppercased, with every character that isn't valid in a shell identifier replaced by `_`, eg:
+// `WEB_UI_VERSION` for `web-ui`, and `VERSION` without a scope. The version is the next version,
+// or the current one of a scope without a release. Nothing is written if a scope failed or two
+// scopes map to the same variable.
+func (r *GitRepo) ExportShellVars(w io.Writer) error {
+	var results []Result
+	if r.allScopes || r.submoduleCfg != nil {
+		var err error
+		if results, err = r.Preview(); err != nil {
+			return err
+		}
+	} else {
+		results = []Result{r.Result()}
+	}
+
+	// written at once, so a failed scope doesn't leave half the exports to eval
+	var buf bytes.Buffer
+	scopes := make(map[string]string, len(results))
+	for _, res := range results {
+		if res.Err != nil {
+			return fmt.Errorf("error exporting the version of scope %s: %w", res.Scope, res.Err)
+		}
+		v := res.Next
+		if v == nil {
+			v = res.Current
+		}
+		if v == nil {
+			continue
+		}
+		name := shellVarName(res.Scope)
+		if other, ok := scopes[name]; ok {
+			return fmt.Errorf("scopes '%s' and '%s' are both exported as %s", other, res.Scope, name)
+		}
+		scopes[name] = res.Scope
+		fmt.Fprintf(&buf, "export %s=%s\n", name, v)
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// shellVarName returns the variable of the version of scope, see ExportShellVars
+func shellVarName(scope string) string {
+	if scope == "" {
+		return shellVarSuffix
+	}
+	name := strings.Map(func(c rune) rune {
+		switch {
+		case c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '_':
+			return c
+		case c >= 'a' && c <= 'z':
+			return c - 'a' + 'A'
+		}
+		return '_'
+	}, scope)
+	if name[0] >= '0' && name[0] <= '9' {
+		name = "_" + name
+	}
+	return name + "_" + shellVarSuffix
+}
@@ -0,0 +1,39 @@
+package autotag
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/alecthomas/assert"
+)
+
+func TestExportShellVars(t *testing.T) {
+	r := newRepoFixture(t, GitRepoConfig{Scheme: "scope-conventional", AllScopes: true},
+		seedCommit("api-v1.0.0", "web-ui-v1.0.0", "2fa-v0.1.0"),
+		testCommit{msg: "feat(api): add login"},
+		testCommit{msg: "fix(2fa): accept lowercase codes"},
+	)
+	var buf bytes.Buffer
+	checkFatal(t, r.ExportShellVars(&buf))
+	assert.Equal(t, "export _2FA_VERSION=0.1.1\nexport API_VERSION=1.1.0\nexport WEB_UI_VERSION=1.0.0\n", buf.String())
+}
+
+func TestExportShellVarsUnscoped(t *testing.T) {
+	r := newRepoFixture(t, GitRepoConfig{Scheme: "conventional"},
+		seedCommit("v1.0.0"),
+		testCommit{msg: "fix: correct typo"},
+	)
+	var buf bytes.Buffer
+	checkFatal(t, r.ExportShellVars(&buf))
+	assert.Equal(t, "export VERSION=1.0.1\n", buf.String())
+}
+
+func TestExportShellVarsCollision(t *testing.T) {
+	r := newRepoFixture(t, GitRepoConfig{Scheme: "scope-conventional", AllScopes: true},
+		seedCommit("web-ui-v1.0.0", "web_ui-v1.0.0"),
+		testCommit{msg: "fix(web-ui): correct typo"},
+	)
+	var buf bytes.Buffer
+	assert.Error(t, r.ExportShellVars(&buf))
+	assert.Equal(t, "", buf.String())
+}
@@ -0,0 +1,77 @@
+package autotag
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/gogs/git-module"
+)
+
+// ErrUnsignedCommit is returned with the UnsignedError policy when a commit of the range doesn't
+// have a verified signature, see RequireSignedCommits
+var ErrUnsignedCommit = errors.New("commit signature not verified")
+
+// Unsigned commit policies decide what happens to a commit without a verified signature, see
+// GitRepoConfig.RequireSignedCommits.
+const (
+	// UnsignedSkip excludes the commit from the version bump (default)
+	UnsignedSkip = "skip"
+	// UnsignedError fails with ErrUnsignedCommit
+	UnsignedError = "error"
+)
+
+// verifiedSignature is the `%G?` status of a good signature by a known key. `U`, a good signature
+// of a key of unknown validity, doesn't pass.
+const verifiedSignature = "G"
+
+// signatureCache caches whether the signature of a commit verifies by its SHA
+type signatureCache struct {
+	mu     sync.Mutex
+	signed map[string]bool
+}
+
+// signedCommit reports whether the signature of commit verifies with the git configuration of the
+// repo, eg: `gpg.ssh.allowedSignersFile`. The result is cached, the commits of a range are checked
+// concurrently with Workers.
+func (r *GitRepo) signedCommit(commit *git.Commit) bool {
+	id := commit.ID.String()
+	r.signatures.mu.Lock()
+	signed, ok := r.signatures.signed[id]
+	r.signatures.mu.Unlock()
+	if ok {
+		return signed
+	}
+
+	out, err := git.NewCommand("show", "--no-patch", "--format=%G?", id).RunInDir(r.repo.Path())
+	if err != nil {
+		r.debugf("error verifying the signature of commit %s: %s\n", id, err)
+	}
+	signed = err == nil && strings.TrimSpace(string(out)) == verifiedSignature
+
+	r.signatures.mu.Lock()
+	r.signatures.signed[id] = signed
+	r.signatures.mu.Unlock()
+	return signed
+}
+
+// unsignedError reports whether commit fails with the UnsignedError policy: it would count towards
+// the bump, but its signature doesn't verify. Commits excluded otherwise, eg: by author, don't fail.
+func (r *GitRepo) unsignedError(commit *git.Commit) bool {
+	if r.signatures == nil || r.unsignedPolicy != UnsignedError {
+		return false
+	}
+	return !r.ignoredByRules(commit) && !r.filteredCommit(commit) && !r.signedCommit(commit)
+}
+
+// checkSignedCommits fails with ErrUnsignedCommit for the first of the commits that fails with the
+// UnsignedError policy
+func (r *GitRepo) checkSignedCommits(commits []*git.Commit) error {
+	for _, c := range commits {
+		if r.unsignedError(c) {
+			return fmt.Errorf("%w: %s", ErrUnsignedCommit, c.ID)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package autotag
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/alecthomas/assert"
+)
+
+// configureSSHSigning makes the repo at dir sign commits with a new SSH key and trust it to verify
+// the signatures
+func configureSSHSigning(t *testing.T, dir string) {
+	key := filepath.Join(t.TempDir(), "key")
+	if out, err := exec.Command("ssh-keygen", "-q", "-t", "ed25519", "-N", "", "-f", key).CombinedOutput(); err != nil {
+		t.Skipf("ssh-keygen not available: %s: %s", err, out)
+	}
+	pub, err := os.ReadFile(key + ".pub")
+	checkFatal(t, err)
+	allowed := filepath.Join(t.TempDir(), "allowed_signers")
+	checkFatal(t, os.WriteFile(allowed, append([]byte("* "), pub...), 0o644))
+
+	runGit(t, dir, "config", "gpg.format", "ssh")
+	runGit(t, dir, "config", "user.signingkey", key+".pub")
+	runGit(t, dir, "config", "gpg.ssh.allowedSignersFile", allowed)
+}
+
+func TestRequireSignedCommits(t *testing.T) {
+	dir := newRepoDir(t, seedCommit("v1.0.0"))
+	configureSSHSigning(t, dir)
+	runGit(t, dir, "commit", "--allow-empty", "-S", "-m", "fix: correct typo")
+	runGit(t, dir, "commit", "--allow-empty", "-m", "feat: add login")
+
+	cfg := GitRepoConfig{RepoPath: dir, Branch: "master", Scheme: "conventional", Prefix: true}
+	r, err := NewRepo(cfg)
+	checkFatal(t, err)
+	assert.Equal(t, "v1.1.0", r.LatestVersion())
+
+	// only the signed fix counts
+	cfg.RequireSignedCommits = true
+	r, err = NewRepo(cfg)
+	checkFatal(t, err)
+	assert.Equal(t, "v1.0.1", r.LatestVersion())
+
+	cfg.UnsignedCommitPolicy = UnsignedError
+	_, err = NewRepo(cfg)
+	assert.True(t, errors.Is(err, ErrUnsignedCommit), "error: %v", err)
+
+	// an unsigned commit ignored otherwise doesn't fail
+	cfg.IgnoreAuthors = []string{"*"}
+	_, err = NewRepo(cfg)
+	assert.False(t, errors.Is(err, ErrUnsignedCommit), "error: %v", err)
+}
+
+func TestRequireSignedCommitsScopes(t *testing.T) {
+	dir := newRepoDir(t, seedCommit("api-v1.0.0", "web-v1.0.0"))
+	configureSSHSigning(t, dir)
+	runGit(t, dir, "commit", "--allow-empty", "-S", "-m", "fix(api): correct typo")
+	runGit(t, dir, "commit", "--allow-empty", "-m", "feat(web): add login")
+	runGit(t, dir, "commit", "--allow-empty", "-S", "-m", "chore(web): tidy up")
+
+	cfg := GitRepoConfig{RepoPath: dir, Branch: "master", Scheme: "scope-conventional", Prefix: true, AllScopes: true, RequireSignedCommits: true}
+	r, err := NewRepo(cfg)
+	checkFatal(t, err)
+	results, err := r.Preview()
+	checkFatal(t, err)
+	assert.Equal(t, 2, len(results))
+	assert.Equal(t, "api-v1.0.1", results[0].Tag)
+	assert.Equal(t, "web-v1.0.1", results[1].Tag)
+
+	cfg.UnsignedCommitPolicy = UnsignedError
+	r, err = NewRepo(cfg)
+	checkFatal(t, err)
+	results, err = r.Preview()
+	checkFatal(t, err)
+	checkFatal(t, results[0].Err)
+	assert.True(t, errors.Is(results[1].Err, ErrUnsignedCommit), "error: %v", results[1].Err)
+}
+
+func TestUnsignedCommitPolicyValidation(t *testing.T) {
+	dir := newRepoDir(t, seedCommit("v1.0.0"))
+	cfg := GitRepoConfig{RepoPath: dir, Branch: "master", Scheme: "conventional", UnsignedCommitPolicy: UnsignedError}
+	_, err := NewRepo(cfg)
+	assert.Error(t, err)
+
+	cfg.RequireSignedCommits, cfg.UnsignedCommitPolicy = true, "warn"
+	_, err = NewRepo(cfg)
+	assert.Error(t, err)
+}
@@ -0,0 +1,97 @@
+package autotag
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/hashicorp/go-version"
+)
+
+// Simulate applies the commit messages in order, as if every commit was released on its own, and
+// returns the version after each, eg: to show contributors how their commit types affect the
+// releases. No repo is needed: the bump options of cfg apply, eg: BumpRules, BumpFooters,
+// PreReleaseName and BuildMetadata, and the versions released so far take the place of the tags,
+// so the base version of each commit is selected from them like from the tags in a repo and
+// PreReleaseIncrement iterates the pre-releases. The messages are evaluated with the
+// "conventional" rules for the "scope-conventional" scheme, their scope is ignored. A commit
+// without a bump releases a patch, as with NewRepo. A commit with a `Skip-Release: true` footer
+// isn't released, the version after it is the one before.
+func Simulate(start *version.Version, messages []string, cfg GitRepoConfig) ([]*version.Version, error) {
+	if start == nil {
+		return nil, errors.New("a start version is required")
+	}
+	if scheme, ok := schemeAliases[cfg.Scheme]; ok {
+		cfg.Scheme = scheme
+	}
+	if err := validateConfig(cfg); err != nil {
+		return nil, err
+	}
+	if cfg.PreReleaseTimestampLayout == "datetime" {
+		cfg.PreReleaseTimestampLayout = datetimeTsLayout
+	}
+	scheme := cfg.Scheme
+	if scheme == "scope-conventional" {
+		scheme = "conventional"
+	}
+
+	r := &GitRepo{
+		graduate:                  cfg.Graduate,
+		preReleaseName:            cfg.PreReleaseName,
+		preReleaseIncrement:       cfg.PreReleaseIncrement || cfg.PreReleaseCounter != nil,
+		preReleaseCounterFunc:     cfg.PreReleaseCounter,
+		preReleaseTimestampLayout: cfg.PreReleaseTimestampLayout,
+		buildMetadata:             cfg.BuildMetadata,
+		versionTransform:          cfg.VersionTransform,
+		scheme:                    scheme,
+		baseOnPreRelease:          cfg.BaseOnPreRelease,
+		preReleaseOrder:           cfg.PreReleaseOrder,
+		strictTypeCase:            cfg.StrictTypeCase,
+		requireConventional:       cfg.RequireConventional,
+		commitRex:                 cfg.CommitRegex,
+		scanBodyHeaders:           cfg.ScanBodyHeaders,
+		bumpFromBody:              cfg.BumpFromBody,
+		verbosity:                 cfg.Verbosity,
+		majorBump:                 cfg.MajorBump,
+		minorBump:                 cfg.MinorBump,
+		patchBump:                 cfg.PatchBump,
+		tagFormat:                 defaultTagFormat(scheme, cfg.Prefix, defaultScopeVersionSeparator),
+		tags:                      tagIndex{"": {}},
+	}
+	r.configureBumps(cfg)
+	release := func(v *version.Version) {
+		r.tags[""][v] = tagRef{name: r.FormatTag("", v)}
+	}
+	release(start)
+
+	latest := start
+	trajectory := make([]*version.Version, 0, len(messages))
+	for i, msg := range messages {
+		if skipReleaseFooterRex.MatchString(msg) {
+			trajectory = append(trajectory, latest)
+			continue
+		}
+		ok, err := r.selectCurrentVersion("")
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, fmt.Errorf("no stable (non pre-release) version to bump commit %d from", i+1)
+		}
+		next, d, err := r.parseMessage(msg)
+		if err != nil {
+			return nil, err
+		}
+		if next == nil {
+			if next, err = r.PatchBump(); err != nil {
+				return nil, err
+			}
+		}
+		if next, err = r.finishVersion("", r.currentVersion, next, d.typ, d.graduate); err != nil {
+			return nil, err
+		}
+		release(next)
+		latest = next
+		trajectory = append(trajectory, next)
+	}
+	return trajectory, nil
+}
@@ -0,0 +1,64 @@
+package autotag
+
+import (
+	"testing"
+
+	"github.com/alecthomas/assert"
+	"github.com/hashicorp/go-version"
+)
+
+func TestSimulate(t *testing.T) {
+	messages := []string{
+		"fix: correct typo",
+		"feat: add login",
+		"fix: correct the login",
+		"feat!: drop the old API",
+		"chore: update the dependencies",
+		"fix: correct typo\n\nRelease-As: minor",
+	}
+	tests := []struct {
+		name     string
+		cfg      GitRepoConfig
+		expected []string
+	}{
+		{
+			name:     "conventional",
+			cfg:      GitRepoConfig{Scheme: "conventional"},
+			expected: []string{"1.0.1", "1.1.0", "1.1.1", "2.0.0", "2.0.1", "2.1.0"},
+		},
+		{
+			name:     "bump rules",
+			cfg:      GitRepoConfig{Scheme: "conventional", BumpRules: BumpRules{"fix": BumpMinor}},
+			expected: []string{"1.1.0", "1.2.0", "1.3.0", "2.0.0", "2.0.1", "2.1.0"},
+		},
+		{
+			name:     "pre-release iteration",
+			cfg:      GitRepoConfig{Scheme: "conventional", PreReleaseName: "rc", PreReleaseIncrement: true},
+			expected: []string{"1.0.1-rc.1", "1.1.0-rc.1", "1.0.1-rc.2", "2.0.0-rc.1", "1.0.1-rc.3", "1.1.0-rc.2"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			trajectory, err := Simulate(version.Must(version.NewVersion("1.0.0")), messages, tc.cfg)
+			checkFatal(t, err)
+			var got []string
+			for _, v := range trajectory {
+				got = append(got, v.String())
+			}
+			assert.Equal(t, tc.expected, got)
+		})
+	}
+}
+
+func TestSimulateAutotagScheme(t *testing.T) {
+	trajectory, err := Simulate(version.Must(version.NewVersion("0.1.0")), []string{"[minor] add login", "update docs", "[major] drop the old API"}, GitRepoConfig{})
+	checkFatal(t, err)
+	assert.Equal(t, 3, len(trajectory))
+	assert.Equal(t, "0.2.0", trajectory[0].String())
+	assert.Equal(t, "0.2.1", trajectory[1].String())
+	assert.Equal(t, "1.0.0", trajectory[2].String())
+
+	_, err = Simulate(nil, []string{"fix: correct typo"}, GitRepoConfig{Scheme: "conventional"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,38 @@
+package autotag
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gogs/git-module"
+)
+
+// historyRange returns the rev-list range of the commits after baseTag up to tip. Without a base
+// tag it is the whole history of tip, or only the commits after SinceRef if set.
+func (r *GitRepo) historyRange(baseTag *git.Commit, tip string) []string {
+	switch {
+	case baseTag != nil:
+		return []string{fmt.Sprintf("%s..%s", baseTag.ID, tip)}
+	case r.sinceID != "":
+		return []string{fmt.Sprintf("%s..%s", r.sinceID, tip)}
+	default:
+		return []string{tip}
+	}
+}
+
+// beforeSince returns the ids of SinceRef and the commits of its history, which don't count for
+// the scopes without a base tag. It is nil if SinceRef isn't set.
+func (r *GitRepo) beforeSince() (map[string]bool, error) {
+	if r.sinceID == "" {
+		return nil, nil
+	}
+	out, err := git.NewCommand("rev-list", r.sinceID).RunInDir(r.repo.Path())
+	if err != nil {
+		return nil, fmt.Errorf("error loading history of since ref %s: %s", r.sinceID, err)
+	}
+	before := make(map[string]bool)
+	for _, id := range strings.Fields(string(out)) {
+		before[id] = true
+	}
+	return before, nil
+}
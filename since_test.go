@@ -0,0 +1,94 @@
+package autotag
+
+import (
+	"testing"
+
+	"github.com/alecthomas/assert"
+)
+
+func TestSinceRef(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      GitRepoConfig
+		tags     []string
+		sinceRef string
+		expected string
+	}{
+		{
+			name:     "whole history",
+			cfg:      GitRepoConfig{Scheme: "conventional", InitialVersion: "0.1.0"},
+			expected: "v1.0.0",
+		},
+		{
+			name:     "early history cut off",
+			cfg:      GitRepoConfig{Scheme: "conventional", InitialVersion: "0.1.0"},
+			sinceRef: "master~1",
+			expected: "v0.1.1",
+		},
+		{
+			name:     "base tag before the since ref",
+			cfg:      GitRepoConfig{Scheme: "conventional"},
+			tags:     []string{"v1.0.0"},
+			sinceRef: "master~1",
+			expected: "v2.0.0",
+		},
+		{
+			name:     "scope",
+			cfg:      GitRepoConfig{Scheme: "scope-conventional", InitialVersion: "0.1.0"},
+			sinceRef: "master~1",
+			expected: "web-v0.1.1",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := newRepoDir(t,
+				seedCommit(tc.tags...),
+				testCommit{msg: "feat(web)!: drop the old pages"},
+				testCommit{msg: "fix(web): handle nil"},
+			)
+
+			cfg := tc.cfg
+			cfg.RepoPath, cfg.Branch, cfg.Prefix, cfg.SinceRef = dir, "master", true, tc.sinceRef
+			r, err := NewRepo(cfg)
+			checkFatal(t, err)
+			assert.Equal(t, tc.expected, r.LatestVersion())
+		})
+	}
+}
+
+func TestSinceRefScopes(t *testing.T) {
+	dir := newRepoDir(t,
+		seedCommit("api-v1.0.0"),
+		testCommit{msg: "feat(api): add login"},
+		testCommit{msg: "feat(web)!: drop the old pages"},
+		testCommit{msg: "fix(web): handle nil"},
+	)
+	r, err := NewRepo(GitRepoConfig{
+		RepoPath:       dir,
+		Branch:         "master",
+		Scheme:         "scope-conventional",
+		Prefix:         true,
+		AllScopes:      true,
+		InitialVersion: "0.1.0",
+		SinceRef:       "master~1",
+	})
+	checkFatal(t, err)
+
+	versions, err := r.NextScopeVersions()
+	checkFatal(t, err)
+	assert.Equal(t, 2, len(versions))
+	// the commits of the tagged scope before the since ref still count
+	assert.Equal(t, "1.1.0", versions["api"].String())
+	assert.Equal(t, "0.1.1", versions["web"].String())
+
+	web, err := r.NextScopeVersion("web")
+	checkFatal(t, err)
+	assert.Equal(t, "0.1.1", web.String())
+}
+
+func TestSinceRefNotFound(t *testing.T) {
+	dir := newRepoDir(t, seedCommit("v1.0.0"))
+	_, err := NewRepo(GitRepoConfig{RepoPath: dir, Branch: "master", SinceRef: "no-such-ref"})
+	assert.Error(t, err)
+}
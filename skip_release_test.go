@@ -0,0 +1,103 @@
+package autotag
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/alecthomas/assert"
+	"github.com/hashicorp/go-version"
+)
+
+func TestSkipReleaseFooter(t *testing.T) {
+	tests := []struct {
+		name        string
+		messages    []string
+		expectedTag string
+		expectedErr error
+	}{
+		{
+			name:        "held feat",
+			messages:    []string{"feat: add search\n\nSkip-Release: true"},
+			expectedErr: ErrNoBump,
+		},
+		{
+			name:        "held feat and a fix",
+			messages:    []string{"feat: add search\n\nSkip-Release: true", "fix: correct typo"},
+			expectedTag: "v1.0.1",
+		},
+		{
+			name:        "later feat bumps",
+			messages:    []string{"feat: add search\n\nskip-release: TRUE", "feat: add login"},
+			expectedTag: "v1.1.0",
+		},
+		{
+			name:        "held breaking change",
+			messages:    []string{"feat!: drop the v1 API\n\nSkip-Release: true", "fix: correct typo"},
+			expectedTag: "v1.0.1",
+		},
+		{
+			name:        "other values bump",
+			messages:    []string{"feat: add search\n\nSkip-Release: false"},
+			expectedTag: "v1.1.0",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			commits := []testCommit{seedCommit("v1.0.0")}
+			for _, msg := range tc.messages {
+				commits = append(commits, testCommit{msg: msg})
+			}
+			r, err := NewRepo(GitRepoConfig{RepoPath: newRepoDir(t, commits...), Branch: "master", Scheme: "conventional", Prefix: true})
+			if tc.expectedErr != nil {
+				assert.True(t, errors.Is(err, tc.expectedErr), "expected %v, got %v", tc.expectedErr, err)
+				return
+			}
+			checkFatal(t, err)
+			assert.Equal(t, tc.expectedTag, r.LatestVersion())
+		})
+	}
+}
+
+func TestSkipReleaseFooterOnly(t *testing.T) {
+	// the held commit is the only one since the tag: no release, not even the fallback patch bump
+	r, err := NewRepo(GitRepoConfig{
+		RepoPath: newRepoDir(t, seedCommit("v1.0.0"), testCommit{msg: "fix: correct typo\n\nSkip-Release: true"}),
+		Branch:   "master",
+		Scheme:   "conventional",
+	})
+	assert.Nil(t, r)
+	assert.True(t, errors.Is(err, ErrNoBump), "expected %v, got %v", ErrNoBump, err)
+	assert.EqualError(t, err, "no bump: no qualifying commits since 1.0.0")
+}
+
+func TestSkipReleaseFooterScopes(t *testing.T) {
+	r := newRepoFixture(t, GitRepoConfig{Scheme: "scope-conventional", AllScopes: true, Prefix: true},
+		seedCommit("api-v1.0.0", "web-v1.0.0"),
+		testCommit{msg: "feat(api): add login"},
+		testCommit{msg: "feat(web): add search\n\nSkip-Release: true"},
+	)
+	tags, errs := previewTags(t, r)
+	assert.Nil(t, errs)
+	assert.Equal(t, map[string]string{"api": "api-v1.1.0", "web": ""}, tags)
+
+	// the latest commit decides without AllScopes
+	_, err := NewRepo(GitRepoConfig{
+		RepoPath: newRepoDir(t, seedCommit("web-v1.0.0"), testCommit{msg: "feat(web): add search\n\nSkip-Release: true"}),
+		Branch:   "master",
+		Scheme:   "scope-conventional",
+		Prefix:   true,
+	})
+	assert.True(t, errors.Is(err, ErrNoBump), "expected %v, got %v", ErrNoBump, err)
+}
+
+func TestSimulateSkipRelease(t *testing.T) {
+	messages := []string{"feat: add search\n\nSkip-Release: true", "fix: correct typo", "feat: add login\n\nSkip-Release: true"}
+	trajectory, err := Simulate(version.Must(version.NewVersion("1.0.0")), messages, GitRepoConfig{Scheme: "conventional"})
+	checkFatal(t, err)
+	var got []string
+	for _, v := range trajectory {
+		got = append(got, v.String())
+	}
+	assert.Equal(t, []string{"1.0.0", "1.0.1", "1.0.1"}, got)
+}
@@ -0,0 +1,52 @@
+package autotag
+
+// SkipReason tells why a tag wasn't considered as the base version, see SkippedTag
+type SkipReason string
+
+const (
+	// SkipReasonNonVersion is a tag that isn't a version tag of the scheme, eg: `latest`
+	SkipReasonNonVersion SkipReason = "non-version"
+	// SkipReasonScope is a version tag of another scope than the one calculated
+	SkipReasonScope SkipReason = "wrong scope"
+	// SkipReasonPreRelease is a pre-release version tag, passed over for a stable base version
+	SkipReasonPreRelease SkipReason = "pre-release"
+)
+
+// SkippedTag is a tag passed over when the base version was selected, with the reason
+type SkippedTag struct {
+	Tag    string
+	Reason SkipReason
+}
+
+// SkippedTags returns the tags passed over when the base version of the calculated version was
+// selected, with CollectSkippedTags: the non version tags first, then in the order they were
+// considered. It is nil without CollectSkippedTags and for the scopes of AllScopes.
+func (r *GitRepo) SkippedTags() []SkippedTag {
+	if !r.collectSkipped {
+		return nil
+	}
+	skipped := make([]SkippedTag, 0, len(r.nonVersionTags)+len(r.skipped))
+	for _, name := range r.nonVersionTags {
+		skipped = append(skipped, SkippedTag{Tag: name, Reason: SkipReasonNonVersion})
+	}
+	return append(skipped, r.skipped...)
+}
+
+// skipTag records the skipped tag while the base version is selected, see selectCurrentVersion
+func (r *GitRepo) skipTag(name string, reason SkipReason) {
+	if r.collectingSkips {
+		r.skipped = append(r.skipped, SkippedTag{Tag: name, Reason: reason})
+	}
+}
+
+// unskipTag drops the record of the skipped tag, eg: of the pre-release selected as base version
+// after all
+func (r *GitRepo) unskipTag(name string) {
+	kept := r.skipped[:0]
+	for _, s := range r.skipped {
+		if s.Tag != name {
+			kept = append(kept, s)
+		}
+	}
+	r.skipped = kept
+}
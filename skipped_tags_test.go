@@ -0,0 +1,36 @@
+package autotag
+
+import (
+	"testing"
+
+	"github.com/alecthomas/assert"
+)
+
+func TestSkippedTags(t *testing.T) {
+	r := newRepoFixture(t, GitRepoConfig{Scheme: "scope-conventional", Prefix: true, CollectSkippedTags: true},
+		seedCommit("latest", "api-v1.0.0", "api-v1.1.0-rc.1", "web-v2.0.0"),
+		testCommit{msg: "feat(api): add login"},
+	)
+	assert.Equal(t, "api-v1.1.0", r.LatestVersion())
+	assert.Equal(t, []SkippedTag{
+		{Tag: "latest", Reason: SkipReasonNonVersion},
+		{Tag: "web-v2.0.0", Reason: SkipReasonScope},
+		{Tag: "api-v1.1.0-rc.1", Reason: SkipReasonPreRelease},
+	}, r.SkippedTags())
+}
+
+func TestSkippedTagsBaseOnPreRelease(t *testing.T) {
+	r := newRepoFixture(t, GitRepoConfig{Scheme: "conventional", Prefix: true, BaseOnPreRelease: true, CollectSkippedTags: true},
+		seedCommit("v0.1.0-rc.1", "v0.1.0-rc.2"),
+		testCommit{msg: "fix: correct typo"},
+	)
+	assert.Equal(t, []SkippedTag{{Tag: "v0.1.0-rc.1", Reason: SkipReasonPreRelease}}, r.SkippedTags())
+}
+
+func TestSkippedTagsOptIn(t *testing.T) {
+	r := newRepoFixture(t, GitRepoConfig{Scheme: "conventional", Prefix: true},
+		seedCommit("latest", "v1.0.0", "v1.1.0-rc.1"),
+	)
+	assert.Nil(t, r.SkippedTags())
+	assert.Nil(t, r.nonVersionTags)
+}
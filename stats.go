@@ -0,0 +1,76 @@
+package autotag
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/hashicorp/go-version"
+)
+
+// Stats counts the releases of a scope by bump level, see ReleaseStats
+type Stats struct {
+	Major int
+	Minor int
+	Patch int
+	// Releasers counts the releases by who created their tag, as `name <email>`, with
+	// ReportTaggers, see GitRepoConfig.ReportTaggers; nil otherwise
+	Releasers map[string]int
+}
+
+// ReleaseStats counts the stable releases of each scope dated from since until before until by
+// the bump level from the previous stable version, eg: for quarterly reviews. The scopes are the
+// normalized scopes of the "scope-conventional" scheme, "" for the other schemes. The date of a
+// release is the date of its tag, see TagDateSource. The first release of a scope has no previous
+// version and isn't counted, neither are pre-releases. Scopes without releases in the range are
+// left out.
+func (r *GitRepo) ReleaseStats(since, until time.Time) (map[string]Stats, error) {
+	tags, err := r.loadTags()
+	if err != nil {
+		return nil, err
+	}
+
+	stats := make(map[string]Stats)
+	for scope, versions := range tags {
+		var stable []*version.Version
+		for v, ref := range versions {
+			if v.Prerelease() == "" && !ref.preRelease {
+				stable = append(stable, v)
+			}
+		}
+		sort.Sort(version.Collection(stable))
+
+		for i := 1; i < len(stable); i++ {
+			date := r.tagDate(versions[stable[i]])
+			if date.Before(since) || !date.Before(until) {
+				continue
+			}
+			s := stats[scope]
+			switch NewVersionDelta(stable[i-1], stable[i]).Level {
+			case BumpMajor:
+				s.Major++
+			case BumpMinor:
+				s.Minor++
+			case BumpPatch:
+				s.Patch++
+			default:
+				// only the build metadata differs
+				continue
+			}
+			if r.reportTaggers {
+				tagger, err := r.tagCreator(versions[stable[i]])
+				if err != nil {
+					return nil, err
+				}
+				if tagger != nil {
+					if s.Releasers == nil {
+						s.Releasers = make(map[string]int)
+					}
+					s.Releasers[fmt.Sprintf("%s <%s>", tagger.Name, tagger.Email)]++
+				}
+			}
+			stats[scope] = s
+		}
+	}
+	return stats, nil
+}
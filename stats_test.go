@@ -0,0 +1,74 @@
+package autotag
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alecthomas/assert"
+	"github.com/gogs/git-module"
+)
+
+func TestReleaseStats(t *testing.T) {
+	tr := createTestRepo(t, "")
+	repo, err := git.Open(tr)
+	checkFatal(t, err)
+	defer cleanupTestRepo(t, repo)
+
+	day := func(month time.Month, d int) time.Time { return time.Date(2023, month, d, 12, 0, 0, 0, time.UTC) }
+	for _, c := range []struct {
+		date time.Time
+		tags []string
+	}{
+		{date: day(1, 10), tags: []string{"api-v1.0.0", "web-v0.1.0"}},
+		{date: day(2, 1), tags: []string{"api-v1.1.0", "web-v0.1.1"}},
+		{date: day(2, 15), tags: []string{"api-v1.1.1", "api-v2.0.0-rc.1"}},
+		{date: day(3, 1), tags: []string{"api-v2.0.0"}},
+		{date: day(4, 1), tags: []string{"api-v2.1.0", "web-v0.2.0"}},
+	} {
+		makeCommitAt(t, repo, "release "+c.date.Format("2006-01-02"), c.date)
+		for _, tag := range c.tags {
+			makeTag(repo, tag)
+		}
+	}
+
+	r, err := NewRepo(GitRepoConfig{RepoPath: repo.Path(), Branch: "master", Scheme: "scope-conventional", Prefix: true, AllScopes: true})
+	checkFatal(t, err)
+
+	tests := []struct {
+		name         string
+		since, until time.Time
+		expected     map[string]Stats
+	}{
+		{
+			name:  "first quarter",
+			since: day(1, 1),
+			until: day(4, 1),
+			expected: map[string]Stats{
+				"api": {Major: 1, Minor: 1, Patch: 1},
+				"web": {Patch: 1},
+			},
+		},
+		{
+			name:  "until is excluded",
+			since: day(3, 1),
+			until: day(4, 1),
+			expected: map[string]Stats{
+				"api": {Major: 1},
+			},
+		},
+		{
+			name:     "first releases",
+			since:    day(1, 1),
+			until:    day(2, 1),
+			expected: map[string]Stats{},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			stats, err := r.ReleaseStats(tc.since, tc.until)
+			checkFatal(t, err)
+			assert.Equal(t, tc.expected, stats)
+		})
+	}
+}
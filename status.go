@@ -0,0 +1,127 @@
+package autotag
+
+import "fmt"
+
+// Status is the outcome of a scope in a batch run, see AutoTagScopeResults
+type Status int
+
+const (
+	// StatusNone is the status of a result that isn't part of a batch run, eg: of a Preview
+	StatusNone Status = iota
+	// StatusTagged is a scope whose new version tag was created
+	StatusTagged
+	// StatusSkipped is a scope without a release: no commit since its base version bumps it. The
+	// Err of the result tells why, eg: ErrNoBump.
+	StatusSkipped
+	// StatusError is a scope that failed, eg: its version is already tagged. The Err of the result
+	// is the error. With the default OnScopeError such an error aborts the batch instead.
+	StatusError
+)
+
+var statusNames = map[Status]string{
+	StatusNone:    "none",
+	StatusTagged:  "tagged",
+	StatusSkipped: "skipped",
+	StatusError:   "error",
+}
+
+func (s Status) String() string {
+	if name, ok := statusNames[s]; ok {
+		return name
+	}
+	return fmt.Sprintf("Status(%d)", int(s))
+}
+
+// AutoTagScopeResults creates the tags of the next versions of all scopes like AutoTagScopes and
+// returns a Result for every scope, in order, with its Status: the tagged scopes, the scopes
+// without a release and, if OnScopeError returns ScopeErrorSkip for their error, the failed
+// scopes. A scope with tags but no usable base version is reported as failed without consulting
+// OnScopeError, as it doesn't abort AutoTagScopes either. When the batch is aborted the created
+// tags are deleted again and no results are returned. A failing PostTagHook returns the results
+// along with its error.
+func (r *GitRepo) AutoTagScopeResults() ([]Result, error) {
+	if err := r.checkEvent(); err != nil {
+		return nil, err
+	}
+	if err := r.checkUpToDate(); err != nil {
+		return nil, err
+	}
+	if err := r.checkCleanTree(); err != nil {
+		return nil, err
+	}
+	bases, err := r.scopeBases()
+	if err != nil {
+		return nil, err
+	}
+
+	results := []Result{}
+	var created []string
+	for _, scope := range sortedScopes(bases) {
+		base := bases[scope]
+		res := Result{Scope: scope, Current: base.version}
+		switch {
+		case base.err != nil:
+			res.Status, res.Err = StatusError, base.err
+		case base.level == BumpNone:
+			res.Status, res.Err = StatusSkipped, fmt.Errorf("%w: no commits to release since %s", ErrNoBump, base.version)
+		default:
+			var err error
+			if res, err = r.tagScopeVersion(scope, base); err != nil {
+				if skipErr := r.scopeError(res.Scope, err); skipErr != nil {
+					r.deleteTags(created)
+					return nil, skipErr
+				}
+				res.Status, res.Err = StatusError, err
+				break
+			}
+			res.Status = StatusTagged
+			created = append(created, res.Tag)
+		}
+		results = append(results, res)
+	}
+
+	// the hooks only run once the batch can't be rolled back anymore
+	for _, res := range results {
+		if res.Status != StatusTagged {
+			continue
+		}
+		if err := r.runPostTagHooks(res); err != nil {
+			return results, err
+		}
+	}
+	return results, nil
+}
+
+// tagScopeVersion creates the tag of the next version of scope bumped from its base, after
+// checking it doesn't collide with the existing tags. The result is returned also on an error if
+// the version is calculated.
+func (r *GitRepo) tagScopeVersion(scope string, base *scopeBase) (Result, error) {
+	res, err := r.nextScopeResult(scope, base)
+	if err != nil {
+		return Result{Scope: scope, Current: base.version}, err
+	}
+	if res.CommitsSinceBase, err = r.countCommitsSinceBase(scope, base.version, base.tag); err != nil {
+		return res, err
+	}
+	err = r.checkVersionCollision(res.Scope, res.Next)
+	if err == nil {
+		err = r.checkAlreadyTagged(res.Scope, res.Next)
+	}
+	if err == nil {
+		err = r.checkRemoteTag(res.Tag)
+	}
+	if err == nil {
+		err = r.createTag(res)
+	}
+	return res, err
+}
+
+// ParseStatus parses the name of a status: none, tagged, skipped or error
+func ParseStatus(name string) (Status, error) {
+	for status, n := range statusNames {
+		if n == name {
+			return status, nil
+		}
+	}
+	return StatusNone, fmt.Errorf("status '%s' is not valid; must be (none|tagged|skipped|error)", name)
+}
@@ -0,0 +1,71 @@
+package autotag
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/alecthomas/assert"
+	"github.com/hashicorp/go-version"
+)
+
+func TestAutoTagScopeResults(t *testing.T) {
+	tagger := &fakeTagger{}
+	// the base version of db tracked outside of git is behind its tags
+	r := newRepoFixture(t, GitRepoConfig{
+		Scheme:          "scope-conventional",
+		Prefix:          true,
+		AllScopes:       true,
+		Tagger:          tagger,
+		CurrentVersions: map[string]*version.Version{"db": version.Must(version.NewVersion("1.2.2"))},
+		OnScopeError:    func(scope string, err error) ScopeErrorAction { return ScopeErrorSkip },
+	},
+		seedCommit("api-v1.0.0", "db-v1.2.3", "web-v1.0.0"),
+		testCommit{msg: "feat(api): add login"},
+		testCommit{msg: "fix(db): correct typo"},
+	)
+
+	results, err := r.AutoTagScopeResults()
+	checkFatal(t, err)
+	assert.Equal(t, 3, len(results))
+
+	assert.Equal(t, "api", results[0].Scope)
+	assert.Equal(t, StatusTagged, results[0].Status)
+	assert.Equal(t, "api-v1.1.0", results[0].Tag)
+	assert.NoError(t, results[0].Err)
+
+	assert.Equal(t, "db", results[1].Scope)
+	assert.Equal(t, StatusError, results[1].Status)
+	assert.True(t, errors.Is(results[1].Err, ErrVersionExists), "expected %v, got %v", ErrVersionExists, results[1].Err)
+
+	assert.Equal(t, "web", results[2].Scope)
+	assert.Equal(t, StatusSkipped, results[2].Status)
+	assert.True(t, errors.Is(results[2].Err, ErrNoBump), "expected %v, got %v", ErrNoBump, results[2].Err)
+
+	assert.Equal(t, 1, len(tagger.created))
+	assert.Equal(t, "api-v1.1.0", tagger.created[0].name)
+}
+
+func TestAutoTagScopeResultsAbort(t *testing.T) {
+	tagger := &fakeTagger{}
+	r := newRepoFixture(t, GitRepoConfig{
+		Scheme:          "scope-conventional",
+		Prefix:          true,
+		AllScopes:       true,
+		Tagger:          tagger,
+		CurrentVersions: map[string]*version.Version{"db": version.Must(version.NewVersion("1.2.2"))},
+	},
+		seedCommit("api-v1.0.0", "db-v1.2.3"),
+		testCommit{msg: "feat(api): add login"},
+		testCommit{msg: "fix(db): correct typo"},
+	)
+
+	results, err := r.AutoTagScopeResults()
+	assert.True(t, errors.Is(err, ErrVersionExists), "expected %v, got %v", ErrVersionExists, err)
+	assert.Equal(t, 0, len(results))
+}
+
+func TestResultMarshalJSONStatus(t *testing.T) {
+	out, err := Result{Scope: "api", Status: StatusSkipped, Err: ErrNoBump}.MarshalJSON()
+	checkFatal(t, err)
+	assert.Equal(t, `{"scope":"api","preRelease":false,"status":"skipped","error":"no bump"}`, string(out))
+}
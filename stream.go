@@ -0,0 +1,99 @@
+package autotag
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+
+	"github.com/hashicorp/go-version"
+)
+
+// resultJSON is the JSON object of a Result
+type resultJSON struct {
+	Repo             string           `json:"repo,omitempty"`
+	Scope            string           `json:"scope"`
+	Current          *version.Version `json:"current,omitempty"`
+	Next             *version.Version `json:"next,omitempty"`
+	Tag              string           `json:"tag,omitempty"`
+	PreRelease       bool             `json:"preRelease"`
+	BuildMetadata    string           `json:"buildMetadata,omitempty"`
+	BumpReason       string           `json:"bumpReason,omitempty"`
+	DecidingCommit   string           `json:"decidingCommit,omitempty"`
+	ChangedPaths     []string         `json:"changedPaths,omitempty"`
+	CommitsSinceBase int              `json:"commitsSinceBase,omitempty"`
+	Status           string           `json:"status,omitempty"`
+	Error            string           `json:"error,omitempty"`
+}
+
+// MarshalJSON encodes the result as an object with the versions as strings and the error as its
+// message, eg: `{"scope":"api","current":"1.0.0","next":"1.1.0","tag":"api-v1.1.0",...}`.
+// Unset fields are left out.
+func (res Result) MarshalJSON() ([]byte, error) {
+	out := resultJSON{
+		Repo:             res.Repo,
+		Scope:            res.Scope,
+		Current:          res.Current,
+		Next:             res.Next,
+		Tag:              res.Tag,
+		PreRelease:       res.PreRelease,
+		BuildMetadata:    res.BuildMetadata,
+		BumpReason:       res.BumpReason,
+		DecidingCommit:   res.DecidingCommit,
+		ChangedPaths:     res.ChangedPaths,
+		CommitsSinceBase: res.CommitsSinceBase,
+	}
+	if res.Status != StatusNone {
+		out.Status = res.Status.String()
+	}
+	if res.Err != nil {
+		out.Error = res.Err.Error()
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON decodes a result encoded by MarshalJSON
+func (res *Result) UnmarshalJSON(data []byte) error {
+	var in resultJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	*res = Result{
+		Repo:             in.Repo,
+		Scope:            in.Scope,
+		Current:          in.Current,
+		Next:             in.Next,
+		Tag:              in.Tag,
+		PreRelease:       in.PreRelease,
+		BuildMetadata:    in.BuildMetadata,
+		BumpReason:       in.BumpReason,
+		DecidingCommit:   in.DecidingCommit,
+		ChangedPaths:     in.ChangedPaths,
+		CommitsSinceBase: in.CommitsSinceBase,
+	}
+	if in.Status != "" {
+		status, err := ParseStatus(in.Status)
+		if err != nil {
+			return err
+		}
+		res.Status = status
+	}
+	if in.Error != "" {
+		res.Err = errors.New(in.Error)
+	}
+	return nil
+}
+
+// StreamResults writes the results of the Preview to w as newline-delimited JSON, one object per
+// line, see Result.MarshalJSON. Each result is written as soon as its scope is calculated, so
+// consumers can start before the whole batch is done. The results are written one at a time in
+// scope order, also with Workers.
+func (r *GitRepo) StreamResults(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	emit := func(res Result) error {
+		return enc.Encode(res)
+	}
+	if r.submoduleCfg != nil {
+		return r.previewSubmodules(emit)
+	}
+	return r.previewScopes(emit)
+}
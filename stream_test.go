@@ -0,0 +1,65 @@
+package autotag
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/assert"
+)
+
+func TestStreamResults(t *testing.T) {
+	r := newRepoFixture(t, GitRepoConfig{Scheme: "scope-conventional", Prefix: true, AllScopes: true},
+		seedCommit("api-v1.0.0", "web-v1.0.0"),
+		testCommit{msg: "feat(api): add login"},
+	)
+
+	var buf bytes.Buffer
+	checkFatal(t, r.StreamResults(&buf))
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	assert.Equal(t, 2, len(lines))
+
+	var api map[string]interface{}
+	checkFatal(t, json.Unmarshal([]byte(lines[0]), &api))
+	assert.Equal(t, "api", api["scope"])
+	assert.Equal(t, "1.0.0", api["current"])
+	assert.Equal(t, "1.1.0", api["next"])
+	assert.Equal(t, "api-v1.1.0", api["tag"])
+	assert.Equal(t, false, api["preRelease"])
+	assert.Equal(t, `{"scope":"web","current":"1.0.0","preRelease":false}`, lines[1])
+
+	// the same results as the preview
+	preview, err := r.Preview()
+	checkFatal(t, err)
+	for i, res := range preview {
+		expected, err := json.Marshal(res)
+		checkFatal(t, err)
+		assert.Equal(t, string(expected), lines[i])
+	}
+}
+
+func TestResultMarshalJSONError(t *testing.T) {
+	out, err := json.Marshal(Result{Scope: "api", Err: ErrNoBump})
+	checkFatal(t, err)
+	assert.Equal(t, `{"scope":"api","preRelease":false,"error":"no bump"}`, string(out))
+}
+
+type failingWriter struct{ writes int }
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	w.writes++
+	return 0, errors.New("broken pipe")
+}
+
+func TestStreamResultsWriteError(t *testing.T) {
+	r := newRepoFixture(t, GitRepoConfig{Scheme: "scope-conventional", Prefix: true, AllScopes: true},
+		seedCommit("api-v1.0.0", "web-v1.0.0"),
+		testCommit{msg: "feat(api): add login"},
+	)
+
+	w := &failingWriter{}
+	assert.Error(t, r.StreamResults(w))
+	assert.Equal(t, 1, w.writes)
+}
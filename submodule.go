@@ -0,0 +1,139 @@
+package autotag
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gogs/git-module"
+)
+
+// gitLinkPrefix starts the content of a `.git` file pointing to the git dir of a submodule or a
+// linked worktree, eg: `gitdir: ../.git/modules/api`
+const gitLinkPrefix = "gitdir:"
+
+// resolveGitLink returns the git dir a `.git` file points to, gitDir itself if it is a directory
+// or doesn't exist
+func resolveGitLink(gitDir string) (string, error) {
+	info, err := os.Stat(gitDir)
+	if err != nil || info.IsDir() {
+		return gitDir, nil
+	}
+	content, err := os.ReadFile(gitDir)
+	if err != nil {
+		return "", err
+	}
+	link := strings.TrimSpace(string(content))
+	if !strings.HasPrefix(link, gitLinkPrefix) {
+		return "", fmt.Errorf("%s is neither a git dir nor a gitdir link", gitDir)
+	}
+	path := strings.TrimSpace(strings.TrimPrefix(link, gitLinkPrefix))
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(filepath.Dir(gitDir), path)
+	}
+	return path, nil
+}
+
+// submodulePaths returns the paths of the submodules in the `.gitmodules` file of the branch
+// commit, in order of the file. A branch without the file has no submodules.
+func (r *GitRepo) submodulePaths() ([]string, error) {
+	if _, err := r.repo.RevParse(r.branchID + ":.gitmodules"); errors.Is(err, git.ErrRevisionNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("error reading the submodules: %s", err)
+	}
+	out, err := git.NewCommand("config", "--blob", r.branchID+":.gitmodules", "--get-regexp", `^submodule\..*\.path$`).RunInDir(r.repo.Path())
+	if err != nil {
+		return nil, fmt.Errorf("error reading the submodules: %s", err)
+	}
+
+	var paths []string
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		// `submodule.<name>.path <path>`
+		if _, path, ok := strings.Cut(line, " "); ok {
+			paths = append(paths, path)
+		}
+	}
+	return paths, nil
+}
+
+// submoduleRepo opens the checkout of the submodule at path with the configuration of the
+// superproject, see Submodules. The version is calculated from the tags and commits of the
+// submodule on its main or master branch.
+func (r *GitRepo) submoduleRepo(path string) (*GitRepo, error) {
+	cfg := *r.submoduleCfg
+	cfg.RepoPath, cfg.Branch, cfg.Rev, cfg.TagMergeBase, cfg.Submodules = filepath.Join(r.workTree, path), "", "", [2]string{}, false
+	if _, err := os.Stat(filepath.Join(cfg.RepoPath, ".git")); err != nil {
+		return nil, fmt.Errorf("submodule %s is not checked out", path)
+	}
+	return NewRepo(cfg)
+}
+
+// SubmoduleResults calculates the next version of every submodule of the branch with Submodules,
+// one Result per submodule in order of `.gitmodules`. The scope of a Result is the path of its
+// submodule. Like with Preview errors of a submodule, eg: one that isn't checked out, are reported
+// in the Err of its result, unless OnScopeError returns ScopeErrorSkip for it.
+func (r *GitRepo) SubmoduleResults() ([]Result, error) {
+	results := []Result{}
+	err := r.previewSubmodules(func(res Result) error {
+		results = append(results, res)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// previewSubmodules passes the result of every submodule to emit as soon as it is calculated, in
+// order, and stops at the first error of emit
+func (r *GitRepo) previewSubmodules(emit func(Result) error) error {
+	paths, err := r.submodulePaths()
+	if err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		res := Result{Scope: path}
+		sub, err := r.submoduleRepo(path)
+		if err == nil {
+			res = sub.Result()
+			res.Scope = path
+		}
+		if err != nil {
+			if r.scopeError(path, err) == nil {
+				continue
+			}
+			res.Err = err
+		}
+		if err := emit(res); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// autoTagSubmodules creates the new version tag of every submodule in the submodule repo and stops
+// at the first error, unless OnScopeError returns ScopeErrorSkip for it. The tags created before
+// are kept, unlike with AutoTagScopes the tags of separate repos aren't rolled back.
+func (r *GitRepo) autoTagSubmodules() error {
+	paths, err := r.submodulePaths()
+	if err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		sub, err := r.submoduleRepo(path)
+		if err == nil {
+			err = sub.AutoTag()
+		}
+		if err != nil {
+			if err = r.scopeError(path, err); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package autotag
+
+import (
+	"testing"
+
+	"github.com/alecthomas/assert"
+)
+
+// newSubmoduleFixture creates a superproject with the submodules services/api, at v1.0.0 with a
+// pending feature, and services/web, at v2.0.0 with a pending fix
+func newSubmoduleFixture(t *testing.T) string {
+	api := newRepoFixture(t, GitRepoConfig{Scheme: "conventional"},
+		seedCommit("v1.0.0"),
+		testCommit{msg: "feat: add login"},
+	)
+	web := newRepoFixture(t, GitRepoConfig{Scheme: "conventional"},
+		seedCommit("v2.0.0"),
+		testCommit{msg: "fix: correct typo"},
+	)
+	super := newRepoFixture(t, GitRepoConfig{Scheme: "conventional", Submodules: true},
+		seedCommit(),
+	)
+	dir := repoRoot(super.repo)
+	runGit(t, dir, "-c", "protocol.file.allow=always", "submodule", "add", repoRoot(api.repo), "services/api")
+	runGit(t, dir, "-c", "protocol.file.allow=always", "submodule", "add", repoRoot(web.repo), "services/web")
+	runGit(t, dir, "commit", "-m", "chore: add the services")
+	return dir
+}
+
+func TestSubmoduleResults(t *testing.T) {
+	dir := newSubmoduleFixture(t)
+
+	r, err := NewRepo(GitRepoConfig{RepoPath: dir, Branch: "master", Scheme: "conventional", Prefix: true, Submodules: true})
+	checkFatal(t, err)
+	results, err := r.Preview()
+	checkFatal(t, err)
+
+	var scopes, tags []string
+	for _, res := range results {
+		checkFatal(t, res.Err)
+		scopes, tags = append(scopes, res.Scope), append(tags, res.Tag)
+	}
+	assert.Equal(t, []string{"services/api", "services/web"}, scopes)
+	assert.Equal(t, []string{"v1.1.0", "v2.0.1"}, tags)
+}
+
+func TestAutoTagSubmodules(t *testing.T) {
+	dir := newSubmoduleFixture(t)
+
+	tagger := &fakeTagger{}
+	r, err := NewRepo(GitRepoConfig{RepoPath: dir, Branch: "master", Scheme: "conventional", Prefix: true, Submodules: true, Tagger: tagger})
+	checkFatal(t, err)
+	checkFatal(t, r.AutoTag())
+
+	var created []string
+	for _, tag := range tagger.created {
+		created = append(created, tag.name)
+	}
+	assert.Equal(t, []string{"v1.1.0", "v2.0.1"}, created)
+}
+
+func TestSubmoduleNotCheckedOut(t *testing.T) {
+	dir := newSubmoduleFixture(t)
+	runGit(t, dir, "submodule", "deinit", "-f", "services/web")
+
+	cfg := GitRepoConfig{RepoPath: dir, Branch: "master", Scheme: "conventional", Prefix: true, Submodules: true}
+	r, err := NewRepo(cfg)
+	checkFatal(t, err)
+	results, err := r.SubmoduleResults()
+	checkFatal(t, err)
+	assert.Equal(t, 2, len(results))
+	assert.Error(t, results[1].Err)
+	assert.Error(t, r.AutoTag())
+
+	cfg.OnScopeError = func(scope string, err error) ScopeErrorAction { return ScopeErrorSkip }
+	r, err = NewRepo(cfg)
+	checkFatal(t, err)
+	results, err = r.SubmoduleResults()
+	checkFatal(t, err)
+	assert.Equal(t, 1, len(results))
+	assert.Equal(t, "services/api", results[0].Scope)
+}
+
+func TestSubmodulesValidation(t *testing.T) {
+	dir := newRepoDir(t, seedCommit("v1.0.0"))
+	_, err := NewRepo(GitRepoConfig{RepoPath: dir, Branch: "master", Scheme: "scope-conventional", Submodules: true})
+	assert.Error(t, err)
+}
@@ -0,0 +1,141 @@
+package autotag
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+)
+
+// tag format placeholders
+const (
+	tagFormatScope   = "{scope}"
+	tagFormatVersion = "{version}"
+)
+
+// tagFormatPlaceholderRex matches the placeholders of a tag format
+var tagFormatPlaceholderRex = regexp.MustCompile(`\{scope\}|\{version\}`)
+
+// defaultScopeVersionSeparator separates the scope and the version of the default tag format
+const defaultScopeVersionSeparator = "-"
+
+// invalidRefChars can't be used in a scope version separator since git doesn't allow them in refs
+const invalidRefChars = " ~^:?*[\\"
+
+// defaultTagFormat returns the tag format of the scheme: `{scope}<separator>v{version}` for the
+// "scope-conventional" scheme and `v{version}` for the others, without the `v` unless prefix is set.
+func defaultTagFormat(scheme string, prefix bool, separator string) string {
+	format := tagFormatVersion
+	if prefix {
+		format = "v" + format
+	}
+	if scheme == "scope-conventional" {
+		format = tagFormatScope + separator + format
+	}
+	return format
+}
+
+// scopeSeparatorRegex returns the regex matching the tags of the default scope tag format with a
+// custom separator, with named `scope` and `version` capture groups. Like the default format the
+// `v` is optional, so tags are found with and without prefix. The version needs all three parts,
+// so a separator like `.` doesn't split `api-v1.2.3` into `api-v1` and `2.3`.
+func scopeSeparatorRegex(separator string) *regexp.Regexp {
+	return regexp.MustCompile(`^(?P<scope>.+?)` + regexp.QuoteMeta(separator) + `v?(?P<version>\d+\.\d+\.\d+[0-9A-Za-z.+-]*)$`)
+}
+
+// validateTagFormat checks that format has a single `{version}` and, for the "scope-conventional"
+// scheme only, a single `{scope}` placeholder
+func validateTagFormat(format, scheme string) error {
+	if strings.Count(format, tagFormatVersion) != 1 {
+		return fmt.Errorf("tag format '%s' must contain %s exactly once", format, tagFormatVersion)
+	}
+	scopes := strings.Count(format, tagFormatScope)
+	if scheme == "scope-conventional" && scopes != 1 {
+		return fmt.Errorf("tag format '%s' must contain %s exactly once", format, tagFormatScope)
+	}
+	if scheme != "scope-conventional" && scopes != 0 {
+		return fmt.Errorf("tag format '%s' can only contain %s with the scope-conventional scheme", format, tagFormatScope)
+	}
+	return nil
+}
+
+// tagFormatRegex returns the regex matching the tag names of format, with named `scope` and
+// `version` capture groups. The literal parts of the format are matched as is.
+func tagFormatRegex(format string) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString("^")
+	last := 0
+	for _, loc := range tagFormatPlaceholderRex.FindAllStringIndex(format, -1) {
+		b.WriteString(regexp.QuoteMeta(format[last:loc[0]]))
+		switch format[loc[0]:loc[1]] {
+		case tagFormatScope:
+			b.WriteString(`(?P<scope>.+?)`)
+		case tagFormatVersion:
+			b.WriteString(`(?P<version>\d[0-9A-Za-z.+-]*)`)
+		}
+		last = loc[1]
+	}
+	b.WriteString(regexp.QuoteMeta(format[last:]))
+	b.WriteString("$")
+	return regexp.MustCompile(b.String())
+}
+
+// FormatTag returns the name of the tag of version v of scope, as created by AutoTag, applying the
+// version prefix and the tag format, of the ScopeRegistry if the scope has its own. The scope is
+// ignored by the schemes without scopes. The name is relative to the version ref namespace.
+func (r *GitRepo) FormatTag(scope string, v *version.Version) string {
+	format := r.tagFormat
+	if c := r.scopeSettings(scope); c != nil && c.tagFormat != "" {
+		format = c.tagFormat
+	}
+	return strings.NewReplacer(tagFormatScope, scope, tagFormatVersion, v.String()).Replace(format)
+}
+
+// ErrNotVersionTag is returned by ParseTag for a name that isn't a version tag of the configured
+// scheme and tag format
+var ErrNotVersionTag = errors.New("not a version tag")
+
+// ParseTag returns the scope and version of the tag name, as read by autotag, so a proposed tag can
+// be validated before it is created. It is the inverse of FormatTag: the version ref namespace is
+// stripped, eg: `refs/tags/api-v1.2.3` is parsed like `api-v1.2.3`, and the scope separator, tag
+// format and `v` prefix are applied like they are to the existing tags. The scope is empty for the
+// schemes without scopes. It returns ErrNotVersionTag if name doesn't match.
+func (r *GitRepo) ParseTag(name string) (string, *version.Version, error) {
+	tagName := strings.TrimPrefix(name, r.refNamespace+"/")
+	if tagName == "" {
+		return "", nil, fmt.Errorf("%w: empty tag name", ErrNotVersionTag)
+	}
+	scope, v, ok := r.parseTagName(tagName)
+	if !ok {
+		return "", nil, fmt.Errorf("%w: '%s' doesn't match the tag format '%s'", ErrNotVersionTag, name, r.tagFormat)
+	}
+	return scope, v, nil
+}
+
+// splitScopeTag splits a tag name into its scope and version parts, using the tag format if one is
+// configured. It returns false if the tag doesn't follow the format.
+func (r *GitRepo) splitScopeTag(tagName string) (scope, ver string, ok bool) {
+	if r.tagRex == nil {
+		return splitScopeTag(tagName)
+	}
+	m := findNamedMatches(r.tagRex, tagName)
+	if m["version"] == "" {
+		return "", "", false
+	}
+	return m["scope"], m["version"], true
+}
+
+// versionFromTag parses the version of a tag name of the schemes without scopes, using the tag
+// format if one is configured
+func (r *GitRepo) versionFromTag(tagName string) (*version.Version, error) {
+	if r.tagRex == nil {
+		return maybeVersionFromTag(tagName)
+	}
+	ver := findNamedMatches(r.tagRex, tagName)["version"]
+	if ver == "" {
+		return nil, fmt.Errorf("tag %s doesn't match the tag format", tagName)
+	}
+	return maybeVersionFromTag(ver)
+}
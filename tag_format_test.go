@@ -0,0 +1,274 @@
+package autotag
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/assert"
+	"github.com/gogs/git-module"
+	"github.com/hashicorp/go-version"
+)
+
+func TestFormatTag(t *testing.T) {
+	tests := []struct {
+		name     string
+		scheme   string
+		prefix   bool
+		format   string
+		scope    string
+		version  string
+		expected string
+	}{
+		{"default", "", true, "", "", "1.2.3", "v1.2.3"},
+		{"default without prefix", "conventional", false, "", "", "1.2.3", "1.2.3"},
+		{"default ignores the scope", "autotag", true, "", "api", "1.2.3", "v1.2.3"},
+		{"scope", "scope-conventional", true, "", "api", "1.2.3", "api-v1.2.3"},
+		{"scope without prefix", "scope-conventional", false, "", "api", "1.2.3", "api-1.2.3"},
+		{"pre-release and metadata", "scope-conventional", true, "", "api", "1.2.3-rc.1+g123", "api-v1.2.3-rc.1+g123"},
+		{"custom format", "", true, "release-{version}", "", "1.2.3", "release-1.2.3"},
+		{"custom scope format", "scope-conventional", true, "{scope}@{version}", "api", "1.2.3", "api@1.2.3"},
+		{"custom format ignores the prefix", "scope-conventional", false, "{scope}/v{version}", "api", "1.2.3", "api/v1.2.3"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &GitRepo{tagFormat: defaultTagFormat(tc.scheme, tc.prefix, defaultScopeVersionSeparator)}
+			if tc.format != "" {
+				r.tagFormat = tc.format
+			}
+			v, err := version.NewVersion(tc.version)
+			checkFatal(t, err)
+			assert.Equal(t, tc.expected, r.FormatTag(tc.scope, v))
+		})
+	}
+}
+
+func TestParseTag(t *testing.T) {
+	tests := []struct {
+		name            string
+		cfg             GitRepoConfig
+		tag             string
+		expectedScope   string
+		expectedVersion string
+		shouldErr       bool
+	}{
+		{name: "default", cfg: GitRepoConfig{Prefix: true}, tag: "v1.2.3", expectedVersion: "1.2.3"},
+		{name: "namespace", cfg: GitRepoConfig{Prefix: true}, tag: "refs/tags/v1.2.3", expectedVersion: "1.2.3"},
+		{name: "pre-release", cfg: GitRepoConfig{Prefix: true}, tag: "v1.2.3-rc.1+g123", expectedVersion: "1.2.3-rc.1+g123"},
+		{name: "scope", cfg: GitRepoConfig{Scheme: "scope-conventional", Prefix: true}, tag: "api-v1.2.3", expectedScope: "api", expectedVersion: "1.2.3"},
+		{name: "scope with dashes", cfg: GitRepoConfig{Scheme: "scope-conventional"}, tag: "billing-api-1.2.3", expectedScope: "billing-api", expectedVersion: "1.2.3"},
+		{name: "separator", cfg: GitRepoConfig{Scheme: "scope-conventional", ScopeVersionSeparator: "@"}, tag: "api@1.2.3", expectedScope: "api", expectedVersion: "1.2.3"},
+		{name: "tag format", cfg: GitRepoConfig{Scheme: "scope-conventional", TagFormat: "{scope}/release-{version}"}, tag: "api/release-1.2.3", expectedScope: "api", expectedVersion: "1.2.3"},
+		{name: "custom namespace", cfg: GitRepoConfig{Prefix: true, VersionRefNamespace: "refs/autotag"}, tag: "refs/autotag/v1.2.3", expectedVersion: "1.2.3"},
+		{name: "not a version", cfg: GitRepoConfig{Prefix: true}, tag: "nightly", shouldErr: true},
+		{name: "empty", cfg: GitRepoConfig{Prefix: true}, tag: "refs/tags/", shouldErr: true},
+		{name: "scope without a scope", cfg: GitRepoConfig{Scheme: "scope-conventional"}, tag: "v1.2.3", shouldErr: true},
+		{name: "other tag format", cfg: GitRepoConfig{Scheme: "scope-conventional", TagFormat: "{scope}@{version}"}, tag: "api-v1.2.3", shouldErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.cfg.InitialVersion = "0.0.0"
+			r := newRepoFixture(t, tc.cfg, testCommit{msg: "feat(api): add login"})
+			scope, v, err := r.ParseTag(tc.tag)
+			if tc.shouldErr {
+				assert.True(t, errors.Is(err, ErrNotVersionTag), "expected %v, got %v", ErrNotVersionTag, err)
+				return
+			}
+			checkFatal(t, err)
+			assert.Equal(t, tc.expectedScope, scope)
+			assert.Equal(t, tc.expectedVersion, v.Original())
+			if !strings.HasPrefix(tc.tag, "refs/") {
+				// round trip through FormatTag
+				scope, parsed, err := r.ParseTag(r.FormatTag(scope, v))
+				checkFatal(t, err)
+				assert.Equal(t, tc.expectedScope, scope)
+				assert.True(t, parsed.Equal(v))
+			}
+		})
+	}
+}
+
+func TestTagFormatRegex(t *testing.T) {
+	tests := []struct {
+		format  string
+		tag     string
+		scope   string
+		version string
+		ok      bool
+	}{
+		{"{scope}@{version}", "api@1.2.3", "api", "1.2.3", true},
+		{"{scope}@{version}", "my-api@1.2.3-rc.1", "my-api", "1.2.3-rc.1", true},
+		{"{scope}@{version}", "api-v1.2.3", "", "", false},
+		{"{scope}/v{version}", "services/api/v1.2.3", "services/api", "1.2.3", true},
+		{"{scope}.v{version}", "api-v1.2.3", "", "", false},
+		{"release-{version}", "release-1.2.3", "", "1.2.3", true},
+		{"release-{version}", "v1.2.3", "", "", false},
+		{"release-{version}", "release-1.2.3-final", "", "1.2.3-final", true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.format+" "+tc.tag, func(t *testing.T) {
+			r := &GitRepo{tagFormat: tc.format, tagRex: tagFormatRegex(tc.format)}
+			scope, ver, ok := r.splitScopeTag(tc.tag)
+			assert.Equal(t, tc.ok, ok)
+			assert.Equal(t, tc.scope, scope)
+			assert.Equal(t, tc.version, ver)
+
+			// tags round-trip
+			if ok {
+				v, err := version.NewVersion(ver)
+				checkFatal(t, err)
+				assert.Equal(t, tc.tag, r.FormatTag(scope, v))
+			}
+		})
+	}
+}
+
+func TestValidateTagFormat(t *testing.T) {
+	assert.NoError(t, validateTagFormat("{scope}@{version}", "scope-conventional"))
+	assert.NoError(t, validateTagFormat("release-{version}", "conventional"))
+	assert.Error(t, validateTagFormat("release", "conventional"))
+	assert.Error(t, validateTagFormat("{version}-{version}", "conventional"))
+	assert.Error(t, validateTagFormat("{scope}@{version}", "conventional"))
+	assert.Error(t, validateTagFormat("release-{version}", "scope-conventional"))
+}
+
+func TestAutoTagTagFormat(t *testing.T) {
+	tests := []struct {
+		name        string
+		scheme      string
+		format      string
+		initialTag  string
+		nextCommit  string
+		expectedTag string
+	}{
+		{
+			name:        "autotag scheme",
+			format:      "release-{version}",
+			initialTag:  "release-1.0.0",
+			nextCommit:  "[minor] add a feature",
+			expectedTag: "release-1.1.0",
+		},
+		{
+			name:        "scope scheme",
+			scheme:      "scope-conventional",
+			format:      "{scope}@{version}",
+			initialTag:  "api@1.0.0",
+			nextCommit:  "fix(api): correct typo",
+			expectedTag: "api@1.0.1",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tr := createTestRepo(t, "")
+			repo, err := git.Open(tr)
+			checkFatal(t, err)
+			defer cleanupTestRepo(t, repo)
+
+			seedTestRepo(t, tc.initialTag, repo)
+			// tags of other formats are ignored
+			makeTag(repo, "v9.0.0")
+			makeTag(repo, "api-v9.0.0")
+			updateReadme(t, repo, tc.nextCommit)
+
+			r, err := NewRepo(GitRepoConfig{
+				RepoPath:  repo.Path(),
+				Branch:    "master",
+				Scheme:    tc.scheme,
+				Prefix:    true,
+				TagFormat: tc.format,
+			})
+			checkFatal(t, err)
+			assert.NoError(t, r.AutoTag())
+
+			cmd := exec.Command("git", "tag", "--points-at", "HEAD")
+			cmd.Dir = repoRoot(repo)
+			out, err := cmd.Output()
+			checkFatal(t, err)
+			assert.Equal(t, tc.expectedTag+"\n", string(out))
+			// the CLI prints the name of the created tag
+			assert.Equal(t, tc.expectedTag, r.LatestVersion())
+		})
+	}
+}
+
+func TestScopeSeparatorRegex(t *testing.T) {
+	tests := []struct {
+		separator string
+		tag       string
+		scope     string
+		version   string
+		ok        bool
+	}{
+		{"@", "api@1.2.3", "api", "1.2.3", true},
+		{"@", "api@v1.2.3", "api", "1.2.3", true},
+		{"@", "api-v1.2.3", "", "", false},
+		{"/", "api/v1.2.3", "api", "1.2.3", true},
+		{"/", "services/api/v1.2.3-rc.1", "services/api", "1.2.3-rc.1", true},
+		{".", "api.v1.2.3", "api", "1.2.3", true},
+		{".", "api-v1.2.3", "", "", false},
+		{"+", "api+1.2.3", "api", "1.2.3", true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.separator+" "+tc.tag, func(t *testing.T) {
+			r := &GitRepo{tagRex: scopeSeparatorRegex(tc.separator)}
+			scope, ver, ok := r.splitScopeTag(tc.tag)
+			assert.Equal(t, tc.ok, ok)
+			assert.Equal(t, tc.scope, scope)
+			assert.Equal(t, tc.version, ver)
+		})
+	}
+}
+
+func TestAutoTagScopeVersionSeparator(t *testing.T) {
+	tests := []struct {
+		separator   string
+		prefix      bool
+		initialTag  string
+		expectedTag string
+	}{
+		{"@", false, "api@1.0.0", "api@1.0.1"},
+		{"/", true, "api/v1.0.0", "api/v1.0.1"},
+		{".", true, "api.v1.0.0", "api.v1.0.1"},
+		{"-", true, "api-v1.0.0", "api-v1.0.1"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.separator, func(t *testing.T) {
+			tr := createTestRepo(t, "")
+			repo, err := git.Open(tr)
+			checkFatal(t, err)
+			defer cleanupTestRepo(t, repo)
+
+			seedTestRepo(t, tc.initialTag, repo)
+			// tags with other separators are ignored
+			if tc.separator != "-" {
+				makeTag(repo, "api-v9.0.0")
+			}
+			updateReadme(t, repo, "fix(api): correct typo")
+
+			r, err := NewRepo(GitRepoConfig{
+				RepoPath:              repo.Path(),
+				Branch:                "master",
+				Scheme:                "scope-conventional",
+				Prefix:                tc.prefix,
+				ScopeVersionSeparator: tc.separator,
+			})
+			checkFatal(t, err)
+			assert.NoError(t, r.AutoTag())
+
+			cmd := exec.Command("git", "tag", "--points-at", "HEAD")
+			cmd.Dir = repoRoot(repo)
+			out, err := cmd.Output()
+			checkFatal(t, err)
+			assert.Equal(t, tc.expectedTag+"\n", string(out))
+			// the CLI prints the name of the created tag
+			assert.Equal(t, tc.expectedTag, r.LatestVersion())
+		})
+	}
+}
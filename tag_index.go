@@ -0,0 +1,121 @@
+package autotag
+
+import (
+	"fmt"
+
+	"github.com/gogs/git-module"
+	"github.com/hashicorp/go-version"
+)
+
+// tagIndex holds the version tags of the repo, parsed once, grouped by scope: the normalized scope
+// of the tag with the "scope-conventional" scheme and "" for the other schemes. Each version maps to its tag
+// and the commit it points to, which is only resolved on first use, see tagRefCommit.
+type tagIndex map[string]map[*version.Version]tagRef
+
+// loadTags returns the tag index, reading and parsing the version tags on first use. The index is
+// cached until RefreshTags is called or a tag is created, callers must not modify it.
+func (r *GitRepo) loadTags() (tagIndex, error) {
+	if r.tags != nil {
+		return r.tags, nil
+	}
+	if len(r.releases) > 0 {
+		r.tags = r.loadReleases()
+		return r.tags, nil
+	}
+
+	tagNames, err := r.versionTags()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch tags: %s", err.Error())
+	}
+
+	index := make(tagIndex)
+	r.nonVersionTags = nil
+	for _, tagName := range tagNames {
+		scope, v, ok := r.parseTagName(tagName)
+		if !ok && r.versionFromMessage {
+			if scope, v, ok = r.versionFromTagMessage(tagName); ok {
+				r.debugf("found version %s of tag %s in its message", v, tagName)
+			}
+		}
+		if !ok {
+			r.debugf("skipping non version tag: %s", tagName)
+			if r.collectSkipped {
+				r.nonVersionTags = append(r.nonVersionTags, tagName)
+			}
+			continue
+		}
+		tagScope := scope
+		scope = r.normalizeScope(scope)
+
+		if index[scope] == nil {
+			index[scope] = make(map[*version.Version]tagRef)
+		}
+		index[scope][v] = tagRef{name: tagName, scope: tagScope, target: &tagTarget{}}
+	}
+	r.tags = index
+	return index, nil
+}
+
+// tagTarget is the commit a version tag points to. Most tags are only needed for their version,
+// eg: to select the base version from hundreds of tags of a scope, so the commit is only looked up
+// on first use and then kept with the index.
+type tagTarget struct {
+	resolved bool
+	commit   *git.Commit
+	err      error
+}
+
+// tagRefCommit returns the commit the tag of ref points to, resolving it on first use. It is nil
+// for a release without a tag in the repo.
+func (r *GitRepo) tagRefCommit(ref tagRef) (*git.Commit, error) {
+	if ref.target == nil {
+		return nil, nil
+	}
+	if !ref.target.resolved {
+		ref.target.commit, ref.target.err = r.tagCommit(ref.name)
+		if ref.target.err != nil {
+			ref.target.err = fmt.Errorf("error reading tag '%s':  %s", ref.name, ref.target.err)
+		}
+		ref.target.resolved = true
+	}
+	return ref.target.commit, ref.target.err
+}
+
+// normalizeScope returns the scope versions of scope are compared and selected by, see
+// ScopeNormalizer
+func (r *GitRepo) normalizeScope(scope string) string {
+	if r.normalizer == nil || scope == "" {
+		return scope
+	}
+	return r.normalizer(scope)
+}
+
+// parseTagName returns the scope and version of the version tag tagName, the scope is empty for
+// the schemes without scopes. It returns false if tagName isn't a version tag.
+func (r *GitRepo) parseTagName(tagName string) (string, *version.Version, bool) {
+	if r.scheme != "scope-conventional" {
+		v, err := r.versionFromTag(tagName)
+		return "", v, err == nil && v != nil
+	}
+
+	scope, ver, ok := r.registryScopeTag(tagName)
+	if !ok {
+		if scope, ver, ok = r.splitScopeTag(tagName); !ok {
+			return "", nil, false
+		}
+		// a scope with its own tag format only has the tags of that format
+		if c := r.scopeSettings(scope); c != nil && c.tagRex != nil {
+			return "", nil, false
+		}
+	}
+	v, err := maybeVersionFromTag(ver)
+	return scope, v, err == nil && v != nil
+}
+
+// RefreshTags drops the cached version tags, so the next calculation reads them again, eg: after
+// tags were fetched, created or deleted outside of autotag
+func (r *GitRepo) RefreshTags() {
+	r.tags = nil
+	r.deleted = nil
+	r.remoteTagCommits = nil
+}
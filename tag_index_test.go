@@ -0,0 +1,294 @@
+package autotag
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alecthomas/assert"
+)
+
+func TestLoadTags(t *testing.T) {
+	r := newRepoFixture(t, GitRepoConfig{Scheme: "scope-conventional", Prefix: true},
+		seedCommit("api-v1.0.0", "worker-v0.1.0", "not-a-version"),
+		testCommit{msg: "fix(api): correct typo"},
+	)
+
+	tags, err := r.loadTags()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(tags))
+	assert.Equal(t, 1, len(tags["api"]))
+	assert.Equal(t, 1, len(tags["worker"]))
+
+	// tags created outside are only seen after a refresh
+	makeTag(r.repo, "billing-v1.0.0")
+	tags, err = r.loadTags()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(tags))
+
+	r.RefreshTags()
+	tags, err = r.loadTags()
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(tags))
+
+	// creating a tag refreshes the index
+	assert.NoError(t, r.AutoTag())
+	tags, err = r.loadTags()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(tags["api"]))
+}
+
+func TestLoadTagsWithoutScopes(t *testing.T) {
+	r := newRepoFixture(t, GitRepoConfig{Prefix: true},
+		seedCommit("v1.0.0", "api-v2.0.0", "not-a-version"),
+		testCommit{msg: "[minor] add login"},
+	)
+
+	tags, err := r.loadTags()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(tags))
+	assert.Equal(t, 1, len(tags[""]))
+	assert.Equal(t, "v1.1.0", r.LatestVersion())
+}
+
+func TestLoadTagsAnnotated(t *testing.T) {
+	r := newRepoFixture(t, GitRepoConfig{Scheme: "scope-conventional", Prefix: true},
+		seedCommit("api-v1.0.0"),
+		testCommit{msg: "feat(api): add login"},
+		testCommit{msg: "fix(api): correct typo"},
+	)
+	dir := r.repo.Path()
+	tagger := []string{"-c", "user.name=autotag", "-c", "user.email=autotag@example.com"}
+	runGit(t, dir, append(tagger, "tag", "-a", "-m", "release api 1.1.0", "api-v1.1.0", "HEAD~1")...)
+	// a tag of the annotated tag object, not of the commit
+	runGit(t, dir, append(tagger, "tag", "-a", "-m", "release api 1.1.1", "api-v1.1.1", "api-v1.1.0")...)
+	// a branch with the name of a tag
+	runGit(t, dir, "branch", "api-v1.1.0", "HEAD~2")
+	r.RefreshTags()
+
+	head, err := r.repo.BranchCommit("master")
+	checkFatal(t, err)
+	parent, err := head.Parent(0)
+	checkFatal(t, err)
+	root, err := parent.Parent(0)
+	checkFatal(t, err)
+
+	tags, err := r.loadTags()
+	assert.NoError(t, err)
+	commits := make(map[string]string)
+	for _, ref := range tags["api"] {
+		c, err := r.tagRefCommit(ref)
+		checkFatal(t, err)
+		commits[ref.name] = c.ID.String()
+	}
+	assert.Equal(t, map[string]string{
+		"api-v1.0.0": root.ID.String(),
+		"api-v1.1.0": parent.ID.String(),
+		"api-v1.1.1": parent.ID.String(),
+	}, commits)
+
+	assert.NoError(t, r.scopeSchemeCalcVersion())
+	assert.Equal(t, "api-v1.1.2", r.LatestVersion())
+}
+
+func TestLoadTagsVersionFromTagMessage(t *testing.T) {
+	tests := []struct {
+		name     string
+		enabled  bool
+		expected string
+		tags     []string
+	}{
+		{name: "disabled", expected: "api-v1.0.1", tags: []string{"api-v1.0.0"}},
+		{name: "enabled", enabled: true, expected: "api-v1.2.1", tags: []string{"legacy-march", "api-v1.0.0"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := newRepoFixture(t, GitRepoConfig{Scheme: "scope-conventional", Prefix: true, VersionFromTagMessage: tc.enabled},
+				seedCommit("api-v1.0.0"),
+				testCommit{msg: "feat(api): add login"},
+				testCommit{msg: "fix(api): correct typo"},
+			)
+			dir := r.repo.Path()
+			tagger := []string{"-c", "user.name=autotag", "-c", "user.email=autotag@example.com"}
+			runGit(t, dir, append(tagger, "tag", "-a", "-m", "Release api-v1.2.0.", "legacy-march", "HEAD~1")...)
+			runGit(t, dir, append(tagger, "tag", "-a", "-m", "Monthly build", "legacy-april", "HEAD~1")...)
+			// the message of a lightweight tag is the commit message
+			runGit(t, dir, "tag", "legacy-may", "HEAD~1")
+			r.RefreshTags()
+
+			inventory, err := r.TagInventory()
+			checkFatal(t, err)
+			var names []string
+			for _, tag := range inventory {
+				assert.Equal(t, "api", tag.Scope)
+				names = append(names, tag.Tag)
+			}
+			assert.Equal(t, tc.tags, names)
+
+			assert.NoError(t, r.scopeSchemeCalcVersion())
+			assert.Equal(t, tc.expected, r.LatestVersion())
+		})
+	}
+}
+
+func TestLoadTagsPacked(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  GitRepoConfig
+		tag  func(t *testing.T, dir, name, rev string)
+	}{
+		{name: "lightweight", tag: func(t *testing.T, dir, name, rev string) {
+			runGit(t, dir, "tag", name, rev)
+		}},
+		{name: "annotated", cfg: GitRepoConfig{TagDateSource: TagDateTag}, tag: func(t *testing.T, dir, name, rev string) {
+			runGit(t, dir, "-c", "user.name=autotag", "-c", "user.email=autotag@example.com", "tag", "-a", "-m", "release "+name, name, rev)
+		}},
+		{name: "ref namespace", cfg: GitRepoConfig{VersionRefNamespace: "refs/autotag"}, tag: func(t *testing.T, dir, name, rev string) {
+			runGit(t, dir, "update-ref", "refs/autotag/"+name, rev)
+		}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := newRepoDir(t,
+				seedCommit(),
+				testCommit{msg: "feat(api): add login"},
+				testCommit{msg: "fix(api): correct typo"},
+			)
+			tc.tag(t, dir, "api-v1.0.0", "master~2")
+			tc.tag(t, dir, "api-v1.1.0", "master~1")
+			// a cloned or garbage collected repo has its refs in packed-refs only
+			runGit(t, dir, "pack-refs", "--all")
+			packed, err := os.ReadFile(filepath.Join(dir, ".git", "packed-refs"))
+			checkFatal(t, err)
+			assert.Contains(t, string(packed), "api-v1.1.0")
+
+			cfg := tc.cfg
+			cfg.RepoPath, cfg.Branch, cfg.Scheme, cfg.Prefix = dir, "master", "scope-conventional", true
+			r, err := NewRepo(cfg)
+			checkFatal(t, err)
+
+			parent, err := r.repo.CommitByRevision("master~1")
+			checkFatal(t, err)
+			tags, err := r.loadTags()
+			checkFatal(t, err)
+			assert.Equal(t, 2, len(tags["api"]))
+			for v, ref := range tags["api"] {
+				if v.String() == "1.1.0" {
+					c, err := r.tagRefCommit(ref)
+					checkFatal(t, err)
+					assert.Equal(t, parent.ID.String(), c.ID.String())
+				}
+			}
+			assert.Equal(t, "api-v1.1.1", r.LatestVersion())
+		})
+	}
+}
+
+// resolvedTargets counts the tags of the index whose commit was resolved
+func resolvedTargets(tags tagIndex) int {
+	n := 0
+	for _, versions := range tags {
+		for _, ref := range versions {
+			if ref.target != nil && ref.target.resolved {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// patchTags returns the tags api-v1.0.0 to api-v1.0.<n-1>
+func patchTags(n int) []string {
+	tags := make([]string, n)
+	for i := range tags {
+		tags[i] = fmt.Sprintf("api-v1.0.%d", i)
+	}
+	return tags
+}
+
+func TestLoadTagsResolvesBaseOnly(t *testing.T) {
+	r := newRepoFixture(t, GitRepoConfig{Scheme: "scope-conventional", Prefix: true},
+		seedCommit(append(patchTags(20), "web-v1.0.0")...),
+		testCommit{msg: "feat(api): add login"},
+	)
+	assert.Equal(t, "api-v1.1.0", r.LatestVersion())
+	assert.Equal(t, 1, resolvedTargets(r.tags))
+}
+
+func BenchmarkSelectBaseVersion(b *testing.B) {
+	r := newRepoFixture(b, GitRepoConfig{Scheme: "scope-conventional", Prefix: true},
+		seedCommit(patchTags(300)...),
+		testCommit{msg: "feat(api): add login"},
+	)
+
+	b.ResetTimer()
+	resolved := 0
+	for i := 0; i < b.N; i++ {
+		r.RefreshTags()
+		if _, _, _, err := r.baseVersionOf("api"); err != nil {
+			b.Fatal(err)
+		}
+		resolved += resolvedTargets(r.tags)
+	}
+	b.ReportMetric(float64(resolved)/float64(b.N), "commits/op")
+}
+
+func TestBuildMetadataTags(t *testing.T) {
+	tests := []struct {
+		name            string
+		cfg             GitRepoConfig
+		tags            []string
+		commit          string
+		expectedCurrent string
+		expectedTag     string
+	}{
+		{
+			name:            "metadata is kept in the base",
+			cfg:             GitRepoConfig{Scheme: "conventional"},
+			tags:            []string{"v1.2.2", "v1.2.3+ci.42"},
+			commit:          "fix: correct typo",
+			expectedCurrent: "1.2.3+ci.42",
+			expectedTag:     "v1.2.4",
+		},
+		{
+			name:            "metadata doesn't take part in precedence",
+			cfg:             GitRepoConfig{Scheme: "conventional"},
+			tags:            []string{"v1.2.9+ci.99", "v1.2.10+ci.1"},
+			commit:          "feat: add login",
+			expectedCurrent: "1.2.10+ci.1",
+			expectedTag:     "v1.3.0",
+		},
+		{
+			name:            "scope with hyphenated metadata",
+			cfg:             GitRepoConfig{Scheme: "scope-conventional"},
+			tags:            []string{"api-v1.2.3+ci.42", "api-v1.2.10+build-7", "api-v1.2.9"},
+			commit:          "fix(api): correct typo",
+			expectedCurrent: "1.2.10+build-7",
+			expectedTag:     "api-v1.2.11",
+		},
+		{
+			name:            "configured metadata replaces the base metadata",
+			cfg:             GitRepoConfig{Scheme: "conventional", BuildMetadata: "ci.43"},
+			tags:            []string{"v1.2.3+ci.42"},
+			commit:          "fix: correct typo",
+			expectedCurrent: "1.2.3+ci.42",
+			expectedTag:     "v1.2.4+ci.43",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.cfg.Prefix = true
+			r := newRepoFixture(t, tc.cfg,
+				seedCommit(tc.tags...),
+				testCommit{msg: tc.commit},
+			)
+			res := r.Result()
+			assert.Equal(t, tc.expectedCurrent, res.Current.String())
+			assert.Equal(t, tc.expectedTag, res.Tag)
+		})
+	}
+}
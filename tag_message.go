@@ -0,0 +1,90 @@
+package autotag
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gogs/git-module"
+	"github.com/hashicorp/go-version"
+)
+
+// versionFromTagMessage returns the scope and version found in the message of the annotated tag
+// tagName, see VersionFromTagMessage. The first word of the message that is a version tag name of
+// the scheme, eg: `api-v1.2.3` of `Release api-v1.2.3`, is used. It returns false for lightweight
+// tags and messages without a version.
+func (r *GitRepo) versionFromTagMessage(tagName string) (string, *version.Version, bool) {
+	out, err := git.NewCommand("for-each-ref", "--format=%(objecttype) %(contents)", r.refNamespace+"/"+tagName).RunInDir(r.repo.Path())
+	if err != nil {
+		return "", nil, false
+	}
+	typ, message, _ := strings.Cut(string(out), " ")
+	if typ != "tag" {
+		return "", nil, false
+	}
+
+	for _, word := range strings.Fields(message) {
+		if scope, v, ok := r.parseTagName(strings.TrimRight(word, ".,;:")); ok {
+			return scope, v, true
+		}
+	}
+	return "", nil, false
+}
+
+// TagMessage renders the message of the tag of res with the TagMessageTemplate and appends the
+// TagRangeTrailers, empty without either. A dry run can call it to check the template renders
+// before any tag is created.
+func (r *GitRepo) TagMessage(res Result) (string, error) {
+	var message string
+	if r.tagMessageTmpl != nil {
+		var err error
+		if message, err = res.Render(r.tagMessageTmpl); err != nil {
+			return "", fmt.Errorf("tag message: %s", err)
+		}
+	}
+	if r.rangeTrailers {
+		if message == "" {
+			message = res.Tag
+		}
+		message = strings.TrimRight(message, "\n") + "\n\n" + r.rangeTrailer(res)
+	}
+	return message, nil
+}
+
+// rangeTrailer returns the From and To trailers of res, see TagRangeTrailers
+func (r *GitRepo) rangeTrailer(res Result) string {
+	var b strings.Builder
+	if tag, ok := r.baseTagName(res.Scope, res.Current); ok {
+		fmt.Fprintf(&b, "From: %s\n", tag)
+	}
+	fmt.Fprintf(&b, "To: %s\n", r.branchID)
+	return b.String()
+}
+
+// baseTagName returns the name of the tag of the base version of scope, false if the base has no
+// tag, eg: the InitialVersion or an injected CurrentVersion
+func (r *GitRepo) baseTagName(scope string, base *version.Version) (string, bool) {
+	if base == nil {
+		return "", false
+	}
+	tags, err := r.loadTags()
+	if err != nil {
+		return "", false
+	}
+	refs := tags[r.normalizeScope(scope)]
+	if ref, ok := refs[base]; ok && ref.target != nil {
+		return ref.name, true
+	}
+	// the index is reloaded after creating a tag, eg: in a batch, so the base isn't a key anymore
+	var names []string
+	for v, ref := range refs {
+		if v.String() == base.String() && ref.target != nil {
+			names = append(names, ref.name)
+		}
+	}
+	if len(names) == 0 {
+		return "", false
+	}
+	sort.Strings(names)
+	return names[0], true
+}
@@ -0,0 +1,91 @@
+package autotag
+
+import (
+	"testing"
+
+	"github.com/alecthomas/assert"
+)
+
+func TestTagMessageTemplate(t *testing.T) {
+	dir := newRepoDir(t,
+		seedCommit("v1.0.0"),
+		testCommit{msg: "feat: add login"},
+	)
+	tagger := &fakeTagger{}
+	r, err := NewRepo(GitRepoConfig{RepoPath: dir, Branch: "master", Scheme: "conventional", Prefix: true, Tagger: tagger, TagMessageTemplate: "Release {{.Tag}} ({{.Level}})"})
+	checkFatal(t, err)
+	checkFatal(t, r.AutoTag())
+	assert.Equal(t, 1, len(tagger.created))
+	assert.Equal(t, "Release v1.1.0 (minor)", tagger.created[0].message)
+	assert.True(t, tagger.created[0].annotated)
+}
+
+func TestTagMessageDryRun(t *testing.T) {
+	// the template only fails on pre-releases, which the sample result of the parsing isn't
+	broken := "Release {{.Tag}}{{if .PreRelease}} {{.Channel}}{{end}}"
+
+	dir := newRepoDir(t,
+		seedCommit("api-v1.0.0"),
+		testCommit{msg: "feat(api): add login"},
+	)
+	cfg := GitRepoConfig{RepoPath: dir, Branch: "master", Scheme: "scope-conventional", Prefix: true, PreReleaseName: "beta", TagMessageTemplate: broken}
+	r, err := NewRepo(cfg)
+	checkFatal(t, err)
+	_, err = r.TagMessage(r.Result())
+	assert.Error(t, err)
+
+	tagger := &fakeTagger{}
+	cfg.AllScopes, cfg.Tagger = true, tagger
+	r, err = NewRepo(cfg)
+	checkFatal(t, err)
+	results, err := r.Preview()
+	checkFatal(t, err)
+	assert.Equal(t, 1, len(results))
+	assert.Contains(t, results[0].Err.Error(), "tag message")
+	assert.Equal(t, 0, len(tagger.created))
+
+	cfg.PreReleaseName = ""
+	r, err = NewRepo(cfg)
+	checkFatal(t, err)
+	results, err = r.Preview()
+	checkFatal(t, err)
+	checkFatal(t, results[0].Err)
+}
+
+func TestTagMessageTemplateValidation(t *testing.T) {
+	dir := newRepoDir(t, seedCommit("v1.0.0"))
+	cfg := GitRepoConfig{RepoPath: dir, Branch: "master", Scheme: "conventional", TagMessageTemplate: "Release {{.Channel}}"}
+	_, err := NewRepo(cfg)
+	assert.Error(t, err)
+
+	cfg.TagMessageTemplate, cfg.VersionRefNamespace = "Release {{.Tag}}", "refs/versions"
+	_, err = NewRepo(cfg)
+	assert.Error(t, err)
+}
+
+func TestTagRangeTrailers(t *testing.T) {
+	dir := newRepoDir(t,
+		seedCommit("api-v1.2.0", "web-v1.0.0"),
+		testCommit{msg: "feat(api): add login"},
+		testCommit{msg: "fix(web): correct typo"},
+	)
+	sha := runGit(t, dir, "rev-parse", "HEAD")
+
+	tagger := &fakeTagger{}
+	r, err := NewRepo(GitRepoConfig{RepoPath: dir, Branch: "master", Scheme: "scope-conventional", Prefix: true, AllScopes: true, Tagger: tagger, TagRangeTrailers: true})
+	checkFatal(t, err)
+	_, err = r.AutoTagScopes()
+	checkFatal(t, err)
+	assert.Equal(t, 2, len(tagger.created))
+	assert.Equal(t, "api-v1.3.0\n\nFrom: api-v1.2.0\nTo: "+sha+"\n", tagger.created[0].message)
+	assert.Equal(t, "web-v1.0.1\n\nFrom: web-v1.0.0\nTo: "+sha+"\n", tagger.created[1].message)
+	assert.True(t, tagger.created[1].annotated)
+
+	// the trailers follow the rendered message, there is no base tag for the initial version
+	dir = newRepoDir(t, testCommit{msg: "feat: add login"})
+	tagger = &fakeTagger{}
+	r, err = NewRepo(GitRepoConfig{RepoPath: dir, Branch: "master", Scheme: "conventional", Prefix: true, Tagger: tagger, InitialVersion: "0.0.0", TagMessageTemplate: "Release {{.Tag}}\n", TagRangeTrailers: true})
+	checkFatal(t, err)
+	checkFatal(t, r.AutoTag())
+	assert.Equal(t, "Release "+r.LatestVersion()+"\n\nTo: "+runGit(t, dir, "rev-parse", "HEAD")+"\n", tagger.created[0].message)
+}
@@ -0,0 +1,50 @@
+package autotag
+
+import (
+	"sort"
+
+	"github.com/gogs/git-module"
+	"github.com/hashicorp/go-version"
+)
+
+// sortVersionsDesc sorts the versions of the tags from the highest. Versions of the same
+// precedence, which only differ in build metadata, eg: `1.2.3+a` and `1.2.3+b`, are the same
+// logical version: of those the tag in the history of the branch comes first, then the tag of the
+// more recent commit, then by tag name, so the base selection doesn't depend on the map order.
+func (r *GitRepo) sortVersionsDesc(keys []*version.Version, versions map[*version.Version]tagRef) {
+	// only the ties are checked for reachability, once per version
+	reachable := make(map[*version.Version]bool)
+	onBranch := func(v *version.Version) bool {
+		ok, checked := reachable[v]
+		if !checked {
+			ok = r.onBranch(versions[v])
+			reachable[v] = ok
+		}
+		return ok
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if c := a.Compare(b); c != 0 {
+			return c > 0
+		}
+		if aOn, bOn := onBranch(a), onBranch(b); aOn != bOn {
+			return aOn
+		}
+		if aDate, bDate := r.tagDate(versions[a]), r.tagDate(versions[b]); !aDate.Equal(bDate) {
+			return aDate.After(bDate)
+		}
+		return versions[a].name < versions[b].name
+	})
+}
+
+// onBranch reports whether the commit of the tag is in the history of the branch, the branch
+// commit included
+func (r *GitRepo) onBranch(ref tagRef) bool {
+	c, err := r.tagRefCommit(ref)
+	if err != nil || c == nil {
+		return false
+	}
+	_, err = git.NewCommand("merge-base", "--is-ancestor", c.ID.String(), r.tipRev()).RunInDir(r.repo.Path())
+	return err == nil
+}
@@ -0,0 +1,113 @@
+package autotag
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alecthomas/assert"
+	"github.com/gogs/git-module"
+)
+
+func TestBuildMetadataTies(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2023, 1, d, 12, 0, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name     string
+		setup    func(t *testing.T, repo *git.Repository, dir string)
+		expected string
+	}{
+		{
+			name: "tag on the branch",
+			setup: func(t *testing.T, repo *git.Repository, dir string) {
+				makeCommitAt(t, repo, "this is a commit", day(1))
+				makeTag(repo, "v1.2.3+b")
+				// a more recent tag of the same version on another branch
+				runGit(t, dir, "checkout", "-q", "-b", "side")
+				makeCommitAt(t, repo, "side commit", day(2))
+				makeTag(repo, "v1.2.3+a")
+				runGit(t, dir, "checkout", "-q", "master")
+			},
+			expected: "1.2.3+b",
+		},
+		{
+			name: "more recent commit",
+			setup: func(t *testing.T, repo *git.Repository, dir string) {
+				makeCommitAt(t, repo, "this is a commit", day(1))
+				makeTag(repo, "v1.2.3+b")
+				makeCommitAt(t, repo, "rebuild", day(2))
+				makeTag(repo, "v1.2.3+a")
+			},
+			expected: "1.2.3+a",
+		},
+		{
+			name: "same commit",
+			setup: func(t *testing.T, repo *git.Repository, dir string) {
+				makeCommitAt(t, repo, "this is a commit", day(1))
+				makeTag(repo, "v1.2.3+b")
+				makeTag(repo, "v1.2.3+a")
+			},
+			expected: "1.2.3+a",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tr := createTestRepo(t, "")
+			repo, err := git.Open(tr)
+			checkFatal(t, err)
+			defer cleanupTestRepo(t, repo)
+
+			tc.setup(t, repo, repoRoot(repo))
+			makeCommitAt(t, repo, "[minor] add login", day(3))
+
+			// the map order of the tags varies, the selection must not
+			for i := 0; i < 10; i++ {
+				r, err := NewRepo(GitRepoConfig{RepoPath: repo.Path(), Branch: "master", Prefix: true})
+				checkFatal(t, err)
+				assert.Equal(t, tc.expected, r.Result().Current.String())
+				assert.Equal(t, "v1.3.0", r.LatestVersion())
+			}
+		})
+	}
+}
+
+func TestBasePerMajor(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2023, 1, d, 12, 0, 0, 0, time.UTC) }
+
+	tr := createTestRepo(t, "")
+	repo, err := git.Open(tr)
+	checkFatal(t, err)
+	defer cleanupTestRepo(t, repo)
+	dir := repoRoot(repo)
+
+	makeCommitAt(t, repo, "this is a commit", day(1))
+	makeTag(repo, "v1.0.0")
+	// the 1.x line is maintained on a release branch while 2.x is released from master
+	runGit(t, dir, "checkout", "-q", "-b", "release-1.x")
+	makeCommitAt(t, repo, "feat: add export", day(2))
+	makeTag(repo, "v1.1.0")
+	makeCommitAt(t, repo, "fix: correct the export", day(5))
+	runGit(t, dir, "checkout", "-q", "master")
+	makeCommitAt(t, repo, "feat!: drop the old API", day(3))
+	makeTag(repo, "v2.0.0")
+	makeCommitAt(t, repo, "fix: correct typo", day(4))
+
+	tests := []struct {
+		name        string
+		branch      string
+		perMajor    bool
+		expectedTag string
+	}{
+		{name: "hotfix of the 1.x line", branch: "release-1.x", perMajor: true, expectedTag: "v1.1.1"},
+		{name: "highest version without", branch: "release-1.x", expectedTag: "v2.1.0"},
+		{name: "2.x line", branch: "master", perMajor: true, expectedTag: "v2.0.1"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := NewRepo(GitRepoConfig{RepoPath: repo.Path(), Branch: tc.branch, Scheme: "conventional", Prefix: true, BasePerMajor: tc.perMajor})
+			checkFatal(t, err)
+			assert.Equal(t, tc.expectedTag, r.LatestVersion())
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package autotag
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gogs/git-module"
+)
+
+// Tagger creates and deletes tags in a repository. It decouples the version calculation from the
+// side effect of writing tags, so alternate backends (or fakes in tests) can be plugged in.
+type Tagger interface {
+	// Create creates the tag name pointing at the target revision. When annotated is true an
+	// annotated tag carrying message is created, otherwise a lightweight tag.
+	Create(name, target, message string, annotated bool) error
+
+	// Delete removes the tag name.
+	Delete(name string) error
+}
+
+// gitTagger is the default Tagger, it writes tags with the git CLI via git-module. Outside of the
+// default namespace the versions are written as plain refs with `git update-ref`.
+type gitTagger struct {
+	repo      *git.Repository
+	namespace string
+
+	// sign creates GPG/SSH signed tags (`git tag -s`), using signingKey (`git tag -u`) if set
+	sign       bool
+	signingKey string
+}
+
+func (t *gitTagger) Create(name, target, message string, annotated bool) error {
+	if t.customNamespace() {
+		if annotated {
+			return fmt.Errorf("annotated tags are not supported in the %s ref namespace", t.namespace)
+		}
+		// an empty old value makes sure an existing ref isn't overwritten
+		_, err := git.NewCommand("update-ref", t.namespace+"/"+name, target, "").RunInDir(t.repo.Path())
+		return err
+	}
+
+	opts := git.CreateTagOptions{
+		Annotated: annotated,
+		Message:   message,
+	}
+	if t.sign {
+		// signed tags are always annotated
+		opts.Annotated = true
+		if t.signingKey != "" {
+			opts.CommandOptions.Args = []string{"--local-user", t.signingKey}
+		} else {
+			opts.CommandOptions.Args = []string{"--sign"}
+		}
+	}
+	if err := t.repo.CreateTag(name, target, opts); err != nil {
+		return err
+	}
+	if t.sign {
+		return t.verifySigned(name)
+	}
+	return nil
+}
+
+// verifySigned makes sure the tag name carries a signature. Some git versions report success and
+// leave an unsigned tag behind when the signing program fails, eg: a missing SSH key, so an
+// unsigned tag is deleted again and reported as an error.
+func (t *gitTagger) verifySigned(name string) error {
+	tag, err := t.repo.Tag(name)
+	if err != nil {
+		return err
+	}
+
+	msg := tag.Message()
+	if strings.Contains(msg, "-----BEGIN ") && strings.Contains(msg, " SIGNATURE-----") {
+		return nil
+	}
+
+	if err := t.repo.DeleteTag(name); err != nil {
+		return fmt.Errorf("tag %s was created without a signature and could not be removed: %s", name, err)
+	}
+	return fmt.Errorf("tag %s could not be signed", name)
+}
+
+func (t *gitTagger) Delete(name string) error {
+	if t.customNamespace() {
+		_, err := git.NewCommand("update-ref", "-d", t.namespace+"/"+name).RunInDir(t.repo.Path())
+		return err
+	}
+	return t.repo.DeleteTag(name)
+}
+
+// customNamespace reports whether versions are written outside of the default tag namespace
+func (t *gitTagger) customNamespace() bool {
+	return t.namespace != "" && t.namespace != DefaultVersionRefNamespace
+}
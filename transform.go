@@ -0,0 +1,49 @@
+package autotag
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/hashicorp/go-version"
+)
+
+// semVerRex matches a SemVer version string: three numeric core segments without leading zeros,
+// see https://semver.org/#spec-item-2. The pre-release and build metadata are validated
+// separately.
+var semVerRex = regexp.MustCompile(`^(0|[1-9][0-9]*)\.(0|[1-9][0-9]*)\.(0|[1-9][0-9]*)(?:-([^+]+))?(?:\+(.+))?$`)
+
+// transformVersion applies the VersionTransform to the version of scope bumped from base and
+// checks that the result is still a SemVer version
+func (r *GitRepo) transformVersion(scope string, base, next *version.Version) (*version.Version, error) {
+	if r.versionTransform == nil {
+		return next, nil
+	}
+
+	v, err := r.versionTransform(next, r.result(scope, base, next))
+	if err != nil {
+		return nil, fmt.Errorf("version transform of %s failed: %w", next, err)
+	}
+	if err = validateSemVer(v); err != nil {
+		return nil, fmt.Errorf("version transform of %s: %s", next, err)
+	}
+	return v, nil
+}
+
+// validateSemVer returns an error if the version string of v, which the tag is named after, isn't a
+// SemVer version. go-version also accepts versions like `1.2.3.4` or `1.2.3-rc.01`.
+func validateSemVer(v *version.Version) error {
+	if v == nil {
+		return fmt.Errorf("no version")
+	}
+	m := semVerRex.FindStringSubmatch(v.String())
+	if m == nil {
+		return fmt.Errorf("'%s' is not valid SemVer", v)
+	}
+	if m[4] != "" && !validateSemVerPreReleaseName(m[4]) {
+		return fmt.Errorf("'%s' is not valid SemVer pre-release", m[4])
+	}
+	if m[5] != "" && !validateSemVerBuildMetadata(m[5]) {
+		return fmt.Errorf("'%s' is not valid SemVer build metadata", m[5])
+	}
+	return nil
+}
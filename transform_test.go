@@ -0,0 +1,125 @@
+package autotag
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/alecthomas/assert"
+	"github.com/hashicorp/go-version"
+)
+
+func TestVersionTransform(t *testing.T) {
+	errAbort := errors.New("frozen")
+
+	tests := []struct {
+		name        string
+		cfg         GitRepoConfig
+		transform   func(v *version.Version, res Result) (*version.Version, error)
+		expectedTag string
+		expectedErr error
+		shouldErr   bool
+	}{
+		{
+			name: "append build metadata",
+			cfg:  GitRepoConfig{PreReleaseName: "rc.1", BuildMetadata: "g123"},
+			transform: func(v *version.Version, res Result) (*version.Version, error) {
+				return version.NewVersion(v.String() + ".ci.42")
+			},
+			expectedTag: "v1.1.0-rc.1+g123.ci.42",
+		},
+		{
+			name: "strip the pre-release",
+			cfg:  GitRepoConfig{PreReleaseName: "rc.1"},
+			transform: func(v *version.Version, res Result) (*version.Version, error) {
+				if !res.PreRelease {
+					return v, nil
+				}
+				return version.NewVersion(v.Core().String())
+			},
+			expectedTag: "v1.1.0",
+		},
+		{
+			name: "unchanged",
+			transform: func(v *version.Version, res Result) (*version.Version, error) {
+				return v, nil
+			},
+			expectedTag: "v1.1.0",
+		},
+		{
+			name: "result of the version",
+			cfg:  GitRepoConfig{Scheme: "scope-conventional"},
+			transform: func(v *version.Version, res Result) (*version.Version, error) {
+				return version.NewVersion(fmt.Sprintf("%s+%s.from.%s", v, res.Scope, res.Current))
+			},
+			expectedTag: "api-v1.1.0+api.from.1.0.0",
+		},
+		{
+			name: "aborted",
+			transform: func(v *version.Version, res Result) (*version.Version, error) {
+				return nil, errAbort
+			},
+			expectedErr: errAbort,
+			shouldErr:   true,
+		},
+		{
+			name: "not SemVer",
+			transform: func(v *version.Version, res Result) (*version.Version, error) {
+				return version.NewVersion("1.1.0.1")
+			},
+			shouldErr: true,
+		},
+		{
+			name: "no version",
+			transform: func(v *version.Version, res Result) (*version.Version, error) {
+				return nil, nil
+			},
+			shouldErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := newRepoDir(t,
+				seedCommit("v1.0.0", "api-v1.0.0"),
+				testCommit{msg: "feat(api): add login"},
+			)
+			cfg := tc.cfg
+			if cfg.Scheme == "" {
+				cfg.Scheme = "conventional"
+			}
+			cfg.RepoPath, cfg.Branch, cfg.Prefix, cfg.VersionTransform = dir, "master", true, tc.transform
+			r, err := NewRepo(cfg)
+			if tc.shouldErr {
+				assert.Error(t, err)
+				if tc.expectedErr != nil {
+					assert.True(t, errors.Is(err, tc.expectedErr), "expected %v, got %v", tc.expectedErr, err)
+				}
+				return
+			}
+			checkFatal(t, err)
+			assert.Equal(t, tc.expectedTag, r.LatestVersion())
+		})
+	}
+}
+
+func TestValidateSemVer(t *testing.T) {
+	tests := []struct {
+		version string
+		valid   bool
+	}{
+		{version: "1.2.3", valid: true},
+		{version: "v1.2.3-rc.1+g123", valid: true},
+		// the version string of 1.2 is 1.2.0
+		{version: "1.2", valid: true},
+		{version: "1.2.3.4", valid: false},
+		{version: "1.2.3-rc.01", valid: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.version, func(t *testing.T) {
+			err := validateSemVer(version.Must(version.NewVersion(tc.version)))
+			assert.Equal(t, tc.valid, err == nil, "error: %v", err)
+		})
+	}
+}
@@ -0,0 +1,23 @@
+package autotag
+
+// StableChannel is the channel of TypeChannels releasing a stable version, without a pre-release
+const StableChannel = "stable"
+
+// typeChannel returns the pre-release name of the channel TypeChannels maps typ to, empty for
+// StableChannel, and false if typ has no channel
+func (r *GitRepo) typeChannel(typ string) (string, bool) {
+	if typ == "" {
+		return "", false
+	}
+	typ = normalizeType(typ, r.strictTypeCase)
+	for t, channel := range r.typeChannels {
+		if normalizeType(t, r.strictTypeCase) != typ {
+			continue
+		}
+		if channel == StableChannel {
+			return "", true
+		}
+		return channel, true
+	}
+	return "", false
+}
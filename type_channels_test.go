@@ -0,0 +1,89 @@
+package autotag
+
+import (
+	"testing"
+
+	"github.com/alecthomas/assert"
+)
+
+func TestTypeChannels(t *testing.T) {
+	channels := map[string]string{"feat": "beta", "fix": StableChannel}
+	tests := []struct {
+		name        string
+		cfg         GitRepoConfig
+		tags        []string
+		commits     []string
+		expectedTag string
+	}{
+		{
+			name:        "feature goes to beta",
+			cfg:         GitRepoConfig{Scheme: "conventional"},
+			tags:        []string{"v1.2.3"},
+			commits:     []string{"fix: correct typo", "feat: add login", "fix: handle nil"},
+			expectedTag: "v1.3.0-beta",
+		},
+		{
+			name:        "fixes go to stable",
+			cfg:         GitRepoConfig{Scheme: "conventional"},
+			tags:        []string{"v1.2.3"},
+			commits:     []string{"fix: correct typo", "fix: handle nil"},
+			expectedTag: "v1.2.4",
+		},
+		{
+			name:        "other types use the pre-release name",
+			cfg:         GitRepoConfig{Scheme: "conventional", PreReleaseName: "rc", BumpRules: BumpRules{"perf": BumpPatch}},
+			tags:        []string{"v1.2.3"},
+			commits:     []string{"perf: cache the tags"},
+			expectedTag: "v1.2.4-rc",
+		},
+		{
+			name:        "stable overrides the pre-release name",
+			cfg:         GitRepoConfig{Scheme: "conventional", PreReleaseName: "rc"},
+			tags:        []string{"v1.2.3"},
+			commits:     []string{"fix: correct typo"},
+			expectedTag: "v1.2.4",
+		},
+		{
+			name:        "scope",
+			cfg:         GitRepoConfig{Scheme: "scope-conventional"},
+			tags:        []string{"api-v1.2.3"},
+			commits:     []string{"feat(api): add login"},
+			expectedTag: "api-v1.3.0-beta",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.cfg.Prefix, tc.cfg.TypeChannels = true, channels
+			commits := []testCommit{seedCommit(tc.tags...)}
+			for _, msg := range tc.commits {
+				commits = append(commits, testCommit{msg: msg})
+			}
+			r := newRepoFixture(t, tc.cfg, commits...)
+			assert.Equal(t, tc.expectedTag, r.LatestVersion())
+		})
+	}
+}
+
+func TestTypeChannelsScopes(t *testing.T) {
+	r := newRepoFixture(t, GitRepoConfig{Scheme: "scope-conventional", AllScopes: true, Prefix: true, TypeChannels: map[string]string{"feat": "beta", "fix": StableChannel}},
+		seedCommit("api-v1.0.0", "web-v1.0.0"),
+		testCommit{msg: "feat(api): add login"},
+		testCommit{msg: "fix(web): correct typo"},
+	)
+	tags, errs := previewTags(t, r)
+	assert.Nil(t, errs)
+	assert.Equal(t, map[string]string{"api": "api-v1.1.0-beta", "web": "web-v1.0.1"}, tags)
+}
+
+func TestTypeChannelsValidation(t *testing.T) {
+	dir := newRepoDir(t, seedCommit("v1.0.0"), testCommit{msg: "fix: correct typo"})
+	for _, channels := range []map[string]string{{"feat!": "beta"}, {"feat": "bad_name"}} {
+		_, err := NewRepo(GitRepoConfig{RepoPath: dir, Branch: "master", Scheme: "conventional", TypeChannels: channels})
+		assert.Error(t, err, "%v", channels)
+	}
+
+	// a stable release on a branch that only produces pre-releases
+	_, err := NewRepo(GitRepoConfig{RepoPath: dir, Branch: "master", Scheme: "conventional", StableBranches: []string{"main"}, TypeChannels: map[string]string{"fix": StableChannel}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,79 @@
+package autotag
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/go-version"
+)
+
+// ErrUnusualBump is returned with StrictUnusualBump when the bump is higher than the recent
+// releases of the scope, see WarnOnUnusualBump
+var ErrUnusualBump = errors.New("unusual bump")
+
+const (
+	// defaultUnusualBumpReleases is the number of recent releases the bump is compared to
+	defaultUnusualBumpReleases = 5
+	// minCadenceReleases is the number of releases needed to tell a cadence, a scope with fewer
+	// releases doesn't have unusual bumps. Fewer are needed if fewer are compared.
+	minCadenceReleases = 3
+)
+
+// releaseCadence returns the highest level of the last n releases of scope up to base, the bumps
+// between its consecutive stable versions, and the number of releases compared
+func (r *GitRepo) releaseCadence(scope string, base *version.Version, n int) (BumpLevel, int, error) {
+	tags, err := r.loadTags()
+	if err != nil {
+		return BumpNone, 0, err
+	}
+	var versions []*version.Version
+	for v := range tags[r.normalizeScope(scope)] {
+		if v.Prerelease() == "" && !v.GreaterThan(base) {
+			versions = append(versions, v)
+		}
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i].LessThan(versions[j]) })
+
+	cadence, releases := BumpNone, 0
+	for i := len(versions) - 1; i > 0 && releases < n; i-- {
+		level := NewVersionDelta(versions[i-1], versions[i]).Level
+		if level == BumpNone {
+			// the same version with other build metadata
+			continue
+		}
+		if level > cadence {
+			cadence = level
+		}
+		releases++
+	}
+	return cadence, releases, nil
+}
+
+// checkUnusualBump warns if the bump of scope from base to next is higher than any of its recent
+// releases, eg: a major after a history of patches, which often is a misparsed commit message.
+// With StrictUnusualBump it fails with ErrUnusualBump instead. Forced bumps aren't checked.
+func (r *GitRepo) checkUnusualBump(scope string, base, next *version.Version) error {
+	if !r.warnUnusualBump || r.releaseBump != BumpNone || base == nil {
+		return nil
+	}
+	level := NewVersionDelta(base, next).Level
+	cadence, releases, err := r.releaseCadence(scope, base, r.unusualBumpReleases)
+	if err != nil {
+		return err
+	}
+	if releases < minCadenceReleases && releases < r.unusualBumpReleases || level <= cadence {
+		return nil
+	}
+
+	name := base.String()
+	if scope != "" {
+		name = fmt.Sprintf("%s of scope %s", base, scope)
+	}
+	msg := fmt.Sprintf("%s bump from %s to %s, the last %d releases were at most %s: is a commit message misparsed?", level, name, next, releases, cadence)
+	if r.strictUnusualBump {
+		return fmt.Errorf("%w: %s", ErrUnusualBump, msg)
+	}
+	r.warnf("%s", msg)
+	return nil
+}
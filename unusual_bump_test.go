@@ -0,0 +1,120 @@
+package autotag
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/assert"
+)
+
+func TestWarnOnUnusualBump(t *testing.T) {
+	patches := []testCommit{
+		seedCommit("v1.0.0"),
+		{msg: "fix: correct typo", tags: []string{"v1.0.1"}},
+		{msg: "fix: handle nil", tags: []string{"v1.0.2"}},
+		{msg: "fix: close the file", tags: []string{"v1.0.3"}},
+	}
+	tests := []struct {
+		name    string
+		history []testCommit
+		commit  string
+		warned  bool
+	}{
+		{
+			name:    "major after patches",
+			history: patches,
+			commit:  "feat!: drop the v1 API",
+			warned:  true,
+		},
+		{
+			name:    "patch after patches",
+			history: patches,
+			commit:  "fix: correct another typo",
+		},
+		{
+			name: "minor after minors",
+			history: []testCommit{
+				seedCommit("v1.0.0"),
+				{msg: "feat: add login", tags: []string{"v1.1.0"}},
+				{msg: "feat: add search", tags: []string{"v1.2.0"}},
+				{msg: "fix: close the file", tags: []string{"v1.2.1"}},
+			},
+			commit: "feat: add export",
+		},
+		{
+			name:    "too few releases",
+			history: patches[:3],
+			commit:  "feat!: drop the v1 API",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			log.SetOutput(&buf)
+			defer log.SetOutput(os.Stderr)
+
+			commits := append(append([]testCommit{}, tc.history...), testCommit{msg: tc.commit})
+			newRepoFixture(t, GitRepoConfig{Scheme: "conventional", WarnOnUnusualBump: true}, commits...)
+			warned := strings.Contains(buf.String(), "warning: major bump from 1.0.")
+			assert.Equal(t, tc.warned, warned, buf.String())
+		})
+	}
+}
+
+func TestWarnOnUnusualBumpOnWarning(t *testing.T) {
+	// the CLI passes the warnings to stderr, the logger is discarded without -v
+	var warnings []string
+	newRepoFixture(t, GitRepoConfig{
+		Scheme:            "conventional",
+		WarnOnUnusualBump: true,
+		Verbosity:         VerbosityQuiet,
+		OnWarning:         func(msg string) { warnings = append(warnings, msg) },
+	},
+		seedCommit("v1.0.0"),
+		testCommit{msg: "fix: correct typo", tags: []string{"v1.0.1"}},
+		testCommit{msg: "fix: handle nil", tags: []string{"v1.0.2"}},
+		testCommit{msg: "fix: close the file", tags: []string{"v1.0.3"}},
+		testCommit{msg: "feat!: drop the v1 API"},
+	)
+	assert.Equal(t, []string{"major bump from 1.0.3 to 2.0.0, the last 3 releases were at most patch: is a commit message misparsed?"}, warnings)
+}
+
+func TestStrictUnusualBump(t *testing.T) {
+	dir := newRepoDir(t,
+		seedCommit("api-v1.0.0", "web-v1.0.0"),
+		testCommit{msg: "fix(api): correct typo", tags: []string{"api-v1.0.1"}},
+		testCommit{msg: "fix(api): handle nil", tags: []string{"api-v1.0.2"}},
+		testCommit{msg: "fix(api): close the file", tags: []string{"api-v1.0.3"}},
+		testCommit{msg: "feat(api)!: drop the v1 API"},
+		testCommit{msg: "feat(web)!: drop the v1 API"},
+	)
+	cfg := GitRepoConfig{RepoPath: dir, Branch: "master", Scheme: "scope-conventional", Prefix: true, AllScopes: true, WarnOnUnusualBump: true, StrictUnusualBump: true}
+	r, err := NewRepo(cfg)
+	checkFatal(t, err)
+	results, err := r.Preview()
+	checkFatal(t, err)
+	assert.Equal(t, 2, len(results))
+	assert.True(t, errors.Is(results[0].Err, ErrUnusualBump), "error: %v", results[0].Err)
+	// web has no cadence yet
+	assert.Equal(t, "web-v2.0.0", results[1].Tag)
+
+	// the last release only
+	dir = newRepoDir(t,
+		seedCommit("v1.0.0"),
+		testCommit{msg: "feat!: drop the v0 API", tags: []string{"v2.0.0"}},
+		testCommit{msg: "fix: correct typo", tags: []string{"v2.0.1"}},
+		testCommit{msg: "feat: add login"},
+	)
+	_, err = NewRepo(GitRepoConfig{RepoPath: dir, Branch: "master", Scheme: "conventional", WarnOnUnusualBump: true, StrictUnusualBump: true, UnusualBumpReleases: 1})
+	assert.True(t, errors.Is(err, ErrUnusualBump), "error: %v", err)
+	_, err = NewRepo(GitRepoConfig{RepoPath: dir, Branch: "master", Scheme: "conventional", WarnOnUnusualBump: true, StrictUnusualBump: true})
+	checkFatal(t, err)
+
+	_, err = NewRepo(GitRepoConfig{RepoPath: dir, Branch: "master", Scheme: "conventional", StrictUnusualBump: true})
+	assert.Error(t, err)
+}
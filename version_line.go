@@ -0,0 +1,47 @@
+package autotag
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/hashicorp/go-version"
+)
+
+// ErrOutsideVersionLine is returned when the next version isn't on the major.minor line of the
+// branch, see BranchVersionPattern
+var ErrOutsideVersionLine = errors.New("version outside the line of the branch")
+
+// branchVersionLine returns the major.minor line the branch name encodes, as the version
+// `major.minor.0`, nil if the branch doesn't match rex
+func branchVersionLine(rex *regexp.Regexp, branch string) *version.Version {
+	m := findNamedMatches(rex, branch)
+	major, err := strconv.ParseInt(m["major"], 10, 64)
+	if err != nil {
+		return nil
+	}
+	minor, err := strconv.ParseInt(m["minor"], 10, 64)
+	if err != nil {
+		return nil
+	}
+	return version.Must(version.NewVersion(fmt.Sprintf("%d.%d.0", major, minor)))
+}
+
+// checkVersionLine returns ErrOutsideVersionLine if next, bumped from base, leaves the line of the
+// branch, eg: a breaking change on `release/api/1.3`
+func (r *GitRepo) checkVersionLine(scope string, base, next *version.Version) error {
+	if r.versionLine == nil {
+		return nil
+	}
+	line, segments := r.versionLine.Segments64(), next.Segments64()
+	if segments[0] == line[0] && segments[1] == line[1] {
+		return nil
+	}
+	what := "the version"
+	if scope != "" {
+		what = "the version of scope " + scope
+	}
+	return fmt.Errorf("%w: %s would be bumped from %s to %s, leaving the %d.%d line of branch '%s'",
+		ErrOutsideVersionLine, what, base, next, line[0], line[1], r.branch)
+}
@@ -0,0 +1,67 @@
+package autotag
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/alecthomas/assert"
+)
+
+const releaseLinePattern = `^release/(?:[^/]+/)?(?P<major>\d+)\.(?P<minor>\d+)$`
+
+func TestBranchVersionLine(t *testing.T) {
+	tests := []struct {
+		name        string
+		commit      string
+		expectedTag string
+		outside     bool
+	}{
+		{
+			name:        "fix on the line",
+			commit:      "fix: correct typo",
+			expectedTag: "v1.3.1",
+		},
+		{
+			name:    "feature leaving the line",
+			commit:  "feat: add login",
+			outside: true,
+		},
+		{
+			name:    "breaking change leaving the line",
+			commit:  "feat!: drop the v1 API",
+			outside: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := newRepoDir(t, seedCommit("v1.3.0"))
+			runGit(t, dir, "checkout", "-b", "release/api/1.3")
+			runGit(t, dir, "commit", "--allow-empty", "-m", tc.commit)
+
+			cfg := GitRepoConfig{RepoPath: dir, Branch: "release/api/1.3", Scheme: "conventional", Prefix: true, BranchVersionPattern: releaseLinePattern}
+			r, err := NewRepo(cfg)
+			if tc.outside {
+				assert.True(t, errors.Is(err, ErrOutsideVersionLine), "error: %v", err)
+				return
+			}
+			checkFatal(t, err)
+			assert.Equal(t, tc.expectedTag, r.LatestVersion())
+		})
+	}
+}
+
+func TestBranchVersionLineUnmatched(t *testing.T) {
+	dir := newRepoDir(t,
+		seedCommit("v1.3.0"),
+		testCommit{msg: "feat!: drop the v1 API"},
+	)
+	r, err := NewRepo(GitRepoConfig{RepoPath: dir, Branch: "master", Scheme: "conventional", Prefix: true, BranchVersionPattern: releaseLinePattern})
+	checkFatal(t, err)
+	assert.Equal(t, "v2.0.0", r.LatestVersion())
+
+	for _, pattern := range []string{`^release/(?P<major>\d+)$`, `^release/(\d+`} {
+		_, err := NewRepo(GitRepoConfig{RepoPath: dir, Branch: "master", Scheme: "conventional", BranchVersionPattern: pattern})
+		assert.Error(t, err, "pattern %q", pattern)
+	}
+}
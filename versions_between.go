@@ -0,0 +1,72 @@
+package autotag
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/go-version"
+)
+
+// VersionsBetween returns the releases tagged after fromRef up to toRef, eg: for release notes
+// across a range of releases from `api-v1.0.0` to HEAD. The tags of the commits in the history of
+// toRef but not of fromRef are reported per scope ("" for the schemes without scopes), sorted by
+// scope, then by version from the lowest. The Current of a result is the version released before
+// it: the previous one of the range, for the first the highest version of the scope tagged in the
+// history of fromRef (nil if there is none). The BumpReason describes the step between both, eg:
+// `minor 1.0.0 -> 1.1.0`, the TargetCommit is the tagged commit. Releases without a tag in the repo
+// are left out.
+func (r *GitRepo) VersionsBetween(fromRef, toRef string) ([]Result, error) {
+	from, err := r.repo.CommitByRevision(fromRef)
+	if err != nil {
+		return nil, fmt.Errorf("error resolving '%s': %s", fromRef, err)
+	}
+	to, err := r.repo.CommitByRevision(toRef)
+	if err != nil {
+		return nil, fmt.Errorf("error resolving '%s': %s", toRef, err)
+	}
+	tags, err := r.loadTags()
+	if err != nil {
+		return nil, err
+	}
+
+	results := []Result{}
+	for _, scope := range sortedScopes(tags) {
+		versions := tags[scope]
+		keys := make([]*version.Version, 0, len(versions))
+		for v := range versions {
+			keys = append(keys, v)
+		}
+		sort.Sort(version.Collection(keys))
+
+		var current *version.Version
+		for _, v := range keys {
+			ref := versions[v]
+			c, err := r.tagRefCommit(ref)
+			if err != nil {
+				return nil, err
+			}
+			if c == nil {
+				continue
+			}
+			released, err := r.isAncestor(c, from)
+			if err != nil {
+				return nil, err
+			}
+			if released {
+				current = v
+				continue
+			}
+			if inRange, err := r.isAncestor(c, to); err != nil {
+				return nil, err
+			} else if !inRange {
+				continue
+			}
+			res := r.result(scope, current, v)
+			res.Tag, res.TargetCommit = ref.name, c
+			res.BumpReason = NewVersionDelta(current, v).String()
+			results = append(results, res)
+			current = v
+		}
+	}
+	return results, nil
+}
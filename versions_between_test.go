@@ -0,0 +1,48 @@
+package autotag
+
+import (
+	"testing"
+
+	"github.com/alecthomas/assert"
+)
+
+func TestVersionsBetween(t *testing.T) {
+	r := newRepoFixture(t, GitRepoConfig{Scheme: "scope-conventional", AllScopes: true, Prefix: true},
+		seedCommit("api-v1.0.0", "web-v0.1.0"),
+		testCommit{msg: "fix(api): handle nil", tags: []string{"api-v1.0.1"}},
+		testCommit{msg: "feat(api): add login", tags: []string{"api-v1.1.0", "web-v0.2.0-rc.1"}},
+		testCommit{msg: "feat(api)!: drop the v1 API", tags: []string{"api-v2.0.0", "web-v0.2.0"}},
+		testCommit{msg: "fix(web): correct the layout"},
+	)
+	dir := repoRoot(r.repo)
+	// a release of another branch isn't in the range
+	runGit(t, dir, "checkout", "-q", "-b", "hotfix", "api-v1.0.1")
+	runGit(t, dir, "commit", "--allow-empty", "-m", "fix(api): backport")
+	runGit(t, dir, "tag", "api-v1.0.2")
+	runGit(t, dir, "checkout", "-q", "master")
+	r.RefreshTags()
+
+	results, err := r.VersionsBetween("api-v1.0.1", "master")
+	checkFatal(t, err)
+	type step struct{ scope, current, tag, reason string }
+	var steps []step
+	for _, res := range results {
+		steps = append(steps, step{res.Scope, versionString(res.Current), res.Tag, res.BumpReason})
+		tagged, err := r.tagCommit(res.Tag)
+		checkFatal(t, err)
+		assert.Equal(t, tagged.ID, res.TargetCommit.ID, res.Tag)
+	}
+	assert.Equal(t, []step{
+		{"api", "1.0.1", "api-v1.1.0", "minor 1.0.1 -> 1.1.0"},
+		{"api", "1.1.0", "api-v2.0.0", "major 1.1.0 -> 2.0.0"},
+		{"web", "0.1.0", "web-v0.2.0-rc.1", "minor 0.1.0 -> 0.2.0-rc.1"},
+		{"web", "0.2.0-rc.1", "web-v0.2.0", "pre-release 0.2.0-rc.1 -> 0.2.0"},
+	}, steps)
+
+	results, err = r.VersionsBetween("master", "master")
+	checkFatal(t, err)
+	assert.Equal(t, 0, len(results))
+
+	_, err = r.VersionsBetween("api-v9.9.9", "master")
+	assert.Error(t, err)
+}
@@ -0,0 +1,102 @@
+package autotag
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// WorkspacePackage is a package of a workspace manifest, see WorkspaceManifest
+type WorkspacePackage struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+}
+
+// readWorkspaceManifest parses the packages of the workspace manifest file, relative to the work
+// tree. A `.json` file holds an array of packages or an object with a `packages` array, any other
+// file a YAML list of `name` and `path` entries, optionally under a `packages:` key.
+func readWorkspaceManifest(workTree, name string) ([]WorkspacePackage, error) {
+	if !filepath.IsAbs(name) {
+		name = filepath.Join(workTree, name)
+	}
+	content, err := os.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("error reading workspace manifest: %s", err)
+	}
+
+	var packages []WorkspacePackage
+	if strings.EqualFold(filepath.Ext(name), ".json") {
+		packages, err = parseWorkspaceJSON(content)
+	} else {
+		packages, err = parseWorkspaceYAML(string(content))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error parsing workspace manifest '%s': %s", name, err)
+	}
+
+	seen := make(map[string]bool, len(packages))
+	for i, pkg := range packages {
+		if pkg.Name == "" || normalizeScopePath(pkg.Path) == "" {
+			return nil, fmt.Errorf("workspace manifest '%s': package %d must have a name and a path", name, i+1)
+		}
+		if seen[pkg.Name] {
+			return nil, fmt.Errorf("workspace manifest '%s': package '%s' is listed twice", name, pkg.Name)
+		}
+		seen[pkg.Name] = true
+	}
+	return packages, nil
+}
+
+// parseWorkspaceJSON parses an array of packages or an object with a `packages` array
+func parseWorkspaceJSON(content []byte) ([]WorkspacePackage, error) {
+	var packages []WorkspacePackage
+	if err := json.Unmarshal(content, &packages); err == nil {
+		return packages, nil
+	}
+	var manifest struct {
+		Packages []WorkspacePackage `json:"packages"`
+	}
+	if err := json.Unmarshal(content, &manifest); err != nil {
+		return nil, err
+	}
+	return manifest.Packages, nil
+}
+
+// parseWorkspaceYAML parses the simple YAML list of packages, eg:
+//
+//	packages:
+//	  - name: api
+//	    path: services/api
+//
+// Comments, blank lines and the `packages:` key are skipped, values may be quoted.
+func parseWorkspaceYAML(content string) ([]WorkspacePackage, error) {
+	var packages []WorkspacePackage
+	s := bufio.NewScanner(strings.NewReader(content))
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") || line == "packages:" {
+			continue
+		}
+		if item := strings.TrimPrefix(line, "-"); item != line {
+			packages = append(packages, WorkspacePackage{})
+			if line = strings.TrimSpace(item); line == "" {
+				continue
+			}
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok || len(packages) == 0 {
+			return nil, fmt.Errorf("line %d: expected a list of name and path entries", n)
+		}
+		value = strings.Trim(strings.TrimSpace(value), `"'`)
+		switch strings.TrimSpace(key) {
+		case "name":
+			packages[len(packages)-1].Name = value
+		case "path":
+			packages[len(packages)-1].Path = value
+		}
+	}
+	return packages, s.Err()
+}
@@ -0,0 +1,70 @@
+package autotag
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alecthomas/assert"
+)
+
+const workspaceYAML = `# generated from pnpm-workspace.yaml
+packages:
+  - name: api
+    path: services/api
+  - name: "web"
+    path: 'apps/web'
+`
+
+func TestReadWorkspaceManifest(t *testing.T) {
+	expected := []WorkspacePackage{{Name: "api", Path: "services/api"}, {Name: "web", Path: "apps/web"}}
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"workspace.yaml":     workspaceYAML,
+		"workspace.json":     `[{"name":"api","path":"services/api"},{"name":"web","path":"apps/web"}]`,
+		"packages.json":      `{"packages":[{"name":"api","path":"services/api"},{"name":"web","path":"apps/web"}]}`,
+		"workspace-list.yml": "- name: api\n  path: services/api\n\n- path: apps/web\n  name: web\n",
+	} {
+		checkFatal(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+		packages, err := readWorkspaceManifest(dir, name)
+		checkFatal(t, err)
+		assert.Equal(t, expected, packages, name)
+	}
+
+	for name, content := range map[string]string{
+		"no-path.yaml":    "- name: api\n",
+		"twice.yaml":      "- name: api\n  path: a\n- name: api\n  path: b\n",
+		"not-list.yaml":   "name api\n",
+		"invalid.json":    `{"packages":`,
+		"empty-name.json": `[{"path":"services/api"}]`,
+	} {
+		checkFatal(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+		_, err := readWorkspaceManifest(dir, name)
+		assert.Error(t, err, name)
+	}
+}
+
+func TestWorkspaceManifestScopes(t *testing.T) {
+	dir := newRepoDir(t,
+		seedCommit("api-v1.0.0"),
+		testCommit{msg: "fix: correct typo", files: []string{"services/api/main.go"}},
+	)
+	checkFatal(t, os.WriteFile(filepath.Join(dir, "workspace.yaml"), []byte(workspaceYAML), 0o644))
+
+	// the scope is derived from the path of the package
+	r, err := NewRepo(GitRepoConfig{RepoPath: dir, Branch: "master", Scheme: "scope-conventional", Prefix: true, WorkspaceManifest: "workspace.yaml"})
+	checkFatal(t, err)
+	assert.Equal(t, "api-v1.0.1", r.LatestVersion())
+
+	// every package is a scope of the batch, also without tags
+	r, err = NewRepo(GitRepoConfig{RepoPath: dir, Branch: "master", Scheme: "scope-conventional", Prefix: true, AllScopes: true, WorkspaceManifest: "workspace.yaml"})
+	checkFatal(t, err)
+	results, err := r.Preview()
+	checkFatal(t, err)
+	assert.Equal(t, 2, len(results))
+	assert.Equal(t, "web", results[1].Scope)
+	assert.Error(t, results[1].Err)
+
+	_, err = NewRepo(GitRepoConfig{RepoPath: dir, Branch: "master", Scheme: "conventional", WorkspaceManifest: "workspace.yaml"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,47 @@
+package autotag
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gogs/git-module"
+)
+
+// ErrDirtyTree is returned by AutoTag with RequireCleanTree when the working tree has uncommitted
+// changes, so the tagged commit doesn't match the tree that is built from
+var ErrDirtyTree = errors.New("working tree has uncommitted changes")
+
+// checkCleanTree returns ErrDirtyTree listing the changed files if the working tree or the index
+// differs from HEAD, when RequireCleanTree is set. Untracked files are ignored. Bare repos have no
+// working tree and are never dirty.
+func (r *GitRepo) checkCleanTree() error {
+	if !r.requireCleanTree {
+		return nil
+	}
+
+	out, err := git.NewCommand("rev-parse", "--is-bare-repository").RunInDir(r.repo.Path())
+	if err != nil {
+		return fmt.Errorf("error checking the working tree: %s", err)
+	}
+	if strings.TrimSpace(string(out)) == "true" {
+		return nil
+	}
+
+	// the repo path is the git dir, which is elsewhere than the working tree for a submodule
+	out, err = git.NewCommand("status", "--porcelain", "--untracked-files=no").RunInDir(r.workTree)
+	if err != nil {
+		return fmt.Errorf("error checking the working tree: %s", err)
+	}
+	var files []string
+	for _, line := range strings.Split(string(out), "\n") {
+		// `XY path`, where XY is the status in the index and in the working tree
+		if len(line) > 3 {
+			files = append(files, line[3:])
+		}
+	}
+	if len(files) > 0 {
+		return fmt.Errorf("%w: %s", ErrDirtyTree, strings.Join(files, ", "))
+	}
+	return nil
+}
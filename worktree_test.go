@@ -0,0 +1,94 @@
+package autotag
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/assert"
+)
+
+func TestRequireCleanTree(t *testing.T) {
+	tests := []struct {
+		name             string
+		change           func(t *testing.T, dir string)
+		requireCleanTree bool
+		dirtyFiles       string
+	}{
+		{
+			name:             "clean",
+			requireCleanTree: true,
+		},
+		{
+			name: "modified file",
+			change: func(t *testing.T, dir string) {
+				checkFatal(t, os.WriteFile(filepath.Join(dir, "README"), []byte("uncommitted"), 0o644))
+			},
+			requireCleanTree: true,
+			dirtyFiles:       "README",
+		},
+		{
+			name: "staged file",
+			change: func(t *testing.T, dir string) {
+				checkFatal(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main"), 0o644))
+				runGit(t, dir, "add", "main.go")
+			},
+			requireCleanTree: true,
+			dirtyFiles:       "main.go",
+		},
+		{
+			name: "untracked file",
+			change: func(t *testing.T, dir string) {
+				checkFatal(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("notes"), 0o644))
+			},
+			requireCleanTree: true,
+		},
+		{
+			name: "check disabled",
+			change: func(t *testing.T, dir string) {
+				checkFatal(t, os.WriteFile(filepath.Join(dir, "README"), []byte("uncommitted"), 0o644))
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := newRepoFixture(t, GitRepoConfig{Prefix: true, RequireCleanTree: tc.requireCleanTree},
+				seedCommit("v1.0.0"),
+				testCommit{msg: "[minor] add login"},
+			)
+			if tc.change != nil {
+				tc.change(t, repoRoot(r.repo))
+			}
+
+			err := r.AutoTag()
+			if tc.dirtyFiles == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.True(t, errors.Is(err, ErrDirtyTree), "expected %v, got %v", ErrDirtyTree, err)
+			assert.True(t, strings.HasSuffix(err.Error(), ": "+tc.dirtyFiles), "unexpected error: %v", err)
+
+			tags, err := r.repo.Tags()
+			checkFatal(t, err)
+			assert.NotContains(t, tags, "v1.1.0")
+		})
+	}
+}
+
+func TestRequireCleanTreeBare(t *testing.T) {
+	dir := newRepoDir(t,
+		seedCommit("v1.0.0"),
+		testCommit{msg: "[minor] add login"},
+	)
+	// the repo path is the directory of the git dir, see generateGitDirPath
+	bare := t.TempDir()
+	runGit(t, bare, "clone", "--bare", dir, ".git")
+
+	r, err := NewRepo(GitRepoConfig{RepoPath: bare, Branch: "master", Prefix: true, RequireCleanTree: true})
+	checkFatal(t, err)
+	assert.NoError(t, r.AutoTag())
+	assert.Equal(t, "v1.1.0", r.LatestVersion())
+}